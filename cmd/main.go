@@ -18,53 +18,652 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	flag "github.com/spf13/pflag"
 
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/doctor"
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/graph"
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/i18n"
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/policy"
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/preflight"
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/publish"
 	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/result"
 	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/validation"
 	apimanifests "github.com/operator-framework/api/pkg/manifests"
 	apierrors "github.com/operator-framework/api/pkg/validation/errors"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
 )
 
+// The information of the build which is set via the ldflags (see the Makefile)
+var (
+	goos      string
+	goarch    string
+	gitCommit string
+	buildDate string
+)
+
+// dataModules defines the modules which provide the data of the checks (e.g. the APIs removed by Kubernetes
+// version) whose versions are informed in the metadata of the run
+var dataModules = []string{
+	"github.com/operator-framework/api",
+	"github.com/operator-framework/operator-registry",
+	"k8s.io/api",
+	"k8s.io/apiextensions-apiserver",
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		runDoctor(os.Args[2:])
+		return
+	}
+	start := time.Now().UTC()
 
-	var optionalValues map[string]string
+	optionalValues := optionalValuesFlag{}
+	var valuesFile string
 	var outputFormat string
+	var checkImages bool
+	var allowedRegistries []string
+	var olmV1 bool
+	var reportInventory bool
+	var validateVersions bool
+	var kubeconfig string
+	var dryRunInstall bool
+	var checkPyxis bool
+	var pyxisProject string
+	var checkOCPVersions bool
+	var preflightImage string
+	var bundleImage string
+	var signedImages []string
+	var cosignKey string
+	var keyless bool
+	var certificateIdentity string
+	var certificateOIDCIssuer string
+	var upstreamSuites []string
+	var selectedSuites []string
+	var profile string
+	var policyPath string
+	var publishURL string
+	var notifyURLs []string
+	var notifyOn string
+	var lang string
+	var docsVersion string
+	var strictLabels bool
+	var graphOutput string
+	var graphFile string
+	var recommend bool
+	var preBundle bool
+	var watchesFile string
+	var showPassed bool
+	var failOn string
 
-	optionalValueEmpty := map[string]string{}
-	flag.StringToStringVarP(&optionalValues, "optional-values", "", optionalValueEmpty,
+	flag.VarP(optionalValues, "optional-values", "",
 		"Inform a []string map of key=values which can be used by the validator. e.g. to check the operator bundle "+
-			"against an Kubernetes version that it is intended to be distributed use `--optional-values=k8s-version=1.22`")
+			"against an Kubernetes version that it is intended to be distributed use `--optional-values=k8s-version=1.22`. "+
+			"It can be informed more than once and the values can contain commas (e.g. range=v4.5,v4.6)")
+	flag.StringVar(&valuesFile, "values-file", "",
+		"Inform a YAML file with the key=values which can be used by the validator (e.g. `--values-file=values.yaml`). "+
+			"The values informed via --optional-values take precedence")
 	flag.StringVarP(&outputFormat, "output", "o", result.Text,
 		"Result format for results. One of: [text, json-alpha1]. Note: output format types containing "+
 			"\"alphaX\" are subject to change and not covered by guarantees of stable APIs.")
 
-	flag.Parse()
+	flag.BoolVar(&checkImages, "check-images", false,
+		"Inspect the images referenced by the bundle in their registries (e.g. to check that they exist). "+
+			"The credentials are read from the auth file informed via REGISTRY_AUTH_FILE or ~/.docker/config.json")
+
+	flag.StringSliceVar(&allowedRegistries, "allowed-registries", nil,
+		"Inform the registries from which the images referenced by the bundle can be used. "+
+			"e.g. `--allowed-registries=registry.redhat.io,quay.io/example`")
+
+	flag.BoolVar(&olmV1, "olm-v1", false,
+		"Check also whether the bundle can be installed via a ClusterExtension with OLM v1")
+
+	flag.BoolVar(&reportInventory, "report-inventory", false,
+		"Report every manifest shipped in the bundle with its kind, name, API version and size")
+
+	flag.BoolVar(&showPassed, "show-passed", false,
+		"Also output the checks which passed with their rule IDs (e.g. `Passed: csv-name`) to inform which criteria "+
+			"were evaluated for each bundle")
+
+	flag.BoolVar(&validateVersions, "validate-package-manifest-versions", false,
+		"Validate each version directory as a bundle when the directory informed is in the legacy "+
+			"PackageManifest format")
+
+	flag.StringVar(&kubeconfig, "kubeconfig", "",
+		"Inform the kubeconfig of a cluster to also validate the bundle against the APIs that it serves "+
+			"(e.g. CRD API versions, RBAC resources and removed APIs)")
+
+	flag.BoolVar(&dryRunInstall, "dry-run-install", false,
+		"Simulate the install of the bundle on the cluster informed via --kubeconfig by performing the "+
+			"server-side dry-run of the resources which OLM would create. Nothing is installed on the cluster")
+
+	flag.BoolVar(&checkPyxis, "check-pyxis", false,
+		"Check the bundle against the Red Hat Catalog (Pyxis) API (e.g. that its version was not already "+
+			"published). The API key is read from PYXIS_API_KEY")
+
+	flag.StringVar(&pyxisProject, "pyxis-project", "",
+		"Inform the ID of the certification project where the bundle will be submitted to also check it "+
+			"with --check-pyxis. e.g. `--pyxis-project=ospid-62423-f26c2a7b`")
+
+	flag.BoolVar(&checkOCPVersions, "check-ocp-versions", false,
+		"Check the OCP versions informed by the bundle against the versions released which are obtained from "+
+			"the OpenShift Update Service and cached locally. The update graph can be informed via "+
+			"OCP_UPDATE_SERVICE_URL")
+
+	flag.StringVar(&preflightImage, "preflight", "",
+		"Inform the bundle image to also run the operator checks of openshift-preflight against it and report "+
+			"their results with the results of this validator. The binary can be informed via PREFLIGHT_BIN. "+
+			"e.g. `--preflight=quay.io/example/memcached-operator-bundle:v0.0.1`")
+
+	flag.StringVar(&bundleImage, "bundle-image", "",
+		"Inform the image of the bundle which is validated to also check its tag and, with --check-images, its "+
+			"labels. e.g. `--bundle-image=quay.io/example/memcached-operator-bundle:v0.0.1`")
+
+	flag.StringSliceVar(&signedImages, "verify-signature", nil,
+		"Inform the bundle and index images whose signatures and provenance attestations are verified via cosign "+
+			"with --cosign-key or --keyless. The binary can be informed via COSIGN_BIN. "+
+			"e.g. `--verify-signature=quay.io/example/memcached-operator-bundle:v0.0.1`")
+
+	flag.StringVar(&cosignKey, "cosign-key", "",
+		"Inform the path or the KMS URI of the public key used to verify the signatures of the images informed via "+
+			"--verify-signature and --bundle-image. e.g. `--cosign-key=cosign.pub`")
+
+	flag.BoolVar(&keyless, "keyless", false,
+		"Verify the signatures of the images informed via --verify-signature and --bundle-image via the certificates "+
+			"issued by Fulcio and the Rekor transparency log instead of a key")
+
+	flag.StringVar(&certificateIdentity, "certificate-identity", "",
+		"Inform the regular expression which the identity of the keyless signatures must match (required by "+
+			"--keyless). e.g. `--certificate-identity=https://github.com/example/.*`")
+
+	flag.StringVar(&certificateOIDCIssuer, "certificate-oidc-issuer", "",
+		"Inform the regular expression which the OIDC issuer of the keyless signatures must match (required by "+
+			"--keyless). e.g. `--certificate-oidc-issuer=https://token.actions.githubusercontent.com`")
+
+	flag.StringSliceVar(&upstreamSuites, "include-upstream", nil,
+		"Inform the suites of operator-framework/api to also run alongside the OpenShift checks. One or more of: "+
+			"[bundle, operatorhub, good-practices]. e.g. `--include-upstream=bundle,operatorhub,good-practices`")
+	_ = flag.CommandLine.MarkDeprecated("include-upstream", "use --select-suites instead")
+
+	flag.StringSliceVar(&selectedSuites, "select-suites", []string{validation.OpenShiftSuite},
+		"Inform the suites of validators to run. One or more of: [openshift, bundle, operatorhub, good-practices, "+
+			"community]. e.g. `--select-suites=openshift,operatorhub,good-practices`")
+
+	flag.StringVar(&profile, "profile", "",
+		"Inform the catalog where the bundle is intended to be published to enable its rule set and severities. "+
+			"One of: [certified, redhat, marketplace, community]. Note that the suites of the profile are run "+
+			"unless --select-suites is informed")
+
+	flag.StringVar(&policyPath, "policy", "",
+		"Inform a policy file (YAML) with the conditions over the results (e.g. maxErrors, maxWarnings and "+
+			"mustPass) which decide whether the validation passed. The decision is reported as the gate")
+
+	flag.StringVar(&publishURL, "publish-results", "",
+		"Inform an http(s):// or s3://bucket/key URL to publish the results in the JSON format with the "+
+			"metadata of the run. e.g. `--publish-results=https://dashboard.example.com/api/results`")
+
+	flag.StringSliceVar(&notifyURLs, "notify", nil,
+		"Inform the http(s):// URLs of the webhooks notified with the summary of the run in the JSON format. The "+
+			"Slack incoming webhooks (hooks.slack.com) receive it as a message. "+
+			"e.g. `--notify=https://hooks.slack.com/services/T000/B000/XXXX`")
+
+	flag.StringVar(&notifyOn, "notify-on", publish.NotifyOnCompletion,
+		"Inform when the webhooks informed via --notify are notified: "+publish.NotifyOnCompletion+" (every run) or "+
+			publish.NotifyOnNewErrors+" (when errors which were not reported by the previous run for the same "+
+			"package appear)")
+
+	flag.StringVar(&lang, "lang", i18n.DefaultLang,
+		"Inform the language of the messages (e.g. `--lang=es`) or the path of a YAML message catalog with the "+
+			"translations. One of: ["+strings.Join(i18n.Languages(), ", ")+"]")
+
+	flag.StringVar(&docsVersion, "docs-version", "",
+		"Inform the OpenShift version of the documentation linked in the messages (e.g. `--docs-version=4.14`). "+
+			"By default, the docs of the highest OpenShift version where the bundle is distributed are linked")
+
+	flag.StringVar(&watchesFile, "watches-file", "",
+		"Inform the watches.yaml of the project of an Ansible-based operator to also check that its roles and "+
+			"playbooks are found and propagate the proxy environment variables (e.g. `--watches-file=watches.yaml`)")
+
+	flag.BoolVar(&strictLabels, "strict-labels", false,
+		"Require the OpenShift versions where the bundle is distributed (com.redhat.openshift.versions) to be "+
+			"informed via the file or range optional values, and the labels required by the Red Hat certification when "+
+			"the file is a bundle.Dockerfile. Their absence is reported as an error")
+
+	flag.StringVar(&graphOutput, "graph-output", "",
+		"Inform the format of the upgrade graph of the channels of the package to also output it with the bundles "+
+			"colored by their OCP compatibility. The bundles of the package found in the catalog optional value are "+
+			"also added. One of: ["+graph.FormatDOT+"]. e.g. `--graph-output=dot`")
+
+	flag.StringVar(&graphFile, "graph-file", "graph.dot",
+		"Inform the file where the upgrade graph is written when --graph-output is informed. "+
+			"e.g. `--graph-file=memcached.dot` (render it with `dot -Tsvg memcached.dot > memcached.svg`)")
 
-	validate(outputFormat)
-	results := runValidator(optionalValues)
-	printResults(results, outputFormat)
+	flag.BoolVar(&recommend, "recommend", false,
+		"Print the com.redhat.openshift.versions range and the olm.maxOpenShiftVersion value recommended for the "+
+			"bundle based on the APIs that it uses instead of validating it. Nothing is written")
+
+	flag.BoolVar(&preBundle, "pre-bundle", false,
+		"Validate the pre-bundle manifests of the path informed instead of a bundle. The path can be the config "+
+			"directory of the project (CSV template and CRDs) or the output of kustomize (e.g. `kustomize build "+
+			"config/manifests > manifests.yaml`). The checks which only apply to the generated bundle are skipped")
+
+	flag.StringVar(&failOn, "fail-on", result.FailOnError,
+		"Inform the lowest severity of the findings which fail the validation. With warning, the exit code is 2 "+
+			"when only warnings are found. One of: ["+result.FailOnError+", "+result.FailOnWarning+"]")
+
+	// The usage errors exit with their own code (the help is printed by the flag set)
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err == flag.ErrHelp {
+		os.Exit(result.ExitSuccess)
+	} else if err != nil {
+		os.Exit(result.ExitUsage)
+	}
+	// The flags are added to the metadata before the optional values are completed with them
+	metadata := newMetadata(start)
+
+	if len(valuesFile) > 0 {
+		fileValues, err := validation.LoadOptionalValues(valuesFile)
+		if err != nil {
+			fatal(result.ExitInternal, err)
+		}
+		for k, v := range fileValues {
+			if _, found := optionalValues[k]; !found {
+				optionalValues[k] = v
+			}
+		}
+	}
+	if checkImages {
+		optionalValues[validation.CheckImagesKey] = "true"
+	}
+	if len(allowedRegistries) > 0 {
+		optionalValues[validation.AllowedRegistriesKey] = strings.Join(allowedRegistries, ",")
+	}
+	if len(kubeconfig) > 0 {
+		optionalValues[validation.KubeconfigKey] = kubeconfig
+	}
+	if len(pyxisProject) > 0 {
+		optionalValues[validation.PyxisProjectKey] = pyxisProject
+	}
+	if strictLabels {
+		optionalValues[validation.StrictLabelsKey] = "true"
+	}
+	if len(docsVersion) > 0 {
+		optionalValues[validation.DocsVersionKey] = docsVersion
+	}
+	if len(watchesFile) > 0 {
+		optionalValues[validation.WatchesFileKey] = watchesFile
+	}
+	if len(bundleImage) > 0 {
+		optionalValues[validation.BundleImageKey] = bundleImage
+	}
+	if len(signedImages) > 0 {
+		optionalValues[validation.SignedImagesKey] = strings.Join(signedImages, ",")
+	}
+	if len(cosignKey) > 0 {
+		optionalValues[validation.CosignKeyKey] = cosignKey
+	}
+	if keyless {
+		optionalValues[validation.KeylessKey] = "true"
+	}
+	if len(certificateIdentity) > 0 {
+		optionalValues[validation.CertificateIdentityKey] = certificateIdentity
+	}
+	if len(certificateOIDCIssuer) > 0 {
+		optionalValues[validation.CertificateOIDCIssuerKey] = certificateOIDCIssuer
+	}
+
+	validate(outputFormat, failOn)
+	if len(graphOutput) > 0 && graphOutput != graph.FormatDOT {
+		fatal(result.ExitUsage, fmt.Errorf("invalid value for graph-output flag: %v", graphOutput))
+	}
+	if len(profile) > 0 {
+		optionalValues[validation.ProfileKey] = profile
+		profileSuites, err := validation.GetProfileSuites(profile)
+		if err != nil {
+			fatal(result.ExitUsage, err)
+		}
+		if !flag.CommandLine.Changed("select-suites") {
+			selectedSuites = profileSuites
+		}
+	}
+	// The suites of the deprecated --include-upstream are merged with the selected ones to run each suite once
+	if _, err := validation.GetUpstreamValidators(upstreamSuites); err != nil {
+		fatal(result.ExitUsage, err)
+	}
+	suites := mergeSuites(selectedSuites, upstreamSuites)
+	validators, err := validation.GetSuiteValidators(suites)
+	if err != nil {
+		fatal(result.ExitUsage, err)
+	}
+	var notifier *publish.Notifier
+	if len(notifyURLs) > 0 {
+		if notifier, err = publish.NewNotifier(notifyURLs, notifyOn); err != nil {
+			fatal(result.ExitUsage, err)
+		}
+	}
+	catalog, err := loadCatalog(lang)
+	if err != nil {
+		fatal(result.ExitUsage, err)
+	}
+	var gatePolicy *policy.Policy
+	if len(policyPath) > 0 {
+		if gatePolicy, err = policy.Load(policyPath); err != nil {
+			fatal(result.ExitInternal, err)
+		}
+	}
+	if olmV1 {
+		validators = append(validators, validation.OLMv1Validator)
+	}
+	if dryRunInstall {
+		validators = append(validators, validation.InstallDryRunValidator)
+	}
+	if checkPyxis {
+		validators = append(validators, validation.PyxisValidator)
+	}
+	if checkOCPVersions {
+		validators = append(validators, validation.OCPVersionsValidator)
+	}
+	// The signatures of the bundle image are also verified when the key or keyless is informed
+	if len(optionalValues[validation.SignedImagesKey]) > 0 || (len(optionalValues[validation.BundleImageKey]) > 0 &&
+		(len(optionalValues[validation.CosignKeyKey]) > 0 || optionalValues[validation.KeylessKey] == "true")) {
+		validators = append(validators, validation.SignatureValidator)
+	}
+
+	load := loadBundle
+	if preBundle {
+		load = loadPreBundle
+		validators = validation.GetPreBundleValidators(validators)
+	}
+
+	// The legacy PackageManifest format can only be validated by version
+	var errs []error
+	bundleDirs := []string{os.Args[1]}
+	var versionDirs []string
+	if !preBundle {
+		if versionDirs, err = validation.GetPackageManifestVersionDirs(os.Args[1]); err != nil {
+			fatal(result.ExitInternal, err)
+		}
+	}
+	if len(versionDirs) > 0 {
+		hint := " and use --validate-package-manifest-versions to validate each version as a bundle"
+		bundleDirs = nil
+		if validateVersions {
+			hint = ""
+			bundleDirs = versionDirs
+		}
+		errs = append(errs, fmt.Errorf("the directory %s is in the legacy PackageManifest format which cannot be "+
+			"published on the OpenShift catalogs. Only the bundle and File-Based Catalog formats are supported. "+
+			"Please, migrate it (e.g. with `operator-sdk pkgman-to-bundle`)%s", os.Args[1], hint))
+	}
+
+	if recommend {
+		if len(bundleDirs) == 0 {
+			fatal(result.ExitErrors, errs[0])
+		}
+		for _, dir := range bundleDirs {
+			printRecommendation(load(dir), optionalValues)
+		}
+		return
+	}
+
+	metadata.Profile = profile
+	metadata.Suites = suites
+	var results []result.GroupResults
+	var inventory []validation.InventoryItem
+	bundles := map[string]*apimanifests.Bundle{}
+	for _, dir := range bundleDirs {
+		bundle := load(dir)
+		bundles[dir] = bundle
+		target := result.TargetOCPVersions{Bundle: bundle.Name}
+		target.Range, target.MaxOpenShiftVersion = validation.GetTargetOCPVersions(bundle, optionalValues)
+		metadata.TargetOCPVersions = append(metadata.TargetOCPVersions, target)
+		results = append(results, runValidator(bundle, dir, validators, optionalValues)...)
+		if reportInventory {
+			items, err := validation.GetInventory(bundle)
+			if err != nil {
+				fatal(result.ExitInternal, err)
+			}
+			inventory = append(inventory, items...)
+		}
+	}
+	if len(graphOutput) > 0 && len(bundles) > 0 {
+		if err := writeGraph(bundles, optionalValues, graphOutput, graphFile); err != nil {
+			errs = append(errs, internalError{err})
+		}
+	}
+	if len(preflightImage) > 0 {
+		preflightResults, err := preflight.Run(preflightImage)
+		if err != nil {
+			errs = append(errs, internalError{err})
+		} else {
+			results = append(results, result.GroupResults{Group: result.Group{Validator: "preflight"},
+				Results: []apierrors.ManifestResult{preflightResults.ManifestResult()}})
+		}
+	}
+	printResults(errs, results, inventory, gatePolicy, catalog, publishURL, notifier, metadata, outputFormat, failOn, showPassed)
 }
 
-func printResults(results []apierrors.ManifestResult, outputFormat string) {
+func printResults(errs []error, results []result.GroupResults, inventory []validation.InventoryItem,
+	gatePolicy *policy.Policy, catalog *i18n.Catalog, publishURL string, notifier *publish.Notifier,
+	metadata *result.Metadata, outputFormat, failOn string, showPassed bool) {
 	// Create Result to be output.
 	res := result.NewResult()
-	res.AddManifestResults(results...)
+	metadata.Complete(time.Now())
+	res.Metadata = metadata
+	res.ShowPassed = showPassed
+	res.FailOn = failOn
+	for _, err := range errs {
+		if _, ok := err.(internalError); ok {
+			res.AddInternalError(err)
+			continue
+		}
+		res.AddError(err)
+	}
+	for _, item := range inventory {
+		res.AddInfo(fmt.Sprintf("Inventory: %s", item))
+		if !item.Supported {
+			res.AddWarn(fmt.Errorf("the kind %s of the manifest %s is not supported by OLM and the bundle will "+
+				"fail to be installed", item.Kind, item.Name))
+		}
+	}
+	res.AddGroupedResults(results...)
+	if gatePolicy != nil {
+		res.SetGate(gatePolicy.Evaluate(res))
+	}
+	// The messages are translated after the policy is evaluated since its expressions which do not match the
+	// validators match the English messages
+	res.Translate(catalog.Translate)
+	// The results are published before printing them since it exits when they did not pass
+	if len(publishURL) > 0 {
+		if err := publish.Publish(publishURL, publish.NewReport(os.Args[1], res)); err != nil {
+			log.Error(err)
+		}
+	}
+	if notifier != nil {
+		if err := notifier.Notify(publish.NewReport(os.Args[1], res)); err != nil {
+			log.Error(err)
+		}
+	}
 
 	if err := res.PrintWithFormat(outputFormat); err != nil {
-		log.Fatal(err)
+		fatal(result.ExitInternal, err)
+	}
+}
+
+// newMetadata returns the metadata of the run started at the time informed with the build of the tool, the
+// versions of its data and the flags informed
+func newMetadata(start time.Time) *result.Metadata {
+	metadata := &result.Metadata{
+		Tool: result.ToolVersion{GitCommit: gitCommit, BuildDate: buildDate, GoVersion: runtime.Version(),
+			Platform: runtime.GOOS + "/" + runtime.GOARCH},
+		StartTime: start,
+	}
+	if len(goos) > 0 && len(goarch) > 0 {
+		metadata.Tool.Platform = goos + "/" + goarch
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		metadata.Tool.Version = info.Main.Version
+		metadata.DataVersions = map[string]string{}
+		for _, dep := range info.Deps {
+			for _, module := range dataModules {
+				if dep.Path == module {
+					metadata.DataVersions[module] = dep.Version
+				}
+			}
+		}
+	}
+	// Only the flags which were informed are added
+	flag.CommandLine.Visit(func(f *flag.Flag) {
+		if values, ok := f.Value.(optionalValuesFlag); ok {
+			metadata.AddOptionalValues(f.Name, values)
+			return
+		}
+		metadata.AddFlag(f.Name, f.Value.String())
+	})
+	return metadata
+}
+
+// printRecommendation prints the OCP versions recommended for the bundle informed
+func printRecommendation(bundle *apimanifests.Bundle, optionalValues map[string]string) {
+	rec := validation.GetRecommendation(bundle, optionalValues)
+	fmt.Printf("Recommended OpenShift versions for %s:\n", bundle.CSV.GetName())
+	current := func(value string) string {
+		if len(value) == 0 {
+			return "not informed"
+		}
+		return value
+	}
+	if len(rec.Range) > 0 {
+		fmt.Printf("  com.redhat.openshift.versions: %q (current: %s)\n", rec.Range, current(rec.CurrentRange))
+	} else {
+		fmt.Printf("  com.redhat.openshift.versions: none (current: %s)\n", current(rec.CurrentRange))
+	}
+	if len(rec.MaxOpenShiftVersion) > 0 {
+		fmt.Printf("  olm.maxOpenShiftVersion: %q (current: %s)\n", rec.MaxOpenShiftVersion,
+			current(rec.CurrentMaxOpenShiftVersion))
+	} else {
+		fmt.Printf("  olm.maxOpenShiftVersion: not required (current: %s)\n", current(rec.CurrentMaxOpenShiftVersion))
+	}
+	fmt.Println("Because:")
+	for _, reason := range rec.Reasons {
+		fmt.Printf("  - %s\n", reason)
+	}
+}
+
+// writeGraph writes the upgrade graph of the package of the bundles informed in the format informed
+func writeGraph(bundles map[string]*apimanifests.Bundle, optionalValues map[string]string, format,
+	path string) error {
+	g, err := validation.GetUpgradeGraph(bundles, optionalValues)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to write the upgrade graph: %s", err)
+	}
+	defer f.Close()
+	if err := g.Write(f, format); err != nil {
+		return fmt.Errorf("unable to write the upgrade graph: %s", err)
 	}
+	log.Infof("the upgrade graph of the package %s was written to %s", g.Package, path)
+	return nil
 }
 
-func runValidator(optionalValues map[string]string) []apierrors.ManifestResult {
-	// Read the bundle
-	bundle, err := apimanifests.GetBundleFromDir(os.Args[1])
+// runDoctor checks the runtime environment and prints the fixes for the issues found. The registries
+// checked can be informed as arguments (e.g. doctor quay.io registry.redhat.io).
+func runDoctor(registries []string) {
+	checks := doctor.New(registries).Run()
+	for _, check := range checks {
+		fmt.Printf("[%s] %s: %s\n", check.Status, check.Name, check.Message)
+		if len(check.Fix) > 0 {
+			fmt.Printf("    %s\n", check.Fix)
+		}
+	}
+	if doctor.Failed(checks) {
+		os.Exit(1)
+	}
+}
+
+// mergeSuites returns the suites informed without duplicates in the order they were informed
+func mergeSuites(suites ...[]string) []string {
+	var merged []string
+	found := map[string]bool{}
+	for _, names := range suites {
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			if !found[name] {
+				found[name] = true
+				merged = append(merged, name)
+			}
+		}
+	}
+	return merged
+}
+
+// loadCatalog returns the message catalog of the language informed or defined in the file informed
+func loadCatalog(lang string) (*i18n.Catalog, error) {
+	if strings.HasSuffix(lang, ".yaml") || strings.HasSuffix(lang, ".yml") {
+		return i18n.LoadFile(lang)
+	}
+	return i18n.Load(lang)
+}
+
+// optionalValuesFlag implements pflag.Value to allow informing the key=values more than once
+type optionalValuesFlag map[string]string
+
+// String returns the sorted key=values informed
+func (f optionalValuesFlag) String() string {
+	var values []string
+	for k, v := range f {
+		values = append(values, k+"="+v)
+	}
+	sort.Strings(values)
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+// Set adds the key=values informed. Note that the values informed later take precedence.
+func (f optionalValuesFlag) Set(value string) error {
+	values, err := validation.ParseOptionalValues(value)
+	if err != nil {
+		return err
+	}
+	for k, v := range values {
+		f[k] = v
+	}
+	return nil
+}
+
+// Type returns the type of the flag shown in the usage
+func (f optionalValuesFlag) Type() string {
+	return "stringToString"
+}
+
+func loadBundle(dir string) *apimanifests.Bundle {
+	// Read the bundle. Note that the bundles which cannot be parsed are invalid
+	bundle, err := validation.LoadBundleFS(os.DirFS(dir), ".")
 	if err != nil {
-		log.Fatal(err)
+		fatal(result.ExitErrors, fmt.Errorf("unable to load the bundle %s: %s", dir, err))
 	}
+	return bundle
+}
+
+// loadPreBundle returns the bundle which would be generated from the pre-bundle manifests informed
+func loadPreBundle(path string) *apimanifests.Bundle {
+	bundle, err := validation.LoadPreBundle(path)
+	if err != nil {
+		fatal(result.ExitErrors, fmt.Errorf("unable to load the pre-bundle manifests %s: %s", path, err))
+	}
+	return bundle
+}
 
+func runValidator(bundle *apimanifests.Bundle, dir string, validators interfaces.Validators,
+	optionalValues map[string]string) []result.GroupResults {
 	objs := bundle.ObjectsToValidate()
 	for _, obj := range bundle.Objects {
 		objs = append(objs, obj)
@@ -72,18 +671,45 @@ func runValidator(optionalValues map[string]string) []apierrors.ManifestResult {
 
 	// Pass the --optional-values. e.g. --optional-values="k8s-version=1.22"
 	// or --optional-values="image-path=bundle.Dockerfile"
-	objs = append(objs, optionalValues)
+	values := map[string]string{validation.BundlePathKey: dir}
+	for k, v := range optionalValues {
+		values[k] = v
+	}
+	objs = append(objs, values)
 
-	// pass the objects to the validator
-	results := validation.OpenShiftValidator.Validate(objs...)
+	// pass the objects to the validators. Each validator is run separately to group its results
+	var results []result.GroupResults
+	for _, v := range validators {
+		results = append(results, result.GroupResults{
+			Group:   result.Group{Package: bundle.Package, Validator: validation.GetValidatorName(v)},
+			Results: v.Validate(objs...),
+		})
+	}
 	return results
 }
 
-func validate(outputFormat string) {
+func validate(outputFormat, failOn string) {
 	if len(os.Args) < 2 {
-		log.Fatal(errors.New("an image tag or directory is a required argument"))
+		fatal(result.ExitUsage, errors.New("an image tag or directory is a required argument"))
+	}
+	if _, err := os.Stat(os.Args[1]); err != nil {
+		fatal(result.ExitUsage, fmt.Errorf("unable to find the directory %s: %s", os.Args[1], err))
 	}
 	if outputFormat != result.JSONAlpha1 && outputFormat != result.Text {
-		log.Fatal(fmt.Errorf("invalid value for output flag: %v", outputFormat))
+		fatal(result.ExitUsage, fmt.Errorf("invalid value for output flag: %v", outputFormat))
+	}
+	if failOn != result.FailOnError && failOn != result.FailOnWarning {
+		fatal(result.ExitUsage, fmt.Errorf("invalid value for fail-on flag: %v", failOn))
 	}
 }
+
+// internalError defines the errors which are failures of the tool instead of the bundle (e.g. IO failures)
+type internalError struct {
+	error
+}
+
+// fatal logs the error informed and exits with the code informed (e.g. result.ExitUsage)
+func fatal(code int, err error) {
+	log.Error(err)
+	os.Exit(code)
+}
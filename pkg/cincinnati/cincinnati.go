@@ -0,0 +1,215 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cincinnati provides a minimal client for the OpenShift Update Service (Cincinnati) which is used
+// to get the OpenShift versions released.
+package cincinnati
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultURL defines the URL of the update graph used when OCP_UPDATE_SERVICE_URL is not informed
+const DefaultURL = "https://api.openshift.com/api/upgrades_info/v1/graph"
+
+// DefaultCacheTTL defines for how long the versions cached are used before requesting them again
+const DefaultCacheTTL = 24 * time.Hour
+
+// ocpMajorVersion defines the major version of the OpenShift releases which are looked for
+const ocpMajorVersion = 4
+
+// maxMinorVersions defines the maximum number of minor versions requested to stop the lookup
+const maxMinorVersions = 100
+
+// cacheFile defines the name of the file where the versions are cached
+const cacheFile = "ocp-versions.json"
+
+// Inspector defines the operations used to inspect the OpenShift releases
+type Inspector interface {
+	// GetVersions returns the major.minor OpenShift versions released (e.g. 4.14) sorted in ascending order
+	GetVersions() ([]string, error)
+}
+
+// Client implements Inspector by requesting the stable channels of the update graph informed via
+// OCP_UPDATE_SERVICE_URL or DefaultURL. The versions are cached in the CacheDir for the CacheTTL.
+type Client struct {
+	HTTPClient *http.Client
+	URL        string
+	// CacheDir is the directory where the versions are cached. Note that they are not cached when it is empty.
+	CacheDir string
+	CacheTTL time.Duration
+}
+
+// cache defines the content of the cache file
+type cache struct {
+	URL       string    `json:"url"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Versions  []string  `json:"versions"`
+}
+
+// NewClient returns a Client for the update graph found in the environment which caches the versions in
+// the user cache directory
+func NewClient() *Client {
+	graphURL := os.Getenv("OCP_UPDATE_SERVICE_URL")
+	if len(graphURL) == 0 {
+		graphURL = DefaultURL
+	}
+	var cacheDir string
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "ocp-olm-catalog-validator")
+	}
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		URL:        graphURL,
+		CacheDir:   cacheDir,
+		CacheTTL:   DefaultCacheTTL,
+	}
+}
+
+// GetVersions returns the major.minor OpenShift versions released (e.g. 4.14) sorted in ascending order. A
+// version is released when its stable channel (e.g. stable-4.14) has any release.
+func (c *Client) GetVersions() ([]string, error) {
+	if versions, ok := c.readCache(); ok {
+		return versions, nil
+	}
+
+	var versions []string
+	for minor := 1; minor <= maxMinorVersions; minor++ {
+		version := fmt.Sprintf("%d.%d", ocpMajorVersion, minor)
+		released, err := c.isReleased(version)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the releases of OCP %s: %s", version, err)
+		}
+		if !released {
+			break
+		}
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no OCP releases were found in the update graph %s", c.URL)
+	}
+
+	c.writeCache(versions)
+	return versions, nil
+}
+
+// isReleased returns true when the stable channel of the version informed has any release
+func (c *Client) isReleased(version string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, c.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.URL.RawQuery = url.Values{"channel": {"stable-" + version}}.Encode()
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	graph := struct {
+		Nodes []struct {
+			Version string `json:"version"`
+		} `json:"nodes"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&graph); err != nil {
+		return false, fmt.Errorf("unable to decode the update graph: %s", err)
+	}
+	// The channels can also have releases of the previous version to allow the upgrades
+	for _, node := range graph.Nodes {
+		if strings.HasPrefix(node.Version, version+".") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CachePath returns the path of the file where the versions are cached. Note that an empty value is returned
+// when they are not cached.
+func (c *Client) CachePath() string {
+	if len(c.CacheDir) == 0 {
+		return ""
+	}
+	return filepath.Join(c.CacheDir, cacheFile)
+}
+
+// CachedAt returns when the versions were cached. Note that a zero time is returned when they were not cached.
+func (c *Client) CachedAt() (time.Time, error) {
+	if len(c.CacheDir) == 0 {
+		return time.Time{}, nil
+	}
+	content, err := ioutil.ReadFile(c.CachePath())
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	cached := cache{}
+	if err := json.Unmarshal(content, &cached); err != nil {
+		return time.Time{}, fmt.Errorf("unable to decode the cache %s: %s", c.CachePath(), err)
+	}
+	return cached.UpdatedAt, nil
+}
+
+// readCache returns the versions cached for the update graph when they have not expired
+func (c *Client) readCache() ([]string, bool) {
+	if len(c.CacheDir) == 0 {
+		return nil, false
+	}
+	content, err := ioutil.ReadFile(c.CachePath())
+	if err != nil {
+		return nil, false
+	}
+	cached := cache{}
+	if err := json.Unmarshal(content, &cached); err != nil || cached.URL != c.URL || len(cached.Versions) == 0 {
+		return nil, false
+	}
+	if time.Since(cached.UpdatedAt) > c.CacheTTL {
+		return nil, false
+	}
+	return cached.Versions, true
+}
+
+// writeCache caches the versions of the update graph. Note that the issues to write the cache are ignored
+// since the versions will be requested again.
+func (c *Client) writeCache(versions []string) {
+	if len(c.CacheDir) == 0 {
+		return
+	}
+	content, err := json.Marshal(cache{URL: c.URL, UpdatedAt: time.Now(), Versions: versions})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.CacheDir, 0755); err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(c.CachePath(), content, 0644)
+}
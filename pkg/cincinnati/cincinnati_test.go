@@ -0,0 +1,88 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cincinnati
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetVersions(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("channel") {
+		case "stable-4.1":
+			fmt.Fprint(w, `{"nodes":[{"version":"4.1.0"},{"version":"4.1.1"}],"edges":[[0,1]]}`)
+		case "stable-4.2":
+			fmt.Fprint(w, `{"nodes":[{"version":"4.1.1"},{"version":"4.2.0"}],"edges":[[0,1]]}`)
+		case "stable-4.3":
+			// Only the releases of the previous version are found while the version is not released
+			fmt.Fprint(w, `{"nodes":[{"version":"4.2.0"}],"edges":[]}`)
+		default:
+			fmt.Fprint(w, `{"nodes":[],"edges":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client(), URL: server.URL, CacheDir: t.TempDir(), CacheTTL: time.Hour}
+	cachedAt, err := client.CachedAt()
+	require.NoError(t, err)
+	require.True(t, cachedAt.IsZero())
+
+	versions, err := client.GetVersions()
+	require.NoError(t, err)
+	require.Equal(t, []string{"4.1", "4.2"}, versions)
+	require.Equal(t, 3, requests)
+
+	// The versions are read from the cache
+	versions, err = client.GetVersions()
+	require.NoError(t, err)
+	require.Equal(t, []string{"4.1", "4.2"}, versions)
+	require.Equal(t, 3, requests)
+	cachedAt, err = client.CachedAt()
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), cachedAt, time.Minute)
+
+	// The cache is not used when it expires
+	client.CacheTTL = 0
+	_, err = client.GetVersions()
+	require.NoError(t, err)
+	require.Equal(t, 6, requests)
+}
+
+func TestClient_GetVersions_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/graph" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client(), URL: server.URL}
+	_, err := client.GetVersions()
+	require.EqualError(t, err, "unable to get the releases of OCP 4.1: unexpected status code 500")
+
+	client.URL = server.URL + "/graph"
+	_, err = client.GetVersions()
+	require.EqualError(t, err, fmt.Sprintf("no OCP releases were found in the update graph %s/graph", server.URL))
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cluster provides a minimal client for the clusters informed via kubeconfig
+// which is used to validate the bundles against the APIs that they serve.
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Inspector defines the operations used to inspect the cluster
+type Inspector interface {
+	// GetAPIResources returns the resources served by the cluster by group version (e.g. apps/v1)
+	GetAPIResources() (map[string][]metav1.APIResource, error)
+	// GetVersion returns the OpenShift and Kubernetes versions of the cluster
+	GetVersion() (*Version, error)
+	// DryRunCreate performs the server-side dry-run of the creation of the object informed. Note that
+	// the namespaced objects are created in the namespace informed.
+	DryRunCreate(obj *unstructured.Unstructured, namespace string) error
+}
+
+// clusterVersionPath defines the path of the ClusterVersion which has the OpenShift version of the cluster
+const clusterVersionPath = "/apis/config.openshift.io/v1/clusterversions/version"
+
+// Version defines the versions of the cluster
+type Version struct {
+	// OpenShift is the version desired by the ClusterVersion (e.g. 4.12.3). Note that it is empty
+	// when the cluster is not an OpenShift cluster.
+	OpenShift string
+	// Kubernetes is the version of the API server (e.g. v1.25.4+77bec7a)
+	Kubernetes string
+}
+
+// Client implements Inspector by requesting the cluster informed via the kubeconfig
+type Client struct {
+	discovery discovery.DiscoveryInterface
+	dynamic   dynamic.Interface
+	mapper    meta.RESTMapper
+}
+
+// NewClient returns a Client for the current context of the kubeconfig informed. When the kubeconfig is
+// empty, it is loaded from KUBECONFIG or ~/.kube/config.
+func NewClient(kubeconfig string) (*Client, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules,
+		&clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the kubeconfig: %s", err)
+	}
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the client: %s", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the client: %s", err)
+	}
+	return &Client{
+		discovery: client,
+		dynamic:   dynamicClient,
+		mapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client)),
+	}, nil
+}
+
+// GetAPIResources returns the resources served by the cluster by group version (e.g. apps/v1). Note that
+// the groups which cannot be discovered (e.g. aggregated APIs unavailable) are ignored.
+func (c *Client) GetAPIResources() (map[string][]metav1.APIResource, error) {
+	_, lists, err := c.discovery.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("unable to discover the APIs of the cluster: %s", err)
+	}
+	resources := map[string][]metav1.APIResource{}
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		resources[list.GroupVersion] = append(resources[list.GroupVersion], list.APIResources...)
+	}
+	return resources, nil
+}
+
+// GetVersion returns the version desired by the ClusterVersion and the version of the API server
+func (c *Client) GetVersion() (*Version, error) {
+	info, err := c.discovery.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the version of the cluster: %s", err)
+	}
+	version := &Version{Kubernetes: info.GitVersion}
+
+	content, err := c.discovery.RESTClient().Get().AbsPath(clusterVersionPath).DoRaw(context.TODO())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return version, nil
+		}
+		return nil, fmt.Errorf("unable to get the ClusterVersion: %s", err)
+	}
+	clusterVersion := struct {
+		Status struct {
+			Desired struct {
+				Version string `json:"version"`
+			} `json:"desired"`
+		} `json:"status"`
+	}{}
+	if err := json.Unmarshal(content, &clusterVersion); err != nil {
+		return nil, fmt.Errorf("unable to decode the ClusterVersion: %s", err)
+	}
+	version.OpenShift = clusterVersion.Status.Desired.Version
+	return version, nil
+}
+
+// DryRunCreate performs the server-side dry-run of the creation of the object informed. Note that the
+// objects which already exist in the cluster (e.g. CRDs) are ignored since they would be updated instead.
+func (c *Client) DryRunCreate(obj *unstructured.Unstructured, namespace string) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fmt.Errorf("the API %s %s is not served by the cluster", gvk.GroupVersion(), gvk.Kind)
+	}
+
+	var resource dynamic.ResourceInterface = c.dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		obj = obj.DeepCopy()
+		obj.SetNamespace(namespace)
+		resource = c.dynamic.Resource(mapping.Resource).Namespace(namespace)
+	}
+	_, err = resource.Create(context.TODO(), obj, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// newTestServer returns a server which serves the discovery of the core and apps APIs and the
+// kubeconfig to reach it
+func newTestServer(t *testing.T, handlers map[string]string) (*httptest.Server, string) {
+	discovery := map[string]string{
+		"/api": `{"kind":"APIVersions","versions":["v1"]}`,
+		"/apis": `{"kind":"APIGroupList","groups":[{"name":"apps","versions":[{"groupVersion":"apps/v1",` +
+			`"version":"v1"}],"preferredVersion":{"groupVersion":"apps/v1","version":"v1"}}]}`,
+		"/api/v1": `{"kind":"APIResourceList","groupVersion":"v1","resources":[{"name":"services",` +
+			`"namespaced":true,"kind":"Service","verbs":["get"]}]}`,
+		"/apis/apps/v1": `{"kind":"APIResourceList","groupVersion":"apps/v1","resources":[{"name":"deployments",` +
+			`"namespaced":true,"kind":"Deployment","verbs":["get"]}]}`,
+	}
+	for path, body := range handlers {
+		discovery[path] = body
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if r.Method != http.MethodGet {
+			require.Equal(t, "All", r.URL.Query().Get("dryRun"))
+			path = r.Method + " " + path
+		}
+		body, ok := discovery[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		status := struct {
+			Kind string `json:"kind"`
+			Code int    `json:"code"`
+		}{}
+		if err := json.Unmarshal([]byte(body), &status); err == nil && status.Kind == "Status" {
+			w.WriteHeader(status.Code)
+		}
+		fmt.Fprint(w, body)
+	}))
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, ioutil.WriteFile(kubeconfig, []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: abc
+`, server.URL)), 0600))
+	return server, kubeconfig
+}
+
+func TestClient_GetAPIResources(t *testing.T) {
+	server, kubeconfig := newTestServer(t, nil)
+	defer server.Close()
+
+	client, err := NewClient(kubeconfig)
+	require.NoError(t, err)
+	resources, err := client.GetAPIResources()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resources))
+	require.Equal(t, "Service", resources["v1"][0].Kind)
+	require.Equal(t, "deployments", resources["apps/v1"][0].Name)
+
+	_, err = NewClient(filepath.Join(t.TempDir(), "not-found"))
+	require.Error(t, err)
+}
+
+func TestClient_GetVersion(t *testing.T) {
+	server, kubeconfig := newTestServer(t, map[string]string{
+		"/version":         `{"major":"1","minor":"25","gitVersion":"v1.25.4+77bec7a"}`,
+		clusterVersionPath: `{"kind":"ClusterVersion","status":{"desired":{"version":"4.12.3"}}}`,
+	})
+	defer server.Close()
+
+	client, err := NewClient(kubeconfig)
+	require.NoError(t, err)
+	version, err := client.GetVersion()
+	require.NoError(t, err)
+	require.Equal(t, &Version{OpenShift: "4.12.3", Kubernetes: "v1.25.4+77bec7a"}, version)
+
+	// The ClusterVersion is not found in the clusters which are not OpenShift clusters
+	server, kubeconfig = newTestServer(t, map[string]string{
+		"/version": `{"major":"1","minor":"26","gitVersion":"v1.26.0"}`,
+	})
+	defer server.Close()
+
+	client, err = NewClient(kubeconfig)
+	require.NoError(t, err)
+	version, err = client.GetVersion()
+	require.NoError(t, err)
+	require.Equal(t, &Version{Kubernetes: "v1.26.0"}, version)
+}
+
+func TestClient_DryRunCreate(t *testing.T) {
+	server, kubeconfig := newTestServer(t, map[string]string{
+		"POST /apis/apps/v1/namespaces/default/deployments": `{"apiVersion":"apps/v1","kind":"Deployment",` +
+			`"metadata":{"name":"valid","namespace":"default"}}`,
+		"POST /api/v1/namespaces/default/services": `{"kind":"Status","apiVersion":"v1","status":"Failure",` +
+			`"message":"Service \"invalid\" is invalid: spec.ports: Required value","reason":"Invalid","code":422}`,
+	})
+	defer server.Close()
+
+	client, err := NewClient(kubeconfig)
+	require.NoError(t, err)
+
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetName("valid")
+	require.NoError(t, client.DryRunCreate(deployment, "default"))
+
+	service := &unstructured.Unstructured{}
+	service.SetAPIVersion("v1")
+	service.SetKind("Service")
+	service.SetName("invalid")
+	require.EqualError(t, client.DryRunCreate(service, "default"),
+		`Service "invalid" is invalid: spec.ports: Required value`)
+
+	role := &unstructured.Unstructured{}
+	role.SetAPIVersion("rbac.authorization.k8s.io/v1")
+	role.SetKind("Role")
+	role.SetName("unknown")
+	require.EqualError(t, client.DryRunCreate(role, "default"),
+		"the API rbac.authorization.k8s.io/v1 Role is not served by the cluster")
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cosign provides the verification of the signatures and attestations of the images via the cosign
+// binary (https://github.com/sigstore/cosign).
+package cosign
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultBinary defines the cosign binary used when COSIGN_BIN is not informed
+const defaultBinary = "cosign"
+
+// ProvenanceType defines the predicate type of the provenance attestations
+const ProvenanceType = "slsaprovenance"
+
+// Options defines how the signatures are verified. Either the Key or Keyless must be informed.
+type Options struct {
+	// Key is the path or the KMS URI of the public key used to verify the signatures
+	Key string
+	// Keyless verifies the signatures via the certificates issued by Fulcio and the Rekor transparency log
+	Keyless bool
+	// CertificateIdentity is the regular expression which the identity of the keyless signatures must match.
+	// It is required by the keyless verification.
+	CertificateIdentity string
+	// CertificateOIDCIssuer is the regular expression which the OIDC issuer of the keyless signatures must
+	// match. It is required by the keyless verification.
+	CertificateOIDCIssuer string
+}
+
+// Validate returns an error when the options do not inform how the signatures are verified or when the keyless
+// verification is not restricted to an identity and issuer
+func (o Options) Validate() error {
+	if len(o.Key) == 0 && !o.Keyless {
+		return fmt.Errorf("neither the key nor the keyless verification was informed")
+	}
+	if len(o.Key) > 0 && o.Keyless {
+		return fmt.Errorf("the key and the keyless verification cannot be informed together")
+	}
+	if o.Keyless && (len(o.CertificateIdentity) == 0 || len(o.CertificateOIDCIssuer) == 0) {
+		return fmt.Errorf("the keyless verification requires the certificate identity and OIDC issuer")
+	}
+	return nil
+}
+
+// args returns the arguments of cosign which configure the verification
+func (o Options) args() []string {
+	if !o.Keyless {
+		return []string{"--key", o.Key}
+	}
+	return []string{"--certificate-identity-regexp", o.CertificateIdentity, "--certificate-oidc-issuer-regexp",
+		o.CertificateOIDCIssuer}
+}
+
+// runCommand defines the function used to run cosign and to return its error output
+var runCommand = func(name string, args ...string) (string, error) {
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if _, exited := err.(*exec.ExitError); err != nil && !exited {
+		return "", fmt.Errorf("unable to run cosign: %s", err)
+	}
+	return stderr.String(), err
+}
+
+// VerifySignature verifies the signatures of the image informed. The binary can be informed via COSIGN_BIN.
+func VerifySignature(image string, opts Options) error {
+	return verify(append(append([]string{"verify"}, opts.args()...), "--", image), opts)
+}
+
+// VerifyAttestation verifies the attestations of the predicate type informed (e.g. ProvenanceType) of the
+// image informed. The binary can be informed via COSIGN_BIN.
+func VerifyAttestation(image, predicateType string, opts Options) error {
+	args := append([]string{"verify-attestation", "--type", predicateType}, opts.args()...)
+	return verify(append(args, "--", image), opts)
+}
+
+// verify runs cosign with the arguments informed (where the image follows "--" to not be parsed as a flag)
+// and returns an error with its output when it fails
+func verify(args []string, opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	binary := os.Getenv("COSIGN_BIN")
+	if len(binary) == 0 {
+		binary = defaultBinary
+	}
+	output, err := runCommand(binary, args...)
+	if err == nil {
+		return nil
+	}
+	// The last line of the output informs the reason (e.g. Error: no matching signatures)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	reason := strings.TrimPrefix(strings.TrimSpace(lines[len(lines)-1]), "Error: ")
+	if len(reason) == 0 {
+		reason = err.Error()
+	}
+	return fmt.Errorf("%s", reason)
+}
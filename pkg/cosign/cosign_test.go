@@ -0,0 +1,66 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosign
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignature(t *testing.T) {
+	defaultRunCommand := runCommand
+	defer func() { runCommand = defaultRunCommand }()
+
+	var calls [][]string
+	runCommand = func(name string, args ...string) (string, error) {
+		require.Equal(t, "cosign", name)
+		calls = append(calls, args)
+		if args[len(args)-1] == "quay.io/example/unsigned:v0.0.1" {
+			return "Verification for quay.io/example/unsigned:v0.0.1 --\n" +
+				"Error: no matching signatures\nmain.go:69: error during command execution: no matching signatures\n" +
+				"Error: no matching signatures", fmt.Errorf("exit status 1")
+		}
+		return "", nil
+	}
+
+	require.NoError(t, VerifySignature("quay.io/example/bundle:v0.0.1", Options{Key: "cosign.pub"}))
+	require.Equal(t, []string{"verify", "--key", "cosign.pub", "--", "quay.io/example/bundle:v0.0.1"}, calls[0])
+
+	require.NoError(t, VerifyAttestation("quay.io/example/bundle:v0.0.1", ProvenanceType,
+		Options{Keyless: true, CertificateIdentity: "https://github.com/example/.*",
+			CertificateOIDCIssuer: "https://token.actions.githubusercontent.com"}))
+	require.Equal(t, []string{"verify-attestation", "--type", "slsaprovenance", "--certificate-identity-regexp",
+		"https://github.com/example/.*", "--certificate-oidc-issuer-regexp",
+		"https://token.actions.githubusercontent.com", "--", "quay.io/example/bundle:v0.0.1"}, calls[1])
+
+	require.EqualError(t, VerifySignature("quay.io/example/unsigned:v0.0.1", Options{Key: "cosign.pub"}),
+		"no matching signatures")
+	require.EqualError(t, VerifySignature("quay.io/example/bundle:v0.0.1", Options{}),
+		"neither the key nor the keyless verification was informed")
+	require.EqualError(t, VerifySignature("quay.io/example/bundle:v0.0.1", Options{Key: "cosign.pub",
+		Keyless: true}), "the key and the keyless verification cannot be informed together")
+	require.EqualError(t, VerifySignature("quay.io/example/bundle:v0.0.1", Options{Keyless: true,
+		CertificateOIDCIssuer: "https://token.actions.githubusercontent.com"}),
+		"the keyless verification requires the certificate identity and OIDC issuer")
+	require.Equal(t, 3, len(calls))
+
+	runCommand = func(name string, args ...string) (string, error) {
+		return "", fmt.Errorf("exit status 1")
+	}
+	require.EqualError(t, VerifySignature("quay.io/example/bundle:v0.0.1", Options{Key: "cosign.pub"}),
+		"exit status 1")
+}
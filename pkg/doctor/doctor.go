@@ -0,0 +1,254 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package doctor provides the checks of the runtime environment of the validator (e.g. the connectivity with
+// the registries, the binaries used and the caches) with the actionable fixes for the issues found.
+package doctor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/cincinnati"
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/registry"
+)
+
+// The status of the checks
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+	StatusFailed  = "failed"
+)
+
+// DefaultRegistries defines the registries checked when none is informed
+var DefaultRegistries = []string{"registry.redhat.io", "registry.connect.redhat.com", "quay.io"}
+
+// Check defines the result of a check of the environment
+type Check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// Fix is the action which solves the issue found
+	Fix string `json:"fix,omitempty"`
+}
+
+// RegistryInspector defines the operations used to check the registries
+type RegistryInspector interface {
+	// Ping checks that the registry informed accepts the requests
+	Ping(host string) error
+	// HasCredentials returns true when the credentials of the registry informed are found
+	HasCredentials(host string) bool
+}
+
+// CacheInspector defines the operations used to check the data cached
+type CacheInspector interface {
+	// CachePath returns the path of the file where the data is cached
+	CachePath() string
+	// CachedAt returns when the data was cached or a zero time when it was not cached
+	CachedAt() (time.Time, error)
+}
+
+// Doctor runs the checks of the environment
+type Doctor struct {
+	Registries []string
+	Registry   RegistryInspector
+	// OCPVersions is the cache of the OCP versions released used by --check-ocp-versions
+	OCPVersions    CacheInspector
+	OCPVersionsTTL time.Duration
+	// CacheDir is the directory where the validator caches its data
+	CacheDir string
+	// LookPath returns the path of the binary informed
+	LookPath func(file string) (string, error)
+	Getenv   func(key string) string
+}
+
+// New returns a Doctor which checks the registries informed (or the DefaultRegistries) with the clients and
+// the environment of the current process
+func New(registries []string) *Doctor {
+	if len(registries) == 0 {
+		registries = DefaultRegistries
+	}
+	var cacheDir string
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "ocp-olm-catalog-validator")
+	}
+	return &Doctor{
+		Registries:     registries,
+		Registry:       registry.NewClient(),
+		OCPVersions:    cincinnati.NewClient(),
+		OCPVersionsTTL: cincinnati.DefaultCacheTTL,
+		CacheDir:       cacheDir,
+		LookPath:       exec.LookPath,
+		Getenv:         os.Getenv,
+	}
+}
+
+// Run returns the results of all checks
+func (d *Doctor) Run() []Check {
+	var checks []Check
+	for _, host := range d.Registries {
+		checks = append(checks, d.checkRegistry(host))
+	}
+	checks = append(checks, d.checkBinary("opm", "", "render the index images into File-Based Catalogs "+
+		"(opm render) for the catalog checks", "https://github.com/operator-framework/operator-registry/releases"))
+	checks = append(checks, d.checkBinary("preflight", "PREFLIGHT_BIN", "run the operator checks of preflight "+
+		"(--preflight)", "https://github.com/redhat-openshift-ecosystem/openshift-preflight/releases"))
+	checks = append(checks, d.checkBinary("cosign", "COSIGN_BIN", "verify the signatures of the images "+
+		"(--verify-signature)", "https://github.com/sigstore/cosign/releases"))
+	checks = append(checks, d.checkProxy())
+	checks = append(checks, d.checkCacheDir())
+	checks = append(checks, d.checkOCPVersions())
+	return checks
+}
+
+// Failed returns true when any of the checks informed failed
+func Failed(checks []Check) bool {
+	for _, check := range checks {
+		if check.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRegistry checks the connectivity with the registry informed and its credentials
+func (d *Doctor) checkRegistry(host string) Check {
+	check := Check{Name: "registry " + host, Status: StatusOK}
+	credentials := "anonymously"
+	if d.Registry.HasCredentials(host) {
+		credentials = "with the credentials of the auth file"
+	}
+	if err := d.Registry.Ping(host); err != nil {
+		check.Status = StatusFailed
+		check.Message = fmt.Sprintf("unable to reach the registry %s: %s", credentials, err)
+		check.Fix = fmt.Sprintf("Please, ensure that %s is reachable (e.g. via the proxy) and log in to it "+
+			"(e.g. podman login %s) or inform the auth file via REGISTRY_AUTH_FILE", host, host)
+		return check
+	}
+	check.Message = "the registry is reachable " + credentials
+	return check
+}
+
+// checkBinary checks that the binary informed, which is required to perform the action informed, is found.
+// Note that the path of the binary can be informed via the environment variable informed (if any).
+func (d *Doctor) checkBinary(binary, env, action, releases string) Check {
+	check := Check{Name: binary, Status: StatusOK}
+	name := binary
+	if len(env) > 0 && len(d.Getenv(env)) > 0 {
+		name = d.Getenv(env)
+	}
+	path, err := d.LookPath(name)
+	if err != nil {
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("the binary %s was not found. Note that it is required to %s", name, action)
+		check.Fix = fmt.Sprintf("Please, install it from %s", releases)
+		if len(env) > 0 {
+			check.Fix += " or inform its path via " + env
+		}
+		return check
+	}
+	check.Message = "found in " + path
+	return check
+}
+
+// checkProxy checks that the proxies informed via the environment are valid URLs
+func (d *Doctor) checkProxy() Check {
+	check := Check{Name: "proxy", Status: StatusOK, Message: "no proxy is configured"}
+	var configured []string
+	for _, env := range []string{"HTTPS_PROXY", "HTTP_PROXY"} {
+		value := d.Getenv(env)
+		if len(value) == 0 {
+			value = d.Getenv(strings.ToLower(env))
+		}
+		if len(value) == 0 {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+			check.Status = StatusFailed
+			check.Message = fmt.Sprintf("the proxy informed via %s is not a valid URL", env)
+			check.Fix = fmt.Sprintf("Please, inform the URL of the proxy via %s (e.g. http://proxy.example.com:3128)",
+				env)
+			return check
+		}
+		// The credentials are not shown
+		u.User = nil
+		configured = append(configured, fmt.Sprintf("%s=%s", env, u))
+	}
+	if len(configured) == 0 {
+		return check
+	}
+	check.Message = "the requests are sent via " + strings.Join(configured, ", ")
+	if noProxy := d.Getenv("NO_PROXY"); len(noProxy) > 0 {
+		check.Message += " except for NO_PROXY=" + noProxy
+	}
+	return check
+}
+
+// checkCacheDir checks that the directory where the validator caches its data is writable
+func (d *Doctor) checkCacheDir() Check {
+	check := Check{Name: "cache directory", Status: StatusOK}
+	fix := "Please, ensure that the user cache directory is writable (e.g. via XDG_CACHE_HOME)"
+	if len(d.CacheDir) == 0 {
+		check.Status = StatusWarning
+		check.Message = "the user cache directory was not found. Note that the data will not be cached"
+		check.Fix = fix
+		return check
+	}
+	if err := os.MkdirAll(d.CacheDir, 0755); err != nil {
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("unable to create the directory %s: %s", d.CacheDir, err)
+		check.Fix = fix
+		return check
+	}
+	file, err := ioutil.TempFile(d.CacheDir, ".doctor")
+	if err != nil {
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("the directory %s is not writable: %s", d.CacheDir, err)
+		check.Fix = fix
+		return check
+	}
+	file.Close()
+	os.Remove(file.Name())
+	check.Message = fmt.Sprintf("the directory %s is writable", d.CacheDir)
+	return check
+}
+
+// checkOCPVersions checks the freshness of the OCP versions released which are cached
+func (d *Doctor) checkOCPVersions() Check {
+	check := Check{Name: "OCP versions", Status: StatusOK}
+	cachedAt, err := d.OCPVersions.CachedAt()
+	switch {
+	case err != nil:
+		check.Status = StatusFailed
+		check.Message = err.Error()
+		check.Fix = fmt.Sprintf("Please, remove the file %s to request the versions again",
+			d.OCPVersions.CachePath())
+	case cachedAt.IsZero():
+		check.Message = "the OCP versions released are not cached. Note that they are requested to the " +
+			"OpenShift Update Service on the first run with --check-ocp-versions"
+	case time.Since(cachedAt) > d.OCPVersionsTTL:
+		check.Message = fmt.Sprintf("the OCP versions released were cached on %s and will be requested again on "+
+			"the next run with --check-ocp-versions", cachedAt.Format(time.RFC3339))
+	default:
+		check.Message = fmt.Sprintf("the OCP versions released were cached on %s", cachedAt.Format(time.RFC3339))
+	}
+	return check
+}
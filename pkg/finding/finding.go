@@ -0,0 +1,164 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package finding provides a typed model of the findings reported by the validators which allows the Go
+// consumers to classify them without parsing the messages. The findings can be converted from and to the
+// errors.ManifestResult returned by the validators.
+package finding
+
+import (
+	"strings"
+
+	"github.com/operator-framework/api/pkg/validation/errors"
+)
+
+// Severity defines whether the finding must be fixed (error) or should be addressed (warning)
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// TypeKey defines the key of the metadata which stores the errors.ErrorType of the finding
+const TypeKey = "type"
+
+// CSVKey defines the key of the metadata which stores the name of the CSV informed in the invalid CSV errors
+const CSVKey = "csv"
+
+// ObjectReference identifies the object of the bundle which the finding is about
+type ObjectReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// Finding represents an error or warning reported by a validator for a bundle
+type Finding struct {
+	// RuleID identifies the check which reported the finding (e.g. the name of the validator)
+	RuleID string `json:"ruleID"`
+	// Severity of the finding
+	Severity Severity `json:"severity"`
+	// Bundle is the name of the bundle which the finding was reported for
+	Bundle string `json:"bundle,omitempty"`
+	// Subject is the object of the bundle which the finding is about when it is known
+	Subject *ObjectReference `json:"subject,omitempty"`
+	// Location is the path of the field which the finding is about (e.g. spec.icon) when it is known
+	Location string `json:"location,omitempty"`
+	// Message describes the finding
+	Message string `json:"message"`
+	// Remediation describes how to address the finding when it is known
+	Remediation string `json:"remediation,omitempty"`
+	// Metadata has additional information about the finding (e.g. the type of the error)
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// badValue is the value of the error which the finding was converted from
+	badValue interface{}
+}
+
+// Details defines the structured data of the findings reported by the checks of this project, which is
+// informed as the value (BadValue) of their errors. Note that it is formatted as an empty value to not change
+// the messages of the errors.
+type Details struct {
+	// CSV is the name of the CSV of the bundle
+	CSV string `json:"csv,omitempty"`
+	// Subject is the object of the bundle which the finding is about. Note that the CSV is the subject when it
+	// is not informed.
+	Subject *ObjectReference `json:"subject,omitempty"`
+	// Remediation describes how to address the finding (e.g. Inform spec.icon)
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// String returns an empty value since the details are not part of the messages of the errors
+func (d Details) String() string {
+	return ""
+}
+
+// FromManifestResult returns the findings of the errors and warnings of the result informed
+// reported by the rule informed
+func FromManifestResult(ruleID string, result errors.ManifestResult) []Finding {
+	var findings []Finding
+	for _, e := range append(append([]errors.Error{}, result.Errors...), result.Warnings...) {
+		findings = append(findings, FromError(ruleID, result.Name, e))
+	}
+	return findings
+}
+
+// FromError returns the finding of the error informed reported by the rule informed for the bundle informed.
+// Note that the subject and the remediation are only known when the error informs its Details.
+func FromError(ruleID, bundle string, err errors.Error) Finding {
+	f := Finding{
+		RuleID:   ruleID,
+		Severity: SeverityWarning,
+		Bundle:   bundle,
+		Location: err.Field,
+		Message:  err.Detail,
+		Metadata: map[string]string{TypeKey: string(err.Type)},
+		badValue: err.BadValue,
+	}
+	if err.Level == errors.LevelError {
+		f.Severity = SeverityError
+	}
+	details, ok := err.BadValue.(Details)
+	if !ok {
+		return f
+	}
+	// The invalid CSV errors inform the name of the CSV before their detail
+	if csv := "(" + details.CSV + ") "; err.Type == errors.ErrorInvalidCSV && strings.HasPrefix(err.Detail, csv) {
+		f.Message = strings.TrimPrefix(err.Detail, csv)
+		f.Metadata[CSVKey] = details.CSV
+	}
+	f.Subject = details.Subject
+	if f.Subject == nil && len(details.CSV) > 0 {
+		f.Subject = &ObjectReference{Kind: "ClusterServiceVersion", Name: details.CSV}
+	}
+	f.Remediation = details.Remediation
+	return f
+}
+
+// ToManifestResult returns the result with the errors and warnings of the findings informed
+func ToManifestResult(bundle string, findings []Finding) errors.ManifestResult {
+	result := errors.ManifestResult{Name: bundle}
+	for _, f := range findings {
+		result.Add(f.ToError())
+	}
+	return result
+}
+
+// ToError returns the finding as an error or warning. Note that the value of the error is the name of the
+// subject when the finding was not converted from an error, or its Details when it is about a CSV.
+func (f Finding) ToError() errors.Error {
+	value := f.badValue
+	if value == nil && f.Subject != nil {
+		value = f.Subject.Name
+	}
+	errType := errors.ErrorType(f.Metadata[TypeKey])
+	if len(errType) == 0 {
+		errType = errors.ErrorFailedValidation
+	}
+	if csv, found := f.Metadata[CSVKey]; found && errType == errors.ErrorInvalidCSV {
+		err := errors.WarnInvalidCSV(f.Message, csv)
+		if f.Severity == SeverityError {
+			err = errors.ErrInvalidCSV(f.Message, csv)
+		}
+		err.BadValue = f.badValue
+		if f.badValue == nil {
+			err.BadValue = Details{CSV: csv, Subject: f.Subject, Remediation: f.Remediation}
+		}
+		return err
+	}
+	if f.Severity == SeverityError {
+		return errors.NewError(errType, f.Message, f.Location, value)
+	}
+	return errors.NewWarn(errType, f.Message, f.Location, value)
+}
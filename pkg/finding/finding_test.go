@@ -0,0 +1,98 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finding
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/validation/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromManifestResult(t *testing.T) {
+	result := errors.ManifestResult{Name: "memcached-operator.v0.0.1"}
+	warn := errors.WarnInvalidCSV("the container manager of the deployment memcached-operator-controller-manager "+
+		"does not define resource limits. Please, define the cpu and memory under resources",
+		"memcached-operator.v0.0.1")
+	warn.BadValue = Details{
+		CSV:         "memcached-operator.v0.0.1",
+		Subject:     &ObjectReference{Kind: "Deployment", Name: "memcached-operator-controller-manager"},
+		Remediation: "Define the cpu and memory under resources",
+	}
+	result.Add(warn)
+	err := errors.ErrInvalidCSV("the CSV name is invalid", "memcached-operator.v0.0.1")
+	err.BadValue = Details{CSV: "memcached-operator.v0.0.1"}
+	result.Add(err)
+	result.Add(errors.ErrInvalidBundle("Bundle csv is nil", nil))
+	// The errors of the upstream validators have no details
+	result.Add(errors.WarnInvalidCSV("the deployment memcached-operator-controller-manager has no replicas. "+
+		"Please, inform them", "memcached-operator.v0.0.1"))
+
+	findings := FromManifestResult("deployment-practices", result)
+	require.Equal(t, []Finding{
+		{
+			RuleID:   "deployment-practices",
+			Severity: SeverityError,
+			Bundle:   "memcached-operator.v0.0.1",
+			Subject:  &ObjectReference{Kind: "ClusterServiceVersion", Name: "memcached-operator.v0.0.1"},
+			Message:  "the CSV name is invalid",
+			Metadata: map[string]string{TypeKey: string(errors.ErrorInvalidCSV), CSVKey: "memcached-operator.v0.0.1"},
+			badValue: Details{CSV: "memcached-operator.v0.0.1"},
+		},
+		{
+			RuleID:   "deployment-practices",
+			Severity: SeverityError,
+			Bundle:   "memcached-operator.v0.0.1",
+			Message:  "Bundle csv is nil",
+			Metadata: map[string]string{TypeKey: string(errors.ErrorInvalidBundle)},
+		},
+		{
+			RuleID:   "deployment-practices",
+			Severity: SeverityWarning,
+			Bundle:   "memcached-operator.v0.0.1",
+			Subject:  &ObjectReference{Kind: "Deployment", Name: "memcached-operator-controller-manager"},
+			Message: "the container manager of the deployment memcached-operator-controller-manager does not " +
+				"define resource limits. Please, define the cpu and memory under resources",
+			Remediation: "Define the cpu and memory under resources",
+			Metadata: map[string]string{TypeKey: string(errors.ErrorInvalidCSV),
+				CSVKey: "memcached-operator.v0.0.1"},
+			badValue: warn.BadValue,
+		},
+		{
+			RuleID:   "deployment-practices",
+			Severity: SeverityWarning,
+			Bundle:   "memcached-operator.v0.0.1",
+			Message: "(memcached-operator.v0.0.1) the deployment memcached-operator-controller-manager has no " +
+				"replicas. Please, inform them",
+			Metadata: map[string]string{TypeKey: string(errors.ErrorInvalidCSV)},
+			badValue: "",
+		},
+	}, findings)
+
+	// The result is kept when it is converted back
+	require.Equal(t, result, ToManifestResult(result.Name, findings))
+}
+
+func TestFinding_ToError(t *testing.T) {
+	f := Finding{
+		RuleID:   "crd",
+		Severity: SeverityWarning,
+		Subject:  &ObjectReference{Kind: "CustomResourceDefinition", Name: "memcacheds.cache.example.com"},
+		Location: "spec.versions",
+		Message:  "the CRD has no storage version",
+	}
+	require.Equal(t, errors.NewWarn(errors.ErrorFailedValidation, "the CRD has no storage version", "spec.versions",
+		"memcacheds.cache.example.com"), f.ToError())
+}
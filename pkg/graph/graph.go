@@ -0,0 +1,262 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graph provides the upgrade graph of the channels of a package with the OCP compatibility verdict of
+// its bundles and its output in the DOT (Graphviz) format (e.g. dot -Tsvg graph.dot > graph.svg).
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// FormatDOT defines the DOT (Graphviz) format of the output
+const FormatDOT = "dot"
+
+// Verdict defines the OCP compatibility verdict of a bundle
+type Verdict string
+
+// The verdicts of the bundles. Note that the unknown verdict is given to the bundles which were not checked
+// (e.g. the bundles replaced which are not found).
+const (
+	VerdictUnknown      Verdict = "unknown"
+	VerdictCompatible   Verdict = "compatible"
+	VerdictWarning      Verdict = "warning"
+	VerdictIncompatible Verdict = "incompatible"
+)
+
+// verdictColors defines the fill colors of the nodes by verdict
+var verdictColors = map[Verdict]string{
+	VerdictUnknown:      "lightgrey",
+	VerdictCompatible:   "palegreen",
+	VerdictWarning:      "khaki",
+	VerdictIncompatible: "salmon",
+}
+
+// verdictOrder defines the verdicts from the least to the most severe
+var verdictOrder = []Verdict{VerdictUnknown, VerdictCompatible, VerdictWarning, VerdictIncompatible}
+
+// Node defines a bundle of the graph
+type Node struct {
+	Name    string
+	Verdict Verdict
+	// Reason informs why the verdict was given (e.g. the olm.maxOpenShiftVersion of the bundle)
+	Reason string
+}
+
+// Edge defines an upgrade from the bundle From to the bundle To, which replaces or skips it
+type Edge struct {
+	From     string
+	To       string
+	Channels []string
+	Skip     bool
+}
+
+// Graph defines the upgrade graph of the channels of a package
+type Graph struct {
+	Package        string
+	DefaultChannel string
+	channels       map[string][]string
+	nodes          map[string]*Node
+	edges          map[[2]string]*Edge
+}
+
+// New returns an empty graph of the package informed
+func New(packageName string) *Graph {
+	return &Graph{Package: packageName, channels: map[string][]string{}, nodes: map[string]*Node{},
+		edges: map[[2]string]*Edge{}}
+}
+
+// AddNode adds the bundle informed. When the bundle was already added, the most severe verdict is kept.
+func (g *Graph) AddNode(name string, verdict Verdict, reason string) {
+	node, found := g.nodes[name]
+	if !found {
+		g.nodes[name] = &Node{Name: name, Verdict: verdict, Reason: reason}
+		return
+	}
+	if severity(verdict) > severity(node.Verdict) {
+		node.Verdict, node.Reason = verdict, reason
+	}
+}
+
+// AddEdge adds the upgrade from the bundle from to the bundle to of the channel informed (if any). Note that
+// the bundle to replaces the bundle from, or skips it when skip is true. The bundles which were not added are
+// added with the unknown verdict.
+func (g *Graph) AddEdge(from, to, channel string, skip bool) {
+	for _, name := range []string{from, to} {
+		if _, found := g.nodes[name]; !found {
+			g.AddNode(name, VerdictUnknown, "")
+		}
+	}
+	edge, found := g.edges[[2]string{from, to}]
+	if !found {
+		edge = &Edge{From: from, To: to, Skip: skip}
+		g.edges[[2]string{from, to}] = edge
+	}
+	// The replaces takes precedence over the skips
+	edge.Skip = edge.Skip && skip
+	if len(channel) > 0 && !contains(edge.Channels, channel) {
+		edge.Channels = append(edge.Channels, channel)
+		sort.Strings(edge.Channels)
+	}
+}
+
+// AddChannelEntry adds the bundle informed to the channel informed. The bundles which were not added are
+// added with the unknown verdict.
+func (g *Graph) AddChannelEntry(channel, name string) {
+	if _, found := g.nodes[name]; !found {
+		g.AddNode(name, VerdictUnknown, "")
+	}
+	if !contains(g.channels[channel], name) {
+		g.channels[channel] = append(g.channels[channel], name)
+	}
+}
+
+// Heads returns the sorted heads of the channel informed, which are its bundles that are not replaced or skipped
+// by another bundle of the channel. Note that a channel with more than one head has a broken upgrade graph.
+func (g *Graph) Heads(channel string) []string {
+	var heads []string
+	for _, name := range g.channels[channel] {
+		head := true
+		for _, edge := range g.edges {
+			if edge.From == name && contains(edge.Channels, channel) {
+				head = false
+				break
+			}
+		}
+		if head {
+			heads = append(heads, name)
+		}
+	}
+	sort.Strings(heads)
+	return heads
+}
+
+// Nodes returns the bundles of the graph sorted by name
+func (g *Graph) Nodes() []Node {
+	var nodes []Node
+	for _, node := range g.nodes {
+		nodes = append(nodes, *node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
+
+// Edges returns the upgrades of the graph sorted by the bundles
+func (g *Graph) Edges() []Edge {
+	var edges []Edge
+	for _, edge := range g.edges {
+		edges = append(edges, *edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// Write writes the graph in the format informed. Only the DOT format is supported.
+func (g *Graph) Write(w io.Writer, format string) error {
+	if format != FormatDOT {
+		return fmt.Errorf("invalid graph output format %s. Only %s is supported", format, FormatDOT)
+	}
+	return g.WriteDOT(w)
+}
+
+// WriteDOT writes the graph in the DOT format. The bundles are filled with the color of their verdict, the
+// heads are linked to their channels and the skips are dashed.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph %s {\n", quote(g.Package))
+	label := "Upgrade graph"
+	if len(g.Package) > 0 {
+		label += " of the package " + g.Package
+	}
+	fmt.Fprintf(b, "\tlabel=%s;\n", quote(label))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box, style=\"rounded,filled\"];\n")
+
+	var channels []string
+	for ch := range g.channels {
+		channels = append(channels, ch)
+	}
+	sort.Strings(channels)
+	for _, ch := range channels {
+		label := ch
+		if ch == g.DefaultChannel {
+			label += " (default)"
+		}
+		fmt.Fprintf(b, "\t%s [label=%s, shape=ellipse, style=solid];\n", quote("channel/"+ch), quote(label))
+		for _, head := range g.Heads(ch) {
+			fmt.Fprintf(b, "\t%s -> %s [style=dotted, arrowhead=none];\n", quote(head), quote("channel/"+ch))
+		}
+	}
+
+	for _, node := range g.Nodes() {
+		tooltip := string(node.Verdict)
+		if len(node.Reason) > 0 {
+			tooltip += ": " + node.Reason
+		}
+		fmt.Fprintf(b, "\t%s [fillcolor=%s, tooltip=%s];\n", quote(node.Name), verdictColors[node.Verdict],
+			quote(tooltip))
+	}
+
+	for _, edge := range g.Edges() {
+		var attrs []string
+		if len(edge.Channels) > 0 {
+			attrs = append(attrs, "label="+quote(strings.Join(edge.Channels, ", ")))
+		}
+		if edge.Skip {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(b, "\t%s -> %s", quote(edge.From), quote(edge.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// severity returns the index of the verdict informed in verdictOrder
+func severity(verdict Verdict) int {
+	for i, v := range verdictOrder {
+		if v == verdict {
+			return i
+		}
+	}
+	return 0
+}
+
+// quote returns the DOT identifier informed as a quoted string
+func quote(id string) string {
+	return `"` + strings.ReplaceAll(id, `"`, `\"`) + `"`
+}
+
+// contains returns true when the list informed contains the value informed
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
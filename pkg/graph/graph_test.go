@@ -0,0 +1,65 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph_WriteDOT(t *testing.T) {
+	g := New("memcached-operator")
+	g.DefaultChannel = "stable"
+	g.AddNode("memcached-operator.v0.0.1", VerdictCompatible, "")
+	g.AddNode("memcached-operator.v0.0.2", VerdictCompatible, "")
+	g.AddNode("memcached-operator.v0.0.2", VerdictIncompatible, `it uses "v1beta1" APIs`)
+	g.AddNode("memcached-operator.v0.0.2", VerdictWarning, "it sets olm.maxOpenShiftVersion")
+	for _, ch := range []string{"alpha", "stable"} {
+		g.AddChannelEntry(ch, "memcached-operator.v0.0.1")
+		g.AddChannelEntry(ch, "memcached-operator.v0.0.2")
+		g.AddChannelEntry(ch, "memcached-operator.v0.0.3")
+		g.AddEdge("memcached-operator.v0.0.1", "memcached-operator.v0.0.2", ch, false)
+	}
+	g.AddEdge("memcached-operator.v0.0.1", "memcached-operator.v0.0.3", "alpha", true)
+	g.AddEdge("memcached-operator.v0.0.2", "memcached-operator.v0.0.3", "alpha", false)
+
+	require.Equal(t, []string{"memcached-operator.v0.0.3"}, g.Heads("alpha"))
+	// The upgrade graph of the stable channel is broken
+	require.Equal(t, []string{"memcached-operator.v0.0.2", "memcached-operator.v0.0.3"}, g.Heads("stable"))
+
+	out := &bytes.Buffer{}
+	require.NoError(t, g.Write(out, FormatDOT))
+	require.Equal(t, `digraph "memcached-operator" {
+	label="Upgrade graph of the package memcached-operator";
+	rankdir=LR;
+	node [shape=box, style="rounded,filled"];
+	"channel/alpha" [label="alpha", shape=ellipse, style=solid];
+	"memcached-operator.v0.0.3" -> "channel/alpha" [style=dotted, arrowhead=none];
+	"channel/stable" [label="stable (default)", shape=ellipse, style=solid];
+	"memcached-operator.v0.0.2" -> "channel/stable" [style=dotted, arrowhead=none];
+	"memcached-operator.v0.0.3" -> "channel/stable" [style=dotted, arrowhead=none];
+	"memcached-operator.v0.0.1" [fillcolor=palegreen, tooltip="compatible"];
+	"memcached-operator.v0.0.2" [fillcolor=salmon, tooltip="incompatible: it uses \"v1beta1\" APIs"];
+	"memcached-operator.v0.0.3" [fillcolor=lightgrey, tooltip="unknown"];
+	"memcached-operator.v0.0.1" -> "memcached-operator.v0.0.2" [label="alpha, stable"];
+	"memcached-operator.v0.0.1" -> "memcached-operator.v0.0.3" [label="alpha", style=dashed];
+	"memcached-operator.v0.0.2" -> "memcached-operator.v0.0.3" [label="alpha"];
+}
+`, out.String())
+
+	require.EqualError(t, g.Write(out, "svg"), "invalid graph output format svg. Only dot is supported")
+}
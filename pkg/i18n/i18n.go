@@ -0,0 +1,138 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n provides the message catalogs used to translate the findings of the validators. Each catalog
+// defines, for a locale, the regular expressions which match the English messages and their translations.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultLang defines the language of the messages reported by the validators which need no catalog
+const DefaultLang = "en"
+
+//go:embed catalogs/*.yaml
+var catalogs embed.FS
+
+// Catalog defines the translations of the messages for a locale, for example:
+//
+//	lang: es
+//	messages:
+//	- id: csv-missing-links
+//	  match: the CSV does not inform (.+) which are used by the console
+//	  translation: el CSV no informa ${1} que son usados por la consola
+type Catalog struct {
+	// Lang is the locale of the translations (e.g. es or pt-br)
+	Lang string `json:"lang"`
+	// Messages has the translations which are applied in order to the messages
+	Messages []Message `json:"messages"`
+}
+
+// Message defines the translation of the messages matching a regular expression
+type Message struct {
+	// ID identifies the message in the catalog
+	ID string `json:"id"`
+	// Match is the regular expression which matches the English message (or part of it)
+	Match string `json:"match"`
+	// Translation replaces the text matched. Note that the groups of the expression can be
+	// referenced (e.g. ${1}).
+	Translation string `json:"translation"`
+
+	re *regexp.Regexp
+}
+
+// Load returns the catalog provided for the locale informed (e.g. es, es_ES.UTF-8 or pt-BR). Note that the
+// catalog of the language is used when there is no catalog for the region. A nil catalog, which does not
+// translate the messages, is returned for the default language.
+func Load(lang string) (*Catalog, error) {
+	locale := normalizeLocale(lang)
+	if locale == DefaultLang || strings.HasPrefix(locale, DefaultLang+"-") {
+		return nil, nil
+	}
+	for _, name := range []string{locale, strings.Split(locale, "-")[0]} {
+		content, err := catalogs.ReadFile(path.Join("catalogs", name+".yaml"))
+		if err != nil {
+			continue
+		}
+		return parseCatalog(name, content)
+	}
+	return nil, fmt.Errorf("the language %s is not supported. Please, inform one of: %s", lang,
+		strings.Join(Languages(), ", "))
+}
+
+// LoadFile returns the catalog defined in the YAML file informed. It allows to provide the translations
+// for the locales which are not shipped with the validator.
+func LoadFile(file string) (*Catalog, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the message catalog: %s", err)
+	}
+	return parseCatalog(file, content)
+}
+
+// Languages returns the sorted locales which have a catalog, including the default language
+func Languages() []string {
+	langs := []string{DefaultLang}
+	entries, _ := catalogs.ReadDir("catalogs")
+	for _, entry := range entries {
+		langs = append(langs, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Translate returns the message informed with the translations of the catalog applied. The message
+// is returned as it is when no translation matches it.
+func (c *Catalog) Translate(msg string) string {
+	if c == nil {
+		return msg
+	}
+	for _, m := range c.Messages {
+		msg = m.re.ReplaceAllString(msg, m.Translation)
+	}
+	return msg
+}
+
+// parseCatalog returns the catalog with the content informed and its expressions compiled
+func parseCatalog(name string, content []byte) (*Catalog, error) {
+	catalog := &Catalog{}
+	if err := yaml.UnmarshalStrict(content, catalog); err != nil {
+		return nil, fmt.Errorf("unable to parse the message catalog %s: %s", name, err)
+	}
+	for i, m := range catalog.Messages {
+		re, err := regexp.Compile(m.Match)
+		if err != nil {
+			return nil, fmt.Errorf("the message %s of the catalog %s has an invalid expression: %s", m.ID,
+				name, err)
+		}
+		catalog.Messages[i].re = re
+	}
+	return catalog, nil
+}
+
+// normalizeLocale returns the locale informed in lower case without the encoding
+// (e.g. es_ES.UTF-8 is returned as es-es)
+func normalizeLocale(lang string) string {
+	lang = strings.Split(strings.Split(lang, ".")[0], "@")[0]
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	for _, lang := range []string{"en", "en_US.UTF-8", "EN-gb"} {
+		catalog, err := Load(lang)
+		require.NoError(t, err)
+		require.Nil(t, catalog)
+		require.Equal(t, "the message", catalog.Translate("the message"))
+	}
+
+	for _, lang := range Languages() {
+		_, err := Load(lang)
+		require.NoError(t, err, lang)
+	}
+
+	catalog, err := Load("es_ES.UTF-8")
+	require.NoError(t, err)
+	require.Equal(t, "es", catalog.Lang)
+	require.Equal(t, "Advertencia: Valor : (memcached-operator.v0.0.1) el deployment manager no define sondas "+
+		"liveness en sus contenedores. Tenga en cuenta que el clúster no podrá detectar ni reiniciar los pods del "+
+		"operador que no estén sanos. Por favor, defina livenessProbe y readinessProbe (p. ej. /healthz y /readyz)",
+		catalog.Translate("Warning: Value : (memcached-operator.v0.0.1) the deployment manager does not define "+
+			"liveness probes in its containers. Note that the cluster will not be able to detect and restart "+
+			"unhealthy operator pods. Please, define livenessProbe and readinessProbe (e.g. /healthz and /readyz)"))
+	require.Equal(t, "Error: Valor : (memcached-operator.v0.0.1) the message is not translated",
+		catalog.Translate("Error: Value : (memcached-operator.v0.0.1) the message is not translated"))
+
+	_, err = Load("fr")
+	require.EqualError(t, err, "the language fr is not supported. Please, inform one of: en, es, ja")
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "pt.yaml")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`lang: pt
+messages:
+- id: provider-url
+  match: 'the URL \((.*)\) of spec\.provider is not valid'
+  translation: 'a URL (${1}) de spec.provider não é válida'
+`), 0600))
+	catalog, err := LoadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "a URL (example) de spec.provider não é válida",
+		catalog.Translate("the URL (example) of spec.provider is not valid"))
+
+	require.NoError(t, ioutil.WriteFile(file, []byte(`messages:
+- id: invalid
+  match: '('
+`), 0600))
+	_, err = LoadFile(file)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "the message invalid of the catalog")
+
+	_, err = LoadFile(filepath.Join(dir, "missing.yaml"))
+	require.Error(t, err)
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy provides the policies which declare the conditions over the aggregated results of the
+// validation to decide whether a bundle passes the gate of a release pipeline.
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/result"
+)
+
+// Policy defines the conditions which the results must respect to pass the gate, for example:
+//
+//	maxErrors: 0
+//	maxWarnings: 3
+//	mustPass:
+//	- ocp-versions
+//	- olm.maxOpenShiftVersion
+type Policy struct {
+	// MaxErrors is the maximum number of errors allowed. No errors are allowed when it is not informed.
+	MaxErrors *int `json:"maxErrors,omitempty"`
+	// MaxWarnings is the maximum number of warnings allowed. Any number is allowed when it is not informed.
+	MaxWarnings *int `json:"maxWarnings,omitempty"`
+	// MustPass has the regular expressions of the checks which must pass. Note that the gate fails when
+	// any error or warning matches with them. They are matched against the validator which reported the
+	// finding (e.g. ocp-versions), which does not change with the language of the messages, and then against
+	// its English message.
+	MustPass []string `json:"mustPass,omitempty"`
+
+	mustPass []*regexp.Regexp
+}
+
+// Load returns the policy defined in the YAML file informed
+func Load(path string) (*Policy, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the policy: %s", err)
+	}
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(content, policy); err != nil {
+		return nil, fmt.Errorf("unable to parse the policy %s: %s", path, err)
+	}
+	for _, expr := range policy.MustPass {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("the policy %s has an invalid expression in mustPass: %s", path, err)
+		}
+		policy.mustPass = append(policy.mustPass, re)
+	}
+	return policy, nil
+}
+
+// Evaluate returns the decision of the policy over the errors and warnings of the result informed
+func (p *Policy) Evaluate(res *result.Result) result.GateDecision {
+	gate := result.GateDecision{}
+	for _, out := range res.Outputs {
+		switch out.Type {
+		case logrus.ErrorLevel.String():
+			gate.Errors++
+		case logrus.WarnLevel.String():
+			gate.Warnings++
+		default:
+			continue
+		}
+		for i, re := range p.mustPass {
+			if re.MatchString(out.Validator) || re.MatchString(out.Message) {
+				gate.Violations = append(gate.Violations, fmt.Sprintf("the check %s must pass: %s",
+					p.MustPass[i], out.Message))
+			}
+		}
+	}
+
+	maxErrors := 0
+	if p.MaxErrors != nil {
+		maxErrors = *p.MaxErrors
+	}
+	if gate.Errors > maxErrors {
+		gate.Violations = append(gate.Violations, fmt.Sprintf("found %d errors where the maximum allowed is %d",
+			gate.Errors, maxErrors))
+	}
+	if p.MaxWarnings != nil && gate.Warnings > *p.MaxWarnings {
+		gate.Violations = append(gate.Violations, fmt.Sprintf("found %d warnings where the maximum allowed is %d",
+			gate.Warnings, *p.MaxWarnings))
+	}
+	gate.Passed = len(gate.Violations) == 0
+	return gate
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	apierrors "github.com/operator-framework/api/pkg/validation/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/result"
+)
+
+// writePolicy returns the path of a policy file with the content informed
+func writePolicy(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	res := result.NewResult()
+	res.AddInfo("Inventory: CustomResourceDefinition memcacheds.cache.example.com")
+	res.AddWarn(errors.New("the CSV does not inform spec.links"))
+	res.AddWarn(errors.New("the olm.maxOpenShiftVersion annotation with the value 4.12 will block the upgrade"))
+
+	tests := []struct {
+		name   string
+		policy string
+		want   result.GateDecision
+	}{
+		{
+			name:   "should pass when the limits are respected",
+			policy: "maxErrors: 0\nmaxWarnings: 3\n",
+			want:   result.GateDecision{Passed: true, Warnings: 2},
+		},
+		{
+			name:   "should fail when the warnings exceed the limit",
+			policy: "maxWarnings: 1\n",
+			want: result.GateDecision{Warnings: 2,
+				Violations: []string{"found 2 warnings where the maximum allowed is 1"}},
+		},
+		{
+			name:   "should fail when a check which must pass is found",
+			policy: "mustPass:\n- olm\\.maxOpenShiftVersion\n",
+			want: result.GateDecision{Warnings: 2, Violations: []string{"the check olm\\.maxOpenShiftVersion " +
+				"must pass: the olm.maxOpenShiftVersion annotation with the value 4.12 will block the upgrade"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := Load(writePolicy(t, tt.policy))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, policy.Evaluate(res))
+		})
+	}
+
+	res.AddGroupedResults(result.GroupResults{Group: result.Group{Validator: "ocp-versions"},
+		Results: []apierrors.ManifestResult{{Name: "memcached-operator.v0.0.1",
+			Warnings: []apierrors.Error{apierrors.WarnInvalidCSV("the bundle has no OCP versions",
+				"memcached-operator.v0.0.1")}}}})
+	policy, err := Load(writePolicy(t, "maxWarnings: 3\nmustPass:\n- ^ocp-versions$\n"))
+	require.NoError(t, err)
+	require.Equal(t, result.GateDecision{Warnings: 3, Violations: []string{"the check ^ocp-versions$ must pass: " +
+		"Warning: Value : (memcached-operator.v0.0.1) the bundle has no OCP versions"}}, policy.Evaluate(res))
+
+	res.AddError(errors.New("the CSV does not support the install mode AllNamespaces"))
+	policy, err = Load(writePolicy(t, "maxWarnings: 3\n"))
+	require.NoError(t, err)
+	require.Equal(t, result.GateDecision{Errors: 1, Warnings: 3,
+		Violations: []string{"found 1 errors where the maximum allowed is 0"}}, policy.Evaluate(res))
+}
+
+func TestLoad(t *testing.T) {
+	_, err := Load(writePolicy(t, "maxErrs: 0\n"))
+	require.Error(t, err)
+
+	_, err = Load(writePolicy(t, "mustPass:\n- '['\n"))
+	require.Error(t, err)
+
+	_, err = Load(filepath.Join(t.TempDir(), "not-found.yaml"))
+	require.Error(t, err)
+}
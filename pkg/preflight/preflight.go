@@ -0,0 +1,105 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preflight provides the glue to run the operator checks of openshift-preflight and to merge
+// their results with the results of the validators, so that both are reported in the same format.
+package preflight
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/validation/errors"
+)
+
+// defaultBinary defines the preflight binary used when PREFLIGHT_BIN is not informed
+const defaultBinary = "preflight"
+
+// Check defines the checks reported by preflight
+type Check struct {
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	Help             string `json:"help,omitempty"`
+	Suggestion       string `json:"suggestion,omitempty"`
+	KnowledgebaseURL string `json:"knowledgebase_url,omitempty"`
+}
+
+// Results defines the results reported by preflight in the JSON format
+type Results struct {
+	Image   string `json:"image"`
+	Passed  bool   `json:"passed"`
+	Results struct {
+		Passed []Check `json:"passed"`
+		Failed []Check `json:"failed"`
+		Errors []Check `json:"errors"`
+	} `json:"results"`
+}
+
+// runCommand defines the function used to run preflight and to return its output
+var runCommand = func(name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// Run executes the operator checks of preflight against the bundle image informed. The binary can be
+// informed via PREFLIGHT_BIN. Note that preflight reads its own configuration from the environment
+// (e.g. PFLT_INDEXIMAGE and KUBECONFIG).
+func Run(image string) (*Results, error) {
+	binary := os.Getenv("PREFLIGHT_BIN")
+	if len(binary) == 0 {
+		binary = defaultBinary
+	}
+	output, err := runCommand(binary, "check", "operator", image)
+	// The results are reported even when preflight exits with an error (e.g. checks failed)
+	results := &Results{}
+	if jsonErr := json.Unmarshal(output, results); jsonErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("unable to run preflight: %s", err)
+		}
+		return nil, fmt.Errorf("unable to decode the results of preflight: %s", jsonErr)
+	}
+	return results, nil
+}
+
+// ManifestResult returns the failed checks and the checks which could not be performed as errors
+func (r *Results) ManifestResult() errors.ManifestResult {
+	result := errors.ManifestResult{Name: fmt.Sprintf("preflight %s", r.Image)}
+	for _, check := range r.Results.Failed {
+		result.Add(errors.ErrFailedValidation(fmt.Sprintf("the preflight check %s failed: %s",
+			check.Name, check.details()), r.Image))
+	}
+	for _, check := range r.Results.Errors {
+		result.Add(errors.ErrFailedValidation(fmt.Sprintf("the preflight check %s could not be performed: %s",
+			check.Name, check.details()), r.Image))
+	}
+	return result
+}
+
+// details returns the description, help, suggestion and knowledge base URL of the check
+func (c Check) details() string {
+	var details []string
+	for _, detail := range []string{c.Description, c.Help, c.Suggestion} {
+		if detail = strings.TrimSuffix(strings.TrimSpace(detail), "."); len(detail) > 0 {
+			details = append(details, detail)
+		}
+	}
+	if len(c.KnowledgebaseURL) > 0 {
+		details = append(details, fmt.Sprintf("For further information see %s", c.KnowledgebaseURL))
+	}
+	return strings.Join(details, ". ")
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preflight
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const resultsJSON = `{
+  "image": "quay.io/example/memcached-operator-bundle:v0.0.1",
+  "passed": false,
+  "results": {
+    "passed": [{"name": "ValidateOperatorBundle", "description": "Validating Bundle image"}],
+    "failed": [{
+      "name": "DeployableByOLM",
+      "description": "Checking if the operator could be deployed by OLM",
+      "help": "Check DeployableByOLM encountered an error.",
+      "suggestion": "Follow the guidelines on the operator-sdk website to learn how to package your operator.",
+      "knowledgebase_url": "https://sdk.operatorframework.io/docs/olm-integration/quickstart-bundle/"
+    }],
+    "errors": [{
+      "name": "ScorecardBasicSpecCheck",
+      "description": "Check to make sure that all CRs have a spec block.",
+      "help": "Check ScorecardBasicSpecCheck encountered an error."
+    }]
+  }
+}`
+
+func TestRun(t *testing.T) {
+	defaultRunCommand := runCommand
+	defer func() { runCommand = defaultRunCommand }()
+
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		require.Equal(t, "preflight", name)
+		require.Equal(t, []string{"check", "operator", "quay.io/example/memcached-operator-bundle:v0.0.1"}, args)
+		return []byte(resultsJSON), fmt.Errorf("exit status 1")
+	}
+	results, err := Run("quay.io/example/memcached-operator-bundle:v0.0.1")
+	require.NoError(t, err)
+	require.False(t, results.Passed)
+	require.Equal(t, 1, len(results.Results.Passed))
+
+	result := results.ManifestResult()
+	require.Equal(t, "preflight quay.io/example/memcached-operator-bundle:v0.0.1", result.Name)
+	require.Equal(t, 2, len(result.Errors))
+	require.Equal(t, "the preflight check DeployableByOLM failed: Checking if the operator could be deployed by "+
+		"OLM. Check DeployableByOLM encountered an error. Follow the guidelines on the operator-sdk website to "+
+		"learn how to package your operator. For further information see "+
+		"https://sdk.operatorframework.io/docs/olm-integration/quickstart-bundle/", result.Errors[0].Detail)
+	require.Equal(t, "the preflight check ScorecardBasicSpecCheck could not be performed: Check to make sure "+
+		"that all CRs have a spec block. Check ScorecardBasicSpecCheck encountered an error",
+		result.Errors[1].Detail)
+
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		return nil, fmt.Errorf("executable file not found in $PATH")
+	}
+	_, err = Run("quay.io/example/memcached-operator-bundle:v0.0.1")
+	require.EqualError(t, err, "unable to run preflight: executable file not found in $PATH")
+}
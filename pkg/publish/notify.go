@@ -0,0 +1,242 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publish
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The events which fire the notifications
+const (
+	// NotifyOnCompletion fires the notifications when the validation run completes
+	NotifyOnCompletion = "completion"
+	// NotifyOnNewErrors fires the notifications when errors which were not reported by the previous run for the
+	// same package appear
+	NotifyOnNewErrors = "new-errors"
+)
+
+// slackHost defines the host of the Slack incoming webhooks which receive the notifications as messages
+const slackHost = "hooks.slack.com"
+
+// Notification defines the summary of the run which is sent to the notification sinks: the bundle, its package,
+// the hostname and time of the run, whether it passed, the count of warnings and the messages of the errors. Note
+// that the messages can quote the content of the bundle, while the flags and the optional values informed to the
+// validator are not sent to not leak their credentials.
+type Notification struct {
+	Bundle   string    `json:"bundle"`
+	Hostname string    `json:"hostname,omitempty"`
+	Time     time.Time `json:"time"`
+	// Package is the package of the bundle validated when it is known
+	Package  string   `json:"package,omitempty"`
+	Passed   bool     `json:"passed"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings int      `json:"warnings"`
+	// NewErrors are the errors which were not reported by the previous run for the same package
+	NewErrors []string `json:"newErrors,omitempty"`
+}
+
+// Notifier sends the notifications of the runs to the generic webhooks (via POST in the JSON format) and
+// to the Slack incoming webhooks (hooks.slack.com)
+type Notifier struct {
+	Targets []string
+	// On is the event which fires the notifications (NotifyOnCompletion or NotifyOnNewErrors)
+	On string
+	// StateDir is the directory where the errors of the last run of each package are stored to find the
+	// new ones. Note that all errors are considered new when it is empty.
+	StateDir string
+}
+
+// NewNotifier returns a Notifier for the targets and event informed which stores the errors of the runs in
+// the user cache directory
+func NewNotifier(targets []string, on string) (*Notifier, error) {
+	if len(on) == 0 {
+		on = NotifyOnCompletion
+	}
+	if on != NotifyOnCompletion && on != NotifyOnNewErrors {
+		return nil, fmt.Errorf("invalid event to notify %q. Please, inform %s or %s", on, NotifyOnCompletion,
+			NotifyOnNewErrors)
+	}
+	for _, target := range targets {
+		if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid URL to notify %s. Please, inform an http(s):// URL", target)
+		}
+	}
+	var stateDir string
+	if dir, err := os.UserCacheDir(); err == nil {
+		stateDir = filepath.Join(dir, "ocp-olm-catalog-validator", "notifications")
+	}
+	return &Notifier{Targets: targets, On: on, StateDir: stateDir}, nil
+}
+
+// Notify sends the notification of the report informed to the targets when the event happened
+func (n *Notifier) Notify(report Report) error {
+	notification := newNotification(report)
+	key := notification.Package
+	if len(key) == 0 {
+		key = report.Metadata.Bundle
+	}
+	previous, found := n.loadErrors(key)
+	for _, err := range notification.Errors {
+		if !found || !contains(previous, err) {
+			notification.NewErrors = append(notification.NewErrors, err)
+		}
+	}
+	var errs []string
+	if err := n.saveErrors(key, notification.Errors); err != nil {
+		errs = append(errs, fmt.Sprintf("unable to store the errors of the run to find the new ones: %s", err))
+	}
+
+	if n.On == NotifyOnCompletion || len(notification.NewErrors) > 0 {
+		for _, target := range n.Targets {
+			if err := send(target, notification); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// newNotification returns the notification of the report informed
+func newNotification(report Report) Notification {
+	notification := Notification{
+		Bundle:   report.Metadata.Bundle,
+		Hostname: report.Metadata.Hostname,
+		Time:     report.Metadata.Time,
+		Passed:   report.Result.Passed,
+	}
+	for _, out := range report.Result.Outputs {
+		if len(notification.Package) == 0 {
+			notification.Package = out.Package
+		}
+		switch out.Type {
+		case logrus.ErrorLevel.String():
+			notification.Errors = append(notification.Errors, out.Message)
+			notification.Passed = false
+		case logrus.WarnLevel.String():
+			notification.Warnings++
+		}
+	}
+	// The policy decides whether the result passed when it is informed
+	if report.Result.Gate != nil {
+		notification.Passed = report.Result.Gate.Passed
+	}
+	return notification
+}
+
+// send posts the notification informed to the target. Note that the Slack incoming webhooks receive it
+// as a message.
+func send(target string, notification Notification) error {
+	var content []byte
+	var err error
+	if u, _ := url.Parse(target); u != nil && u.Host == slackHost {
+		content, err = json.Marshal(map[string]string{"text": slackMessage(notification)})
+	} else {
+		content, err = json.Marshal(notification)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to encode the notification: %s", err)
+	}
+
+	resp, err := httpClient.Post(target, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("unable to notify %s: %s", target, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unable to notify %s: unexpected status code %d", target, resp.StatusCode)
+	}
+	return nil
+}
+
+// slackMessage returns the text of the Slack message of the notification informed
+func slackMessage(notification Notification) string {
+	status := "passed"
+	if !notification.Passed {
+		status = "failed"
+	}
+	subject := notification.Bundle
+	if len(notification.Package) > 0 {
+		subject = fmt.Sprintf("%s (%s)", subject, notification.Package)
+	}
+	text := fmt.Sprintf("The validation of %s %s with %d errors and %d warnings", subject, status,
+		len(notification.Errors), notification.Warnings)
+	if len(notification.NewErrors) > 0 {
+		text += "\nNew errors:"
+		for _, err := range notification.NewErrors {
+			text += "\n• " + err
+		}
+	}
+	return text
+}
+
+// loadErrors returns the errors stored of the last run for the key informed and whether they were found
+func (n *Notifier) loadErrors(key string) ([]string, bool) {
+	if len(n.StateDir) == 0 {
+		return nil, false
+	}
+	content, err := ioutil.ReadFile(n.statePath(key))
+	if err != nil {
+		return nil, false
+	}
+	var errs []string
+	if err := json.Unmarshal(content, &errs); err != nil {
+		return nil, false
+	}
+	return errs, true
+}
+
+// saveErrors stores the errors of the run for the key informed
+func (n *Notifier) saveErrors(key string, errs []string) error {
+	if len(n.StateDir) == 0 {
+		return nil
+	}
+	content, err := json.Marshal(errs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(n.StateDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(n.statePath(key), content, 0644)
+}
+
+// statePath returns the path of the file where the errors of the key informed are stored
+func (n *Notifier) statePath(key string) string {
+	return filepath.Join(n.StateDir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(key))))
+}
+
+// contains returns true when the list informed has the value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publish
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/result"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	var notifications []Notification
+	var payloads []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		notification := Notification{}
+		require.NoError(t, json.Unmarshal(content, &notification))
+		notifications = append(notifications, notification)
+		payload := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(content, &payload))
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	_, err := NewNotifier([]string{server.URL}, "always")
+	require.EqualError(t, err, "invalid event to notify \"always\". Please, inform completion or new-errors")
+	_, err = NewNotifier([]string{"s3://bucket/key"}, "")
+	require.EqualError(t, err, "invalid URL to notify s3://bucket/key. Please, inform an http(s):// URL")
+
+	notifier, err := NewNotifier([]string{server.URL}, NotifyOnNewErrors)
+	require.NoError(t, err)
+	notifier.StateDir = t.TempDir()
+
+	res := result.NewResult()
+	res.AddError(errors.New("the CSV does not support the install mode AllNamespaces"))
+	res.AddWarn(errors.New("the CSV has no icon"))
+	require.NoError(t, notifier.Notify(NewReport("bundle/", res)))
+	require.Equal(t, 1, len(notifications))
+	require.Equal(t, "bundle/", notifications[0].Bundle)
+	require.NotContains(t, payloads[0], "args")
+	require.NotContains(t, payloads[0], "metadata")
+	require.False(t, notifications[0].Passed)
+	require.Equal(t, 1, notifications[0].Warnings)
+	require.Equal(t, []string{"the CSV does not support the install mode AllNamespaces"},
+		notifications[0].NewErrors)
+
+	// The errors reported by the previous run are not new
+	require.NoError(t, notifier.Notify(NewReport("bundle/", res)))
+	require.Equal(t, 1, len(notifications))
+
+	res.AddError(errors.New("the CSV has no description"))
+	require.NoError(t, notifier.Notify(NewReport("bundle/", res)))
+	require.Equal(t, 2, len(notifications))
+	require.Equal(t, []string{"the CSV has no description"}, notifications[1].NewErrors)
+
+	// The notifications are always sent on completion
+	notifier.On = NotifyOnCompletion
+	require.NoError(t, notifier.Notify(NewReport("bundle/", res)))
+	require.Equal(t, 3, len(notifications))
+	require.Empty(t, notifications[2].NewErrors)
+	require.Equal(t, 2, len(notifications[2].Errors))
+
+	notifier.Targets = []string{"http://127.0.0.1:0/"}
+	require.Error(t, notifier.Notify(NewReport("bundle/", res)))
+}
+
+func Test_slackMessage(t *testing.T) {
+	notification := Notification{
+		Bundle:    "bundle/",
+		Package:   "memcached-operator",
+		Errors:    []string{"the CSV has no description", "the CSV has no icon"},
+		Warnings:  1,
+		NewErrors: []string{"the CSV has no icon"},
+	}
+	require.Equal(t, "The validation of bundle/ (memcached-operator) failed with 2 errors and 1 warnings\n"+
+		"New errors:\n• the CSV has no icon", slackMessage(notification))
+
+	notification = Notification{Bundle: "bundle/", Passed: true}
+	require.Equal(t, "The validation of bundle/ passed with 0 errors and 0 warnings", slackMessage(notification))
+}
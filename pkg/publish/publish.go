@@ -0,0 +1,110 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package publish provides the clients used to publish the results of the validation with the metadata of
+// the run to a remote endpoint (HTTP or S3), so that they can be tracked by central dashboards.
+package publish
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/result"
+)
+
+// Metadata defines the information of the run which is published with the results
+type Metadata struct {
+	// Bundle is the directory or image of the bundle validated
+	Bundle string `json:"bundle"`
+	// Hostname is the host where the validation ran
+	Hostname string `json:"hostname,omitempty"`
+	// Time is the time when the results were published
+	Time time.Time `json:"time"`
+}
+
+// Report defines the content published
+type Report struct {
+	Metadata Metadata       `json:"metadata"`
+	Result   *result.Result `json:"result"`
+}
+
+// NewReport returns the report of the result informed with the metadata of the current run
+func NewReport(bundle string, res *result.Result) Report {
+	hostname, _ := os.Hostname()
+	return Report{
+		Metadata: Metadata{
+			Bundle:   bundle,
+			Hostname: hostname,
+			Time:     time.Now().UTC(),
+		},
+		Result: res,
+	}
+}
+
+// httpClient defines the client used to publish the reports
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Publish sends the report informed to the target which can be an http(s) URL, where it is sent via POST,
+// or an s3://bucket/key URL. The token informed via PUBLISH_RESULTS_TOKEN is sent as a bearer token to the
+// http(s) endpoints while the S3 credentials are read from the AWS environment variables.
+func Publish(target string, report Report) error {
+	content, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("unable to encode the report: %s", err)
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid URL to publish the results %s: %s", target, err)
+	}
+
+	var req *http.Request
+	switch u.Scheme {
+	case "http", "https":
+		if req, err = http.NewRequest(http.MethodPost, target, bytes.NewReader(content)); err != nil {
+			return err
+		}
+		if token := os.Getenv("PUBLISH_RESULTS_TOKEN"); len(token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	case "s3":
+		key := strings.TrimPrefix(u.Path, "/")
+		// The reports are stored by time when the key is a prefix
+		if len(key) == 0 || strings.HasSuffix(key, "/") {
+			key += report.Metadata.Time.Format("20060102T150405Z") + ".json"
+		}
+		if req, err = newS3PutRequest(u.Host, key, content, time.Now().UTC()); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unable to publish the results to %s: the scheme %q is not supported. Please, "+
+			"inform an http(s):// or s3:// URL", target, u.Scheme)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to publish the results to %s: %s", target, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unable to publish the results to %s: unexpected status code %d", target, resp.StatusCode)
+	}
+	return nil
+}
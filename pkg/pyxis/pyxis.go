@@ -0,0 +1,187 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pyxis provides a minimal client for the Red Hat Catalog API (Pyxis) which is used to check the
+// bundles against the packages, bundles and certification projects already registered.
+package pyxis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultURL defines the URL of the Pyxis API used when PYXIS_URL is not informed
+const DefaultURL = "https://catalog.redhat.com/api/containers"
+
+// Package defines the attributes of the operator packages which are inspected by the validators
+type Package struct {
+	PackageName string `json:"package_name"`
+	// Association is the ID of the certification project which owns the package
+	Association string `json:"association"`
+}
+
+// Bundle defines the attributes of the operator bundles which are inspected by the validators
+type Bundle struct {
+	CSVName    string `json:"csv_name"`
+	Package    string `json:"package"`
+	Version    string `json:"version"`
+	OCPVersion string `json:"ocp_version"`
+}
+
+// Project defines the attributes of the certification projects which are inspected by the validators
+type Project struct {
+	ID            string `json:"_id"`
+	Name          string `json:"name"`
+	ProjectStatus string `json:"project_status"`
+	Container     struct {
+		Type string `json:"type"`
+	} `json:"container"`
+}
+
+// Index defines the index images by OCP version of the catalogs (organizations)
+type Index struct {
+	OCPVersion   string `json:"ocp_version"`
+	Organization string `json:"organization"`
+	EndOfLife    string `json:"end_of_life,omitempty"`
+}
+
+// Inspector defines the operations used to inspect the Red Hat Catalog
+type Inspector interface {
+	// GetPackage returns the package informed or nil when it is not found
+	GetPackage(name string) (*Package, error)
+	// GetBundle returns the bundle with the CSV name informed of the package or nil when it is not found
+	GetBundle(packageName, csvName string) (*Bundle, error)
+	// GetProject returns the certification project with the ID informed
+	GetProject(id string) (*Project, error)
+	// GetIndices returns the indices of the organization informed (e.g. certified-operators)
+	GetIndices(organization string) ([]Index, error)
+}
+
+// Client implements Inspector by requesting the Pyxis API informed via PYXIS_URL or DefaultURL. The API key
+// is read from PYXIS_API_KEY. Otherwise, the requests are performed anonymously.
+type Client struct {
+	HTTPClient *http.Client
+	URL        string
+	apiKey     string
+}
+
+// NewClient returns a Client for the API and with the key found in the environment
+func NewClient() *Client {
+	apiURL := os.Getenv("PYXIS_URL")
+	if len(apiURL) == 0 {
+		apiURL = DefaultURL
+	}
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		URL:        strings.TrimSuffix(apiURL, "/"),
+		apiKey:     os.Getenv("PYXIS_API_KEY"),
+	}
+}
+
+// GetPackage returns the package informed or nil when it is not found
+func (c *Client) GetPackage(name string) (*Package, error) {
+	var packages []Package
+	if err := c.list("/v1/operators/packages", fmt.Sprintf("package_name==%q", name), &packages); err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return nil, nil
+	}
+	return &packages[0], nil
+}
+
+// GetBundle returns the bundle with the CSV name informed of the package or nil when it is not found
+func (c *Client) GetBundle(packageName, csvName string) (*Bundle, error) {
+	var bundles []Bundle
+	if err := c.list("/v1/operators/bundles", fmt.Sprintf("csv_name==%q;package==%q", csvName, packageName),
+		&bundles); err != nil {
+		return nil, err
+	}
+	if len(bundles) == 0 {
+		return nil, nil
+	}
+	return &bundles[0], nil
+}
+
+// GetProject returns the certification project with the ID informed
+func (c *Client) GetProject(id string) (*Project, error) {
+	project := &Project{}
+	if err := c.get("/v1/projects/certification/id/"+url.PathEscape(id), nil, project); err != nil {
+		return nil, fmt.Errorf("unable to get the certification project %s: %s", id, err)
+	}
+	return project, nil
+}
+
+// GetIndices returns the indices of the organization informed (e.g. certified-operators)
+func (c *Client) GetIndices(organization string) ([]Index, error) {
+	var indices []Index
+	if err := c.list("/v1/operators/indices", fmt.Sprintf("organization==%q", organization), &indices); err != nil {
+		return nil, err
+	}
+	return indices, nil
+}
+
+// list requests the items of the path informed which match with the filter and decodes them into the
+// value informed
+func (c *Client) list(path, filter string, v interface{}) error {
+	page := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	query := url.Values{"filter": {filter}, "page_size": {"100"}}
+	if err := c.get(path, query, &page); err != nil {
+		return fmt.Errorf("unable to list %s: %s", strings.TrimPrefix(path, "/v1/"), err)
+	}
+	if len(page.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(page.Data, v)
+}
+
+// get requests the path informed and decodes the response into the value informed
+func (c *Client) get(path string, query url.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.URL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Accept", "application/json")
+	if len(c.apiKey) > 0 {
+		req.Header.Set("X-API-KEY", c.apiKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("not found")
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("unauthorized. Please, ensure that a valid API key is informed via PYXIS_API_KEY")
+	default:
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("unable to decode the response: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pyxis
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "abc", r.Header.Get("X-API-KEY"))
+		filter := r.URL.Query().Get("filter")
+		switch {
+		case r.URL.Path == "/v1/operators/packages" && filter == `package_name=="memcached-operator"`:
+			fmt.Fprint(w, `{"data":[{"package_name":"memcached-operator","association":"ospid-1"}]}`)
+		case r.URL.Path == "/v1/operators/bundles" &&
+			filter == `csv_name=="memcached-operator.v0.0.1";package=="memcached-operator"`:
+			fmt.Fprint(w, `{"data":[{"csv_name":"memcached-operator.v0.0.1","package":"memcached-operator",`+
+				`"version":"0.0.1","ocp_version":"4.12"}]}`)
+		case r.URL.Path == "/v1/operators/indices" && filter == `organization=="certified-operators"`:
+			fmt.Fprint(w, `{"data":[{"ocp_version":"4.12","organization":"certified-operators"}]}`)
+		case r.URL.Path == "/v1/projects/certification/id/ospid-1":
+			fmt.Fprint(w, `{"_id":"ospid-1","name":"Memcached","project_status":"active",`+
+				`"container":{"type":"operator bundle"}}`)
+		case r.URL.Path == "/v1/projects/certification/id/ospid-2":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			fmt.Fprint(w, `{"data":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client(), URL: server.URL, apiKey: "abc"}
+
+	pkg, err := client.GetPackage("memcached-operator")
+	require.NoError(t, err)
+	require.Equal(t, &Package{PackageName: "memcached-operator", Association: "ospid-1"}, pkg)
+	pkg, err = client.GetPackage("unknown")
+	require.NoError(t, err)
+	require.Nil(t, pkg)
+
+	bundle, err := client.GetBundle("memcached-operator", "memcached-operator.v0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, "0.0.1", bundle.Version)
+	bundle, err = client.GetBundle("memcached-operator", "memcached-operator.v0.0.2")
+	require.NoError(t, err)
+	require.Nil(t, bundle)
+
+	indices, err := client.GetIndices("certified-operators")
+	require.NoError(t, err)
+	require.Equal(t, []Index{{OCPVersion: "4.12", Organization: "certified-operators"}}, indices)
+
+	project, err := client.GetProject("ospid-1")
+	require.NoError(t, err)
+	require.Equal(t, "operator bundle", project.Container.Type)
+	_, err = client.GetProject("ospid-2")
+	require.EqualError(t, err, "unable to get the certification project ospid-2: not found")
+}
@@ -0,0 +1,476 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registry provides a minimal client for the container registries (Docker Registry HTTP API V2)
+// which is used to inspect the images referenced by the bundles.
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+)
+
+// The media types of the manifests which can be returned by the registries
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// acceptedMediaTypes defines the media types of the manifests accepted by the client
+var acceptedMediaTypes = []string{
+	MediaTypeDockerManifestList,
+	MediaTypeOCIIndex,
+	MediaTypeDockerManifest,
+	MediaTypeOCIManifest,
+}
+
+// dockerHubRegistry defines the host used to reach the images of docker.io
+const dockerHubRegistry = "registry-1.docker.io"
+
+// Platform defines the platform of an image informed in a manifest list
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Descriptor defines the manifests of the images informed in a manifest list
+type Descriptor struct {
+	Digest   string   `json:"digest"`
+	Platform Platform `json:"platform"`
+}
+
+// Manifest defines the attributes of the image manifests which are inspected by the validators
+type Manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    Descriptor   `json:"config,omitempty"`
+	Manifests []Descriptor `json:"manifests,omitempty"`
+}
+
+// IsList returns true when the manifest is a manifest list (multi-arch image)
+func (m Manifest) IsList() bool {
+	return m.MediaType == MediaTypeDockerManifestList || m.MediaType == MediaTypeOCIIndex
+}
+
+// Platforms returns the platforms provided by the manifest list
+func (m Manifest) Platforms() []Platform {
+	var platforms []Platform
+	for _, manifest := range m.Manifests {
+		platforms = append(platforms, manifest.Platform)
+	}
+	return platforms
+}
+
+// Inspector defines the operations used to inspect the images
+type Inspector interface {
+	// GetManifest returns the manifest of the image informed
+	GetManifest(image string) (*Manifest, error)
+	// GetLabels returns the labels of the image informed
+	GetLabels(image string) (map[string]string, error)
+}
+
+// Client implements Inspector by requesting the manifests to the registries. The credentials are read from
+// the auth file informed via REGISTRY_AUTH_FILE or from the auth files of podman
+// (${XDG_RUNTIME_DIR}/containers/auth.json and ~/.config/containers/auth.json) and docker
+// (~/.docker/config.json). Otherwise, the requests are performed anonymously.
+type Client struct {
+	HTTPClient *http.Client
+	auths      map[string]string
+	// helpers are the credential helpers (credHelpers and credsStore) by registry which store credentials
+	// that cannot be read by the client
+	helpers map[string]string
+}
+
+// NewClient returns a Client with the credentials found in the auth files
+func NewClient() *Client {
+	auths, helpers := loadAuths(getAuthFiles())
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		auths:      auths,
+		helpers:    helpers,
+	}
+}
+
+// GetManifest returns the manifest of the image informed
+func (c *Client) GetManifest(image string) (*Manifest, error) {
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, err
+	}
+	ref = reference.TagNameOnly(ref)
+
+	var tagOrDigest string
+	switch r := ref.(type) {
+	case reference.Digested:
+		tagOrDigest = r.Digest().String()
+	case reference.Tagged:
+		tagOrDigest = r.Tag()
+	}
+	return c.getManifest(ref, tagOrDigest)
+}
+
+// GetLabels returns the labels of the image informed. Note that the labels of the linux/amd64 image
+// are returned when the image is a manifest list.
+func (c *Client) GetLabels(image string) (map[string]string, error) {
+	manifest, err := c.GetManifest(image)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.IsList() {
+		var digest string
+		for _, m := range manifest.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+				digest = m.Digest
+				break
+			}
+		}
+		if len(digest) == 0 {
+			return nil, fmt.Errorf("the manifest list has no linux/amd64 image")
+		}
+		if manifest, err = c.getManifest(ref, digest); err != nil {
+			return nil, err
+		}
+	}
+	if len(manifest.Config.Digest) == 0 {
+		return nil, fmt.Errorf("the manifest has no config")
+	}
+
+	resp, err := c.get(ref, "blobs", manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	config := struct {
+		Config struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"config"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return nil, fmt.Errorf("unable to decode the image config: %s", err)
+	}
+	return config.Config.Labels, nil
+}
+
+// Ping checks that the registry informed (e.g. quay.io) implements the Docker Registry HTTP API V2 and
+// accepts the credentials found in the auth file or the anonymous requests
+func (c *Client) Ping(host string) error {
+	domain := host
+	if host == "docker.io" {
+		host = dockerHubRegistry
+	}
+	resp, err := c.do(fmt.Sprintf("https://%s/v2/", host), domain)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return c.unauthorized(domain)
+	}
+	return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
+
+// HasCredentials returns true when the credentials of the registry informed are found in the auth file
+func (c *Client) HasCredentials(host string) bool {
+	_, ok := c.auths[host]
+	return ok
+}
+
+// unauthorized returns the error of the requests to the registry informed which were not authorized. Note that
+// it informs when its credentials are stored by a credential helper, which is not supported.
+func (c *Client) unauthorized(host string) error {
+	if helper, ok := c.helpers[host]; ok {
+		return fmt.Errorf("unauthorized. Note that the credentials for %s are stored by the credential helper "+
+			"%s, which is not supported. Please, inform them in the auth file via REGISTRY_AUTH_FILE (e.g. podman "+
+			"login --authfile)", host, helper)
+	}
+	return fmt.Errorf("unauthorized. Please, ensure that the credentials for %s are informed in the auth file",
+		host)
+}
+
+// getManifest returns the manifest with the tag or digest informed of the repository of the reference
+func (c *Client) getManifest(ref reference.Named, tagOrDigest string) (*Manifest, error) {
+	resp, err := c.get(ref, "manifests", tagOrDigest)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	manifest := &Manifest{}
+	if err := json.NewDecoder(resp.Body).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("unable to decode the manifest: %s", err)
+	}
+	if len(manifest.MediaType) == 0 {
+		manifest.MediaType = resp.Header.Get("Content-Type")
+	}
+	return manifest, nil
+}
+
+// get requests the manifest or blob (kind) with the tag or digest informed of the repository of the
+// reference and returns the response when it succeeds
+func (c *Client) get(ref reference.Named, kind, tagOrDigest string) (*http.Response, error) {
+	host := reference.Domain(ref)
+	if host == "docker.io" {
+		host = dockerHubRegistry
+	}
+	url := fmt.Sprintf("https://%s/v2/%s/%s/%s", host, reference.Path(ref), kind, tagOrDigest)
+
+	resp, err := c.do(url, reference.Domain(ref))
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s unknown", strings.TrimSuffix(kind, "s"))
+	case http.StatusUnauthorized, http.StatusForbidden:
+		resp.Body.Close()
+		return nil, c.unauthorized(reference.Domain(ref))
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
+
+// do performs the GET request for the url informed and handles the token authentication when required
+func (c *Client) do(url, domain string) (*http.Response, error) {
+	req, err := c.newRequest(url)
+	if err != nil {
+		return nil, err
+	}
+	if auth, ok := c.auths[domain]; ok {
+		req.Header.Set("Authorization", "Basic "+auth)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return nil, c.unauthorized(domain)
+	}
+	token, err := c.getToken(challenge, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = c.newRequest(url)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return c.HTTPClient.Do(req)
+}
+
+// newRequest returns a GET request for the url informed accepting the manifest media types
+func (c *Client) newRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(acceptedMediaTypes, ", "))
+	return req, nil
+}
+
+// getToken requests the bearer token to the realm informed in the challenge, which must be an https URL
+func (c *Client) getToken(challenge, domain string) (string, error) {
+	params := parseChallenge(challenge[len("bearer "):])
+	req, err := http.NewRequest(http.MethodGet, params["realm"], nil)
+	if err != nil {
+		return "", err
+	}
+	// The credentials are never sent in clear text
+	if req.URL.Scheme != "https" {
+		return "", fmt.Errorf("unable to get the token from %s: the realm does not use https", params["realm"])
+	}
+	query := req.URL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if len(params[key]) > 0 {
+			query.Set(key, params[key])
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+	if auth, ok := c.auths[domain]; ok {
+		req.Header.Set("Authorization", "Basic "+auth)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to get the token from %s: status code %d", params["realm"], resp.StatusCode)
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("unable to decode the token: %s", err)
+	}
+	if len(token.Token) > 0 {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
+
+// parseChallenge returns the parameters of the WWW-Authenticate challenge
+// (e.g. realm="https://auth.docker.io/token",service="registry.docker.io"). Note that the quoted values
+// can have commas (e.g. scope="repository:example/operator:pull,push") and escaped characters.
+func parseChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	for rest := challenge; len(rest) > 0; {
+		rest = strings.TrimLeft(rest, " \t,")
+		i := strings.IndexAny(rest, "=,")
+		if i < 0 {
+			break
+		}
+		if rest[i] == ',' {
+			// The parameters without value are ignored
+			rest = rest[i:]
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:i]))
+		rest = strings.TrimLeft(rest[i+1:], " \t")
+
+		var value strings.Builder
+		if strings.HasPrefix(rest, `"`) {
+			i = 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			if i < len(rest) {
+				i++
+			}
+			rest = rest[i:]
+		} else {
+			i = strings.Index(rest, ",")
+			if i < 0 {
+				i = len(rest)
+			}
+			value.WriteString(strings.TrimSpace(rest[:i]))
+			rest = rest[i:]
+		}
+		params[key] = value.String()
+	}
+	return params
+}
+
+// getAuthFiles returns the auth files where the credentials are looked up in the order used by podman
+func getAuthFiles() []string {
+	if path := os.Getenv("REGISTRY_AUTH_FILE"); len(path) > 0 {
+		return []string{path}
+	}
+	var paths []string
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); len(dir) > 0 {
+		paths = append(paths, filepath.Join(dir, "containers", "auth.json"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "containers", "auth.json"),
+			filepath.Join(home, ".docker", "config.json"))
+	}
+	return paths
+}
+
+// loadAuths returns the base64 encoded credentials and the credential helpers by registry found in the auth
+// files informed. Note that the first file which informs a registry is used for it.
+func loadAuths(paths []string) (map[string]string, map[string]string) {
+	auths, helpers := map[string]string{}, map[string]string{}
+	informed := map[string]bool{}
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		config := struct {
+			Auths map[string]struct {
+				Auth string `json:"auth"`
+			} `json:"auths"`
+			CredsStore  string            `json:"credsStore"`
+			CredHelpers map[string]string `json:"credHelpers"`
+		}{}
+		if err := json.Unmarshal(content, &config); err != nil {
+			continue
+		}
+
+		// The credHelpers take precedence over the auths of the same file
+		hosts := map[string]bool{}
+		for host, helper := range config.CredHelpers {
+			if host = normalizeHost(host); !informed[host] {
+				hosts[host] = true
+				helpers[host] = helper
+			}
+		}
+		for host, auth := range config.Auths {
+			if host = normalizeHost(host); informed[host] || hosts[host] {
+				continue
+			}
+			hosts[host] = true
+			// The entries without credentials are stored by the credsStore
+			if len(auth.Auth) == 0 {
+				if len(config.CredsStore) > 0 {
+					helpers[host] = config.CredsStore
+				}
+				continue
+			}
+			if _, err := base64.StdEncoding.DecodeString(auth.Auth); err == nil {
+				auths[host] = auth.Auth
+			}
+		}
+		for host := range hosts {
+			informed[host] = true
+		}
+	}
+	return auths, helpers
+}
+
+// normalizeHost returns the registry of the key informed of an auth file. Note that the keys of Docker Hub
+// (e.g. https://index.docker.io/v1/) are returned as docker.io which is the domain of its images.
+func normalizeHost(host string) string {
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	host = strings.Split(host, "/")[0]
+	if host == "index.docker.io" || host == dockerHubRegistry {
+		return "docker.io"
+	}
+	return host
+}
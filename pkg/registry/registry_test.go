@@ -0,0 +1,183 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetManifest(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			require.Equal(t, "repository:example/operator:pull", r.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token":"abc"}`)
+		case r.Header.Get("Authorization") != "Bearer abc":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",`+
+				`scope="repository:example/operator:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/example/operator/manifests/v0.0.1":
+			w.Header().Set("Content-Type", MediaTypeDockerManifestList)
+			fmt.Fprint(w, `{"mediaType":"`+MediaTypeDockerManifestList+`","manifests":[`+
+				`{"digest":"sha256:1","platform":{"architecture":"amd64","os":"linux"}},`+
+				`{"digest":"sha256:2","platform":{"architecture":"arm64","os":"linux"}}]}`)
+		case r.URL.Path == "/v2/example/operator/manifests/v0.0.2":
+			w.Header().Set("Content-Type", MediaTypeOCIManifest)
+			fmt.Fprint(w, `{"schemaVersion":2}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	client := &Client{HTTPClient: server.Client()}
+
+	manifest, err := client.GetManifest(host + "/example/operator:v0.0.1")
+	require.NoError(t, err)
+	require.True(t, manifest.IsList())
+	require.Equal(t, []Platform{{Architecture: "amd64", OS: "linux"}, {Architecture: "arm64", OS: "linux"}},
+		manifest.Platforms())
+
+	manifest, err = client.GetManifest(host + "/example/operator:v0.0.2")
+	require.NoError(t, err)
+	require.False(t, manifest.IsList())
+	require.Equal(t, MediaTypeOCIManifest, manifest.MediaType)
+
+	_, err = client.GetManifest(host + "/example/operator:v0.0.3")
+	require.EqualError(t, err, "manifest unknown")
+
+	_, err = client.GetManifest("Invalid:latest")
+	require.Error(t, err)
+}
+
+func TestClient_GetLabels(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/example/operator/manifests/v0.0.1":
+			fmt.Fprint(w, `{"mediaType":"`+MediaTypeOCIIndex+`","manifests":[`+
+				`{"digest":"sha256:1","platform":{"architecture":"arm64","os":"linux"}},`+
+				`{"digest":"sha256:2","platform":{"architecture":"amd64","os":"linux"}}]}`)
+		case "/v2/example/operator/manifests/sha256:2":
+			fmt.Fprint(w, `{"mediaType":"`+MediaTypeOCIManifest+`","config":{"digest":"sha256:3"}}`)
+		case "/v2/example/operator/blobs/sha256:3":
+			fmt.Fprint(w, `{"architecture":"amd64","config":{"Labels":{"com.redhat.component":"ubi9-container"}}}`)
+		case "/v2/example/operator/manifests/v0.0.2":
+			fmt.Fprint(w, `{"mediaType":"`+MediaTypeOCIManifest+`","config":{"digest":"sha256:4"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	client := &Client{HTTPClient: server.Client()}
+
+	labels, err := client.GetLabels(host + "/example/operator:v0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"com.redhat.component": "ubi9-container"}, labels)
+
+	_, err = client.GetLabels(host + "/example/operator:v0.0.2")
+	require.EqualError(t, err, "blob unknown")
+}
+
+func Test_parseChallenge(t *testing.T) {
+	params := parseChallenge(`realm="https://auth.docker.io/token",service="registry.docker.io",` +
+		`scope="repository:library/memcached:pull"`)
+	require.Equal(t, map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/memcached:pull",
+	}, params)
+
+	params = parseChallenge(`realm="https://auth.example.com/token", service=registry.example.com, ` +
+		`scope="repository:example/operator:pull,push",error="insufficient_scope \"push\""`)
+	require.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:example/operator:pull,push",
+		"error":   `insufficient_scope "push"`,
+	}, params)
+}
+
+func Test_loadAuths(t *testing.T) {
+	dir := t.TempDir()
+	podman := filepath.Join(dir, "auth.json")
+	require.NoError(t, ioutil.WriteFile(podman, []byte(`{"auths": {"quay.io": {"auth": "cG9kbWFuOnF1YXk="}}}`),
+		0600))
+	docker := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(docker, []byte(`{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": "ZG9ja2VyOmh1Yg=="},
+			"quay.io": {"auth": "ZG9ja2VyOnF1YXk="},
+			"registry.redhat.io": {},
+			"registry.connect.redhat.com": {"auth": "cmVkaGF0OmNvbm5lY3Q="}
+		},
+		"credsStore": "desktop",
+		"credHelpers": {"registry.connect.redhat.com": "ecr-login"}
+	}`), 0600))
+
+	auths, helpers := loadAuths([]string{podman, filepath.Join(dir, "missing.json"), docker})
+	require.Equal(t, map[string]string{"docker.io": "ZG9ja2VyOmh1Yg==", "quay.io": "cG9kbWFuOnF1YXk="}, auths)
+	require.Equal(t, map[string]string{"registry.redhat.io": "desktop", "registry.connect.redhat.com": "ecr-login"},
+		helpers)
+
+	client := &Client{auths: auths, helpers: helpers}
+	require.True(t, client.HasCredentials("docker.io"))
+	require.EqualError(t, client.unauthorized("registry.redhat.io"), "unauthorized. Note that the credentials for "+
+		"registry.redhat.io are stored by the credential helper desktop, which is not supported. Please, inform "+
+		"them in the auth file via REGISTRY_AUTH_FILE (e.g. podman login --authfile)")
+}
+
+func TestClient_getToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Fail(t, "the credentials were sent to a realm which does not use https")
+	}))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client(), auths: map[string]string{"quay.io": "YWJjOmRlZg=="}}
+	_, err := client.getToken(fmt.Sprintf(`Bearer realm="%s/token",service="quay.io"`, server.URL), "quay.io")
+	require.EqualError(t, err, "unable to get the token from "+server.URL+"/token: the realm does not use https")
+}
+
+func TestClient_Ping(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic YWJjOmRlZg==" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "{}")
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	client := &Client{HTTPClient: server.Client()}
+	require.False(t, client.HasCredentials(host))
+	require.EqualError(t, client.Ping(host), "unauthorized. Please, ensure that the credentials for "+host+
+		" are informed in the auth file")
+
+	client.auths = map[string]string{host: "YWJjOmRlZg=="}
+	require.True(t, client.HasCredentials(host))
+	require.NoError(t, client.Ping(host))
+}
@@ -21,6 +21,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	apierrors "github.com/operator-framework/api/pkg/validation/errors"
 	registrybundle "github.com/operator-framework/operator-registry/pkg/lib/bundle"
@@ -32,16 +35,167 @@ const (
 	Text       = "text"
 )
 
+// The exit codes of the validation which allow the wrappers to distinguish the bundles which did not pass
+// from the failures of the tool
+const (
+	// ExitSuccess is returned when the validation passed
+	ExitSuccess = 0
+	// ExitErrors is returned when errors were found or the policy informed did not pass
+	ExitErrors = 1
+	// ExitWarnings is returned when warnings were found and the result fails on warnings (see FailOn)
+	ExitWarnings = 2
+	// ExitUsage is returned when the arguments or the flags informed are invalid
+	ExitUsage = 3
+	// ExitInternal is returned when the validation could not be performed (e.g. IO failures)
+	ExitInternal = 4
+)
+
+// The lowest severities of the findings which fail the result
+const (
+	FailOnError   = "error"
+	FailOnWarning = "warning"
+)
+
 // Result represents the final result
 type Result struct {
 	Passed  bool     `json:"passed"`
 	Outputs []output `json:"outputs"`
+	// Gate is the decision of the policy informed which determines whether the result passed
+	Gate *GateDecision `json:"gate,omitempty"`
+	// Metadata describes the run which produced the result (e.g. the version of the tool and the flags)
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// ShowPassed adds an info output for each group without errors and warnings when the grouped results
+	// are added, so that the checks which were evaluated are also informed (e.g. Passed: csv-name)
+	ShowPassed bool `json:"-"`
+	// FailOn is the lowest severity of the findings which fail the result (FailOnError by default)
+	FailOn string `json:"-"`
+	// internal is true when an internal error was added
+	internal bool
+}
+
+// PassedPrefix defines the prefix of the messages of the outputs added for the groups which passed,
+// which is followed by the name of their validator (rule ID)
+const PassedPrefix = "Passed: "
+
+// GateDecision represents the decision of a policy over the result
+type GateDecision struct {
+	Passed     bool     `json:"passed"`
+	Errors     int      `json:"errors"`
+	Warnings   int      `json:"warnings"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Metadata defines the information of the run which makes the structured outputs self-describing and
+// reproducible when they are archived
+type Metadata struct {
+	// Tool is the build of the validator which produced the result
+	Tool ToolVersion `json:"tool"`
+	// DataVersions are the versions of the modules which provide the data of the checks (e.g. the APIs removed
+	// by Kubernetes version) by module path
+	DataVersions map[string]string `json:"dataVersions,omitempty"`
+	// TargetOCPVersions are the OCP versions where each bundle validated is distributed
+	TargetOCPVersions []TargetOCPVersions `json:"targetOCPVersions,omitempty"`
+	// Profile is the profile informed (e.g. certified)
+	Profile string `json:"profile,omitempty"`
+	// Suites are the suites of validators which were run
+	Suites []string `json:"suites,omitempty"`
+	// Flags are the flags informed with their values. Note that the values of the SecretFlags are redacted,
+	// including the ones informed via the optional values.
+	Flags map[string]string `json:"flags,omitempty"`
+	// StartTime and EndTime are the times when the run started and when the result was completed
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	// Duration is the duration of the run (e.g. 1.5s)
+	Duration string `json:"duration"`
+}
+
+// ToolVersion defines the build of the validator
+type ToolVersion struct {
+	Version   string `json:"version,omitempty"`
+	GitCommit string `json:"gitCommit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion,omitempty"`
+	Platform  string `json:"platform,omitempty"`
+}
+
+// TargetOCPVersions defines the OCP versions where a bundle is distributed
+type TargetOCPVersions struct {
+	Bundle string `json:"bundle"`
+	// Range is the value of the com.redhat.openshift.versions label (if informed)
+	Range string `json:"range,omitempty"`
+	// MaxOpenShiftVersion is the value of the olm.maxOpenShiftVersion property (if informed)
+	MaxOpenShiftVersion string `json:"maxOpenShiftVersion,omitempty"`
+}
+
+// SecretFlags are the flags, and the keys of the optional values, whose values can carry credentials (e.g. the
+// webhook URLs) and are never added to the metadata
+var SecretFlags = []string{"notify", "publish-results", "kubeconfig", "cosign-key"}
+
+// RedactedValue replaces the values of the SecretFlags in the metadata
+const RedactedValue = "REDACTED"
+
+// AddFlag adds the flag informed to the metadata, redacting its value when it is one of the SecretFlags
+func (m *Metadata) AddFlag(name, value string) {
+	if m.Flags == nil {
+		m.Flags = map[string]string{}
+	}
+	if isSecretFlag(name) {
+		value = RedactedValue
+	}
+	m.Flags[name] = value
+}
+
+// AddOptionalValues adds the flag informed with the sorted key=values informed to the metadata, redacting the
+// values of the keys which are SecretFlags (e.g. --optional-values=kubeconfig=/home/user/.kube/config)
+func (m *Metadata) AddOptionalValues(name string, values map[string]string) {
+	var pairs []string
+	for k, v := range values {
+		if isSecretFlag(k) {
+			v = RedactedValue
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	m.AddFlag(name, "["+strings.Join(pairs, ",")+"]")
+}
+
+// isSecretFlag returns true when the flag or key informed is one of the SecretFlags
+func isSecretFlag(name string) bool {
+	for _, secret := range SecretFlags {
+		if name == secret {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete sets the time when the run was completed and its duration
+func (m *Metadata) Complete(end time.Time) {
+	m.EndTime = end.UTC()
+	m.Duration = m.EndTime.Sub(m.StartTime).String()
 }
 
 // output represents the logs which are used to return the final result in the JSON format
 type output struct {
 	Type    string `json:"type"`
 	Message string `json:"message"`
+	// Package, Bundle and Validator identify the group of the findings reported by the validators
+	Package   string `json:"package,omitempty"`
+	Bundle    string `json:"bundle,omitempty"`
+	Validator string `json:"validator,omitempty"`
+}
+
+// Group identifies the package, bundle and validator which reported a set of results
+type Group struct {
+	Package   string
+	Bundle    string
+	Validator string
+}
+
+// GroupResults represents the results reported by a validator for a bundle of a package
+type GroupResults struct {
+	Group
+	Results []apierrors.ManifestResult
 }
 
 // NewResult return a new result object which starts with passed == true since has no errors
@@ -49,16 +203,75 @@ func NewResult() *Result {
 	return &Result{Passed: true}
 }
 
-// AddManifestResults adds warnings and errors in results to Results.
+// AddManifestResults adds warnings and errors in results to Results grouped by the name of their bundles.
+// See AddGroupedResults.
 func (o *Result) AddManifestResults(results ...apierrors.ManifestResult) {
-	for _, r := range results {
-		for _, w := range r.Warnings {
-			o.AddWarn(w)
+	o.AddGroupedResults(GroupResults{Results: results})
+}
+
+// AddGroupedResults adds warnings and errors in the results of the groups to Results. Note that the same
+// finding reported by more than one validator for a bundle is only added once and that the warnings whose
+// detail is already informed as the context of an error of the same bundle are not added. The bundle of the
+// group defaults to the name of the results. See ShowPassed.
+func (o *Result) AddGroupedResults(groups ...GroupResults) {
+	errs := map[string][]string{}
+	for _, g := range groups {
+		for _, r := range g.Results {
+			bundle := groupBundle(g.Group, r)
+			for _, e := range r.Errors {
+				errs[bundle] = append(errs[bundle], e.Detail)
+			}
+		}
+	}
+
+	added := map[string]bool{}
+	for _, g := range groups {
+		for _, r := range g.Results {
+			group := g.Group
+			group.Bundle = groupBundle(g.Group, r)
+			for _, w := range r.Warnings {
+				key := group.Bundle + "/" + w.Error()
+				if added[key] || isContextOf(w.Detail, errs[group.Bundle]) {
+					continue
+				}
+				added[key] = true
+				o.addOutput(logrus.WarnLevel, w, group)
+			}
+			for _, e := range r.Errors {
+				key := group.Bundle + "/" + e.Error()
+				if added[key] {
+					continue
+				}
+				added[key] = true
+				o.addOutput(logrus.ErrorLevel, e, group)
+				o.Passed = false
+			}
+			if o.ShowPassed && len(g.Validator) > 0 && len(r.Errors) == 0 && len(r.Warnings) == 0 {
+				o.addOutput(logrus.InfoLevel, errors.New(PassedPrefix+g.Validator), group)
+			}
 		}
-		for _, e := range r.Errors {
-			o.AddError(e)
+	}
+}
+
+// groupBundle returns the bundle of the group informed which defaults to the name of the result
+func groupBundle(group Group, result apierrors.ManifestResult) string {
+	if len(group.Bundle) == 0 {
+		return result.Name
+	}
+	return group.Bundle
+}
+
+// isContextOf returns true when the detail informed is part of any of the details of the errors
+func isContextOf(detail string, errs []string) bool {
+	if len(detail) == 0 {
+		return false
+	}
+	for _, err := range errs {
+		if strings.Contains(err, detail) {
+			return true
 		}
 	}
+	return false
 }
 
 // AddInfo will add a log to the result with the Info Level
@@ -71,50 +284,174 @@ func (o *Result) AddInfo(msg string) {
 
 // AddError will add a log to the result with the Error Level
 func (o *Result) AddError(err error) {
+	o.addOutput(logrus.ErrorLevel, err, Group{})
+	o.Passed = false
+}
+
+// AddInternalError will add a log to the result with the Error Level for a failure of the tool instead of
+// the bundle (e.g. IO failures). See ExitCode.
+func (o *Result) AddInternalError(err error) {
+	o.AddError(err)
+	o.internal = true
+}
+
+// AddWarn will add a log to the result with the Warn Level
+func (o *Result) AddWarn(err error) {
+	o.addOutput(logrus.WarnLevel, err, Group{})
+}
+
+// addOutput will add a log to the result with the level and group informed. Note that the bundle
+// validation errors are added as one log per error.
+func (o *Result) addOutput(lvl logrus.Level, err error, group Group) {
+	msgs := []string{err.Error()}
 	verr := registrybundle.ValidationError{}
 	if errors.As(err, &verr) {
+		msgs = nil
 		for _, valErr := range verr.Errors {
-			o.Outputs = append(o.Outputs, output{
-				Type:    logrus.ErrorLevel.String(),
-				Message: valErr.Error(),
-			})
+			msgs = append(msgs, valErr.Error())
 		}
-	} else {
+	}
+	for _, msg := range msgs {
 		o.Outputs = append(o.Outputs, output{
-			Type:    logrus.ErrorLevel.String(),
-			Message: err.Error(),
+			Type:      lvl.String(),
+			Message:   msg,
+			Package:   group.Package,
+			Bundle:    group.Bundle,
+			Validator: group.Validator,
 		})
 	}
-	o.Passed = false
 }
 
-// AddWarn will add a log to the result with the Warn Level
-func (o *Result) AddWarn(err error) {
-	o.Outputs = append(o.Outputs, output{
-		Type:    logrus.WarnLevel.String(),
-		Message: err.Error(),
-	})
+// SetGate will set the decision of the policy which determines whether the result passed
+func (o *Result) SetGate(gate GateDecision) {
+	o.Gate = &gate
+	o.Passed = gate.Passed
+}
+
+// Translate will replace the messages of the outputs with their translations
+func (o *Result) Translate(translate func(msg string) string) {
+	for i := range o.Outputs {
+		o.Outputs[i].Message = translate(o.Outputs[i].Message)
+	}
 }
 
-// printText will print the output in human readable format
+// printText will print the output in human readable format. The findings of the validators are
+// printed grouped by package, bundle and validator with their counts.
 func (o *Result) printText(logger *logrus.Entry) error {
 	for _, obj := range o.Outputs {
-		lvl, err := logrus.ParseLevel(obj.Type)
-		if err != nil {
+		if len(obj.Bundle) > 0 {
+			continue
+		}
+		if err := printOutput(logger, obj, ""); err != nil {
 			return err
 		}
-		switch lvl {
-		case logrus.InfoLevel:
-			logger.Info(obj.Message)
-		case logrus.WarnLevel:
-			logger.Warn(obj.Message)
-		case logrus.ErrorLevel:
-			logger.Error(obj.Message)
-		default:
-			return fmt.Errorf("unknown output level %q", obj.Type)
+	}
+	if err := o.printGroups(logger); err != nil {
+		return err
+	}
+
+	if o.Gate != nil {
+		msg := fmt.Sprintf("errors: %d, warnings: %d", o.Gate.Errors, o.Gate.Warnings)
+		if !o.Gate.Passed {
+			logger.Errorf("Gate failed (%s): %s", msg, strings.Join(o.Gate.Violations, "; "))
+		} else {
+			logger.Infof("Gate passed (%s)", msg)
+		}
+	}
+	return nil
+}
+
+// printGroups will print the outputs which have a group hierarchically (package, bundle, validator and
+// findings) with the count of errors and warnings of each group
+func (o *Result) printGroups(logger *logrus.Entry) error {
+	var groups []Group
+	outputs := map[Group][]output{}
+	for _, obj := range o.Outputs {
+		if len(obj.Bundle) == 0 {
+			continue
+		}
+		group := Group{Package: obj.Package, Bundle: obj.Bundle, Validator: obj.Validator}
+		if _, found := outputs[group]; !found {
+			groups = append(groups, group)
+		}
+		outputs[group] = append(outputs[group], obj)
+	}
+	// The validators are kept in the order which they were run
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Package != groups[j].Package {
+			return groups[i].Package < groups[j].Package
+		}
+		return groups[i].Bundle < groups[j].Bundle
+	})
+
+	for i, group := range groups {
+		indent := ""
+		if len(group.Package) > 0 {
+			if i == 0 || groups[i-1].Package != group.Package {
+				logger.Infof("Package %s (%s)", group.Package, o.countGroup(func(g Group) bool {
+					return g.Package == group.Package
+				}))
+			}
+			indent += groupIndent
+		}
+		if i == 0 || groups[i-1].Package != group.Package || groups[i-1].Bundle != group.Bundle {
+			logger.Infof("%sBundle %s (%s)", indent, group.Bundle, o.countGroup(func(g Group) bool {
+				return g.Package == group.Package && g.Bundle == group.Bundle
+			}))
+		}
+		indent += groupIndent
+		if len(group.Validator) > 0 {
+			logger.Infof("%sValidator %s (%s)", indent, group.Validator, o.countGroup(func(g Group) bool {
+				return g == group
+			}))
+			indent += groupIndent
+		}
+		for _, obj := range outputs[group] {
+			if err := printOutput(logger, obj, indent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// groupIndent defines the indentation of each level of the grouped output
+const groupIndent = "  "
+
+// countGroup returns the count of errors and warnings of the outputs whose group matches
+func (o *Result) countGroup(matches func(Group) bool) string {
+	errs, warns := 0, 0
+	for _, obj := range o.Outputs {
+		if len(obj.Bundle) == 0 || !matches(Group{Package: obj.Package, Bundle: obj.Bundle,
+			Validator: obj.Validator}) {
+			continue
+		}
+		switch obj.Type {
+		case logrus.ErrorLevel.String():
+			errs++
+		case logrus.WarnLevel.String():
+			warns++
 		}
 	}
+	return fmt.Sprintf("errors: %d, warnings: %d", errs, warns)
+}
 
+// printOutput will print the message of the output with the indentation informed according to its level
+func printOutput(logger *logrus.Entry, obj output, indent string) error {
+	lvl, err := logrus.ParseLevel(obj.Type)
+	if err != nil {
+		return err
+	}
+	switch lvl {
+	case logrus.InfoLevel:
+		logger.Info(indent + obj.Message)
+	case logrus.WarnLevel:
+		logger.Warn(indent + obj.Message)
+	case logrus.ErrorLevel:
+		logger.Error(indent + obj.Message)
+	default:
+		return fmt.Errorf("unknown output level %q", obj.Type)
+	}
 	return nil
 }
 
@@ -143,11 +480,15 @@ func (o *Result) prepare() error {
 		lvlBytes, _ := lvl.MarshalText()
 		o.Outputs[i].Type = string(lvlBytes)
 	}
+	// The policy decides whether the result passed when it is informed
+	if o.Gate != nil {
+		o.Passed = o.Gate.Passed
+	}
 	return nil
 }
 
-// PrintWithFormat prints output to w in format, and exits if some object in output
-// is not in a passing state.
+// PrintWithFormat prints output to w in format, and exits with the code informed by ExitCode if some
+// object in output is not in a passing state.
 func (o *Result) PrintWithFormat(format string) (err error) {
 	// the prepare will ensure the result data if the setters were not used
 	if err = o.prepare(); err != nil {
@@ -155,12 +496,31 @@ func (o *Result) PrintWithFormat(format string) (err error) {
 	}
 
 	printf := o.getPrintFuncFormat(format)
-	if err = printf(o); err == nil && !o.Passed {
-		os.Exit(1) // Exit with error when any Error type was added
+	if err = printf(o); err == nil && o.ExitCode() != ExitSuccess {
+		os.Exit(o.ExitCode())
 	}
 	return err
 }
 
+// ExitCode returns ExitInternal when an internal error was added, ExitErrors when the result did not pass,
+// ExitWarnings when it has warnings and fails on them and ExitSuccess otherwise
+func (o *Result) ExitCode() int {
+	if o.internal {
+		return ExitInternal
+	}
+	if !o.Passed {
+		return ExitErrors
+	}
+	if o.FailOn == FailOnWarning {
+		for _, obj := range o.Outputs {
+			if obj.Type == logrus.WarnLevel.String() {
+				return ExitWarnings
+			}
+		}
+	}
+	return ExitSuccess
+}
+
 // getPrintFuncFormat returns a function that writes an Result to w in a given
 // format, defaulting to "text" if format is not recognized.
 func (o *Result) getPrintFuncFormat(format string) func(*Result) error {
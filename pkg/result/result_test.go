@@ -0,0 +1,208 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package result
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "github.com/operator-framework/api/pkg/validation/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult_AddManifestResults(t *testing.T) {
+	deprecation := "this bundle is using APIs which were deprecated and removed in v1.22. Migrate the API(s) for " +
+		"CRD: ([\"etcdbackups.etcd.database.coreos.com\"])"
+	openshift := apierrors.ManifestResult{Name: "etcdoperator.v0.9.4"}
+	openshift.Add(apierrors.WarnFailedValidation(deprecation, "etcdoperator.v0.9.4"))
+	openshift.Add(apierrors.WarnInvalidCSV("the CSV does not inform spec.links", "etcdoperator.v0.9.4"))
+	cluster := apierrors.ManifestResult{Name: "etcdoperator.v0.9.4"}
+	cluster.Add(apierrors.ErrInvalidCSV(deprecation+". Note that the cluster runs Kubernetes v1.25.4",
+		"etcdoperator.v0.9.4"))
+	cluster.Add(apierrors.WarnInvalidCSV("the CSV does not inform spec.links", "etcdoperator.v0.9.4"))
+
+	res := NewResult()
+	res.AddManifestResults(openshift, cluster)
+	require.Equal(t, []output{
+		{Type: "warning", Message: "Warning: Value : (etcdoperator.v0.9.4) the CSV does not inform spec.links",
+			Bundle: "etcdoperator.v0.9.4"},
+		{Type: "error", Message: "Error: Value : (etcdoperator.v0.9.4) " + deprecation +
+			". Note that the cluster runs Kubernetes v1.25.4", Bundle: "etcdoperator.v0.9.4"},
+	}, res.Outputs)
+	require.False(t, res.Passed)
+}
+
+func TestResult_AddGroupedResults(t *testing.T) {
+	// The findings without the CSV (e.g. of the catalog) have the same message for every bundle
+	deprecation := "the package uses APIs which were removed in v1.22"
+	v1 := apierrors.ManifestResult{Name: "memcached-operator.v0.0.1"}
+	v1.Add(apierrors.ErrInvalidBundle(deprecation+". Note that the cluster runs Kubernetes v1.25.4", nil))
+	v1.Add(apierrors.WarnInvalidBundle("the package has no default channel", nil))
+	v2 := apierrors.ManifestResult{Name: "memcached-operator.v0.0.2"}
+	v2.Add(apierrors.WarnInvalidBundle(deprecation, nil))
+	v2.Add(apierrors.WarnInvalidBundle("the package has no default channel", nil))
+
+	res := NewResult()
+	res.AddGroupedResults(
+		GroupResults{Group: Group{Validator: "catalog"}, Results: []apierrors.ManifestResult{v1, v2}},
+		GroupResults{Group: Group{Validator: "channels"}, Results: []apierrors.ManifestResult{v2}},
+	)
+	require.Equal(t, []output{
+		{Type: "warning", Message: "Warning: : the package has no default channel",
+			Bundle: "memcached-operator.v0.0.1", Validator: "catalog"},
+		{Type: "error", Message: "Error: : " + deprecation + ". Note that the cluster runs Kubernetes v1.25.4",
+			Bundle: "memcached-operator.v0.0.1", Validator: "catalog"},
+		{Type: "warning", Message: "Warning: : " + deprecation, Bundle: "memcached-operator.v0.0.2",
+			Validator: "catalog"},
+		{Type: "warning", Message: "Warning: : the package has no default channel",
+			Bundle: "memcached-operator.v0.0.2", Validator: "catalog"},
+	}, res.Outputs)
+}
+
+func TestResult_printText(t *testing.T) {
+	csvName := apierrors.ManifestResult{Name: "memcached-operator.v0.0.1"}
+	csvName.Add(apierrors.ErrInvalidCSV("the CSV name is invalid", "memcached-operator.v0.0.1"))
+	csvName.Add(apierrors.WarnInvalidCSV("the CSV does not inform spec.links", "memcached-operator.v0.0.1"))
+	size := apierrors.ManifestResult{Name: "memcached-operator.v0.0.2"}
+	size.Add(apierrors.WarnInvalidCSV("the bundle is large", "memcached-operator.v0.0.2"))
+
+	res := NewResult()
+	res.AddError(errors.New("the directory is in the legacy format"))
+	res.AddGroupedResults(
+		GroupResults{Group: Group{Package: "memcached-operator", Validator: "csv-name"},
+			Results: []apierrors.ManifestResult{csvName}},
+		GroupResults{Group: Group{Package: "memcached-operator", Validator: "size"},
+			Results: []apierrors.ManifestResult{{Name: "memcached-operator.v0.0.1"}, size}},
+	)
+
+	var out bytes.Buffer
+	logger := NewLoggerTo(&out)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	require.NoError(t, res.printText(logrus.NewEntry(logger)))
+	require.Equal(t, `level=error msg="the directory is in the legacy format"
+level=info msg="Package memcached-operator (errors: 1, warnings: 2)"
+level=info msg="  Bundle memcached-operator.v0.0.1 (errors: 1, warnings: 1)"
+level=info msg="    Validator csv-name (errors: 1, warnings: 1)"
+level=warning msg="      Warning: Value : (memcached-operator.v0.0.1) the CSV does not inform spec.links"
+level=error msg="      Error: Value : (memcached-operator.v0.0.1) the CSV name is invalid"
+level=info msg="  Bundle memcached-operator.v0.0.2 (errors: 0, warnings: 1)"
+level=info msg="    Validator size (errors: 0, warnings: 1)"
+level=warning msg="      Warning: Value : (memcached-operator.v0.0.2) the bundle is large"
+`, out.String())
+}
+
+func TestResult_ShowPassed(t *testing.T) {
+	size := apierrors.ManifestResult{Name: "memcached-operator.v0.0.1"}
+	size.Add(apierrors.WarnInvalidCSV("the bundle is large", "memcached-operator.v0.0.1"))
+
+	res := NewResult()
+	res.ShowPassed = true
+	res.AddGroupedResults(
+		GroupResults{Group: Group{Package: "memcached-operator", Validator: "csv-name"},
+			Results: []apierrors.ManifestResult{{Name: "memcached-operator.v0.0.1"}}},
+		GroupResults{Group: Group{Package: "memcached-operator", Validator: "size"},
+			Results: []apierrors.ManifestResult{size}},
+	)
+	require.True(t, res.Passed)
+
+	var out bytes.Buffer
+	logger := NewLoggerTo(&out)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	require.NoError(t, res.printText(logrus.NewEntry(logger)))
+	require.Equal(t, `level=info msg="Package memcached-operator (errors: 0, warnings: 1)"
+level=info msg="  Bundle memcached-operator.v0.0.1 (errors: 0, warnings: 1)"
+level=info msg="    Validator csv-name (errors: 0, warnings: 0)"
+level=info msg="      Passed: csv-name"
+level=info msg="    Validator size (errors: 0, warnings: 1)"
+level=warning msg="      Warning: Value : (memcached-operator.v0.0.1) the bundle is large"
+`, out.String())
+}
+
+func TestResult_ExitCode(t *testing.T) {
+	res := NewResult()
+	require.Equal(t, ExitSuccess, res.ExitCode())
+
+	res.AddWarn(errors.New("the CSV does not inform spec.links"))
+	require.Equal(t, ExitSuccess, res.ExitCode())
+	res.FailOn = FailOnWarning
+	require.Equal(t, ExitWarnings, res.ExitCode())
+
+	res.AddError(errors.New("the CSV name is invalid"))
+	require.Equal(t, ExitErrors, res.ExitCode())
+
+	res.SetGate(GateDecision{Passed: true, Errors: 1, Warnings: 1})
+	require.Equal(t, ExitWarnings, res.ExitCode())
+	res.FailOn = FailOnError
+	require.Equal(t, ExitSuccess, res.ExitCode())
+
+	res.AddInternalError(errors.New("unable to write the upgrade graph"))
+	require.Equal(t, ExitInternal, res.ExitCode())
+}
+
+func TestResult_Translate(t *testing.T) {
+	res := NewResult()
+	res.AddWarn(errors.New("the message"))
+	res.Translate(strings.ToUpper)
+	require.Equal(t, []output{{Type: "warning", Message: "THE MESSAGE"}}, res.Outputs)
+}
+
+func TestMetadata_Complete(t *testing.T) {
+	start := time.Date(2023, 3, 10, 13, 49, 41, 0, time.UTC)
+	res := NewResult()
+	res.Metadata = &Metadata{Tool: ToolVersion{GitCommit: "e1c2e05"}, Profile: "certified", StartTime: start,
+		TargetOCPVersions: []TargetOCPVersions{{Bundle: "memcached-operator.v0.0.1", Range: "v4.8-v4.10"}}}
+	res.Metadata.Complete(start.Add(1500 * time.Millisecond))
+	require.Equal(t, "1.5s", res.Metadata.Duration)
+
+	content, err := json.Marshal(res)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"passed": true, "outputs": null, "metadata": {"tool": {"gitCommit": "e1c2e05"},
+		"targetOCPVersions": [{"bundle": "memcached-operator.v0.0.1", "range": "v4.8-v4.10"}],
+		"profile": "certified", "startTime": "2023-03-10T13:49:41Z", "endTime": "2023-03-10T13:49:42.5Z",
+		"duration": "1.5s"}}`, string(content))
+}
+
+func TestMetadata_AddFlag(t *testing.T) {
+	webhook := "https://hooks.slack.com/services/T000/B000/XXXX"
+	res := NewResult()
+	res.Metadata = &Metadata{}
+	res.Metadata.AddFlag("notify", "["+webhook+"]")
+	res.Metadata.AddFlag("publish-results", "https://results.example.com/upload?token=secret")
+	res.Metadata.AddFlag("output", "json-alpha1")
+	require.Equal(t, map[string]string{"notify": RedactedValue, "publish-results": RedactedValue,
+		"output": "json-alpha1"}, res.Metadata.Flags)
+
+	content, err := json.Marshal(res)
+	require.NoError(t, err)
+	require.NotContains(t, string(content), webhook)
+	require.NotContains(t, string(content), "secret")
+}
+
+func TestMetadata_AddOptionalValues(t *testing.T) {
+	metadata := &Metadata{}
+	metadata.AddOptionalValues("optional-values", map[string]string{
+		"range":      "v4.8",
+		"kubeconfig": "/home/user/.kube/config",
+		"cosign-key": "hashivault://cosign",
+	})
+	require.Equal(t, map[string]string{
+		"optional-values": "[cosign-key=" + RedactedValue + ",kubeconfig=" + RedactedValue + ",range=v4.8]",
+	}, metadata.Flags)
+}
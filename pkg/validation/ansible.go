@@ -0,0 +1,357 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"sigs.k8s.io/yaml"
+)
+
+// The project layouts informed by operator-sdk for the Ansible-based operators via the CSV annotation
+// and the bundle annotation (metadata/annotations.yaml)
+const (
+	projectLayoutAnnotation       = "operators.operatorframework.io/project_layout"
+	projectLayoutBundleAnnotation = "operators.operatorframework.io.metrics.project_layout"
+	ansibleProjectLayoutPrefix    = "ansible"
+)
+
+// ansibleGatheringEnv defines the environment variable set by operator-sdk in the manager of the
+// Ansible-based operators
+const ansibleGatheringEnv = "ANSIBLE_GATHERING"
+
+// ansibleImageDir defines the directory of the Ansible-based operator images where the project is copied
+// (e.g. /opt/ansible/roles/memcached)
+const ansibleImageDir = "/opt/ansible/"
+
+// The verbs required by the Ansible-based operators on the watched resources to reconcile them,
+// to report their status and to set them as the owners of the resources created by the roles
+var (
+	ansibleWatchVerbs     = []string{"get", "list", "watch", "patch", "update"}
+	ansibleStatusVerbs    = []string{"update", "patch"}
+	ansibleFinalizerVerbs = []string{"update"}
+)
+
+// ansibleWatch defines the entries of the watches.yaml of the Ansible-based operators which are checked
+type ansibleWatch struct {
+	Group     string            `json:"group"`
+	Version   string            `json:"version"`
+	Kind      string            `json:"kind"`
+	Role      string            `json:"role"`
+	Playbook  string            `json:"playbook"`
+	Finalizer *ansibleFinalizer `json:"finalizer,omitempty"`
+}
+
+// ansibleFinalizer defines the finalizer of the entries of the watches.yaml
+type ansibleFinalizer struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Playbook string `json:"playbook"`
+}
+
+// AnsibleValidator validates the bundles of the Ansible-based operators, which are identified via the
+// project_layout annotations, the ANSIBLE_GATHERING environment variable of their containers or the watches
+// file informed via the WatchesFileKey. Following its current checks:
+//
+// - Ensure that the entries of the watches file inform the version and kind of the resource and a role or a
+// playbook which is found in the project of the watches file
+//
+// - Ensure that the operator is allowed to get, list, watch, patch and update the resources watched
+//
+// - Warn when the operator is not allowed to update the status of the resources watched
+//
+// - Warn when the operator is not allowed to update the finalizers of the resources watched, which is required by
+// the OwnerReferencesPermissionEnforcement admission plugin of OpenShift to set them as the owners of the resources
+// created by the roles
+//
+// - Warn when the operator claims to be proxy-aware and the roles and playbooks of the watches file do not
+// propagate the proxy environment variables injected by OLM in the Ansible runner to the operands
+var AnsibleValidator interfaces.Validator = newBundleValidator("ansible", checkAnsibleWatches,
+	checkAnsibleRBAC, checkAnsibleProxy)
+
+// checkAnsibleWatches will verify the entries of the watches file informed
+func checkAnsibleWatches(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(checks.watchesFile) == 0 {
+		return checks
+	}
+	watches, err := readAnsibleWatches(checks.watchesFile)
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+
+	projectDir := filepath.Dir(checks.watchesFile)
+	for _, w := range watches {
+		name := getAnsibleWatchName(w)
+		if len(w.Version) == 0 || len(w.Kind) == 0 {
+			checks.errs = append(checks.errs, fmt.Errorf("the entry %s of the watches file %s must inform the "+
+				"version and the kind of the resource watched", name, checks.watchesFile))
+		}
+		checks = checkAnsibleReference(checks, projectDir, name, w.Role, w.Playbook)
+		if w.Finalizer != nil && (len(w.Finalizer.Role) > 0 || len(w.Finalizer.Playbook) > 0) {
+			checks = checkAnsibleReference(checks, projectDir, name+" (finalizer "+w.Finalizer.Name+")",
+				w.Finalizer.Role, w.Finalizer.Playbook)
+		}
+	}
+	return checks
+}
+
+// checkAnsibleReference will verify that only one of the role and the playbook informed by the entry of the
+// watches file is informed and found in the project
+func checkAnsibleReference(checks OpenShiftOperatorChecks, projectDir, name, role,
+	playbook string) OpenShiftOperatorChecks {
+	if (len(role) == 0) == (len(playbook) == 0) {
+		checks.errs = append(checks.errs, fmt.Errorf("the entry %s of the watches file %s must inform either "+
+			"a role or a playbook", name, checks.watchesFile))
+		return checks
+	}
+
+	kind, path := "role", getAnsibleRolePath(projectDir, role)
+	if len(playbook) > 0 {
+		kind, path = "playbook", getAnsibleProjectPath(projectDir, playbook)
+	}
+	if _, err := os.Stat(path); err != nil {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the %s %s of the entry %s of the watches file "+
+			"%s was not found in the project (%s). Note that the reconciliation of the resource fails when it is not "+
+			"copied to the image", kind, role+playbook, name, checks.watchesFile, path),
+			"ensure that its path is valid"))
+	}
+	return checks
+}
+
+// checkAnsibleRBAC will verify that the operator is allowed to reconcile the resources watched
+func checkAnsibleRBAC(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if !isAnsibleOperator(checks) {
+		return checks
+	}
+
+	strategy := checks.bundle.CSV.Spec.InstallStrategy.StrategySpec
+	missing := func(resource, group string, verbs []string) []string {
+		var result []string
+		for _, verb := range verbs {
+			if !hasPermission(strategy.Permissions, group, resource, verb) &&
+				!hasPermission(strategy.ClusterPermissions, group, resource, verb) {
+				result = append(result, verb)
+			}
+		}
+		return result
+	}
+
+	for _, r := range getAnsibleWatchedResources(checks) {
+		if verbs := missing(r.resource, r.group, ansibleWatchVerbs); len(verbs) > 0 {
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the operator is not allowed to %s the %s "+
+				"watched. Note that the Ansible-based operators cannot reconcile them without these permissions",
+				strings.Join(verbs, ", "), r.String()), "add them to the permissions or clusterPermissions of the CSV"))
+		}
+		if verbs := missing(r.resource+"/status", r.group, ansibleStatusVerbs); len(verbs) > 0 {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the operator is not allowed to %s the "+
+				"status of the %s watched. Note that the Ansible-based operators report the result of the runs via "+
+				"the status", strings.Join(verbs, ", "), r.String()), "add the permissions to %s/status", r.resource))
+		}
+		if verbs := missing(r.resource+"/finalizers", r.group, ansibleFinalizerVerbs); len(verbs) > 0 {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the operator is not allowed to %s the "+
+				"finalizers of the %s watched. Note that the Ansible-based operators set them as the owners of the "+
+				"resources created with blockOwnerDeletion, which is rejected on OpenShift by the "+
+				"OwnerReferencesPermissionEnforcement admission plugin without this permission",
+				strings.Join(verbs, ", "), r.String()), "add the permissions to %s/finalizers", r.resource))
+		}
+	}
+	return checks
+}
+
+// checkAnsibleProxy will verify that the roles and playbooks of the watches file propagate the proxy
+// environment variables when the operator claims to be proxy-aware
+func checkAnsibleProxy(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(checks.watchesFile) == 0 || !claimsFeature(checks.bundle.CSV, proxyAwareFeature, proxyAwareFeature) {
+		return checks
+	}
+	// Note that issues to read the watches file are reported by checkAnsibleWatches
+	watches, err := readAnsibleWatches(checks.watchesFile)
+	if err != nil {
+		return checks
+	}
+
+	projectDir := filepath.Dir(checks.watchesFile)
+	var paths []string
+	for _, w := range watches {
+		if len(w.Role) > 0 {
+			paths = append(paths, getAnsibleRolePath(projectDir, w.Role))
+		}
+		if len(w.Playbook) > 0 {
+			// The playbooks usually import the roles of the project
+			paths = append(paths, getAnsibleProjectPath(projectDir, w.Playbook), filepath.Join(projectDir, "roles"))
+		}
+	}
+	for _, path := range paths {
+		if referencesProxyEnvs(path) {
+			return checks
+		}
+	}
+	if len(paths) > 0 {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the operator claims to be %s but the roles "+
+			"and playbooks of the watches file %s do not reference the environment variables (%s). Note that OLM "+
+			"injects them in the Ansible runner but the operands created by the roles are not configured to use the "+
+			"cluster proxy unless they are propagated", proxyAwareFeature, checks.watchesFile,
+			strings.Join(proxyEnvs, ", ")), "propagate them (e.g. \"{{ lookup('env', 'HTTP_PROXY') }}\")"))
+	}
+	return checks
+}
+
+// isAnsibleOperator returns true when the bundle is of an Ansible-based operator
+func isAnsibleOperator(checks OpenShiftOperatorChecks) bool {
+	if len(checks.watchesFile) > 0 ||
+		strings.HasPrefix(checks.bundle.CSV.GetAnnotations()[projectLayoutAnnotation], ansibleProjectLayoutPrefix) {
+		return true
+	}
+	annotations := getBundleAnnotations(checks, getBundleDir(checks))
+	if strings.HasPrefix(annotations[projectLayoutBundleAnnotation], ansibleProjectLayoutPrefix) {
+		return true
+	}
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			for _, env := range c.Env {
+				if env.Name == ansibleGatheringEnv {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// ansibleResource defines the resources watched by the Ansible-based operators
+type ansibleResource struct {
+	group    string
+	resource string
+}
+
+// String returns the resource with its group (e.g. memcacheds.cache.example.com)
+func (r ansibleResource) String() string {
+	if len(r.group) == 0 {
+		return r.resource
+	}
+	return r.resource + "." + r.group
+}
+
+// getAnsibleWatchedResources returns the resources of the watches file informed or, when it is not informed,
+// the owned CRDs of the CSV. Note that only the resources of the CRDs of the bundle are returned since
+// the name of the resource of the kinds watched is unknown otherwise.
+func getAnsibleWatchedResources(checks OpenShiftOperatorChecks) []ansibleResource {
+	crds := map[string]ansibleResource{}
+	for _, crd := range getBundleCRDs(checks.bundle) {
+		parts := strings.SplitN(crd.name, ".", 2)
+		if len(parts) == 2 {
+			crds[parts[1]+"/"+crd.kind] = ansibleResource{group: parts[1], resource: parts[0]}
+		}
+	}
+
+	// Note that issues to read the watches file are reported by checkAnsibleWatches
+	var watches []ansibleWatch
+	var err error
+	if len(checks.watchesFile) > 0 {
+		watches, err = readAnsibleWatches(checks.watchesFile)
+	}
+	var watched []string
+	for _, w := range watches {
+		watched = append(watched, w.Group+"/"+w.Kind)
+	}
+	if len(checks.watchesFile) == 0 || err != nil {
+		for _, owned := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+			if parts := strings.SplitN(owned.Name, ".", 2); len(parts) == 2 {
+				watched = append(watched, parts[1]+"/"+owned.Kind)
+			}
+		}
+	}
+
+	found := map[string]bool{}
+	var resources []ansibleResource
+	for _, key := range watched {
+		if r, ok := crds[key]; ok && !found[key] {
+			found[key] = true
+			resources = append(resources, r)
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].String() < resources[j].String() })
+	return resources
+}
+
+// readAnsibleWatches returns the entries of the watches file informed
+func readAnsibleWatches(path string) ([]ansibleWatch, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the watches file %s: %s", path, err)
+	}
+	var watches []ansibleWatch
+	if err := yaml.Unmarshal(content, &watches); err != nil {
+		return nil, fmt.Errorf("unable to parse the watches file %s: %s", path, err)
+	}
+	return watches, nil
+}
+
+// getAnsibleWatchName returns the name of the entry of the watches file (e.g. cache.example.com/v1alpha1/Memcached)
+func getAnsibleWatchName(w ansibleWatch) string {
+	return strings.Trim(w.Group+"/"+w.Version+"/"+w.Kind, "/")
+}
+
+// getAnsibleRolePath returns the path of the role informed in the project. Note that the roles can be informed
+// by name, which are looked up in the roles directory, or by path.
+func getAnsibleRolePath(projectDir, role string) string {
+	if !strings.Contains(role, "/") {
+		return filepath.Join(projectDir, "roles", role)
+	}
+	return getAnsibleProjectPath(projectDir, role)
+}
+
+// getAnsibleProjectPath returns the path in the project of the path informed, which is relative to the
+// project or to the directory of the project in the image (/opt/ansible)
+func getAnsibleProjectPath(projectDir, path string) string {
+	if strings.HasPrefix(path, ansibleImageDir) {
+		return filepath.Join(projectDir, strings.TrimPrefix(path, ansibleImageDir))
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(projectDir, path)
+}
+
+// referencesProxyEnvs returns true when any file of the path informed references the proxy
+// environment variables
+func referencesProxyEnvs(path string) bool {
+	found := false
+	_ = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || found {
+			return nil
+		}
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil
+		}
+		upper := strings.ToUpper(string(content))
+		for _, env := range proxyEnvs {
+			if strings.Contains(upper, env) {
+				found = true
+			}
+		}
+		return nil
+	})
+	return found
+}
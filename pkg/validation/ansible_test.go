@@ -0,0 +1,168 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func Test_AnsibleValidator(t *testing.T) {
+	type args struct {
+		bundleDir   string
+		watchesFile string
+		mutate      func(bundle *manifests.Bundle)
+	}
+	ansibleLayout := func(bundle *manifests.Bundle) {
+		bundle.CSV.Annotations[projectLayoutAnnotation] = "ansible.sdk.operatorframework.io/v1"
+	}
+	proxyAware := func(bundle *manifests.Bundle) {
+		bundle.CSV.Annotations[featureAnnotationPrefix+proxyAwareFeature] = "true"
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the bundle is not of an Ansible-based operator",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions = nil
+				},
+			},
+		},
+		{
+			name: "should pass when the operator is allowed to reconcile the resources watched",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate:    ansibleLayout,
+			},
+		},
+		{
+			name: "should pass when the roles of the watches file propagate the proxy environment variables",
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1",
+				watchesFile: "./testdata/ansible/watches.yaml",
+				mutate:      proxyAware,
+			},
+		},
+		{
+			name:        "should fail when the operator is not allowed to reconcile the resources watched",
+			wantError:   true,
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions[0].Rules = []rbacv1.PolicyRule{{
+						APIGroups: []string{"cache.example.com"},
+						Resources: []string{"memcacheds"},
+						Verbs:     []string{"get", "update"},
+					}}
+					deployment := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0]
+					container := &deployment.Spec.Template.Spec.Containers[0]
+					container.Env = append(container.Env, corev1.EnvVar{Name: ansibleGatheringEnv, Value: "explicit"})
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the operator is not allowed to list, watch, patch the " +
+					"memcacheds.cache.example.com watched. Note that the Ansible-based operators cannot reconcile " +
+					"them without these permissions. Please, add them to the permissions or clusterPermissions of " +
+					"the CSV",
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the operator is not allowed to update, patch the " +
+					"status of the memcacheds.cache.example.com watched. Note that the Ansible-based operators " +
+					"report the result of the runs via the status. Please, add the permissions to memcacheds/status",
+				"Warning: Value : (memcached-operator.v0.0.1) the operator is not allowed to update the finalizers " +
+					"of the memcacheds.cache.example.com watched. Note that the Ansible-based operators set them as " +
+					"the owners of the resources created with blockOwnerDeletion, which is rejected on OpenShift by " +
+					"the OwnerReferencesPermissionEnforcement admission plugin without this permission. Please, add " +
+					"the permissions to memcacheds/finalizers",
+			},
+		},
+		{
+			name:      "should fail when the entries of the watches file are invalid",
+			wantError: true,
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1",
+				watchesFile: "./testdata/ansible/watches_invalid.yaml",
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the role not-found of the entry " +
+					"cache.example.com/v1alpha1/Memcached of the watches file ./testdata/ansible/watches_invalid.yaml " +
+					"was not found in the project (testdata/ansible/roles/not-found). Note that the reconciliation " +
+					"of the resource fails when it is not copied to the image. Please, ensure that its path is valid",
+				"Error: Value : (memcached-operator.v0.0.1) the entry cache.example.com/v1alpha1 of the watches " +
+					"file ./testdata/ansible/watches_invalid.yaml must inform the version and the kind of the " +
+					"resource watched",
+				"Error: Value : (memcached-operator.v0.0.1) the entry cache.example.com/v1alpha1 of the watches " +
+					"file ./testdata/ansible/watches_invalid.yaml must inform either a role or a playbook",
+			},
+		},
+		{
+			name:      "should fail when the watches file is not found",
+			wantError: true,
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1",
+				watchesFile: "./testdata/ansible/not-found.yaml",
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) unable to read the watches file " +
+					"./testdata/ansible/not-found.yaml: open ./testdata/ansible/not-found.yaml: no such file or " +
+					"directory",
+			},
+		},
+		{
+			name:        "should warn when the roles of the proxy-aware operator do not propagate the proxy variables",
+			wantWarning: true,
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1",
+				watchesFile: "./testdata/ansible/watches_no_proxy.yaml",
+				mutate:      proxyAware,
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the operator claims to be proxy-aware but the roles " +
+					"and playbooks of the watches file ./testdata/ansible/watches_no_proxy.yaml do not reference " +
+					"the environment variables (HTTP_PROXY, HTTPS_PROXY, NO_PROXY). Note that OLM injects them in " +
+					"the Ansible runner but the operands created by the roles are not configured to use the cluster " +
+					"proxy unless they are propagated. Please, propagate them " +
+					"(e.g. \"{{ lookup('env', 'HTTP_PROXY') }}\")",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := AnsibleValidator.Validate(bundle, map[string]string{WatchesFileKey: tt.args.watchesFile})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
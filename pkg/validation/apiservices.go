@@ -0,0 +1,78 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// defaultAPIServicePort defines the port used by OLM when the containerPort is not informed
+const defaultAPIServicePort = 443
+
+// APIServiceValidator validates the aggregated APIs defined under spec.apiservicedefinitions.owned
+// in the CSV. Following its current checks:
+//
+// - Warn when the CSV owns aggregated APIs about the extra constraints to distribute them on OpenShift
+//
+// - Ensure that the deployment used by the aggregated API is defined in the install strategy
+// and that it exposes the containerPort used by the Service created by OLM
+var APIServiceValidator interfaces.Validator = newBundleValidator("apiservices", checkAPIServices)
+
+// checkAPIServices will verify the aggregated APIs owned by the CSV
+func checkAPIServices(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	csv := checks.bundle.CSV
+	owned := csv.Spec.APIServiceDefinitions.Owned
+	if len(owned) == 0 {
+		return checks
+	}
+
+	checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the CSV owns aggregated APIs (%d) under "+
+		"spec.apiservicedefinitions. Note that on OpenShift OLM generates and rotates the certificates of the "+
+		"APIService and the operator is responsible for the storage of its resources since it cannot use the cluster "+
+		"etcd", len(owned)), "consider using CRDs instead of aggregated APIs when possible"))
+
+	ports := map[string]map[int32]bool{}
+	for _, dep := range csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		ports[dep.Name] = map[int32]bool{}
+		for _, c := range dep.Spec.Template.Spec.Containers {
+			for _, p := range c.Ports {
+				ports[dep.Name][p.ContainerPort] = true
+			}
+		}
+	}
+
+	for _, api := range owned {
+		name := fmt.Sprintf("%s.%s", api.Version, api.Group)
+		depPorts, found := ports[api.DeploymentName]
+		if !found {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the aggregated API %s uses the deployment (%s) "+
+				"which was not found in the install strategy of the CSV", name, api.DeploymentName),
+				"Deployment", api.DeploymentName))
+			continue
+		}
+		port := api.ContainerPort
+		if port == 0 {
+			port = defaultAPIServicePort
+		}
+		if !depPorts[port] {
+			checks.warns = append(checks.warns, withSubject(fmt.Errorf("the aggregated API %s uses the containerPort "+
+				"(%d) which is not exposed by the containers of the deployment %s. Note that the Service created by "+
+				"OLM will target this port", name, port, api.DeploymentName), "Deployment", api.DeploymentName))
+		}
+	}
+	return checks
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_APIServiceValidator(t *testing.T) {
+	const guidance = "Warning: Value : (memcached-operator.v0.0.1) the CSV owns aggregated APIs (1) under " +
+		"spec.apiservicedefinitions. Note that on OpenShift OLM generates and rotates the certificates of the " +
+		"APIService and the operator is responsible for the storage of its resources since it cannot use the " +
+		"cluster etcd. Please, consider using CRDs instead of aggregated APIs when possible"
+
+	type args struct {
+		apiService *operatorsv1alpha1.APIServiceDescription
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the CSV has no aggregated APIs",
+		},
+		{
+			name:        "should warn with the guidance when the aggregated API is wired",
+			wantWarning: true,
+			args: args{
+				apiService: &operatorsv1alpha1.APIServiceDescription{
+					Name:           "memcacheds",
+					Group:          "cache.example.com",
+					Version:        "v1alpha1",
+					Kind:           "Memcached",
+					DeploymentName: "memcached-operator-controller-manager",
+					ContainerPort:  8443,
+				},
+			},
+			warnStrings: []string{guidance},
+		},
+		{
+			name:        "should warn when the aggregated API port is not exposed",
+			wantWarning: true,
+			args: args{
+				apiService: &operatorsv1alpha1.APIServiceDescription{
+					Name:           "memcacheds",
+					Group:          "cache.example.com",
+					Version:        "v1alpha1",
+					Kind:           "Memcached",
+					DeploymentName: "memcached-operator-controller-manager",
+				},
+			},
+			warnStrings: []string{guidance,
+				"Warning: Value : (memcached-operator.v0.0.1) the aggregated API v1alpha1.cache.example.com uses " +
+					"the containerPort (443) which is not exposed by the containers of the deployment " +
+					"memcached-operator-controller-manager. Note that the Service created by OLM will target this port",
+			},
+		},
+		{
+			name:        "should fail when the aggregated API deployment is not found",
+			wantError:   true,
+			wantWarning: true,
+			args: args{
+				apiService: &operatorsv1alpha1.APIServiceDescription{
+					Name:           "memcacheds",
+					Group:          "cache.example.com",
+					Version:        "v1alpha1",
+					Kind:           "Memcached",
+					DeploymentName: "not-found",
+				},
+			},
+			warnStrings: []string{guidance},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the aggregated API " +
+				"v1alpha1.cache.example.com uses the deployment (not-found) which was not found in the install " +
+				"strategy of the CSV"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			if tt.args.apiService != nil {
+				bundle.CSV.Spec.APIServiceDefinitions.Owned = append(bundle.CSV.Spec.APIServiceDefinitions.Owned,
+					*tt.args.apiService)
+			}
+
+			results := APIServiceValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/encoding"
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// LoadBundleFS returns the bundle found in the directory informed of the file system. It loads the bundles as
+// manifests.GetBundleFromDir but allows to validate them from embedded file systems, archives (e.g. zip.Reader)
+// or remote stores without writing them to disk (e.g. LoadBundleFS(os.DirFS("bundle"), ".")).
+//
+// Note that the file system should also be informed to the validators with the objects to be validated
+// so that the paths informed via the optional values (e.g. file=metadata/annotations.yaml) are read from it.
+func LoadBundleFS(fsys fs.FS, dir string) (*manifests.Bundle, error) {
+	dir = cleanFSPath(dir)
+	var bundle *manifests.Bundle
+	foundCSV := false
+	var errs []error
+	walkErr := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") && name != dir {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to load file %s: %s", name, err))
+			return nil
+		}
+		csv := unstructured.Unstructured{}
+		if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 30).Decode(&csv); err != nil ||
+			csv.GetKind() != operatorsv1alpha1.ClusterServiceVersionKind {
+			return nil
+		}
+
+		foundCSV = true
+		loaded, err := loadBundleFSObjects(fsys, csv.GetName(), path.Dir(name))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error loading objs in directory: %s", err))
+		}
+		if loaded == nil || loaded.CSV == nil {
+			errs = append(errs, fmt.Errorf("no bundle csv found"))
+			return nil
+		}
+		bundle = loaded
+		return nil
+	})
+	if walkErr != nil {
+		errs = append(errs, walkErr)
+	}
+
+	switch {
+	case !foundCSV:
+		errs = append(errs, fmt.Errorf("unable to find a csv in bundle directory %s", dir))
+	case bundle == nil:
+		errs = append(errs, fmt.Errorf("unable to load bundle from directory %s", dir))
+	default:
+		errs = append(errs, setBundleFSSize(fsys, dir, bundle))
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// loadBundleFSObjects returns the bundle with the objects found in the directory of its CSV
+func loadBundleFSObjects(fsys fs.FS, csvName string, dir string) (*manifests.Bundle, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	bundle := &manifests.Bundle{Name: csvName}
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if entry.IsDir() {
+			errs = append(errs, fmt.Errorf("bundle manifests dir contains directory: %s", name))
+			continue
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			errs = append(errs, fmt.Errorf("bundle manifests dir has hidden file: %s", name))
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to load file %s: %s", name, err))
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 30).Decode(obj); err != nil {
+			errs = append(errs, fmt.Errorf("unable to decode object: %s", err))
+			continue
+		}
+		bundle.Objects = append(bundle.Objects, obj)
+
+		// The content is decoded again into the typed objects
+		decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 30)
+		switch obj.GetKind() {
+		case operatorsv1alpha1.ClusterServiceVersionKind:
+			if bundle.CSV != nil {
+				return nil, fmt.Errorf("invalid bundle: contains multiple CSVs")
+			}
+			csv := &operatorsv1alpha1.ClusterServiceVersion{}
+			if err := decoder.Decode(csv); err != nil {
+				return nil, fmt.Errorf("unable to parse CSV %s: %s", entry.Name(), err)
+			}
+			bundle.CSV = csv
+		case "CustomResourceDefinition":
+			switch version := obj.GetAPIVersion(); version {
+			case apiextensionsv1beta1.SchemeGroupVersion.String():
+				crd := &apiextensionsv1beta1.CustomResourceDefinition{}
+				if err := decoder.Decode(crd); err != nil {
+					return nil, fmt.Errorf("unable to parse CRD %s: %s", entry.Name(), err)
+				}
+				bundle.V1beta1CRDs = append(bundle.V1beta1CRDs, crd)
+			case apiextensionsv1.SchemeGroupVersion.String():
+				crd := &apiextensionsv1.CustomResourceDefinition{}
+				if err := decoder.Decode(crd); err != nil {
+					return nil, fmt.Errorf("unable to parse CRD %s: %s", entry.Name(), err)
+				}
+				bundle.V1CRDs = append(bundle.V1CRDs, crd)
+			default:
+				return nil, fmt.Errorf("unsupported CRD version %s for %s", version, entry.Name())
+			}
+		}
+	}
+	return bundle, utilerrors.NewAggregate(errs)
+}
+
+// setBundleFSSize sets the size and the compressed size of the files found in the directory informed
+func setBundleFSSize(fsys fs.FS, dir string, bundle *manifests.Bundle) error {
+	return fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		bundle.Size += int64(len(content))
+		compressed, err := encoding.GzipBase64Encode(content)
+		if err != nil {
+			return err
+		}
+		bundle.CompressedSize += int64(len(compressed))
+		return nil
+	})
+}
+
+// getFS returns the file system informed with the objects to be validated. Note that nil is returned
+// when it is not informed and the files are read from disk.
+func getFS(objs ...interface{}) fs.FS {
+	for _, obj := range objs {
+		if fsys, ok := obj.(fs.FS); ok {
+			return fsys
+		}
+	}
+	return nil
+}
+
+// readFile returns the content of the file informed from the file system or from disk when it is nil
+func readFile(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil {
+		return ioutil.ReadFile(name)
+	}
+	return fs.ReadFile(fsys, cleanFSPath(name))
+}
+
+// statFile returns the info of the file informed from the file system or from disk when it is nil
+func statFile(fsys fs.FS, name string) (fs.FileInfo, error) {
+	if fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(fsys, cleanFSPath(name))
+}
+
+// cleanFSPath returns the path informed as a valid path of a file system (e.g. ./metadata/ is
+// returned as metadata)
+func cleanFSPath(name string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBundleFS(t *testing.T) {
+	for _, dir := range []string{"valid_bundle_v1", "valid_bundle_v1beta1", "bundle_with_deprecated_resources",
+		"invalid_bundle_sa"} {
+		t.Run(dir, func(t *testing.T) {
+			want, err := manifests.GetBundleFromDir("./testdata/" + dir)
+			require.NoError(t, err)
+			bundle, err := LoadBundleFS(os.DirFS("testdata"), "./"+dir+"/")
+			require.NoError(t, err)
+			require.Equal(t, want, bundle)
+		})
+	}
+
+	_, err := LoadBundleFS(os.DirFS("testdata"), "annotations")
+	require.EqualError(t, err, "unable to find a csv in bundle directory annotations")
+}
+
+func Test_validateWithFS(t *testing.T) {
+	csv, err := ioutil.ReadFile("./testdata/valid_bundle_v1beta1/etcdoperator.v0.9.4.clusterserviceversion.yaml")
+	require.NoError(t, err)
+	fsys := fstest.MapFS{
+		"manifests/etcdoperator.v0.9.4.clusterserviceversion.yaml": {Data: csv},
+		"metadata/annotations.yaml": {Data: []byte("annotations:\n" +
+			"  com.redhat.openshift.versions: \"v4.6-v4.9\"\n")},
+	}
+	bundle, err := LoadBundleFS(fsys, ".")
+	require.NoError(t, err)
+	require.Equal(t, "etcdoperator.v0.9.4", bundle.Name)
+
+	// The file informed is read from the file system informed with the objects
+	objs := append(bundle.ObjectsToValidate(), fsys, map[string]string{FilePathKey: "./metadata/annotations.yaml"})
+	results := OpenShiftValidator.Validate(objs...)
+	require.Equal(t, 1, len(results))
+	require.False(t, results[0].HasError(), "%v", results[0].Errors)
+
+	results = OpenShiftValidator.Validate(append(bundle.ObjectsToValidate(),
+		map[string]string{FilePathKey: "./metadata/annotations.yaml"})...)
+	require.Equal(t, 1, len(results))
+	require.True(t, results[0].HasError())
+}
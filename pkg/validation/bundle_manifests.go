@@ -0,0 +1,86 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"reflect"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// BundleManifestsValidator validates the manifests shipped in the bundle. Following its current checks:
+//
+// - Ensure that the bundle has no more than one manifest defining the same GVK and name. Note that
+// OLM behaviour in this scenario is undefined and catalog builds might pick any of them.
+//
+// - Warn when the bundle ships manifests whose kind is not supported by OLM (e.g. Deployment or Job). Note
+// that the supported kinds are the CSV, CRDs, ServiceAccounts, Roles, ClusterRoles and their bindings, Services,
+// Secrets, ConfigMaps, PodDisruptionBudgets, PriorityClasses, VerticalPodAutoscalers, the Prometheus and
+// console kinds and NetworkPolicies.
+var BundleManifestsValidator interfaces.Validator = newBundleValidator("bundle-manifests", checkDuplicateManifests,
+	checkSupportedKinds)
+
+// checkDuplicateManifests will verify that each GVK and name is defined only once in the bundle
+func checkDuplicateManifests(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	var keys []string
+	objsByKey := map[string][]*unstructured.Unstructured{}
+	for _, obj := range checks.bundle.Objects {
+		if obj == nil {
+			continue
+		}
+		key := fmt.Sprintf("%s %s", obj.GroupVersionKind(), obj.GetName())
+		if len(obj.GetNamespace()) > 0 {
+			key = fmt.Sprintf("%s %s/%s", obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+		}
+		if _, found := objsByKey[key]; !found {
+			keys = append(keys, key)
+		}
+		objsByKey[key] = append(objsByKey[key], obj)
+	}
+
+	for _, key := range keys {
+		objs := objsByKey[key]
+		if len(objs) < 2 {
+			continue
+		}
+		drift := ""
+		for _, obj := range objs[1:] {
+			if !reflect.DeepEqual(objs[0].Object, obj.Object) {
+				drift = " and their content differs"
+				break
+			}
+		}
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the bundle has %d manifests defining (%s)%s. "+
+			"Note that OLM behaviour is undefined when the same object is defined more than once", len(objs), key,
+			drift), "ensure that the bundle has only one manifest for each object"))
+	}
+	return checks
+}
+
+// checkSupportedKinds will verify that OLM supports to install the kinds of the manifests shipped in the bundle
+func checkSupportedKinds(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, obj := range checks.bundle.Objects {
+		if obj == nil || isSupportedKind(obj.GetKind()) {
+			continue
+		}
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s %s (%s) shipped in the bundle has a "+
+			"kind which is not supported by OLM. Note that it will not be created when the bundle is installed",
+			obj.GetKind(), obj.GetName(), obj.GetAPIVersion()),
+			"remove it from the bundle and let the operator create it"))
+	}
+	return checks
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_BundleManifestsValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the bundle has no duplicate manifests",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name:      "should fail when the bundle has the same manifest twice",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.Objects = append(bundle.Objects, findObject(bundle, "ServiceAccount").DeepCopy())
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the bundle has 2 manifests defining " +
+				"(/v1, Kind=ServiceAccount memcached-operator-controller-manager). Note that OLM behaviour is " +
+				"undefined when the same object is defined more than once. Please, ensure that the bundle has " +
+				"only one manifest for each object"},
+		},
+		{
+			name:      "should fail when the bundle has the same CRD twice with drift between them",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					crd := findObject(bundle, "CustomResourceDefinition").DeepCopy()
+					crd.SetLabels(map[string]string{"drift": "true"})
+					bundle.Objects = append(bundle.Objects, crd)
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the bundle has 2 manifests defining " +
+				"(apiextensions.k8s.io/v1, Kind=CustomResourceDefinition memcacheds.cache.example.com) and their " +
+				"content differs. Note that OLM behaviour is undefined when the same object is defined more than " +
+				"once. Please, ensure that the bundle has only one manifest for each object"},
+		},
+		{
+			name:        "should warn when the bundle ships a manifest whose kind is not supported by OLM",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					obj := &unstructured.Unstructured{}
+					obj.SetAPIVersion("batch/v1")
+					obj.SetKind("Job")
+					obj.SetName("memcached-setup")
+					bundle.Objects = append(bundle.Objects, obj)
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the Job memcached-setup (batch/v1) " +
+				"shipped in the bundle has a kind which is not supported by OLM. Note that it will not be created " +
+				"when the bundle is installed. Please, remove it from the bundle and let the operator create it"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := BundleManifestsValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// findObject returns the first object of the kind informed found in the bundle
+func findObject(bundle *manifests.Bundle, kind string) *unstructured.Unstructured {
+	for _, obj := range bundle.Objects {
+		if obj.GetKind() == kind {
+			return obj
+		}
+	}
+	return nil
+}
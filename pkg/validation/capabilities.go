@@ -0,0 +1,116 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// capabilitiesAnnotation defines the CSV annotation used to inform the capability level of the operator
+const capabilitiesAnnotation = "capabilities"
+
+// The capability levels which require the operator to provide metrics and status
+const (
+	deepInsightsCapability = "Deep Insights"
+	autoPilotCapability    = "Auto Pilot"
+)
+
+// capabilityLevels defines the capability levels ordered from the lowest to the highest
+var capabilityLevels = []string{"Basic Install", "Seamless Upgrades", "Full Lifecycle", deepInsightsCapability,
+	autoPilotCapability}
+
+// metricsKinds defines the kinds of the manifests used to provide metrics and alerts
+var metricsKinds = []string{"ServiceMonitor", "PodMonitor", "PrometheusRule"}
+
+// CapabilitiesValidator validates the capability level informed via the capabilities annotation of the CSV.
+// Following its current checks:
+//
+// - Ensure that the capabilities annotation is one of the known levels (Basic Install, Seamless Upgrades,
+// Full Lifecycle, Deep Insights or Auto Pilot)
+//
+// - Warn when the Deep Insights or Auto Pilot levels are claimed but the bundle does not ship a
+// ServiceMonitor, PodMonitor or PrometheusRule
+//
+// - Warn when the Auto Pilot level is claimed but the owned CRDs do not have the status subresource or
+// status descriptors
+var CapabilitiesValidator interfaces.Validator = newBundleValidator("capabilities", checkCapabilities)
+
+// checkCapabilities will verify the capability level claimed against the bundle contents
+func checkCapabilities(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	capability, ok := checks.bundle.CSV.GetAnnotations()[capabilitiesAnnotation]
+	if !ok {
+		return checks
+	}
+	if !containsAny(capabilityLevels, capability) {
+		checks.errs = append(checks.errs, fmt.Errorf("the annotation %s has the value (%s) which is not a known "+
+			"level. The known levels are: %s", capabilitiesAnnotation, capability,
+			strings.Join(capabilityLevels, ", ")))
+		return checks
+	}
+
+	if capability != deepInsightsCapability && capability != autoPilotCapability {
+		return checks
+	}
+	hasMetrics := false
+	for _, obj := range checks.bundle.Objects {
+		if containsAny(metricsKinds, obj.GetKind()) {
+			hasMetrics = true
+			break
+		}
+	}
+	if !hasMetrics {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the operator claims the capability level %s "+
+			"but the bundle does not ship any manifest of the kinds (%s)", capability,
+			strings.Join(metricsKinds, ", ")),
+			"ensure that the operator provides metrics and alerts for it and its operands"))
+	}
+
+	if capability == autoPilotCapability && !hasStatusSurface(checks) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the operator claims the capability level %s "+
+			"but its owned CRDs do not have the status subresource or status descriptors", capability),
+			"ensure that the operator reports the status of its operands"))
+	}
+	return checks
+}
+
+// hasStatusSurface returns true when an owned CRD has the status subresource or status descriptors
+func hasStatusSurface(checks OpenShiftOperatorChecks) bool {
+	for _, owned := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+		if len(owned.StatusDescriptors) > 0 {
+			return true
+		}
+	}
+	for _, crd := range checks.bundle.V1CRDs {
+		for _, v := range crd.Spec.Versions {
+			if v.Subresources != nil && v.Subresources.Status != nil {
+				return true
+			}
+		}
+	}
+	for _, crd := range checks.bundle.V1beta1CRDs {
+		if crd.Spec.Subresources != nil && crd.Spec.Subresources.Status != nil {
+			return true
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Subresources != nil && v.Subresources.Status != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CapabilitiesValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the capability level is known",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+			},
+		},
+		{
+			name: "should pass when the Auto Pilot level is claimed with metrics and status",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[capabilitiesAnnotation] = autoPilotCapability
+				},
+			},
+		},
+		{
+			name:      "should fail when the capability level is unknown",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[capabilitiesAnnotation] = "Basic"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation capabilities has the " +
+				"value (Basic) which is not a known level. The known levels are: Basic Install, Seamless Upgrades, " +
+				"Full Lifecycle, Deep Insights, Auto Pilot"},
+		},
+		{
+			name:        "should warn when the Auto Pilot level is claimed without metrics and status",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[capabilitiesAnnotation] = autoPilotCapability
+					for i := range bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+						bundle.CSV.Spec.CustomResourceDefinitions.Owned[i].StatusDescriptors = nil
+					}
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (etcdoperator.v0.9.4) the operator claims the capability level Auto Pilot but " +
+					"the bundle does not ship any manifest of the kinds (ServiceMonitor, PodMonitor, PrometheusRule). " +
+					"Please, ensure that the operator provides metrics and alerts for it and its operands",
+				"Warning: Value : (etcdoperator.v0.9.4) the operator claims the capability level Auto Pilot but " +
+					"its owned CRDs do not have the status subresource or status descriptors. Please, ensure that " +
+					"the operator reports the status of its operands",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := CapabilitiesValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blang/semver"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// maxOpenShiftVersionProperty defines the bundle property used to block the cluster upgrades
+const maxOpenShiftVersionProperty = "olm.maxOpenShiftVersion"
+
+// CatalogDependenciesValidator simulates the resolution of the dependencies of the bundle against the
+// File-Based Catalog informed via the CatalogKey. Note that the index images must be rendered into a
+// directory first (e.g. opm render <index-image> > catalog/index.json). Following its current checks:
+//
+// - Ensure that the olm.package and olm.gvk dependencies of the metadata/dependencies.yaml and the CRDs and
+// APIServices required by the CSV are provided by a bundle of the catalog
+//
+// - Warn when the providers found block the cluster upgrade via olm.maxOpenShiftVersion while the bundle
+// is distributed to upper OCP versions. Note that the dependencies will not be resolvable on them.
+var CatalogDependenciesValidator interfaces.Validator = newBundleValidator("catalog-dependencies",
+	checkCatalogDependencies)
+
+// catalogRequirement defines a dependency of the bundle which must be provided by the catalog
+type catalogRequirement struct {
+	description  string
+	isProvidedBy func(props *property.Properties) bool
+}
+
+// catalogProvider defines the properties of a bundle of the catalog
+type catalogProvider struct {
+	name                string
+	props               *property.Properties
+	maxOpenShiftVersion string
+}
+
+// checkCatalogDependencies will verify that the dependencies of the bundle are resolvable with the catalog
+func checkCatalogDependencies(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(checks.catalog) == 0 {
+		return checks
+	}
+	if info, err := os.Stat(checks.catalog); err != nil || !info.IsDir() {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the catalog %s is not a directory",
+			checks.catalog), "inform a File-Based Catalog directory (e.g. rendered with opm render <index-image>)"))
+		return checks
+	}
+	cfg, err := declcfg.LoadFS(os.DirFS(checks.catalog))
+	if err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to load the catalog %s: %s", checks.catalog, err))
+		return checks
+	}
+
+	var providers []catalogProvider
+	for _, b := range cfg.Bundles {
+		props, err := property.Parse(b.Properties)
+		if err != nil {
+			checks.warns = append(checks.warns, fmt.Errorf("unable to parse the properties of the bundle %s of "+
+				"the catalog: %s", b.Name, err))
+			continue
+		}
+		providers = append(providers, catalogProvider{name: b.Name, props: props,
+			maxOpenShiftVersion: getMaxOpenShiftVersionProperty(props)})
+	}
+
+	requirements, err := getCatalogRequirements(checks)
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+	ocpRange := getOCPRange(checks)
+	for _, req := range requirements {
+		var found []catalogProvider
+		for _, p := range providers {
+			if req.isProvidedBy(p.props) {
+				found = append(found, p)
+			}
+		}
+		if len(found) == 0 {
+			checks.errs = append(checks.errs, fmt.Errorf("the dependency on %s is not provided by any bundle "+
+				"of the catalog %s. Note that the operator will fail to be installed", req.description,
+				checks.catalog))
+			continue
+		}
+		if len(ocpRange) == 0 {
+			continue
+		}
+
+		// The dependency is resolvable on the upper versions while any provider does not block them
+		highest := semver.Version{}
+		for _, p := range found {
+			if len(p.maxOpenShiftVersion) == 0 {
+				highest = semver.Version{}
+				break
+			}
+			if v, err := semver.ParseTolerant(p.maxOpenShiftVersion); err == nil && v.GT(highest) {
+				highest = v
+			}
+		}
+		if highest.Major == 0 {
+			continue
+		}
+		next := fmt.Sprintf("%d.%d", highest.Major, highest.Minor+1)
+		if upper, err := rangeAllowsVersionOrUpper(ocpRange, next); err == nil && upper {
+			checks.warns = append(checks.warns, fmt.Errorf("the dependency on %s is only provided by bundles "+
+				"which set %s to %d.%d while the bundle is distributed to OCP %s (%s). Note that it will not be "+
+				"resolvable on OCP %s or upper versions", req.description, maxOpenShiftVersionProperty,
+				highest.Major, highest.Minor, ocpRange, ocpLabel, next))
+		}
+	}
+	return checks
+}
+
+// getCatalogRequirements returns the dependencies informed in the dependencies.yaml and the CRDs and
+// APIServices required by the CSV
+func getCatalogRequirements(checks OpenShiftOperatorChecks) ([]catalogRequirement, error) {
+	dependencies, err := getBundleDependencies(checks)
+	if err != nil {
+		return nil, err
+	}
+
+	var requirements []catalogRequirement
+	for _, dep := range dependencies {
+		value := dep.Value
+		versionField := "version"
+		if dep.Type == constraintDependencyType {
+			constraint := map[string]json.RawMessage{}
+			if err := json.Unmarshal(dep.Value, &constraint); err != nil {
+				continue
+			}
+			if pkg, ok := constraint["package"]; ok {
+				dep.Type, value, versionField = packageDependencyType, pkg, "versionRange"
+			} else if gvk, ok := constraint["gvk"]; ok {
+				dep.Type, value = gvkDependencyType, gvk
+			}
+		}
+
+		// Note that the invalid dependencies are reported by the DependenciesValidator
+		switch dep.Type {
+		case packageDependencyType:
+			pkg := packageDependency{}
+			if err := json.Unmarshal(value, &pkg); err != nil {
+				continue
+			}
+			version := pkg.Version
+			if versionField == "versionRange" {
+				version = pkg.VersionRange
+			}
+			if req, ok := newPackageRequirement(pkg.PackageName, version); ok {
+				requirements = append(requirements, req)
+			}
+		case gvkDependencyType:
+			gvk := gvkDependency{}
+			if err := json.Unmarshal(value, &gvk); err != nil {
+				continue
+			}
+			requirements = append(requirements, newGVKRequirement(gvk.Group, gvk.Version, gvk.Kind))
+		}
+	}
+
+	for _, required := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Required {
+		group := required.Name
+		if nameParts := strings.SplitN(required.Name, ".", 2); len(nameParts) == 2 {
+			group = nameParts[1]
+		}
+		requirements = append(requirements, newGVKRequirement(group, required.Version, required.Kind))
+	}
+	for _, required := range checks.bundle.CSV.Spec.APIServiceDefinitions.Required {
+		requirements = append(requirements, newGVKRequirement(required.Group, required.Version, required.Kind))
+	}
+	return requirements, nil
+}
+
+// newPackageRequirement returns the requirement of a package version in the semver range informed
+func newPackageRequirement(packageName, versionRange string) (catalogRequirement, bool) {
+	inRange, err := semver.ParseRange(versionRange)
+	if err != nil || len(packageName) == 0 {
+		return catalogRequirement{}, false
+	}
+	return catalogRequirement{
+		description: fmt.Sprintf("the package %s (%s)", packageName, versionRange),
+		isProvidedBy: func(props *property.Properties) bool {
+			for _, p := range props.Packages {
+				v, err := semver.ParseTolerant(p.Version)
+				if err == nil && p.PackageName == packageName && inRange(v) {
+					return true
+				}
+			}
+			return false
+		},
+	}, true
+}
+
+// newGVKRequirement returns the requirement of the API informed
+func newGVKRequirement(group, version, kind string) catalogRequirement {
+	return catalogRequirement{
+		description: fmt.Sprintf("the API %s/%s %s", group, version, kind),
+		isProvidedBy: func(props *property.Properties) bool {
+			for _, gvk := range props.GVKs {
+				if gvk.Group == group && gvk.Version == version && gvk.Kind == kind {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// getMaxOpenShiftVersionProperty returns the value of the olm.maxOpenShiftVersion property when informed
+func getMaxOpenShiftVersionProperty(props *property.Properties) string {
+	for _, p := range props.Others {
+		if p.Type != maxOpenShiftVersionProperty {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(p.Value, &value); err != nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+	return ""
+}
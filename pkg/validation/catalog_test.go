@@ -0,0 +1,135 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CatalogDependenciesValidator(t *testing.T) {
+	type args struct {
+		catalog      string
+		ocpRange     string
+		dependencies string
+		mutate       func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the catalog is not informed",
+			args: args{
+				mutate: requireEtcdCluster,
+			},
+		},
+		{
+			name: "should pass when the dependencies are provided by the catalog",
+			args: args{
+				catalog:  "./testdata/catalog",
+				ocpRange: "v4.10-v4.12",
+				dependencies: "dependencies:\n- type: olm.package\n  value:\n    packageName: etcd\n" +
+					"    version: \">=0.9.0\"\n",
+				mutate: requireEtcdCluster,
+			},
+		},
+		{
+			name:      "should fail when the dependencies are not provided by the catalog",
+			wantError: true,
+			args: args{
+				catalog: "./testdata/catalog",
+				dependencies: "dependencies:\n- type: olm.constraint\n  value:\n    package:\n" +
+					"      packageName: etcd\n      versionRange: \">=1.0.0\"\n",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.CustomResourceDefinitions.Required = []operatorsv1alpha1.CRDDescription{{
+						Name: "etcdbackups.etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdBackup"}}
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the dependency on the package etcd (>=1.0.0) is not " +
+					"provided by any bundle of the catalog ./testdata/catalog. Note that the operator will fail to " +
+					"be installed",
+				"Error: Value : (memcached-operator.v0.0.1) the dependency on the API " +
+					"etcd.database.coreos.com/v1beta2 EtcdBackup is not provided by any bundle of the catalog " +
+					"./testdata/catalog. Note that the operator will fail to be installed",
+			},
+		},
+		{
+			name:        "should warn when the providers block the upper OCP versions targeted",
+			wantWarning: true,
+			args: args{
+				catalog:  "./testdata/catalog",
+				ocpRange: "v4.10",
+				mutate:   requireEtcdCluster,
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the dependency on the API " +
+				"etcd.database.coreos.com/v1beta2 EtcdCluster is only provided by bundles which set " +
+				"olm.maxOpenShiftVersion to 4.12 while the bundle is distributed to OCP v4.10 " +
+				"(com.redhat.openshift.versions). Note that it will not be resolvable on OCP 4.13 or upper versions"},
+		},
+		{
+			name:      "should fail when the catalog is not a directory",
+			wantError: true,
+			args: args{
+				catalog: "quay.io/example/index:v4.12",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the catalog quay.io/example/index:v4.12 " +
+				"is not a directory. Please, inform a File-Based Catalog directory (e.g. rendered with opm render " +
+				"<index-image>)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			dir := t.TempDir()
+			if len(tt.args.dependencies) > 0 {
+				require.NoError(t, os.Mkdir(filepath.Join(dir, "metadata"), 0755))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "metadata", dependenciesFile),
+					[]byte(tt.args.dependencies), 0644))
+			}
+
+			results := CatalogDependenciesValidator.Validate(bundle, map[string]string{
+				CatalogKey:    tt.args.catalog,
+				RangeKey:      tt.args.ocpRange,
+				BundlePathKey: filepath.Join(dir, "manifests"),
+			})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// requireEtcdCluster sets the EtcdCluster CRD as required by the CSV
+func requireEtcdCluster(bundle *manifests.Bundle) {
+	bundle.CSV.Spec.CustomResourceDefinitions.Required = []operatorsv1alpha1.CRDDescription{{
+		Name: "etcdclusters.etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}}
+}
@@ -0,0 +1,214 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// certificationLabel defines a label required by the Red Hat certification for the bundle images
+type certificationLabel struct {
+	name string
+	// example is the value used to exemplify the label in the messages
+	example string
+	// check returns a description of the expected format when the value is invalid
+	check func(value string) (string, bool)
+}
+
+// imageNameRegexp matches the repositories of the images (e.g. my-org/my-operator-bundle)
+var imageNameRegexp = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+)*)*$`)
+
+// componentRegexp matches the names of the components (e.g. my-operator-bundle-container)
+var componentRegexp = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*$`)
+
+// releaseRegexp matches the releases of the images (e.g. 1 or 1.el9)
+var releaseRegexp = regexp.MustCompile(`^[A-Za-z0-9]+([._+-][A-Za-z0-9]+)*$`)
+
+// distributionScopes defines the values accepted by the distribution-scope label
+var distributionScopes = []string{"public", "private", "authoritative-source-only", "restricted"}
+
+// certificationLabels defines the labels required by the Red Hat certification in the order they are checked
+var certificationLabels = []certificationLabel{
+	{name: "name", example: "my-org/my-operator-bundle", check: matchFormat(imageNameRegexp,
+		"the repository of the image in lowercase (e.g. my-org/my-operator-bundle)")},
+	{name: "vendor", example: "My Company"},
+	{name: "version", example: "v0.0.1", check: func(value string) (string, bool) {
+		_, ok := parseVersionReference(value)
+		return "a semantic version with or without the v prefix (e.g. v0.0.1)", ok
+	}},
+	{name: "release", example: "1", check: matchFormat(releaseRegexp,
+		"alphanumeric characters separated by '.', '_', '+' or '-' (e.g. 1)")},
+	{name: "summary", example: "My Operator bundle"},
+	{name: "description", example: "My Operator manages My Application on OpenShift"},
+	{name: "com.redhat.component", example: "my-operator-bundle-container", check: matchFormat(componentRegexp,
+		"lowercase alphanumeric characters separated by '.', '_' or '-' (e.g. my-operator-bundle-container)")},
+	{name: "distribution-scope", example: "public", check: func(value string) (string, bool) {
+		return "one of: " + strings.Join(distributionScopes, ", "), containsAny(distributionScopes, value)
+	}},
+}
+
+// matchFormat returns a check of the values which match with the regexp informed
+func matchFormat(re *regexp.Regexp, format string) func(value string) (string, bool) {
+	return func(value string) (string, bool) {
+		return format, re.MatchString(value)
+	}
+}
+
+// CertificationLabelsValidator validates the labels of the bundle.Dockerfile informed via the optional key
+// value file, which are required by the Red Hat certification. Note that the checks are only performed when
+// the file informed is a Dockerfile and the OCP versions label is checked by the OpenShiftValidator.
+// Following its current checks:
+//
+// - Ensure that the name, vendor, version, release, summary, description, com.redhat.component and
+// distribution-scope labels are informed. Note that they are only required for the certified, redhat and
+// marketplace profiles or when the optional value strict-labels=true is informed.
+//
+// - Ensure that the name, version, release, com.redhat.component and distribution-scope labels have a
+// valid format
+//
+// Note that the issues are reported as errors for the certified, redhat and marketplace profiles and as
+// warnings otherwise, except the missing labels which are always reported as errors when they are required.
+var CertificationLabelsValidator interfaces.Validator = newBundleValidator("certification-labels",
+	checkCertificationLabels)
+
+// checkCertificationLabels will verify the labels required by the certification in the bundle.Dockerfile
+func checkCertificationLabels(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(checks.filePath) == 0 {
+		return checks
+	}
+	// Note that issues to read the file are reported by the OpenShiftValidator
+	content, err := readFile(checks.fsys, checks.filePath)
+	if err != nil || !isDockerfile(string(content)) {
+		return checks
+	}
+
+	labels := parseDockerfileLabels(string(content))
+	required := isStrictProfile(checks.profile) || checks.strictLabels
+	for _, label := range certificationLabels {
+		value := strings.TrimSpace(labels[label.name])
+		if len(value) == 0 {
+			if required {
+				checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the label %s required by the Red Hat "+
+					"certification was not found in %s", label.name, checks.filePath),
+					"add it to the bundle.Dockerfile (e.g. LABEL %s=\"%s\")", label.name, label.example))
+			}
+			continue
+		}
+		if label.check == nil {
+			continue
+		}
+		if format, ok := label.check(value); !ok {
+			checks = addStrictFinding(checks, withRemediation(fmt.Errorf("the label %s with the value %q in %s has an "+
+				"invalid format", label.name, value, checks.filePath), "ensure that it is %s", format))
+		}
+	}
+	return checks
+}
+
+// isDockerfile returns true when the content informed has a FROM instruction
+func isDockerfile(content string) bool {
+	for _, instruction := range getDockerfileInstructions(content) {
+		fields := strings.Fields(instruction)
+		if len(fields) > 0 && strings.EqualFold(fields[0], "FROM") {
+			return true
+		}
+	}
+	return false
+}
+
+// getDockerfileInstructions returns the instructions of the Dockerfile informed joining the lines
+// continued with \ and ignoring the comments and empty lines
+func getDockerfileInstructions(content string) []string {
+	var instructions []string
+	current := ""
+	for _, line := range splitLabelLines(content) {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			current += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		instructions = append(instructions, current+line)
+		current = ""
+	}
+	if len(strings.TrimSpace(current)) > 0 {
+		instructions = append(instructions, current)
+	}
+	return instructions
+}
+
+// parseDockerfileLabels returns the labels informed via the LABEL instructions of the Dockerfile informed
+// (e.g. LABEL name="my-org/my-operator-bundle" version=v0.0.1). Note that the last value informed for
+// a label is returned as it happens when the image is built.
+func parseDockerfileLabels(content string) map[string]string {
+	labels := map[string]string{}
+	for _, instruction := range getDockerfileInstructions(content) {
+		fields := strings.Fields(instruction)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "LABEL") {
+			continue
+		}
+		args := strings.TrimSpace(instruction[len(fields[0]):])
+		for len(args) > 0 {
+			key, rest := readDockerfileWord(args, "= \t")
+			if !strings.HasPrefix(rest, "=") {
+				// legacy syntax which informs a single label (e.g. LABEL name my-org/my-operator-bundle)
+				value, _ := readDockerfileWord(strings.TrimSpace(rest), "")
+				labels[key] = value
+				break
+			}
+			var value string
+			value, rest = readDockerfileWord(rest[1:], " \t")
+			labels[key] = value
+			args = strings.TrimSpace(rest)
+		}
+	}
+	return labels
+}
+
+// readDockerfileWord returns the word found at the beginning of the value informed without its quotes
+// and the rest of the value. Note that the unquoted words end on any of the separators informed.
+func readDockerfileWord(value, separators string) (string, string) {
+	if len(value) == 0 {
+		return "", ""
+	}
+	if quote := value[0]; quote == '"' || quote == '\'' {
+		var word strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch {
+			case value[i] == '\\' && quote == '"' && i+1 < len(value):
+				i++
+				word.WriteByte(value[i])
+			case value[i] == quote:
+				return word.String(), value[i+1:]
+			default:
+				word.WriteByte(value[i])
+			}
+		}
+		return word.String(), ""
+	}
+	if len(separators) == 0 {
+		return value, ""
+	}
+	if end := strings.IndexAny(value, separators); end >= 0 {
+		return value[:end], value[end:]
+	}
+	return value, ""
+}
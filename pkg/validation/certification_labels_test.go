@@ -0,0 +1,187 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CertificationLabelsValidator(t *testing.T) {
+	type args struct {
+		filePath     string
+		dockerfile   string
+		profile      string
+		strictLabels bool
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when no file is informed",
+		},
+		{
+			name: "should pass when the file informed is not a Dockerfile",
+			args: args{
+				filePath: "./testdata/annotations/annotations.yaml",
+			},
+		},
+		{
+			name: "should pass when all labels are informed with a valid format",
+			args: args{
+				filePath: "./testdata/dockerfile/certified_bundle.Dockerfile",
+				profile:  CertifiedProfile,
+			},
+		},
+		{
+			name: "should pass when the labels are informed with the legacy syntax",
+			args: args{
+				dockerfile: "FROM scratch\r\n" +
+					"LABEL name example/memcached-operator-bundle\r\n" +
+					"LABEL vendor=Example version=0.0.1 release=1.el9\r\n" +
+					"label summary='Memcached Operator' description=\"The \\\"Memcached\\\" Operator\"\r\n" +
+					"LABEL \"com.redhat.component\"=memcached-operator-bundle-container distribution-scope=public\r\n",
+				profile: CertifiedProfile,
+			},
+		},
+		{
+			name: "should pass when the labels are not informed and no profile is informed",
+			args: args{
+				filePath: "./testdata/dockerfile/valid_bundle.Dockerfile",
+			},
+		},
+		{
+			name:      "should fail when the labels are not informed with the strict labels",
+			wantError: true,
+			args: args{
+				dockerfile: "FROM scratch\n" +
+					"LABEL name=example/memcached-operator-bundle vendor=Example version=0.0.1 release=1 \\\n" +
+					"      summary=\"Memcached Operator\" description=\"Memcached Operator\" \\\n" +
+					"      com.redhat.component=memcached-operator-bundle-container\n",
+				strictLabels: true,
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the label distribution-scope required by the Red Hat " +
+					"certification was not found in bundle.Dockerfile. Please, add it to the bundle.Dockerfile " +
+					"(e.g. LABEL distribution-scope=\"public\")",
+			},
+		},
+		{
+			name:      "should fail when the labels are not informed for the certified profile",
+			wantError: true,
+			args: args{
+				filePath: "./testdata/dockerfile/valid_bundle.Dockerfile",
+				profile:  CertifiedProfile,
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the label name required by the Red Hat certification was " +
+					"not found in ./testdata/dockerfile/valid_bundle.Dockerfile. Please, add it to the " +
+					"bundle.Dockerfile (e.g. LABEL name=\"my-org/my-operator-bundle\")",
+				"Error: Value : (memcached-operator.v0.0.1) the label vendor required by the Red Hat certification " +
+					"was not found in ./testdata/dockerfile/valid_bundle.Dockerfile. Please, add it to the " +
+					"bundle.Dockerfile (e.g. LABEL vendor=\"My Company\")",
+				"Error: Value : (memcached-operator.v0.0.1) the label version required by the Red Hat certification " +
+					"was not found in ./testdata/dockerfile/valid_bundle.Dockerfile. Please, add it to the " +
+					"bundle.Dockerfile (e.g. LABEL version=\"v0.0.1\")",
+				"Error: Value : (memcached-operator.v0.0.1) the label release required by the Red Hat certification " +
+					"was not found in ./testdata/dockerfile/valid_bundle.Dockerfile. Please, add it to the " +
+					"bundle.Dockerfile (e.g. LABEL release=\"1\")",
+				"Error: Value : (memcached-operator.v0.0.1) the label summary required by the Red Hat certification " +
+					"was not found in ./testdata/dockerfile/valid_bundle.Dockerfile. Please, add it to the " +
+					"bundle.Dockerfile (e.g. LABEL summary=\"My Operator bundle\")",
+				"Error: Value : (memcached-operator.v0.0.1) the label description required by the Red Hat " +
+					"certification was not found in ./testdata/dockerfile/valid_bundle.Dockerfile. Please, add it " +
+					"to the bundle.Dockerfile (e.g. LABEL description=\"My Operator manages My Application on " +
+					"OpenShift\")",
+				"Error: Value : (memcached-operator.v0.0.1) the label com.redhat.component required by the Red Hat " +
+					"certification was not found in ./testdata/dockerfile/valid_bundle.Dockerfile. Please, add it " +
+					"to the bundle.Dockerfile (e.g. LABEL com.redhat.component=\"my-operator-bundle-container\")",
+				"Error: Value : (memcached-operator.v0.0.1) the label distribution-scope required by the Red Hat " +
+					"certification was not found in ./testdata/dockerfile/valid_bundle.Dockerfile. Please, add it " +
+					"to the bundle.Dockerfile (e.g. LABEL distribution-scope=\"public\")",
+			},
+		},
+		{
+			name:        "should warn when the labels have an invalid format and no profile is informed",
+			wantWarning: true,
+			args: args{
+				dockerfile: "FROM scratch\n" +
+					"LABEL name=\"Example/Memcached Operator\" vendor=\"Example Inc.\" version=\"latest\" \\\n" +
+					"      release=\"1 2\" summary=\"Memcached Operator\" description=\"Memcached Operator\" \\\n" +
+					"      com.redhat.component=\"Memcached_Operator\" distribution-scope=\"everyone\"\n",
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the label name with the value \"Example/Memcached " +
+					"Operator\" in bundle.Dockerfile has an invalid format. Please, ensure that it is the " +
+					"repository of the image in lowercase (e.g. my-org/my-operator-bundle)",
+				"Warning: Value : (memcached-operator.v0.0.1) the label version with the value \"latest\" in " +
+					"bundle.Dockerfile has an invalid format. Please, ensure that it is a semantic version with or " +
+					"without the v prefix (e.g. v0.0.1)",
+				"Warning: Value : (memcached-operator.v0.0.1) the label release with the value \"1 2\" in " +
+					"bundle.Dockerfile has an invalid format. Please, ensure that it is alphanumeric characters " +
+					"separated by '.', '_', '+' or '-' (e.g. 1)",
+				"Warning: Value : (memcached-operator.v0.0.1) the label com.redhat.component with the value " +
+					"\"Memcached_Operator\" in bundle.Dockerfile has an invalid format. Please, ensure that it is " +
+					"lowercase alphanumeric characters separated by '.', '_' or '-' (e.g. my-operator-bundle-container)",
+				"Warning: Value : (memcached-operator.v0.0.1) the label distribution-scope with the value " +
+					"\"everyone\" in bundle.Dockerfile has an invalid format. Please, ensure that it is one of: " +
+					"public, private, authoritative-source-only, restricted",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+
+			objs := []interface{}{bundle}
+			filePath := tt.args.filePath
+			if len(tt.args.dockerfile) > 0 {
+				filePath = "bundle.Dockerfile"
+				objs = append(objs, fstest.MapFS{filePath: {Data: []byte(tt.args.dockerfile)}})
+			}
+			values := map[string]string{FilePathKey: filePath, ProfileKey: tt.args.profile}
+			if tt.args.strictLabels {
+				values[StrictLabelsKey] = "true"
+			}
+			objs = append(objs, values)
+
+			results := CertificationLabelsValidator.Validate(objs...)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+func Test_parseDockerfileLabels(t *testing.T) {
+	labels := parseDockerfileLabels("FROM scratch\n" +
+		"# LABEL commented=true\n" +
+		"LABEL a=1 b=\"two words\" \\\n" +
+		"  # comments are allowed in the continuations\n" +
+		"  c='three'\n" +
+		"LABEL d four\n" +
+		"LABEL a=5\n" +
+		"COPY bundle/manifests /manifests/\n")
+	require.Equal(t, map[string]string{"a": "5", "b": "two words", "c": "three", "d": "four"}, labels)
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"io/fs"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/operator-framework/api/pkg/validation/errors"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/finding"
+)
+
+// checkFunc defines the functions used to perform the checks over the bundle. The errors
+// and warnings found are stored in the OpenShiftOperatorChecks returned.
+type checkFunc func(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks
+
+// newBundleValidator returns a Validator with the name informed which will perform the checks informed
+// for each bundle found in the objects to be validated
+func newBundleValidator(name string, checkFuncs ...checkFunc) interfaces.Validator {
+	return Named(name, interfaces.ValidatorFunc(func(objs ...interface{}) (results []errors.ManifestResult) {
+		optionalValues := getOptionalValues(objs...)
+		fsys := getFS(objs...)
+		for _, obj := range objs {
+			switch v := obj.(type) {
+			case *manifests.Bundle:
+				results = append(results, validateBundleWith(v, optionalValues, fsys, checkFuncs...))
+			}
+		}
+		return results
+	}))
+}
+
+// namedValidator implements Validator by wrapping a Validator which has no name
+type namedValidator struct {
+	interfaces.Validator
+	name string
+}
+
+// Name returns the name of the validator
+func (v namedValidator) Name() string {
+	return v.name
+}
+
+// WithValidators appends the validator to the validators informed
+func (v namedValidator) WithValidators(validators ...interfaces.Validator) interfaces.Validators {
+	return append(validators, v)
+}
+
+// Named returns the Validator informed identified by the name informed
+func Named(name string, validator interfaces.Validator) interfaces.Validator {
+	return namedValidator{Validator: validator, name: name}
+}
+
+// GetValidatorName returns the name of the Validator informed or an empty string when it has no name
+func GetValidatorName(validator interfaces.Validator) string {
+	if named, ok := validator.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return ""
+}
+
+// getOptionalValues returns the key=values informed to the validator
+// (e.g. --optional-values="file=bundle.Dockerfile")
+func getOptionalValues(objs ...interface{}) map[string]string {
+	optionalValues := map[string]string{}
+	for _, obj := range objs {
+		switch obj := obj.(type) {
+		case map[string]string:
+			for k, v := range obj {
+				optionalValues[k] = v
+			}
+		}
+	}
+	return optionalValues
+}
+
+// validateBundleWith will perform the checks informed against the bundle. The files informed via the
+// optional values are read from the file system informed or from disk when it is nil.
+func validateBundleWith(bundle *manifests.Bundle, optionalValues map[string]string, fsys fs.FS,
+	checkFuncs ...checkFunc) errors.ManifestResult {
+	result := errors.ManifestResult{}
+	if bundle == nil {
+		result.Add(errors.ErrInvalidBundle("Bundle is nil", nil))
+		return result
+	}
+	result.Name = bundle.Name
+
+	if bundle.CSV == nil {
+		result.Add(errors.ErrInvalidBundle("Bundle csv is nil", bundle.Name))
+		return result
+	}
+
+	checks := OpenShiftOperatorChecks{
+		bundle:            *bundle,
+		fsys:              fsys,
+		filePath:          optionalValues[FilePathKey],
+		bundlePath:        optionalValues[BundlePathKey],
+		profile:           optionalValues[ProfileKey],
+		checkImages:       optionalValues[CheckImagesKey] == "true",
+		allowedRegistries: getAllowedRegistries(optionalValues[AllowedRegistriesKey]),
+		catalog:           optionalValues[CatalogKey],
+		kubeconfig:        optionalValues[KubeconfigKey],
+		pyxisProject:      optionalValues[PyxisProjectKey],
+		docsVersion:       optionalValues[DocsVersionKey],
+		strictLabels:      optionalValues[StrictLabelsKey] == "true",
+		signedImages:      getSignedImages(optionalValues[SignedImagesKey]),
+		cosignOptions:     getCosignOptions(optionalValues),
+		watchesFile:       optionalValues[WatchesFileKey],
+		labelRange:        optionalValues[RangeKey],
+		rangeValue:        optionalValues[RangeKey],
+		errs:              []error{},
+		warns:             []error{},
+	}
+	// The image of the bundle is only known by the bundle when it was pulled from it
+	if image := optionalValues[BundleImageKey]; len(image) > 0 {
+		checks.bundle.BundleImage = image
+	}
+
+	for _, check := range checkFuncs {
+		checks = check(checks)
+	}
+
+	for _, err := range checks.errs {
+		result.Add(toManifestError(errors.LevelError, err, bundle.CSV.GetName()))
+	}
+	for _, warn := range checks.warns {
+		result.Add(toManifestError(errors.LevelWarn, warn, bundle.CSV.GetName()))
+	}
+
+	return result
+}
+
+// checkError defines the errors of the checks which inform the subject and the remediation of the finding
+type checkError struct {
+	msg         string
+	subject     *finding.ObjectReference
+	remediation string
+}
+
+// Error returns the message of the finding followed by its remediation
+func (e checkError) Error() string {
+	return e.msg
+}
+
+// toCheckError returns the error informed as a checkError
+func toCheckError(err error) checkError {
+	if e, ok := err.(checkError); ok {
+		return e
+	}
+	return checkError{msg: err.Error()}
+}
+
+// withSubject returns the error informed about the object of the kind and name informed
+// (e.g. Deployment memcached-operator-controller-manager)
+func withSubject(err error, kind, name string) error {
+	e := toCheckError(err)
+	e.subject = &finding.ObjectReference{Kind: kind, Name: name}
+	return e
+}
+
+// withRemediation returns the error informed followed by the remediation informed
+// (e.g. "Please, inform spec.icon"). Note that the error is returned as it is when the remediation is empty.
+func withRemediation(err error, format string, args ...interface{}) error {
+	remediation := fmt.Sprintf(format, args...)
+	if remediation == "" {
+		return err
+	}
+	e := toCheckError(err)
+	e.msg += ". Please, " + remediation
+	first, size := utf8.DecodeRuneInString(remediation)
+	e.remediation = string(unicode.ToUpper(first)) + remediation[size:]
+	return e
+}
+
+// toManifestError returns the invalid CSV error of the level informed for the error of the checks informed.
+// Note that its finding.Details are informed as its value.
+func toManifestError(level errors.Level, err error, csv string) errors.Error {
+	result := errors.WarnInvalidCSV(err.Error(), csv)
+	if level == errors.LevelError {
+		result = errors.ErrInvalidCSV(err.Error(), csv)
+	}
+	e := toCheckError(err)
+	result.BadValue = finding.Details{CSV: csv, Subject: e.subject, Remediation: e.remediation}
+	return result
+}
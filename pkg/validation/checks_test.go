@@ -0,0 +1,117 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/operator-framework/api/pkg/validation/errors"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/finding"
+)
+
+// requireResult checks that the result has only the errors and warnings expected
+func requireResult(t *testing.T, result errors.ManifestResult, wantError, wantWarning bool,
+	errStrings, warnStrings []string) {
+	t.Helper()
+	require.Equal(t, wantWarning, len(result.Warnings) > 0, "warnings: %v", result.Warnings)
+	if wantWarning {
+		require.Equal(t, len(warnStrings), len(result.Warnings), "warnings: %v", result.Warnings)
+		for _, w := range result.Warnings {
+			require.Contains(t, warnStrings, w.Error())
+		}
+	}
+
+	require.Equal(t, wantError, len(result.Errors) > 0, "errors: %v", result.Errors)
+	if wantError {
+		require.Equal(t, len(errStrings), len(result.Errors), "errors: %v", result.Errors)
+		for _, err := range result.Errors {
+			require.Contains(t, errStrings, err.Error())
+		}
+	}
+}
+
+func Test_validateBundleWith(t *testing.T) {
+	var called bool
+	check := func(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+		called = true
+		require.Equal(t, "bundle.Dockerfile", checks.filePath)
+		require.Equal(t, "v4.8", checks.labelRange)
+		require.Equal(t, "quay.io/example/memcached-operator-bundle:v0.0.1", checks.bundle.BundleImage)
+		return checks
+	}
+
+	result := validateBundleWith(nil, map[string]string{}, nil, check)
+	require.True(t, result.HasError())
+	require.False(t, called)
+
+	result = validateBundleWith(&manifests.Bundle{Name: "test"}, map[string]string{}, nil, check)
+	require.True(t, result.HasError())
+	require.False(t, called)
+
+	bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+	require.NoError(t, err)
+	optionalValues := getOptionalValues(bundle, map[string]string{FilePathKey: "bundle.Dockerfile"},
+		map[string]string{RangeKey: "v4.8", BundleImageKey: "quay.io/example/memcached-operator-bundle:v0.0.1"})
+	result = validateBundleWith(bundle, optionalValues, nil, check)
+	require.False(t, result.HasError())
+	require.True(t, called)
+}
+
+func Test_toManifestError(t *testing.T) {
+	err := toManifestError(errors.LevelWarn, withSubject(withRemediation(fmt.Errorf("the deployment %s has no "+
+		"replicas", "memcached"), "inform %s", "spec.replicas"), "Deployment", "memcached"), "memcached.v0.0.1")
+	require.Equal(t, "Warning: Value : (memcached.v0.0.1) the deployment memcached has no replicas. Please, "+
+		"inform spec.replicas", err.Error())
+	require.Equal(t, finding.Details{
+		CSV:         "memcached.v0.0.1",
+		Subject:     &finding.ObjectReference{Kind: "Deployment", Name: "memcached"},
+		Remediation: "Inform spec.replicas",
+	}, err.BadValue)
+
+	err = toManifestError(errors.LevelError, fmt.Errorf("the CSV is invalid"), "memcached.v0.0.1")
+	require.Equal(t, "Error: Value : (memcached.v0.0.1) the CSV is invalid", err.Error())
+	require.Equal(t, finding.Details{CSV: "memcached.v0.0.1"}, err.BadValue)
+}
+
+func Test_withRemediation(t *testing.T) {
+	err := withRemediation(fmt.Errorf("the CSV has no icon"), "inform %s", "spec.icon")
+	require.Equal(t, "the CSV has no icon. Please, inform spec.icon", err.Error())
+	require.Equal(t, "Inform spec.icon", toCheckError(err).remediation)
+
+	err = withRemediation(fmt.Errorf("the CSV has no icon"), "%s", "éditer spec.icon")
+	require.Equal(t, "Éditer spec.icon", toCheckError(err).remediation)
+
+	// The error is kept when the remediation is empty
+	err = fmt.Errorf("the CSV has no icon")
+	require.Equal(t, err, withRemediation(err, "%s", ""))
+}
+
+func TestGetValidatorName(t *testing.T) {
+	require.Equal(t, "openshift", GetValidatorName(OpenShiftValidator))
+	require.Equal(t, "csv-name", GetValidatorName(CSVNameValidator))
+	require.Equal(t, OperatorHubSuite, GetValidatorName(UpstreamSuites[OperatorHubSuite][0]))
+	require.Equal(t, "", GetValidatorName(interfaces.ValidatorFunc(func(objs ...interface{}) []errors.ManifestResult {
+		return nil
+	})))
+
+	validators := CSVNameValidator.WithValidators(SizeValidator)
+	require.Equal(t, []string{"size", "csv-name"}, []string{GetValidatorName(validators[0]),
+		GetValidatorName(validators[1])})
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/api/pkg/validation"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/cluster"
+)
+
+// newClusterInspector defines the function used to create the client which inspects the cluster
+var newClusterInspector = func(kubeconfig string) (cluster.Inspector, error) {
+	return cluster.NewClient(kubeconfig)
+}
+
+// ClusterValidator validates the bundle against the APIs served by the cluster informed via the
+// KubeconfigKey. Note that these checks are only performed when the kubeconfig is informed. Following its
+// current checks:
+//
+// - Ensure that the API versions and kinds of the manifests shipped in the bundle (e.g. the CRDs) are
+// served by the cluster or by the CRDs of the bundle. Note that the APIs removed from the cluster are
+// reported as well.
+//
+// - Warn when the rules under spec.install.spec.permissions and clusterPermissions refer to resources which
+// are not served by the cluster or by the CRDs and APIServices of the bundle
+//
+// - Ensure that the bundle does not use APIs removed in the Kubernetes version of the cluster
+//
+// - Ensure that the olm.maxOpenShiftVersion annotation is not lower than the OpenShift version of the cluster
+// and warn when it is equal to it, since it blocks the upgrade of the cluster to the next minor version
+//
+// - Warn when the versions informed via the com.redhat.openshift.versions label do not include the
+// OpenShift version of the cluster
+var ClusterValidator interfaces.Validator = newBundleValidator("cluster", checkCluster)
+
+// servedAPIs defines the resources served by the cluster and by the bundle
+type servedAPIs struct {
+	// kinds has the kinds served by group version (e.g. apps/v1 Deployment)
+	kinds map[string]bool
+	// resources has the resources served by group (e.g. apps/deployments)
+	resources map[string]bool
+}
+
+// k8sVersionKey defines the key used to inform the Kubernetes version to the validator of the removed APIs
+const k8sVersionKey = "k8s-version"
+
+// checkCluster will verify the bundle against the cluster informed via kubeconfig
+func checkCluster(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(checks.kubeconfig) == 0 {
+		return checks
+	}
+	inspector, err := newClusterInspector(checks.kubeconfig)
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+	checks = checkClusterAPIs(checks, inspector)
+	checks = checkClusterVersion(checks, inspector)
+	return checks
+}
+
+// checkClusterAPIs will verify that the APIs used by the bundle are served by the cluster
+func checkClusterAPIs(checks OpenShiftOperatorChecks, inspector cluster.Inspector) OpenShiftOperatorChecks {
+	resources, err := inspector.GetAPIResources()
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+	apis := getServedAPIs(checks, resources)
+
+	for _, obj := range checks.bundle.Objects {
+		if obj == nil || obj.GetKind() == operatorsv1alpha1.ClusterServiceVersionKind {
+			continue
+		}
+		if !apis.kinds[obj.GetAPIVersion()+" "+obj.GetKind()] {
+			checks.errs = append(checks.errs, fmt.Errorf("the %s %s uses the API %s which is not served by the "+
+				"cluster. Note that it will fail to be installed", obj.GetKind(), obj.GetName(), obj.GetAPIVersion()))
+		}
+	}
+
+	strategy := checks.bundle.CSV.Spec.InstallStrategy.StrategySpec
+	checks = checkClusterRules(checks, apis, "permissions", strategy.Permissions)
+	checks = checkClusterRules(checks, apis, "clusterPermissions", strategy.ClusterPermissions)
+	return checks
+}
+
+// checkClusterVersion will verify the removed APIs, the olm.maxOpenShiftVersion annotation and the OCP label
+// against the versions of the cluster
+func checkClusterVersion(checks OpenShiftOperatorChecks, inspector cluster.Inspector) OpenShiftOperatorChecks {
+	version, err := inspector.GetVersion()
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+
+	if kubeVersion, err := semver.ParseTolerant(version.Kubernetes); err == nil {
+		results := validation.AlphaDeprecatedAPIsValidator.Validate(&checks.bundle,
+			map[string]string{k8sVersionKey: fmt.Sprintf("%d.%d", kubeVersion.Major, kubeVersion.Minor)})
+		for _, result := range results {
+			for _, e := range result.Errors {
+				checks.errs = append(checks.errs, fmt.Errorf("%s. Note that the cluster runs Kubernetes %s",
+					strings.TrimSuffix(e.Detail, "."), version.Kubernetes))
+			}
+		}
+	}
+
+	// The checks below are only performed for OpenShift clusters
+	if len(version.OpenShift) == 0 {
+		return checks
+	}
+	ocpVersion, err := semver.ParseTolerant(version.OpenShift)
+	if err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to parse the OpenShift version of the cluster (%s): %s",
+			version.OpenShift, err))
+		return checks
+	}
+	current := fmt.Sprintf("%d.%d", ocpVersion.Major, ocpVersion.Minor)
+	next := fmt.Sprintf("%d.%d", ocpVersion.Major, ocpVersion.Minor+1)
+
+	if r := getOCPRange(checks); len(r) > 0 {
+		if found, err := rangeContainsVersion(r, current, true); err == nil && !found {
+			checks.warns = append(checks.warns, fmt.Errorf("the versions informed via the label %s (%s) do not "+
+				"include the OpenShift version of the cluster (%s). Note that the bundle will not be distributed "+
+				"in its catalogs", ocpLabel, r, current))
+		}
+	}
+
+	// The errors of the annotation are reported by the OpenShiftValidator
+	maxValue := getMaxAnnotationValue(OpenShiftOperatorChecks{bundle: checks.bundle}).maxValue
+	maxVersion, err := semver.ParseTolerant(maxValue)
+	if len(maxValue) == 0 || err != nil {
+		return checks
+	}
+	maxVersion = semver.Version{Major: maxVersion.Major, Minor: maxVersion.Minor}
+	ocpVersion = semver.Version{Major: ocpVersion.Major, Minor: ocpVersion.Minor}
+	switch {
+	case maxVersion.LT(ocpVersion):
+		checks.errs = append(checks.errs, fmt.Errorf("the %s annotation with the value %s is lower than the "+
+			"OpenShift version of the cluster (%s). Note that the bundle is not compatible with the cluster",
+			olmmaxOcpVersion, maxValue, current))
+	case maxVersion.EQ(ocpVersion):
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s annotation with the value %s will "+
+			"block the upgrade of the cluster from OpenShift %s to %s", olmmaxOcpVersion, maxValue, current, next),
+			"ensure that a version of the operator compatible with %s is published before the cluster upgrade. For "+
+				"further information see %s", next,
+			getOCPDocLink(getDocsVersion(checks, current), ocpDocPageManagingVersions)))
+	}
+	return checks
+}
+
+// checkClusterRules will verify that the resources of the rules informed are served
+func checkClusterRules(checks OpenShiftOperatorChecks, apis servedAPIs, field string,
+	permissions []operatorsv1alpha1.StrategyDeploymentPermissions) OpenShiftOperatorChecks {
+	for _, perm := range permissions {
+		for _, rule := range perm.Rules {
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					// The subresources (e.g. deployments/scale) are served with their resources
+					resource = strings.SplitN(resource, "/", 2)[0]
+					if group == "*" || resource == "*" || apis.resources[group+"/"+resource] {
+						continue
+					}
+					checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the rule of the "+
+						"service account %s under spec.install.spec.%s in the CSV refers to the resource %s of the "+
+						"API group (%s) which is not served by the cluster", perm.ServiceAccountName, field, resource,
+						group), "ensure that it has no typos or that it is provided by a dependency"),
+						"ServiceAccount", perm.ServiceAccountName))
+				}
+			}
+		}
+	}
+	return checks
+}
+
+// getServedAPIs returns the kinds and resources served by the cluster and by the bundle
+func getServedAPIs(checks OpenShiftOperatorChecks, resources map[string][]metav1.APIResource) servedAPIs {
+	apis := servedAPIs{kinds: map[string]bool{}, resources: map[string]bool{}}
+	for groupVersion, list := range resources {
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list {
+			apis.kinds[groupVersion+" "+r.Kind] = true
+			apis.resources[gv.Group+"/"+strings.SplitN(r.Name, "/", 2)[0]] = true
+		}
+	}
+
+	// The CRDs and APIServices of the bundle will be served once it is installed
+	for _, crd := range getBundleCRDs(checks.bundle) {
+		nameParts := strings.SplitN(crd.name, ".", 2)
+		if len(nameParts) != 2 {
+			continue
+		}
+		apis.resources[nameParts[1]+"/"+nameParts[0]] = true
+		for _, v := range crd.versions {
+			apis.kinds[nameParts[1]+"/"+v.name+" "+crd.kind] = true
+		}
+	}
+	for _, owned := range checks.bundle.CSV.Spec.APIServiceDefinitions.Owned {
+		apis.resources[owned.Group+"/"+owned.Name] = true
+		apis.kinds[owned.Group+"/"+owned.Version+" "+owned.Kind] = true
+	}
+	return apis
+}
@@ -0,0 +1,278 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/cluster"
+)
+
+// fakeClusterInspector implements cluster.Inspector with the version, the resources informed as
+// "groupVersion kind resource" and the errors of the dry-run by "kind name"
+type fakeClusterInspector struct {
+	version   cluster.Version
+	resources []string
+	rejected  map[string]string
+}
+
+func (f fakeClusterInspector) GetAPIResources() (map[string][]metav1.APIResource, error) {
+	resources := map[string][]metav1.APIResource{}
+	for _, r := range f.resources {
+		fields := strings.Fields(r)
+		resources[fields[0]] = append(resources[fields[0]], metav1.APIResource{Kind: fields[1], Name: fields[2]})
+	}
+	return resources, nil
+}
+
+func (f fakeClusterInspector) GetVersion() (*cluster.Version, error) {
+	return &f.version, nil
+}
+
+func (f fakeClusterInspector) DryRunCreate(obj *unstructured.Unstructured, namespace string) error {
+	if msg, ok := f.rejected[obj.GetKind()+" "+obj.GetName()]; ok {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// ocp412Resources defines the resources served by the cluster used in the tests
+var ocp412Resources = []string{
+	"v1 Pod pods",
+	"v1 Service services",
+	"v1 ServiceAccount serviceaccounts",
+	"v1 ConfigMap configmaps",
+	"v1 Event events",
+	"v1 Endpoints endpoints",
+	"v1 PersistentVolumeClaim persistentvolumeclaims",
+	"v1 Secret secrets",
+	"apps/v1 Deployment deployments",
+	"coordination.k8s.io/v1 Lease leases",
+	"authentication.k8s.io/v1 TokenReview tokenreviews",
+	"authorization.k8s.io/v1 SubjectAccessReview subjectaccessreviews",
+	"rbac.authorization.k8s.io/v1 ClusterRole clusterroles",
+	"apiextensions.k8s.io/v1 CustomResourceDefinition customresourcedefinitions",
+	"monitoring.coreos.com/v1 ServiceMonitor servicemonitors",
+}
+
+// ocp412Cluster defines the cluster used in the tests
+var ocp412Cluster = fakeClusterInspector{
+	version:   cluster.Version{OpenShift: "4.12.3", Kubernetes: "v1.25.4+77bec7a"},
+	resources: ocp412Resources,
+}
+
+func Test_ClusterValidator(t *testing.T) {
+	defaultInspector := newClusterInspector
+	defer func() { newClusterInspector = defaultInspector }()
+
+	type args struct {
+		bundleDir  string
+		kubeconfig string
+		inspector  cluster.Inspector
+		labelRange string
+		mutate     func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the kubeconfig is not informed",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				inspector: fakeClusterInspector{},
+			},
+		},
+		{
+			name: "should pass when the APIs are served by the cluster",
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				kubeconfig: "kubeconfig",
+				inspector:  ocp412Cluster,
+			},
+		},
+		{
+			name:      "should fail when the APIs of the manifests are not served by the cluster",
+			wantError: true,
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1beta1",
+				kubeconfig: "kubeconfig",
+				inspector:  ocp412Cluster,
+			},
+			errStrings: []string{
+				"Error: Value : (etcdoperator.v0.9.4) the CustomResourceDefinition " +
+					"etcdbackups.etcd.database.coreos.com uses the API apiextensions.k8s.io/v1beta1 which is not " +
+					"served by the cluster. Note that it will fail to be installed",
+				"Error: Value : (etcdoperator.v0.9.4) the CustomResourceDefinition " +
+					"etcdclusters.etcd.database.coreos.com uses the API apiextensions.k8s.io/v1beta1 which is not " +
+					"served by the cluster. Note that it will fail to be installed",
+				"Error: Value : (etcdoperator.v0.9.4) the CustomResourceDefinition " +
+					"etcdrestores.etcd.database.coreos.com uses the API apiextensions.k8s.io/v1beta1 which is not " +
+					"served by the cluster. Note that it will fail to be installed",
+				"Error: Value : (etcdoperator.v0.9.4) this bundle is using APIs which were deprecated and " +
+					"removed in v1.22. More info: https://kubernetes.io/docs/reference/using-api/deprecation-guide/#v1-22. " +
+					"Migrate the API(s) for CRD: ([\"etcdbackups.etcd.database.coreos.com\" " +
+					"\"etcdclusters.etcd.database.coreos.com\" \"etcdrestores.etcd.database.coreos.com\"]). " +
+					"Note that the cluster runs Kubernetes v1.25.4+77bec7a",
+			},
+		},
+		{
+			name:        "should warn when the rules refer to resources not served by the cluster",
+			wantWarning: true,
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				kubeconfig: "kubeconfig",
+				inspector:  ocp412Cluster,
+				mutate: func(bundle *manifests.Bundle) {
+					perms := bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions
+					perms[0].Rules[0].Resources = []string{"deploymnts"}
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the rule of the service account " +
+				"memcached-operator-controller-manager under spec.install.spec.clusterPermissions in the CSV refers " +
+				"to the resource deploymnts of the API group (apps) which is not served by the cluster. Please, " +
+				"ensure that it has no typos or that it is provided by a dependency"},
+		},
+		{
+			name:      "should fail when the bundle uses APIs removed in the Kubernetes version of the cluster",
+			wantError: true,
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				kubeconfig: "kubeconfig",
+				inspector:  ocp412Cluster,
+				mutate: func(bundle *manifests.Bundle) {
+					for _, obj := range bundle.Objects {
+						if obj.GetName() == "memcached-operator-controller-manager-metrics-service" {
+							obj.SetAPIVersion("policy/v1beta1")
+							obj.SetKind("PodDisruptionBudget")
+						}
+					}
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the PodDisruptionBudget " +
+					"memcached-operator-controller-manager-metrics-service uses the API policy/v1beta1 which is not " +
+					"served by the cluster. Note that it will fail to be installed",
+				"Error: Value : (memcached-operator.v0.0.1) this bundle is using APIs which were deprecated and " +
+					"removed in v1.25. More info: https://kubernetes.io/docs/reference/using-api/deprecation-guide/#v1-25. " +
+					"Migrate the API(s) for PodDisruptionBudget: " +
+					"([\"memcached-operator-controller-manager-metrics-service\"]). " +
+					"Note that the cluster runs Kubernetes v1.25.4+77bec7a",
+			},
+		},
+		{
+			name:      "should fail when the maxOpenShiftVersion is lower than the version of the cluster",
+			wantError: true,
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				kubeconfig: "kubeconfig",
+				inspector:  ocp412Cluster,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations["olm.properties"] = `[{"type": "olm.maxOpenShiftVersion", "value": "4.11"}]`
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the olm.maxOpenShiftVersion " +
+				"annotation with the value 4.11 is lower than the OpenShift version of the cluster (4.12). Note that " +
+				"the bundle is not compatible with the cluster"},
+		},
+		{
+			name:        "should warn when the maxOpenShiftVersion blocks the next upgrade of the cluster",
+			wantWarning: true,
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				kubeconfig: "kubeconfig",
+				inspector:  ocp412Cluster,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations["olm.properties"] = `[{"type": "olm.maxOpenShiftVersion", "value": "4.12"}]`
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the olm.maxOpenShiftVersion " +
+				"annotation with the value 4.12 will block the upgrade of the cluster from OpenShift 4.12 to 4.13. " +
+				"Please, ensure that a version of the operator compatible with 4.13 is published before the " +
+				"cluster upgrade. For further information see https://docs.openshift.com/container-platform/4.12/" +
+				"operators/operator_sdk/osdk-working-bundle-images.html#osdk-control-compat_osdk-working-bundle-images"},
+		},
+		{
+			name:        "should warn when the OCP label does not include the version of the cluster",
+			wantWarning: true,
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				kubeconfig: "kubeconfig",
+				inspector:  ocp412Cluster,
+				labelRange: "v4.8-v4.11",
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the versions informed via the " +
+				"label com.redhat.openshift.versions (v4.8-v4.11) do not include the OpenShift version of the " +
+				"cluster (4.12). Note that the bundle will not be distributed in its catalogs"},
+		},
+		{
+			name: "should pass when the cluster is not an OpenShift cluster",
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				kubeconfig: "kubeconfig",
+				inspector: fakeClusterInspector{
+					version:   cluster.Version{Kubernetes: "v1.26.0"},
+					resources: ocp412Resources,
+				},
+				labelRange: "v4.8-v4.11",
+			},
+		},
+		{
+			name:      "should fail when the cluster cannot be reached",
+			wantError: true,
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				kubeconfig: "kubeconfig",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to load the kubeconfig: " +
+				"not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+			newClusterInspector = func(kubeconfig string) (cluster.Inspector, error) {
+				if tt.args.inspector == nil {
+					return nil, fmt.Errorf("unable to load the kubeconfig: not found")
+				}
+				return tt.args.inspector, nil
+			}
+
+			results := ClusterValidator.Validate(bundle, map[string]string{
+				KubeconfigKey: tt.args.kubeconfig,
+				RangeKey:      tt.args.labelRange,
+			})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// ConsoleMetadataValidator validates the fields of the CSV used by the OpenShift console to render the
+// operator tile and its details page. Following its current checks:
+//
+// - Warn when the displayName, provider name, maintainers or links are not informed in the CSV. Note that
+// this check is only performed for the certified and marketplace profiles.
+//
+// - Warn when the emails of the maintainers or the URLs of the links and provider are not valid
+//
+// - Ensure that the icons informed under spec.icon are base64 encoded png or svg images and warn when
+// their size or dimensions are not reasonable. Note that a missing icon is only reported for the strict
+// profiles (certified, redhat and marketplace).
+//
+// - Warn when spec.description is empty, equal to the short description informed via the description
+// annotation, too long or when it has raw HTML which is stripped by the console when the markdown is rendered
+//
+// - Warn when spec.maturity is not one of the values known by the console (e.g. alpha, beta or stable)
+//
+// - Warn when spec.keywords has empty or duplicate keywords. Note that missing keywords are only reported
+// when a profile is informed since the bundles of all catalogs are listed by the console.
+var ConsoleMetadataValidator interfaces.Validator = newBundleValidator("console-metadata", checkDisplayMetadata,
+	checkIcons, checkDescription, checkMaturity, checkKeywords)
+
+// shortDescriptionAnnotation defines the CSV annotation used to inform the description shown in the tile
+const shortDescriptionAnnotation = "description"
+
+// The limits of the descriptions which are considered reasonable to be rendered by the console
+const (
+	maxDescriptionLength      = 64 * 1024
+	maxShortDescriptionLength = 135
+)
+
+// The regular expressions used to find the raw HTML in the markdown of the description. Note that the
+// code blocks and spans are removed before looking for the HTML tags.
+var (
+	markdownCodeRegexp = regexp.MustCompile("(?s:```.*?```)|(?m:^(?:    |\t)[^\n]*$)|`[^`\n]*`")
+	htmlTagRegexp      = regexp.MustCompile(`<\s*/?\s*([a-zA-Z][a-zA-Z0-9-]*)(\s[^>]*)?/?>`)
+)
+
+// knownMaturities defines the values of spec.maturity which are known by the console
+var knownMaturities = []string{"planning", "pre-alpha", "alpha", "beta", "stable", "mature", "inactive",
+	"deprecated"}
+
+// The media types of the icons which can be rendered by the console
+const (
+	pngMediaType = "image/png"
+	svgMediaType = "image/svg+xml"
+)
+
+// The limits of the icons which are considered reasonable to be rendered by the console
+const (
+	maxIconSize      = 100 * 1024
+	minIconDimension = 16
+	maxIconDimension = 1024
+)
+
+// checkDisplayMetadata will verify the fields of the CSV used to render the operator in the console
+func checkDisplayMetadata(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	spec := checks.bundle.CSV.Spec
+
+	if checks.profile == CertifiedProfile || checks.profile == MarketplaceProfile {
+		var missing []string
+		if len(strings.TrimSpace(spec.DisplayName)) == 0 {
+			missing = append(missing, "spec.displayName")
+		}
+		if len(strings.TrimSpace(spec.Provider.Name)) == 0 {
+			missing = append(missing, "spec.provider.name")
+		}
+		if len(spec.Maintainers) == 0 {
+			missing = append(missing, "spec.maintainers")
+		}
+		if len(spec.Links) == 0 {
+			missing = append(missing, "spec.links")
+		}
+		if len(missing) > 0 {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the CSV does not inform %s which are used "+
+				"by the console to render the operator", strings.Join(missing, ", ")), "inform them"))
+		}
+	}
+
+	for i, maintainer := range spec.Maintainers {
+		if _, err := mail.ParseAddress(maintainer.Email); err != nil {
+			checks.warns = append(checks.warns, fmt.Errorf("the email (%s) of the maintainer [%d] %s under "+
+				"spec.maintainers is not valid: %s", maintainer.Email, i, maintainer.Name, err))
+		}
+	}
+	for i, link := range spec.Links {
+		if !isValidURL(link.URL) {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the URL (%s) of the link [%d] %s under "+
+				"spec.links is not valid", link.URL, i, link.Name), "inform an http or https URL"))
+		}
+	}
+	if len(spec.Provider.URL) > 0 && !isValidURL(spec.Provider.URL) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the URL (%s) of spec.provider is not valid",
+			spec.Provider.URL), "inform an http or https URL"))
+	}
+	return checks
+}
+
+// isValidURL returns true when the value informed is an absolute http or https URL
+func isValidURL(value string) bool {
+	u, err := url.ParseRequestURI(value)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+// checkIcons will verify the icons informed under spec.icon
+func checkIcons(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	found := false
+	for i, icon := range checks.bundle.CSV.Spec.Icon {
+		if len(icon.Data) == 0 {
+			continue
+		}
+		found = true
+
+		data, err := base64.StdEncoding.DecodeString(icon.Data)
+		if err != nil {
+			checks.errs = append(checks.errs, fmt.Errorf("the icon [%d] under spec.icon is not base64 encoded: %s",
+				i, err))
+			continue
+		}
+		if len(data) > maxIconSize {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the icon [%d] under spec.icon has %d bytes",
+				i, len(data)), "use an icon with at most %d bytes since it is loaded with the operator in the console",
+				maxIconSize))
+		}
+
+		switch icon.MediaType {
+		case pngMediaType:
+			config, err := png.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				checks.errs = append(checks.errs, fmt.Errorf("the icon [%d] under spec.icon is not a valid %s "+
+					"image: %s", i, icon.MediaType, err))
+				continue
+			}
+			if config.Width < minIconDimension || config.Height < minIconDimension ||
+				config.Width > maxIconDimension || config.Height > maxIconDimension {
+				checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the icon [%d] under spec.icon has the "+
+					"dimensions %dx%d", i, config.Width, config.Height), "use an icon between %dx%d and %dx%d pixels",
+					minIconDimension, minIconDimension, maxIconDimension, maxIconDimension))
+			}
+		case svgMediaType:
+			if !strings.Contains(string(data), "<svg") {
+				checks.errs = append(checks.errs, fmt.Errorf("the icon [%d] under spec.icon is not a valid %s "+
+					"image", i, icon.MediaType))
+			}
+		default:
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the icon [%d] under spec.icon has the media "+
+				"type (%s) which cannot be rendered by the console", i, icon.MediaType), "use %s or %s", pngMediaType,
+				svgMediaType))
+		}
+	}
+
+	if !found && isStrictProfile(checks.profile) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the CSV does not inform an icon under "+
+			"spec.icon"), "inform it since it is used by the console to render the operator"))
+	}
+	return checks
+}
+
+// checkDescription will verify spec.description which is rendered as markdown by the console
+func checkDescription(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	description := strings.TrimSpace(checks.bundle.CSV.Spec.Description)
+	short := strings.TrimSpace(checks.bundle.CSV.GetAnnotations()[shortDescriptionAnnotation])
+
+	if len(short) > maxShortDescriptionLength {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the annotation %s has %d characters",
+			shortDescriptionAnnotation, len(short)),
+			"use at most %d characters since it is truncated in the tile of the operator", maxShortDescriptionLength))
+	}
+
+	if len(description) == 0 {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the CSV does not inform spec.description"),
+			"describe the operator and how to use it since it is shown in its details page in the console"))
+		return checks
+	}
+	if description == short {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("spec.description is equal to the short "+
+			"description informed via the annotation %s", shortDescriptionAnnotation),
+			"describe the operator and how to use it in details"))
+	}
+	if len(description) > maxDescriptionLength {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("spec.description has %d characters",
+			len(description)), "use at most %d characters and link the documentation of the operator instead",
+			maxDescriptionLength))
+	}
+
+	var tags []string
+	for _, match := range htmlTagRegexp.FindAllStringSubmatch(markdownCodeRegexp.ReplaceAllString(description, ""), -1) {
+		tag := strings.ToLower(match[1])
+		if !containsAny(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > 0 {
+		sort.Strings(tags)
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("spec.description has raw HTML (%s). Note that "+
+			"the console sanitizes the markdown and might strip it (e.g. scripts)", strings.Join(tags, ", ")),
+			"use markdown instead"))
+	}
+	return checks
+}
+
+// checkMaturity will verify that spec.maturity is known by the console when it is informed
+func checkMaturity(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	maturity := checks.bundle.CSV.Spec.Maturity
+	if len(maturity) > 0 && !containsAny(knownMaturities, maturity) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("spec.maturity (%s) is not known by the console",
+			maturity), "inform one of: %s", strings.Join(knownMaturities, ", ")))
+	}
+	return checks
+}
+
+// checkKeywords will verify spec.keywords which is used by the console to search the operators
+func checkKeywords(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	keywords := checks.bundle.CSV.Spec.Keywords
+	if len(keywords) == 0 {
+		if len(checks.profile) > 0 {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the CSV does not inform spec.keywords"),
+				"inform them since they are used by the console to search the operator"))
+		}
+		return checks
+	}
+
+	var found, duplicates []string
+	empty := false
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		switch {
+		case len(keyword) == 0:
+			empty = true
+		case containsAny(found, keyword):
+			if !containsAny(duplicates, keyword) {
+				duplicates = append(duplicates, keyword)
+			}
+		default:
+			found = append(found, keyword)
+		}
+	}
+	if empty {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("spec.keywords has empty keywords"),
+			"remove them"))
+	}
+	if len(duplicates) > 0 {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("spec.keywords has duplicate keywords (%s)",
+			strings.Join(duplicates, ", ")), "remove them"))
+	}
+	return checks
+}
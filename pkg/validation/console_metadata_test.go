@@ -0,0 +1,271 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConsoleMetadataValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		profile   string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the display metadata is informed with v1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the display metadata is informed with v1beta1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+			},
+		},
+		{
+			name: "should pass when the display metadata is not informed with the community profile",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CommunityProfile,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.DisplayName = ""
+					bundle.CSV.Spec.Links = nil
+				},
+			},
+		},
+		{
+			name:        "should warn when the display metadata is not informed with the certified profile",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CertifiedProfile,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.DisplayName = " "
+					bundle.CSV.Spec.Provider.Name = ""
+					bundle.CSV.Spec.Maintainers = nil
+					bundle.CSV.Spec.Links = nil
+					bundle.CSV.Spec.Icon = []operatorsv1alpha1.Icon{{Data: encodePNG(t, 64, 64), MediaType: pngMediaType}}
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the CSV does not inform " +
+				"spec.displayName, spec.provider.name, spec.maintainers, spec.links which are used by the console " +
+				"to render the operator. Please, inform them"},
+		},
+		{
+			name:        "should warn when the emails and URLs are not valid",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Maintainers[0].Email = "your-email"
+					bundle.CSV.Spec.Links[0].URL = "memcached-operator.domain"
+					bundle.CSV.Spec.Provider.URL = "ftp://your.domain"
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the email (your-email) of the maintainer [0] " +
+					"Maintainer Name under spec.maintainers is not valid: mail: missing '@' or angle-addr",
+				"Warning: Value : (memcached-operator.v0.0.1) the URL (memcached-operator.domain) of the link [0] " +
+					"Memcached Operator under spec.links is not valid. Please, inform an http or https URL",
+				"Warning: Value : (memcached-operator.v0.0.1) the URL (ftp://your.domain) of spec.provider is not " +
+					"valid. Please, inform an http or https URL",
+			},
+		},
+		{
+			name: "should pass when the icons are valid",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CertifiedProfile,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Icon = []operatorsv1alpha1.Icon{
+						{Data: encodePNG(t, 64, 64), MediaType: pngMediaType},
+						{Data: base64.StdEncoding.EncodeToString([]byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`)),
+							MediaType: svgMediaType},
+					}
+				},
+			},
+		},
+		{
+			name:      "should fail when the icons are not valid",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Icon = []operatorsv1alpha1.Icon{
+						{Data: "not-base64!", MediaType: pngMediaType},
+						{Data: base64.StdEncoding.EncodeToString([]byte("GIF89a")), MediaType: "image/gif"},
+						{Data: base64.StdEncoding.EncodeToString([]byte("<html/>")), MediaType: svgMediaType},
+					}
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the icon [0] under spec.icon is not base64 encoded: " +
+					"illegal base64 data at input byte 3",
+				"Error: Value : (memcached-operator.v0.0.1) the icon [1] under spec.icon has the media type " +
+					"(image/gif) which cannot be rendered by the console. Please, use image/png or image/svg+xml",
+				"Error: Value : (memcached-operator.v0.0.1) the icon [2] under spec.icon is not a valid " +
+					"image/svg+xml image",
+			},
+		},
+		{
+			name:        "should warn when the icon dimensions are not reasonable",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Icon = []operatorsv1alpha1.Icon{{Data: encodePNG(t, 8, 2048), MediaType: pngMediaType}}
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the icon [0] under spec.icon has the " +
+				"dimensions 8x2048. Please, use an icon between 16x16 and 1024x1024 pixels"},
+		},
+		{
+			name:        "should warn when the icon is missing with a strict profile",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				profile:   RedHatProfile,
+			},
+			warnStrings: []string{"Warning: Value : (etcdoperator.v0.9.4) the CSV does not inform an icon under " +
+				"spec.icon. Please, inform it since it is used by the console to render the operator"},
+		},
+		{
+			name: "should pass when the description has HTML only in code",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Description = "## Usage\n\nCreate a `<Memcached>` CR:\n\n```yaml\n<script/>\n```" +
+						"\n\n    <div>\n\nSee <https://memcached.org>."
+				},
+			},
+		},
+		{
+			name:        "should warn when the description is missing and the short description is too long",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Description = " "
+					bundle.CSV.Annotations[shortDescriptionAnnotation] = strings.Repeat("a", 136)
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the annotation description has 136 characters. " +
+					"Please, use at most 135 characters since it is truncated in the tile of the operator",
+				"Warning: Value : (memcached-operator.v0.0.1) the CSV does not inform spec.description. Please, " +
+					"describe the operator and how to use it since it is shown in its details page in the console",
+			},
+		},
+		{
+			name:        "should warn when the description is the short description with raw HTML",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Description = "<p>Memcached</p><SCRIPT src=\"x.js\"></script><br/>"
+					bundle.CSV.Annotations[shortDescriptionAnnotation] = bundle.CSV.Spec.Description
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) spec.description is equal to the short description " +
+					"informed via the annotation description. Please, describe the operator and how to use it in " +
+					"details",
+				"Warning: Value : (memcached-operator.v0.0.1) spec.description has raw HTML (br, p, script). Note " +
+					"that the console sanitizes the markdown and might strip it (e.g. scripts). Please, use markdown " +
+					"instead",
+			},
+		},
+		{
+			name:        "should warn when the maturity is not known and the keywords are duplicate or empty",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Maturity = "GA"
+					bundle.CSV.Spec.Keywords = []string{"memcached", "Cache", " ", "cache ", "memcached", "cache"}
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) spec.maturity (GA) is not known by the console. " +
+					"Please, inform one of: planning, pre-alpha, alpha, beta, stable, mature, inactive, deprecated",
+				"Warning: Value : (memcached-operator.v0.0.1) spec.keywords has empty keywords. Please, remove them",
+				"Warning: Value : (memcached-operator.v0.0.1) spec.keywords has duplicate keywords (cache, " +
+					"memcached). Please, remove them",
+			},
+		},
+		{
+			name: "should pass when the keywords are not informed without a profile",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Keywords = nil
+				},
+			},
+		},
+		{
+			name:        "should warn when the keywords are not informed with a profile",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CommunityProfile,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.Keywords = nil
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the CSV does not inform " +
+				"spec.keywords. Please, inform them since they are used by the console to search the operator"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := ConsoleMetadataValidator.Validate(bundle, map[string]string{ProfileKey: tt.args.profile})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// encodePNG returns a base64 encoded png image with the dimensions informed
+func encodePNG(t *testing.T, width, height int) string {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+// consolePluginsAnnotation defines the CSV annotation used to inform the console plugins which can be
+// enabled by the user when the operator is installed
+const consolePluginsAnnotation = "console.openshift.io/plugins"
+
+// consolePluginKind defines the kind of the ConsolePlugin
+const consolePluginKind = "ConsolePlugin"
+
+// The OCP versions from which the ConsolePlugin API and its v1 version are available
+const (
+	consolePluginMinOCPVersion   = "4.10"
+	consolePluginV1MinOCPVersion = "4.12"
+)
+
+// ConsolePluginValidator validates the console plugins declared via the console.openshift.io/plugins
+// annotation of the CSV and the ConsolePlugin manifests shipped in the bundle. Following its current checks:
+//
+// - Ensure that the console.openshift.io/plugins annotation is a JSON array of valid plugin names and warn
+// when the plugins informed are not shipped in the bundle or when the plugins shipped are not informed
+//
+// - Ensure that the ConsolePlugin manifests inform the Service which serves the plugin and that its port
+// is exposed by the Service when it is shipped in the bundle. Otherwise, warn since the console will fail
+// to load the plugin.
+//
+// - Warn when the bundle targets OCP versions where the ConsolePlugin API (4.10) or its v1 version (4.12)
+// is not available. Note that this check is only performed when the OCP range is informed.
+var ConsolePluginValidator interfaces.Validator = newBundleValidator("console-plugins", checkConsolePlugins)
+
+// checkConsolePlugins will verify the console plugins declared by the bundle
+func checkConsolePlugins(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	var shipped []*unstructured.Unstructured
+	var shippedNames []string
+	for _, obj := range checks.bundle.Objects {
+		if obj.GetKind() == consolePluginKind {
+			shipped = append(shipped, obj)
+			shippedNames = append(shippedNames, obj.GetName())
+		}
+	}
+
+	value, declared := checks.bundle.CSV.GetAnnotations()[consolePluginsAnnotation]
+	if !declared && len(shipped) == 0 {
+		return checks
+	}
+
+	var plugins []string
+	if declared {
+		if err := json.Unmarshal([]byte(value), &plugins); err != nil {
+			checks.errs = append(checks.errs, fmt.Errorf("the annotation %s must be a JSON array of strings "+
+				"(e.g. '[\"my-plugin\"]'): %s", consolePluginsAnnotation, err))
+			return checks
+		}
+	}
+	for _, plugin := range plugins {
+		if errs := k8svalidation.IsDNS1123Subdomain(plugin); len(errs) > 0 {
+			checks.errs = append(checks.errs, fmt.Errorf("the plugin (%s) informed via the annotation %s is not "+
+				"a valid name: %s", plugin, consolePluginsAnnotation, strings.Join(errs, ", ")))
+			continue
+		}
+		if !containsAny(shippedNames, plugin) {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the plugin (%s) informed via the "+
+				"annotation %s is not shipped in the bundle", plugin, consolePluginsAnnotation),
+				"ensure that the operator creates the ConsolePlugin"))
+		}
+	}
+
+	for _, obj := range shipped {
+		if !containsAny(plugins, obj.GetName()) {
+			checks.warns = append(checks.warns, fmt.Errorf("the ConsolePlugin %s is shipped in the bundle but it "+
+				"is not informed via the annotation %s. Note that the users will not be able to enable it when "+
+				"the operator is installed", obj.GetName(), consolePluginsAnnotation))
+		}
+		checks = checkConsolePluginService(checks, obj)
+	}
+
+	return checkConsolePluginsOCPVersion(checks, shipped)
+}
+
+// checkConsolePluginService will verify the Service which serves the ConsolePlugin informed
+func checkConsolePluginService(checks OpenShiftOperatorChecks, plugin *unstructured.Unstructured) OpenShiftOperatorChecks {
+	// the service is informed under spec.backend.service for v1 and under spec.service for v1alpha1
+	service, found, _ := unstructured.NestedMap(plugin.Object, "spec", "backend", "service")
+	if !found {
+		service, _, _ = unstructured.NestedMap(plugin.Object, "spec", "service")
+	}
+	name, _, _ := unstructured.NestedString(service, "name")
+	if len(name) == 0 {
+		checks.errs = append(checks.errs, fmt.Errorf("the ConsolePlugin %s does not inform the name of the "+
+			"Service which serves the plugin", plugin.GetName()))
+		return checks
+	}
+	port, _, _ := unstructured.NestedInt64(service, "port")
+
+	for _, obj := range checks.bundle.Objects {
+		if obj.GetKind() != "Service" || obj.GetName() != name {
+			continue
+		}
+		ports, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+		for _, p := range ports {
+			if servicePort, ok := p.(map[string]interface{}); ok {
+				if value, _, _ := unstructured.NestedInt64(servicePort, "port"); value == port {
+					return checks
+				}
+			}
+		}
+		checks.errs = append(checks.errs, withSubject(fmt.Errorf("the ConsolePlugin %s is served by the port %d of "+
+			"the Service %s but the Service does not expose it", plugin.GetName(), port, name), "Service", name))
+		return checks
+	}
+	checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the ConsolePlugin %s is served by the "+
+		"Service %s which is not shipped in the bundle", plugin.GetName(), name),
+		"ensure that the operator creates it"), "Service", name))
+	return checks
+}
+
+// checkConsolePluginsOCPVersion will verify that the ConsolePlugin API is available in the OCP versions targeted
+func checkConsolePluginsOCPVersion(checks OpenShiftOperatorChecks,
+	shipped []*unstructured.Unstructured) OpenShiftOperatorChecks {
+	ocpRange := getOCPRange(checks)
+	if len(ocpRange) == 0 {
+		return checks
+	}
+
+	if lower, err := rangeAllowsVersionLowerThan(ocpRange, consolePluginMinOCPVersion); err == nil && lower {
+		checks.warns = append(checks.warns, fmt.Errorf("the bundle declares console plugins but it targets OCP "+
+			"versions (%s) where they are not supported. Note that the ConsolePlugin API is only available "+
+			"from OCP %s", ocpRange, consolePluginMinOCPVersion))
+		return checks
+	}
+	for _, obj := range shipped {
+		if obj.GetAPIVersion() != "console.openshift.io/v1" {
+			continue
+		}
+		if lower, err := rangeAllowsVersionLowerThan(ocpRange, consolePluginV1MinOCPVersion); err == nil && lower {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the ConsolePlugin %s uses the version "+
+				"console.openshift.io/v1 but the bundle targets OCP versions (%s) where it is not available. Note "+
+				"that it is only available from OCP %s", obj.GetName(), ocpRange, consolePluginV1MinOCPVersion),
+				"use console.openshift.io/v1alpha1 instead"))
+		}
+	}
+	return checks
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_ConsolePluginValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		ocpRange  string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the bundle has no console plugins",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				ocpRange:  "v4.6",
+			},
+		},
+		{
+			name: "should pass when the console plugin is declared and shipped",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				ocpRange:  "v4.12",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[consolePluginsAnnotation] = `["memcached-plugin"]`
+					addConsolePlugin(bundle, "console.openshift.io/v1", "memcached-operator-webhook-service", 443)
+				},
+			},
+		},
+		{
+			name:      "should fail when the console plugins annotation is not a JSON array",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[consolePluginsAnnotation] = "memcached-plugin"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"console.openshift.io/plugins must be a JSON array of strings (e.g. '[\"my-plugin\"]'): " +
+				"invalid character 'm' looking for beginning of value"},
+		},
+		{
+			name:        "should fail when the plugin name is invalid or the plugins are not consistent",
+			wantError:   true,
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[consolePluginsAnnotation] = `["Memcached_Plugin", "other-plugin"]`
+					addConsolePlugin(bundle, "console.openshift.io/v1alpha1", "memcached-operator-webhook-service",
+						9443)
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the plugin (Memcached_Plugin) informed via the " +
+					"annotation console.openshift.io/plugins is not a valid name: a lowercase RFC 1123 subdomain " +
+					"must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an " +
+					"alphanumeric character (e.g. 'example.com', regex used for validation is " +
+					"'[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')",
+				"Error: Value : (memcached-operator.v0.0.1) the ConsolePlugin memcached-plugin is served by the " +
+					"port 9443 of the Service memcached-operator-webhook-service but the Service does not expose it",
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the plugin (other-plugin) informed via the " +
+					"annotation console.openshift.io/plugins is not shipped in the bundle. Please, ensure that the " +
+					"operator creates the ConsolePlugin",
+				"Warning: Value : (memcached-operator.v0.0.1) the ConsolePlugin memcached-plugin is shipped in the " +
+					"bundle but it is not informed via the annotation console.openshift.io/plugins. Note that the " +
+					"users will not be able to enable it when the operator is installed",
+			},
+		},
+		{
+			name:        "should warn when the Service of the plugin is not shipped",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[consolePluginsAnnotation] = `["memcached-plugin"]`
+					addConsolePlugin(bundle, "console.openshift.io/v1", "memcached-plugin-service", 9443)
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the ConsolePlugin memcached-plugin " +
+				"is served by the Service memcached-plugin-service which is not shipped in the bundle. Please, " +
+				"ensure that the operator creates it"},
+		},
+		{
+			name:        "should warn when the bundle targets OCP versions without the ConsolePlugin v1 API",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				ocpRange:  "v4.10-v4.12",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[consolePluginsAnnotation] = `["memcached-plugin"]`
+					addConsolePlugin(bundle, "console.openshift.io/v1", "memcached-operator-webhook-service", 443)
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the ConsolePlugin memcached-plugin " +
+				"uses the version console.openshift.io/v1 but the bundle targets OCP versions (v4.10-v4.12) where " +
+				"it is not available. Note that it is only available from OCP 4.12. Please, use " +
+				"console.openshift.io/v1alpha1 instead"},
+		},
+		{
+			name:        "should warn when the bundle targets OCP versions without console plugins",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				ocpRange:  "v4.8",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[consolePluginsAnnotation] = `["memcached-plugin"]`
+					addConsolePlugin(bundle, "console.openshift.io/v1alpha1", "memcached-operator-webhook-service",
+						443)
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the bundle declares console plugins " +
+				"but it targets OCP versions (v4.8) where they are not supported. Note that the ConsolePlugin API " +
+				"is only available from OCP 4.10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := ConsolePluginValidator.Validate(bundle, map[string]string{RangeKey: tt.args.ocpRange})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// addConsolePlugin ships the ConsolePlugin memcached-plugin with the apiVersion and Service informed
+func addConsolePlugin(bundle *manifests.Bundle, apiVersion, service string, port int64) {
+	plugin := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	plugin.SetAPIVersion(apiVersion)
+	plugin.SetKind(consolePluginKind)
+	plugin.SetName("memcached-plugin")
+	fields := []string{"spec", "backend", "service"}
+	if apiVersion == "console.openshift.io/v1alpha1" {
+		fields = []string{"spec", "service"}
+	}
+	_ = unstructured.SetNestedMap(plugin.Object, map[string]interface{}{
+		"name":      service,
+		"namespace": "memcached-operator-system",
+		"port":      port,
+		"basePath":  "/",
+	}, fields...)
+	bundle.Objects = append(bundle.Objects, plugin)
+}
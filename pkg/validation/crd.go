@@ -0,0 +1,343 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// injectCABundleAnnotation defines the annotation used by the OpenShift service CA operator
+// to inject the CA bundle into the CRD conversion webhooks
+const injectCABundleAnnotation = "service.beta.openshift.io/inject-cabundle"
+
+// servingCertSecretAnnotation defines the annotation used by the OpenShift service CA operator
+// to generate the serving certificate for the Services
+const servingCertSecretAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+
+// CRDValidator validates the CRDs shipped in the bundle against the APIs defined in the CSV.
+// Following its current checks:
+//
+// - Ensure that each CRD defined under spec.customresourcedefinitions.owned in the CSV has its
+// CRD manifest shipped in the bundle with the same name, kind and with the version informed
+//
+// - Warn when the bundle ships CRDs which are not defined as owned or required in the CSV. Note that
+// these CRDs are installed but are not used by OLM for the dependency resolution.
+//
+// - Ensure that each CRD has exactly one storage version and at least one served version, and
+// that the versions defined under spec.customresourcedefinitions.owned in the CSV are served
+//
+// - Ensure that the CRDs do not set spec.preserveUnknownFields to true. Note that it is rejected by the
+// apiextensions.k8s.io/v1 API and that the apiextensions.k8s.io/v1beta1 API uses true by default. The
+// apiextensions.k8s.io/v1beta1 CRDs are only checked when the bundle is distributed to OCP 4.9 or upper
+// versions, where they must be migrated to apiextensions.k8s.io/v1.
+//
+// - Ensure that the CRDs which define a conversion webhook have the certificates configured. It means
+// the CRD must be informed in a ConversionWebhook of the CSV spec.webhookdefinitions so that OLM will manage
+// them or the CRD must use the service.beta.openshift.io/inject-cabundle annotation. Note that in the last
+// case the Service shipped in the bundle must use the service.beta.openshift.io/serving-cert-secret-name annotation.
+var CRDValidator interfaces.Validator = newBundleValidator("crd", checkOwnedCRDs, checkUnreferencedCRDs,
+	checkCRDVersions, checkPreserveUnknownFields, checkConversionWebhooks)
+
+// bundleCRD has the info of the CRDs shipped in the bundle regardless of its API version
+type bundleCRD struct {
+	name     string
+	kind     string
+	versions []bundleCRDVersion
+}
+
+// bundleCRDVersion has the info of the versions defined in the CRDs shipped in the bundle
+type bundleCRDVersion struct {
+	name    string
+	served  bool
+	storage bool
+	schema  *apiextensionsv1.JSONSchemaProps
+}
+
+// getBundleCRDs returns the v1 and v1beta1 CRDs shipped in the bundle
+func getBundleCRDs(bundle manifests.Bundle) []bundleCRD {
+	var crds []bundleCRD
+	for _, crd := range bundle.V1CRDs {
+		if crd == nil {
+			continue
+		}
+		c := bundleCRD{name: crd.GetName(), kind: crd.Spec.Names.Kind}
+		for _, v := range crd.Spec.Versions {
+			version := bundleCRDVersion{name: v.Name, served: v.Served, storage: v.Storage}
+			if v.Schema != nil {
+				version.schema = v.Schema.OpenAPIV3Schema
+			}
+			c.versions = append(c.versions, version)
+		}
+		crds = append(crds, c)
+	}
+	for _, crd := range bundle.V1beta1CRDs {
+		if crd == nil {
+			continue
+		}
+		// The v1beta1 API allows to inform a single schema for all versions via spec.validation
+		var schema *apiextensionsv1.JSONSchemaProps
+		if crd.Spec.Validation != nil {
+			schema = convertV1beta1Schema(crd.Spec.Validation.OpenAPIV3Schema)
+		}
+		c := bundleCRD{name: crd.GetName(), kind: crd.Spec.Names.Kind}
+		for _, v := range crd.Spec.Versions {
+			version := bundleCRDVersion{name: v.Name, served: v.Served, storage: v.Storage, schema: schema}
+			if v.Schema != nil {
+				version.schema = convertV1beta1Schema(v.Schema.OpenAPIV3Schema)
+			}
+			c.versions = append(c.versions, version)
+		}
+		// The v1beta1 API allows to inform a single version via spec.version
+		if len(crd.Spec.Versions) == 0 && len(crd.Spec.Version) > 0 {
+			c.versions = append(c.versions, bundleCRDVersion{name: crd.Spec.Version, served: true, storage: true,
+				schema: schema})
+		}
+		crds = append(crds, c)
+	}
+	return crds
+}
+
+// convertV1beta1Schema returns the v1 schema equivalent to the v1beta1 schema informed
+func convertV1beta1Schema(schema *apiextensionsv1beta1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+	if schema == nil {
+		return nil
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	converted := &apiextensionsv1.JSONSchemaProps{}
+	if err := json.Unmarshal(b, converted); err != nil {
+		return nil
+	}
+	return converted
+}
+
+// getVersion returns the version of the CRD with the name informed
+func (c bundleCRD) getVersion(name string) (bundleCRDVersion, bool) {
+	for _, v := range c.versions {
+		if v.name == name {
+			return v, true
+		}
+	}
+	return bundleCRDVersion{}, false
+}
+
+// checkOwnedCRDs will verify that the CRDs owned by the CSV are shipped in the bundle
+func checkOwnedCRDs(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	crdsByName := map[string]bundleCRD{}
+	for _, crd := range getBundleCRDs(checks.bundle) {
+		crdsByName[crd.name] = crd
+	}
+
+	for _, owned := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+		crd, found := crdsByName[owned.Name]
+		if !found {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the CRD %s is defined under "+
+				"spec.customresourcedefinitions.owned in the CSV but its manifest was not found in the bundle",
+				owned.Name), "CustomResourceDefinition", owned.Name))
+			continue
+		}
+		if crd.kind != owned.Kind {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the kind (%s) defined for the CRD %s under "+
+				"spec.customresourcedefinitions.owned in the CSV does not match with the kind (%s) of the CRD shipped "+
+				"in the bundle", owned.Kind, owned.Name, crd.kind), "CustomResourceDefinition", owned.Name))
+		}
+		if _, found := crd.getVersion(owned.Version); !found {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the version (%s) defined for the CRD %s under "+
+				"spec.customresourcedefinitions.owned in the CSV was not found in the CRD shipped in the bundle",
+				owned.Version, owned.Name), "CustomResourceDefinition", owned.Name))
+		}
+	}
+	return checks
+}
+
+// checkUnreferencedCRDs will warn when the CRDs shipped in the bundle are not referenced in the CSV
+func checkUnreferencedCRDs(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	referenced := map[string]bool{}
+	for _, owned := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+		referenced[owned.Name] = true
+	}
+	for _, required := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Required {
+		referenced[required.Name] = true
+	}
+
+	for _, crd := range getBundleCRDs(checks.bundle) {
+		if !referenced[crd.name] {
+			checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the CRD %s is shipped in the "+
+				"bundle but it is not defined under spec.customresourcedefinitions.owned or required in the CSV. Note "+
+				"that it will be installed but OLM will not use it to resolve the dependencies", crd.name),
+				"ensure that it is not a stale manifest"), "CustomResourceDefinition", crd.name))
+		}
+	}
+	return checks
+}
+
+// checkCRDVersions will verify the served and storage versions of the CRDs shipped in the bundle
+func checkCRDVersions(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	crdsByName := map[string]bundleCRD{}
+	for _, crd := range getBundleCRDs(checks.bundle) {
+		crdsByName[crd.name] = crd
+
+		storage := 0
+		served := 0
+		for _, v := range crd.versions {
+			if v.storage {
+				storage++
+			}
+			if v.served {
+				served++
+			}
+		}
+		if storage != 1 {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the CRD %s has %d storage versions. Exactly one "+
+				"version must be defined as storage", crd.name, storage), "CustomResourceDefinition", crd.name))
+		}
+		if served == 0 {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the CRD %s has no served versions. At least one "+
+				"version must be defined as served", crd.name), "CustomResourceDefinition", crd.name))
+		}
+	}
+
+	for _, owned := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+		crd, found := crdsByName[owned.Name]
+		if !found {
+			continue
+		}
+		if v, found := crd.getVersion(owned.Version); found && !v.served {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the version (%s) defined for the CRD %s under "+
+				"spec.customresourcedefinitions.owned in the CSV is not served by the CRD", owned.Version, owned.Name),
+				"CustomResourceDefinition", owned.Name))
+		}
+	}
+	return checks
+}
+
+// checkPreserveUnknownFields will verify that the CRDs do not preserve the unknown fields
+func checkPreserveUnknownFields(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, crd := range checks.bundle.V1CRDs {
+		if crd != nil && crd.Spec.PreserveUnknownFields {
+			checks.errs = append(checks.errs, withSubject(withRemediation(fmt.Errorf("the CRD %s sets "+
+				"spec.preserveUnknownFields to true which is not allowed for apiextensions.k8s.io/v1 CRDs",
+				crd.GetName()),
+				"set it to false or remove it and use x-kubernetes-preserve-unknown-fields in the schema where needed"),
+				"CustomResourceDefinition", crd.GetName()))
+		}
+	}
+	// when the OCP range is not informed we assume that the bundle targets the latest versions
+	if ocpRange := getOCPRange(checks); len(ocpRange) > 0 {
+		upper, err := rangeAllowsVersionOrUpper(ocpRange, ocpVerV1beta1Unsupported)
+		if err != nil || !upper {
+			return checks
+		}
+	}
+	for _, crd := range checks.bundle.V1beta1CRDs {
+		if crd == nil {
+			continue
+		}
+		if crd.Spec.PreserveUnknownFields == nil {
+			checks.errs = append(checks.errs, withSubject(withRemediation(fmt.Errorf("the CRD %s does not set "+
+				"spec.preserveUnknownFields and then it will be true which is the default value for "+
+				"apiextensions.k8s.io/v1beta1 CRDs. Note that unknown fields are not pruned and the CRD cannot be "+
+				"migrated to apiextensions.k8s.io/v1", crd.GetName()), "set it to false"),
+				"CustomResourceDefinition", crd.GetName()))
+		} else if *crd.Spec.PreserveUnknownFields {
+			checks.errs = append(checks.errs, withSubject(withRemediation(fmt.Errorf("the CRD %s sets "+
+				"spec.preserveUnknownFields to true. Note that unknown fields are not pruned and the CRD cannot be "+
+				"migrated to apiextensions.k8s.io/v1", crd.GetName()), "set it to false"),
+				"CustomResourceDefinition", crd.GetName()))
+		}
+	}
+	return checks
+}
+
+// checkConversionWebhooks will verify that the CA will be injected in the CRD conversion webhooks
+func checkConversionWebhooks(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	managedByOLM := map[string]bool{}
+	for _, webhook := range checks.bundle.CSV.Spec.WebhookDefinitions {
+		if webhook.Type != operatorsv1alpha1.ConversionWebhook {
+			continue
+		}
+		for _, crd := range webhook.ConversionCRDs {
+			managedByOLM[crd] = true
+		}
+	}
+
+	for _, crd := range checks.bundle.V1CRDs {
+		if crd == nil || crd.Spec.Conversion == nil || crd.Spec.Conversion.Strategy != apiextensionsv1.WebhookConverter {
+			continue
+		}
+		serviceName := ""
+		if crd.Spec.Conversion.Webhook != nil && crd.Spec.Conversion.Webhook.ClientConfig != nil &&
+			crd.Spec.Conversion.Webhook.ClientConfig.Service != nil {
+			serviceName = crd.Spec.Conversion.Webhook.ClientConfig.Service.Name
+		}
+		checks = checkConversionWebhookCA(checks, crd.GetName(), crd.GetAnnotations(), serviceName, managedByOLM)
+	}
+	for _, crd := range checks.bundle.V1beta1CRDs {
+		if crd == nil || crd.Spec.Conversion == nil || crd.Spec.Conversion.Strategy != apiextensionsv1beta1.WebhookConverter {
+			continue
+		}
+		serviceName := ""
+		if crd.Spec.Conversion.WebhookClientConfig != nil && crd.Spec.Conversion.WebhookClientConfig.Service != nil {
+			serviceName = crd.Spec.Conversion.WebhookClientConfig.Service.Name
+		}
+		checks = checkConversionWebhookCA(checks, crd.GetName(), crd.GetAnnotations(), serviceName, managedByOLM)
+	}
+	return checks
+}
+
+// checkConversionWebhookCA will verify the CA configuration of the conversion webhook of the CRD informed
+func checkConversionWebhookCA(checks OpenShiftOperatorChecks, crdName string, annotations map[string]string,
+	serviceName string, managedByOLM map[string]bool) OpenShiftOperatorChecks {
+	if managedByOLM[crdName] {
+		return checks
+	}
+
+	if annotations[injectCABundleAnnotation] != "true" {
+		checks.errs = append(checks.errs, withSubject(withRemediation(fmt.Errorf("the CRD %s defines a conversion "+
+			"webhook but its certificates are not configured", crdName),
+			"define a ConversionWebhook with this CRD under spec.webhookdefinitions in the CSV so that OLM will "+
+				"manage them or use the annotation %s. Note that OLM on OpenShift will not provide the certificates "+
+				"for tools such as cert-manager", injectCABundleAnnotation), "CustomResourceDefinition", crdName))
+		return checks
+	}
+
+	if len(serviceName) == 0 {
+		return checks
+	}
+	for _, obj := range checks.bundle.Objects {
+		if obj.GetKind() == "Service" && obj.GetName() == serviceName {
+			if len(obj.GetAnnotations()[servingCertSecretAnnotation]) == 0 {
+				checks.warns = append(checks.warns, withSubject(fmt.Errorf("the CRD %s uses the annotation %s but the "+
+					"Service %s does not use the annotation %s. Note that its serving certificate will not be "+
+					"generated", crdName, injectCABundleAnnotation, serviceName, servingCertSecretAnnotation),
+					"CustomResourceDefinition", crdName))
+			}
+			return checks
+		}
+	}
+	checks.warns = append(checks.warns, withSubject(fmt.Errorf("the CRD %s uses the annotation %s but the Service %s "+
+		"used by its conversion webhook was not found in the bundle", crdName, injectCABundleAnnotation, serviceName),
+		"CustomResourceDefinition", crdName))
+	return checks
+}
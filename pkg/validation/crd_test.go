@@ -0,0 +1,280 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func Test_CRDValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		ocpRange  string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the owned CRDs are shipped in the bundle with v1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the owned CRDs are shipped in the bundle with v1beta1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate:    disablePreserveUnknownFields,
+			},
+		},
+		{
+			name:      "should fail when the owned CRD is not shipped in the bundle",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.V1CRDs = nil
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the CRD memcacheds.cache.example.com " +
+				"is defined under spec.customresourcedefinitions.owned in the CSV but its manifest was not found " +
+				"in the bundle"},
+		},
+		{
+			name:      "should fail when the owned CRD kind and version do not match with the CRD shipped",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.CustomResourceDefinitions.Owned[0].Kind = "Memcache"
+					bundle.CSV.Spec.CustomResourceDefinitions.Owned[0].Version = "v1"
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the kind (Memcache) defined for the CRD " +
+					"memcacheds.cache.example.com under spec.customresourcedefinitions.owned in the CSV does not " +
+					"match with the kind (Memcached) of the CRD shipped in the bundle",
+				"Error: Value : (memcached-operator.v0.0.1) the version (v1) defined for the CRD " +
+					"memcacheds.cache.example.com under spec.customresourcedefinitions.owned in the CSV was not " +
+					"found in the CRD shipped in the bundle",
+			},
+		},
+		{
+			name:        "should warn when the CRD shipped is not referenced in the CSV",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate: func(bundle *manifests.Bundle) {
+					disablePreserveUnknownFields(bundle)
+					bundle.CSV.Spec.CustomResourceDefinitions.Owned = bundle.CSV.Spec.CustomResourceDefinitions.Owned[1:]
+				},
+			},
+			warnStrings: []string{"Warning: Value : (etcdoperator.v0.9.4) the CRD etcdclusters.etcd.database.coreos.com " +
+				"is shipped in the bundle but it is not defined under spec.customresourcedefinitions.owned or " +
+				"required in the CSV. Note that it will be installed but OLM will not use it to resolve the " +
+				"dependencies. Please, ensure that it is not a stale manifest"},
+		},
+		{
+			name:      "should fail when the CRD has no served and storage versions",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.V1CRDs[0].Spec.Versions[0].Served = false
+					bundle.V1CRDs[0].Spec.Versions[0].Storage = false
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the CRD memcacheds.cache.example.com has 0 storage " +
+					"versions. Exactly one version must be defined as storage",
+				"Error: Value : (memcached-operator.v0.0.1) the CRD memcacheds.cache.example.com has no served " +
+					"versions. At least one version must be defined as served",
+				"Error: Value : (memcached-operator.v0.0.1) the version (v1alpha1) defined for the CRD " +
+					"memcacheds.cache.example.com under spec.customresourcedefinitions.owned in the CSV is not " +
+					"served by the CRD",
+			},
+		},
+		{
+			name:      "should fail when the CRD has more than one storage version",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					v := bundle.V1CRDs[0].Spec.Versions[0]
+					v.Name = "v1beta1"
+					bundle.V1CRDs[0].Spec.Versions = append(bundle.V1CRDs[0].Spec.Versions, v)
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the CRD memcacheds.cache.example.com " +
+				"has 2 storage versions. Exactly one version must be defined as storage"},
+		},
+		{
+			name:      "should fail when the v1 CRD preserves unknown fields",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.V1CRDs[0].Spec.PreserveUnknownFields = true
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the CRD memcacheds.cache.example.com " +
+				"sets spec.preserveUnknownFields to true which is not allowed for apiextensions.k8s.io/v1 CRDs. " +
+				"Please, set it to false or remove it and use x-kubernetes-preserve-unknown-fields in the " +
+				"schema where needed"},
+		},
+		{
+			name:      "should fail when the v1beta1 CRD relies on the default value or preserves unknown fields",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate: func(bundle *manifests.Bundle) {
+					disablePreserveUnknownFields(bundle)
+					preserve := true
+					bundle.V1beta1CRDs[0].Spec.PreserveUnknownFields = &preserve
+					bundle.V1beta1CRDs[1].Spec.PreserveUnknownFields = nil
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (etcdoperator.v0.9.4) the CRD etcdbackups.etcd.database.coreos.com sets " +
+					"spec.preserveUnknownFields to true. Note that unknown fields are not pruned and the CRD " +
+					"cannot be migrated to apiextensions.k8s.io/v1. Please, set it to false",
+				"Error: Value : (etcdoperator.v0.9.4) the CRD etcdclusters.etcd.database.coreos.com does not set " +
+					"spec.preserveUnknownFields and then it will be true which is the default value for " +
+					"apiextensions.k8s.io/v1beta1 CRDs. Note that unknown fields are not pruned and the CRD " +
+					"cannot be migrated to apiextensions.k8s.io/v1. Please, set it to false",
+			},
+		},
+		{
+			name: "should pass when the v1beta1 CRD preserves unknown fields and the bundle is distributed to OCP 4.8",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				ocpRange:  "v4.6-v4.8",
+				mutate: func(bundle *manifests.Bundle) {
+					disablePreserveUnknownFields(bundle)
+					preserve := true
+					bundle.V1beta1CRDs[0].Spec.PreserveUnknownFields = &preserve
+					bundle.V1beta1CRDs[1].Spec.PreserveUnknownFields = nil
+				},
+			},
+		},
+		{
+			name: "should pass when the conversion webhook is managed by OLM",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					addConversionWebhook(bundle)
+					bundle.CSV.Spec.WebhookDefinitions = append(bundle.CSV.Spec.WebhookDefinitions,
+						operatorsv1alpha1.WebhookDescription{
+							Type:           operatorsv1alpha1.ConversionWebhook,
+							ConversionCRDs: []string{"memcacheds.cache.example.com"},
+						})
+				},
+			},
+		},
+		{
+			name: "should pass when the conversion webhook uses the OpenShift service CA",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					addConversionWebhook(bundle)
+					bundle.V1CRDs[0].SetAnnotations(map[string]string{injectCABundleAnnotation: "true"})
+					for _, obj := range bundle.Objects {
+						if obj.GetName() == "memcached-operator-webhook-service" {
+							obj.SetAnnotations(map[string]string{servingCertSecretAnnotation: "webhook-server-cert"})
+						}
+					}
+				},
+			},
+		},
+		{
+			name:      "should fail when the conversion webhook has no certificates configured",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate:    addConversionWebhook,
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the CRD memcacheds.cache.example.com " +
+				"defines a conversion webhook but its certificates are not configured. Please, define a " +
+				"ConversionWebhook with this CRD under spec.webhookdefinitions in the CSV so that OLM will manage " +
+				"them or use the annotation service.beta.openshift.io/inject-cabundle. Note that OLM on OpenShift " +
+				"will not provide the certificates for tools such as cert-manager"},
+		},
+		{
+			name:        "should warn when the Service of the conversion webhook has no serving certificate",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					addConversionWebhook(bundle)
+					bundle.V1CRDs[0].SetAnnotations(map[string]string{injectCABundleAnnotation: "true"})
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the CRD memcacheds.cache.example.com " +
+				"uses the annotation service.beta.openshift.io/inject-cabundle but the Service " +
+				"memcached-operator-webhook-service does not use the annotation " +
+				"service.beta.openshift.io/serving-cert-secret-name. Note that its serving certificate will not " +
+				"be generated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := CRDValidator.Validate(bundle, map[string]string{RangeKey: tt.args.ocpRange})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// disablePreserveUnknownFields sets spec.preserveUnknownFields to false for all v1beta1 CRDs of the bundle
+func disablePreserveUnknownFields(bundle *manifests.Bundle) {
+	for _, crd := range bundle.V1beta1CRDs {
+		preserve := false
+		crd.Spec.PreserveUnknownFields = &preserve
+	}
+}
+
+// addConversionWebhook configures a conversion webhook for the CRD of the memcached bundle
+func addConversionWebhook(bundle *manifests.Bundle) {
+	bundle.V1CRDs[0].Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig: &apiextensionsv1.WebhookClientConfig{
+				Service: &apiextensionsv1.ServiceReference{
+					Name:      "memcached-operator-webhook-service",
+					Namespace: "system",
+				},
+			},
+			ConversionReviewVersions: []string{"v1"},
+		},
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"sigs.k8s.io/yaml"
+)
+
+// createdAtAnnotation defines the CSV annotation which informs when the bundle was created
+const createdAtAnnotation = "createdAt"
+
+// csvMetadataProperty defines the bundle property of the File-Based Catalogs which informs the CSV metadata
+const csvMetadataProperty = "olm.csv.metadata"
+
+// createdAtLayouts defines the timestamp formats accepted for the createdAt annotation
+var createdAtLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// createdAtPlaceholderRegexp matches the templating placeholders which were not replaced
+// (e.g. {{ .CreatedAt }}, ${CREATED_AT}, $(date), <timestamp> or CREATED_AT)
+var createdAtPlaceholderRegexp = regexp.MustCompile(`\{\{.*\}\}|\$\{.*\}|\$\(.*\)|<.*>|^[A-Z][A-Z_]*$`)
+
+// createdAtMaxSkew defines how far in the future the createdAt annotation is tolerated
+const createdAtMaxSkew = 7 * 24 * time.Hour
+
+// now returns the current time. It is a variable so that it can be replaced by the tests.
+var now = time.Now
+
+// CreatedAtValidator validates the createdAt annotation of the CSV when it is informed. Following its
+// current checks:
+//
+// - Ensure that the createdAt is not a templating placeholder which was not replaced (e.g. {{ .CreatedAt }})
+//
+// - Ensure that the createdAt is a RFC3339 timestamp (e.g. 2006-01-02T15:04:05Z) or one of the formats
+// accepted (e.g. 2006-01-02 15:04:05)
+//
+// - Warn when the createdAt is more than 7 days in the future
+//
+// - Warn when the createdAt is older than the one of the bundle replaced (spec.replaces). Note that this
+// check is only performed when the File-Based Catalog is informed via the CatalogKey.
+var CreatedAtValidator interfaces.Validator = newBundleValidator("created-at", checkCreatedAt)
+
+// checkCreatedAt will verify the format and the value of the createdAt annotation
+func checkCreatedAt(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	value, found := checks.bundle.CSV.GetAnnotations()[createdAtAnnotation]
+	if !found {
+		return checks
+	}
+	if createdAtPlaceholderRegexp.MatchString(strings.TrimSpace(value)) {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the %s annotation (%s) is a templating "+
+			"placeholder", createdAtAnnotation, value),
+			"ensure that it is replaced with the timestamp of the bundle creation (e.g. %s)",
+			now().UTC().Format(time.RFC3339)))
+		return checks
+	}
+	createdAt, ok := parseCreatedAt(value)
+	if !ok {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the %s annotation (%s) is not a valid timestamp",
+			createdAtAnnotation, value), "inform a RFC3339 timestamp (e.g. %s)", now().UTC().Format(time.RFC3339)))
+		return checks
+	}
+
+	if createdAt.After(now().Add(createdAtMaxSkew)) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s annotation (%s) is in the future",
+			createdAtAnnotation, value), "ensure that it informs when the bundle was created"))
+	}
+
+	replaces := checks.bundle.CSV.Spec.Replaces
+	if len(replaces) == 0 || len(checks.catalog) == 0 {
+		return checks
+	}
+	// Note that issues to load the catalog are reported by the CatalogDependenciesValidator
+	replacedValue := getCatalogCreatedAt(checks.catalog, replaces)
+	if replacedAt, ok := parseCreatedAt(replacedValue); ok && createdAt.Before(replacedAt) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s annotation (%s) is older than the one "+
+			"(%s) of the bundle replaced %s", createdAtAnnotation, value, replacedValue, replaces),
+			"ensure that it was updated when the bundle was created"))
+	}
+	return checks
+}
+
+// parseCreatedAt returns the time of the createdAt value informed when it has any of the accepted formats
+func parseCreatedAt(value string) (time.Time, bool) {
+	for _, layout := range createdAtLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// getCatalogCreatedAt returns the createdAt annotation of the bundle informed of the File-Based Catalog. It
+// is read from the olm.csv.metadata property or from the CSV informed via the olm.bundle.object properties.
+// Note that an empty value is returned when it is not found.
+func getCatalogCreatedAt(catalog, bundleName string) string {
+	cfg, err := declcfg.LoadFS(os.DirFS(catalog))
+	if err != nil {
+		return ""
+	}
+	for _, b := range cfg.Bundles {
+		if b.Name == bundleName {
+			return getCatalogCSVMetadata(b).Annotations[createdAtAnnotation]
+		}
+	}
+	return ""
+}
+
+// catalogCSVMetadata defines the attributes of the CSV of the bundles of the File-Based Catalogs which are
+// inspected by the validators
+type catalogCSVMetadata struct {
+	Annotations map[string]string `json:"annotations"`
+	Provider    struct {
+		Name string `json:"name"`
+	} `json:"provider"`
+}
+
+// getCatalogCSVMetadata returns the CSV metadata of the bundle of the File-Based Catalog informed. It is read
+// from the olm.csv.metadata property or from the CSV informed via the olm.bundle.object properties.
+func getCatalogCSVMetadata(b declcfg.Bundle) catalogCSVMetadata {
+	metadata := catalogCSVMetadata{}
+	for _, p := range b.Properties {
+		switch p.Type {
+		case csvMetadataProperty:
+			if err := json.Unmarshal(p.Value, &metadata); err == nil {
+				return metadata
+			}
+		case property.TypeBundleObject:
+			obj := property.BundleObject{}
+			if err := json.Unmarshal(p.Value, &obj); err != nil || obj.IsRef() {
+				continue
+			}
+			data, _ := obj.GetData(nil, "")
+			csv := struct {
+				Kind     string `json:"kind"`
+				Metadata struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"metadata"`
+				Spec catalogCSVMetadata `json:"spec"`
+			}{}
+			if err := yaml.Unmarshal(data, &csv); err == nil && csv.Kind == "ClusterServiceVersion" {
+				metadata = csv.Spec
+				metadata.Annotations = csv.Metadata.Annotations
+				return metadata
+			}
+		}
+	}
+	return metadata
+}
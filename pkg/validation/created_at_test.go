@@ -0,0 +1,125 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreatedAtValidator(t *testing.T) {
+	type args struct {
+		createdAt string
+		replaces  string
+		catalog   string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the createdAt is not informed",
+		},
+		{
+			name: "should pass when the createdAt is a RFC3339 timestamp",
+			args: args{
+				createdAt: "2023-03-10T13:49:41Z",
+			},
+		},
+		{
+			name: "should pass when the createdAt has an accepted format and is newer than the replaced one",
+			args: args{
+				createdAt: "2023-03-10 13:49:41",
+				replaces:  "etcdoperator.v0.9.4",
+				catalog:   "./testdata/catalog",
+			},
+		},
+		{
+			name: "should pass when the replaced bundle is not found in the catalog",
+			args: args{
+				createdAt: "2019-01-01",
+				replaces:  "memcached-operator.v0.0.0",
+				catalog:   "./testdata/catalog",
+			},
+		},
+		{
+			name:      "should fail when the createdAt is a templating placeholder",
+			wantError: true,
+			args: args{
+				createdAt: "{{ .CreatedAt }}",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the createdAt annotation " +
+				"({{ .CreatedAt }}) is a templating placeholder. Please, ensure that it is replaced with the " +
+				"timestamp of the bundle creation (e.g. 2023-06-01T12:00:00Z)"},
+		},
+		{
+			name:      "should fail when the createdAt is not a valid timestamp",
+			wantError: true,
+			args: args{
+				createdAt: "10/03/2023",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the createdAt annotation " +
+				"(10/03/2023) is not a valid timestamp. Please, inform a RFC3339 timestamp (e.g. " +
+				"2023-06-01T12:00:00Z)"},
+		},
+		{
+			name:        "should warn when the createdAt is in the future",
+			wantWarning: true,
+			args: args{
+				createdAt: "2033-06-01T12:00:00Z",
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the createdAt annotation " +
+				"(2033-06-01T12:00:00Z) is in the future. Please, ensure that it informs when the bundle was " +
+				"created"},
+		},
+		{
+			name:        "should warn when the createdAt is older than the replaced one",
+			wantWarning: true,
+			args: args{
+				createdAt: "2019-01-01T00:00:00Z",
+				replaces:  "etcdoperator.v0.9.4",
+				catalog:   "./testdata/catalog",
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the createdAt annotation " +
+				"(2019-01-01T00:00:00Z) is older than the one (2019-02-28 01:03:00) of the bundle replaced " +
+				"etcdoperator.v0.9.4. Please, ensure that it was updated when the bundle was created"},
+		},
+	}
+
+	defaultNow := now
+	defer func() { now = defaultNow }()
+	now = func() time.Time { return time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC) }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			if len(tt.args.createdAt) > 0 {
+				bundle.CSV.Annotations[createdAtAnnotation] = tt.args.createdAt
+			}
+			bundle.CSV.Spec.Replaces = tt.args.replaces
+
+			results := CreatedAtValidator.Validate(bundle, map[string]string{CatalogKey: tt.args.catalog})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
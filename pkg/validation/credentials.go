@@ -0,0 +1,141 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// credentialEnvRegexp matches the names of the environment variables which usually inform credentials
+// (e.g. DB_PASSWORD, GITHUB_TOKEN or AWS_SECRET_ACCESS_KEY)
+var credentialEnvRegexp = regexp.MustCompile(`(?i)(^|_)(password|passwd|pwd|secret|token|api_?key|access_?key|` +
+	`secret_?key|private_?key|credentials?)$`)
+
+// privateKeyRegexp matches the PEM encoded private keys
+var privateKeyRegexp = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+
+// kubeconfigRegexp matches the credentials of the users of the kubeconfig files
+var kubeconfigRegexp = regexp.MustCompile(`(client-key-data|client-certificate-data)\s*:|` +
+	`(?s)kind:\s*Config\b.*users:.*(token|password)\s*:`)
+
+// CredentialsValidator looks for the credentials leaked in the manifests shipped in the bundle. Note that the
+// bundles are publicly available once they are published in the catalogs. Following its current checks:
+//
+// - Ensure that the Secrets shipped in the bundle have no data
+//
+// - Ensure that the environment variables of the containers whose name looks like a credential (e.g.
+// DB_PASSWORD or GITHUB_TOKEN) are not informed with a hard-coded value
+//
+// - Ensure that the manifests have no private keys or kubeconfig files with credentials
+var CredentialsValidator interfaces.Validator = newBundleValidator("credentials", checkCredentials)
+
+// checkCredentials will look for the credentials leaked in the manifests of the bundle
+func checkCredentials(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	// reported keeps the values already reported to not report them twice
+	reported := map[string]bool{}
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			for _, env := range c.Env {
+				if len(env.Value) == 0 || !credentialEnvRegexp.MatchString(env.Name) {
+					continue
+				}
+				reported[env.Value] = true
+				checks.errs = append(checks.errs, withSubject(withRemediation(fmt.Errorf("the environment variable %s "+
+					"of the container %s of the deployment %s has a hard-coded value which looks like a credential",
+					env.Name, c.Name, dep.Name), "read it from a Secret via valueFrom.secretKeyRef"),
+					"Deployment", dep.Name))
+			}
+		}
+	}
+
+	for _, obj := range checks.bundle.Objects {
+		if obj == nil {
+			continue
+		}
+		if obj.GetKind() == "Secret" {
+			checks = checkSecretData(checks, obj)
+			continue
+		}
+		checks = checkCredentialBlobs(checks, obj, obj.Object, "", reported)
+	}
+	return checks
+}
+
+// checkSecretData will verify that the Secret informed has no data
+func checkSecretData(checks OpenShiftOperatorChecks, secret *unstructured.Unstructured) OpenShiftOperatorChecks {
+	var keys []string
+	for _, field := range []string{"data", "stringData"} {
+		data, _, _ := unstructured.NestedMap(secret.Object, field)
+		for key, value := range data {
+			if s, ok := value.(string); ok && len(s) == 0 {
+				continue
+			}
+			keys = append(keys, field+"."+key)
+		}
+	}
+	if len(keys) == 0 {
+		return checks
+	}
+	sort.Strings(keys)
+	checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the Secret %s shipped in the bundle has data (%s). "+
+		"Note that the bundles are publicly available in the catalogs", secret.GetName(), strings.Join(keys, ", ")),
+		"remove the data and let the operator or the users create it"))
+	return checks
+}
+
+// checkCredentialBlobs will look for the private keys and kubeconfig files with credentials in the values
+// of the object informed. The path informs the field of the value (e.g. data.kubeconfig).
+func checkCredentialBlobs(checks OpenShiftOperatorChecks, obj *unstructured.Unstructured, value interface{},
+	path string, reported map[string]bool) OpenShiftOperatorChecks {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			checks = checkCredentialBlobs(checks, obj, v[key], strings.TrimPrefix(path+"."+key, "."), reported)
+		}
+	case []interface{}:
+		for i, item := range v {
+			checks = checkCredentialBlobs(checks, obj, item, fmt.Sprintf("%s[%d]", path, i), reported)
+		}
+	case string:
+		if reported[v] {
+			return checks
+		}
+		blob := ""
+		switch {
+		case privateKeyRegexp.MatchString(v):
+			blob = "a private key"
+		case kubeconfigRegexp.MatchString(v):
+			blob = "a kubeconfig with credentials"
+		default:
+			return checks
+		}
+		reported[v] = true
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the %s %s shipped in the bundle has %s in %s. "+
+			"Note that the bundles are publicly available in the catalogs", obj.GetKind(), obj.GetName(), blob, path),
+			"remove it"))
+	}
+	return checks
+}
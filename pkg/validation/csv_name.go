@@ -0,0 +1,149 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/blang/semver"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// packageLabel defines the label used to inform the package name of the bundle
+const packageLabel = "operators.operatorframework.io.bundle.package.v1"
+
+// CSVNameValidator validates the CSV name against the convention used to publish
+// the bundles on the OpenShift catalogs. Following its current checks:
+//
+// - Ensure that the CSV metadata.name follows the convention <package>.vX.Y.Z
+//
+// - Ensure that the version in the CSV metadata.name matches with its spec.version
+//
+// - Ensure that the CSV metadata.name starts with the package name. Note that the
+// package name is only checked when it is informed via the bundle or via the file
+// informed with the optional key value file (index image or annotations path).
+//
+// - Ensure that the spec.version matches with the version used to name the bundle directory or
+// to tag the bundle image. Note that the directory is only checked when it is informed via
+// the optional key value bundle-path and the image via the optional key value bundle-image.
+var CSVNameValidator interfaces.Validator = newBundleValidator("csv-name", checkCSVName, checkBundleVersionReference)
+
+// checkCSVName will verify if the CSV name follows the convention <package>.vX.Y.Z
+func checkCSVName(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	csvName := checks.bundle.CSV.GetName()
+	prefix, version, ok := splitCSVName(csvName)
+	if !ok {
+		checks.errs = append(checks.errs, fmt.Errorf("metadata.name (%s) does not follow the "+
+			"convention <package>.vX.Y.Z (e.g. memcached-operator.v0.0.1)", csvName))
+		return checks
+	}
+
+	specVersion := checks.bundle.CSV.Spec.Version.String()
+	if version.String() != specVersion {
+		checks.errs = append(checks.errs, fmt.Errorf("the version (%s) informed in the metadata.name (%s) "+
+			"does not match with the spec.version (%s)", version, csvName, specVersion))
+	}
+
+	packageName := getPackageName(checks)
+	if len(packageName) > 0 && prefix != packageName {
+		checks.warns = append(checks.warns, fmt.Errorf("metadata.name (%s) does not start with "+
+			"the package name (%s). It is expected to follow the convention <package>.vX.Y.Z",
+			csvName, packageName))
+	}
+	return checks
+}
+
+// splitCSVName returns the prefix and the version of the CSV name informed
+// e.g. memcached-operator.v0.0.1 returns memcached-operator and 0.0.1
+func splitCSVName(csvName string) (string, semver.Version, bool) {
+	for i := strings.Index(csvName, ".v"); i >= 0; {
+		if version, err := semver.Parse(csvName[i+2:]); err == nil && i > 0 {
+			return csvName[:i], version, true
+		}
+		next := strings.Index(csvName[i+2:], ".v")
+		if next < 0 {
+			break
+		}
+		i = i + 2 + next
+	}
+	return "", semver.Version{}, false
+}
+
+// getPackageName returns the package name of the bundle. If it is not
+// set in the bundle then, it will be looked for in the file informed.
+func getPackageName(checks OpenShiftOperatorChecks) string {
+	if len(checks.bundle.Package) > 0 {
+		return checks.bundle.Package
+	}
+	if len(checks.filePath) == 0 {
+		return ""
+	}
+	// Note that issues to read the file are reported by the OpenShiftValidator
+	packageName, _ := getLabelValueFromFile(checks.fsys, checks.filePath, packageLabel)
+	return packageName
+}
+
+// checkBundleVersionReference will warn when the bundle directory or image tag is named
+// after a version which does not match with the spec.version
+func checkBundleVersionReference(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	specVersion := checks.bundle.CSV.Spec.Version.String()
+
+	if len(checks.bundlePath) > 0 {
+		dir := filepath.Base(filepath.Clean(checks.bundlePath))
+		if dir == "manifests" {
+			dir = filepath.Base(filepath.Dir(filepath.Clean(checks.bundlePath)))
+		}
+		if version, ok := parseVersionReference(dir); ok && version.String() != specVersion {
+			checks.warns = append(checks.warns, fmt.Errorf("the bundle directory (%s) is named after "+
+				"the version %s which does not match with the spec.version (%s)",
+				checks.bundlePath, version, specVersion))
+		}
+	}
+
+	if len(checks.bundle.BundleImage) > 0 {
+		tag := getImageTag(checks.bundle.BundleImage)
+		if version, ok := parseVersionReference(tag); ok && version.String() != specVersion {
+			checks.warns = append(checks.warns, fmt.Errorf("the bundle image (%s) is tagged with "+
+				"the version %s which does not match with the spec.version (%s)",
+				checks.bundle.BundleImage, version, specVersion))
+		}
+	}
+	return checks
+}
+
+// parseVersionReference returns the version when the value informed is a
+// version with or without the v prefix (e.g. v0.0.1 or 0.0.1)
+func parseVersionReference(value string) (semver.Version, bool) {
+	version, err := semver.Parse(strings.TrimPrefix(value, "v"))
+	if err != nil {
+		return semver.Version{}, false
+	}
+	return version, true
+}
+
+// getImageTag returns the tag of the image informed. Note that an empty value is
+// returned when the image is referenced by digest or has no tag.
+func getImageTag(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CSVNameValidator(t *testing.T) {
+	type args struct {
+		csvName     string
+		specVersion string
+		packageName string
+		filePath    string
+		bundlePath  string
+		bundleImage string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the name follows the convention",
+			args: args{
+				csvName:     "memcached-operator.v0.0.1",
+				specVersion: "0.0.1",
+			},
+		},
+		{
+			name: "should pass when the name follows the convention with the package from the file",
+			args: args{
+				csvName:     "memcached-operator.v0.0.1",
+				specVersion: "0.0.1",
+				filePath:    "./testdata/dockerfile/valid_bundle.Dockerfile",
+			},
+		},
+		{
+			name: "should pass when the version has pre-release and build metadata",
+			args: args{
+				csvName:     "memcached-operator.v0.0.1-rc.1+build",
+				specVersion: "0.0.1-rc.1+build",
+			},
+		},
+		{
+			name:      "should fail when the name does not have the version",
+			wantError: true,
+			args: args{
+				csvName:     "memcached-operator",
+				specVersion: "0.0.1",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator) metadata.name (memcached-operator) does " +
+				"not follow the convention <package>.vX.Y.Z (e.g. memcached-operator.v0.0.1)"},
+		},
+		{
+			name:      "should fail when the version does not match with the spec.version",
+			wantError: true,
+			args: args{
+				csvName:     "memcached-operator.v0.0.2",
+				specVersion: "0.0.1",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.2) the version (0.0.2) informed in the " +
+				"metadata.name (memcached-operator.v0.0.2) does not match with the spec.version (0.0.1)"},
+		},
+		{
+			name:        "should warn when the name does not start with the package name",
+			wantWarning: true,
+			args: args{
+				csvName:     "memcached.v0.0.1",
+				specVersion: "0.0.1",
+				packageName: "memcached-operator",
+			},
+			warnStrings: []string{"Warning: Value : (memcached.v0.0.1) metadata.name (memcached.v0.0.1) does " +
+				"not start with the package name (memcached-operator). It is expected to follow the " +
+				"convention <package>.vX.Y.Z"},
+		},
+		{
+			name: "should pass when the bundle directory and image tag match with the spec.version",
+			args: args{
+				csvName:     "memcached-operator.v0.0.1",
+				specVersion: "0.0.1",
+				bundlePath:  "bundles/v0.0.1/manifests/",
+				bundleImage: "quay.io/example/memcached-operator-bundle:0.0.1",
+			},
+		},
+		{
+			name: "should pass when the bundle directory and image tag are not named after a version",
+			args: args{
+				csvName:     "memcached-operator.v0.0.1",
+				specVersion: "0.0.1",
+				bundlePath:  "./bundle",
+				bundleImage: "localhost:5000/memcached-operator-bundle:latest",
+			},
+		},
+		{
+			name:        "should warn when the bundle directory and image tag do not match with the spec.version",
+			wantWarning: true,
+			args: args{
+				csvName:     "memcached-operator.v0.0.1",
+				specVersion: "0.0.1",
+				bundlePath:  "bundles/0.0.2",
+				bundleImage: "localhost:5000/memcached-operator-bundle:v0.0.3",
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the bundle directory (bundles/0.0.2) is named " +
+					"after the version 0.0.2 which does not match with the spec.version (0.0.1)",
+				"Warning: Value : (memcached-operator.v0.0.1) the bundle image " +
+					"(localhost:5000/memcached-operator-bundle:v0.0.3) is tagged with the version 0.0.3 which " +
+					"does not match with the spec.version (0.0.1)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+
+			bundle.Package = tt.args.packageName
+			bundle.CSV.SetName(tt.args.csvName)
+			require.NoError(t, bundle.CSV.Spec.Version.UnmarshalJSON([]byte(`"`+tt.args.specVersion+`"`)))
+
+			results := CSVNameValidator.Validate(bundle, map[string]string{
+				FilePathKey:    tt.args.filePath,
+				BundlePathKey:  tt.args.bundlePath,
+				BundleImageKey: tt.args.bundleImage,
+			})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+func Test_splitCSVName(t *testing.T) {
+	tests := []struct {
+		csvName     string
+		wantPrefix  string
+		wantVersion string
+		wantOk      bool
+	}{
+		{csvName: "etcdoperator.v0.9.4", wantPrefix: "etcdoperator", wantVersion: "0.9.4", wantOk: true},
+		{csvName: "my.operator.v1.0.0", wantPrefix: "my.operator", wantVersion: "1.0.0", wantOk: true},
+		{csvName: "vault.vendor.v1.2.3", wantPrefix: "vault.vendor", wantVersion: "1.2.3", wantOk: true},
+		{csvName: "operator.v1.0", wantOk: false},
+		{csvName: ".v1.0.0", wantOk: false},
+		{csvName: "operator", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.csvName, func(t *testing.T) {
+			prefix, version, ok := splitCSVName(tt.csvName)
+			require.Equal(t, tt.wantOk, ok)
+			if tt.wantOk {
+				require.Equal(t, tt.wantPrefix, prefix)
+				require.Equal(t, tt.wantVersion, version.String())
+			}
+		})
+	}
+}
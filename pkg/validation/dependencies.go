@@ -0,0 +1,333 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/operator-framework/api/pkg/constraints"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"sigs.k8s.io/yaml"
+)
+
+// dependenciesFile defines the name of the file under the metadata directory of the bundle
+// which informs its dependencies
+const dependenciesFile = "dependencies.yaml"
+
+// The types of the dependencies which can be informed in the dependencies.yaml
+const (
+	packageDependencyType    = "olm.package"
+	gvkDependencyType        = "olm.gvk"
+	constraintDependencyType = "olm.constraint"
+)
+
+// constraintKinds defines the kinds of the olm.constraint dependencies. Each constraint must
+// inform exactly one of them.
+var constraintKinds = []string{"cel", "all", "any", "not", "gvk", "package"}
+
+// compoundConstraintKinds defines the kinds of the olm.constraint dependencies which nest other constraints
+var compoundConstraintKinds = []string{"all", "any", "not"}
+
+// olmPropertyTypes defines the types of the properties provided by OLM for the bundles which can be
+// referenced by the cel constraints. Note that the bundles can also declare their own properties.
+var olmPropertyTypes = []string{
+	"olm.package",
+	"olm.gvk",
+	"olm.package.required",
+	"olm.gvk.required",
+	"olm.label",
+	"olm.channel",
+	"olm.skips",
+	"olm.skipRange",
+	"olm.maxOpenShiftVersion",
+	"olm.deprecated",
+	"olm.substitutesFor",
+	"olm.bundle.object",
+	"olm.bundle.mediatype",
+	"olm.csv.metadata",
+}
+
+// celPropertyTypeRegexp matches the property types compared in the cel rules (e.g. p.type == "olm.label")
+var celPropertyTypeRegexp = regexp.MustCompile(`\.type\s*==\s*["']([^"']*)["']|["']([^"']*)["']\s*==\s*\w+\.type\b`)
+
+// celEnvironment is used to compile the rules of the cel constraints as OLM does
+var celEnvironment = constraints.NewCelEnvironment()
+
+// DependenciesValidator validates the dependencies of the bundle informed in the metadata/dependencies.yaml
+// which is looked for in the directory informed via the BundlePathKey or next to the annotations informed
+// via the FilePathKey. Following its current checks:
+//
+// - Ensure that the file can be parsed and that the types of the dependencies are olm.package, olm.gvk
+// or olm.constraint
+//
+// - Ensure that the olm.package dependencies inform the package name and a valid semver range and that
+// they do not refer to the package of the bundle
+//
+// - Ensure that the olm.gvk dependencies inform the group, version and kind and that the olm.constraint
+// dependencies and their nested (all, any and not) constraints inform exactly one constraint
+//
+// - Ensure that the rules of the cel constraints compile to a boolean expression and warn when they compare
+// property types which are not provided by OLM (e.g. typos)
+//
+// - Ensure that the olm.constraint dependencies inform the failureMessage shown when they cannot be
+// satisfied. Note that it is an error only for the certified, redhat and marketplace profiles.
+var DependenciesValidator interfaces.Validator = newBundleValidator("dependencies", checkDependencies)
+
+// bundleDependency defines a dependency informed in the dependencies.yaml
+type bundleDependency struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// packageDependency defines the value of the olm.package dependencies. Note that the olm.constraint
+// dependencies use versionRange instead of version.
+type packageDependency struct {
+	PackageName  string `json:"packageName"`
+	Version      string `json:"version"`
+	VersionRange string `json:"versionRange"`
+}
+
+// gvkDependency defines the value of the olm.gvk dependencies
+type gvkDependency struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// checkDependencies will verify the dependencies informed in the metadata/dependencies.yaml
+func checkDependencies(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	dependencies, err := getBundleDependencies(checks)
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+
+	for i, dep := range dependencies {
+		var err error
+		switch dep.Type {
+		case packageDependencyType:
+			err = validatePackageDependency(dep.Value, "version", getPackageName(checks))
+		case gvkDependencyType:
+			err = validateGVKDependency(dep.Value)
+		case constraintDependencyType:
+			var rules []string
+			err = validateConstraintDependency(dep.Value, getPackageName(checks), &rules)
+			if err == nil {
+				checks = checkConstraintDependency(checks, i, dep.Value, rules)
+			}
+		default:
+			err = withRemediation(fmt.Errorf("has the unknown type (%s)", dep.Type), "use %s, %s or %s",
+				packageDependencyType, gvkDependencyType, constraintDependencyType)
+		}
+		if err != nil {
+			checks.errs = append(checks.errs, fmt.Errorf("the dependency [%d] informed in %s %s", i,
+				dependenciesFile, err))
+		}
+	}
+	return checks
+}
+
+// validatePackageDependency returns an error when the package dependency is invalid. The field with the
+// semver range is version for the olm.package dependencies and versionRange for the olm.constraint ones.
+func validatePackageDependency(value json.RawMessage, versionField, packageName string) error {
+	dep := packageDependency{}
+	if err := json.Unmarshal(value, &dep); err != nil {
+		return fmt.Errorf("has an invalid value: %s", err)
+	}
+	version := dep.Version
+	if versionField == "versionRange" {
+		version = dep.VersionRange
+	}
+
+	if len(dep.PackageName) == 0 {
+		return fmt.Errorf("does not inform the packageName")
+	}
+	if len(packageName) > 0 && dep.PackageName == packageName {
+		return fmt.Errorf("refers to the package of the bundle (%s) which cannot depend on itself",
+			packageName)
+	}
+	if len(version) == 0 {
+		return fmt.Errorf("does not inform the %s of the package %s", versionField, dep.PackageName)
+	}
+	if _, err := semver.ParseRange(version); err != nil {
+		return fmt.Errorf("has an invalid semver range (%s) for the package %s: %s", version, dep.PackageName,
+			err)
+	}
+	return nil
+}
+
+// validateGVKDependency returns an error when the gvk dependency is invalid
+func validateGVKDependency(value json.RawMessage) error {
+	dep := gvkDependency{}
+	if err := json.Unmarshal(value, &dep); err != nil {
+		return fmt.Errorf("has an invalid value: %s", err)
+	}
+	if len(dep.Group) == 0 || len(dep.Version) == 0 || len(dep.Kind) == 0 {
+		return fmt.Errorf("does not inform the group, version and kind")
+	}
+	return nil
+}
+
+// validateConstraintDependency returns an error when the constraint dependency or any of its nested
+// constraints is invalid. The rules of the cel constraints found are added to the rules informed.
+func validateConstraintDependency(value json.RawMessage, packageName string, rules *[]string) error {
+	constraint := map[string]json.RawMessage{}
+	if err := json.Unmarshal(value, &constraint); err != nil {
+		return fmt.Errorf("has an invalid value: %s", err)
+	}
+	var kinds, unknown []string
+	for k := range constraint {
+		if containsAny(constraintKinds, k) {
+			kinds = append(kinds, k)
+		} else if k != "failureMessage" {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	if len(unknown) > 0 {
+		return fmt.Errorf("informs the unknown fields (%s). Note that OLM is unable to parse the constraint",
+			strings.Join(unknown, ", "))
+	}
+	sort.Strings(kinds)
+	if len(kinds) != 1 {
+		return withRemediation(fmt.Errorf("informs %d constraints (%s)", len(kinds), strings.Join(kinds, ", ")),
+			"inform exactly one of: %s", strings.Join(constraintKinds, ", "))
+	}
+
+	switch kinds[0] {
+	case "package":
+		return validatePackageDependency(constraint["package"], "versionRange", packageName)
+	case "gvk":
+		return validateGVKDependency(constraint["gvk"])
+	case "cel":
+		return validateCELConstraint(constraint["cel"], rules)
+	}
+	return validateCompoundConstraint(constraint[kinds[0]], kinds[0], packageName, rules)
+}
+
+// validateCELConstraint returns an error when the rule of the cel constraint does not compile to a
+// boolean expression
+func validateCELConstraint(value json.RawMessage, rules *[]string) error {
+	cel := constraints.Cel{}
+	if err := json.Unmarshal(value, &cel); err != nil {
+		return fmt.Errorf("has an invalid cel constraint: %s", err)
+	}
+	if len(strings.TrimSpace(cel.Rule)) == 0 {
+		return fmt.Errorf("does not inform the rule of the cel constraint")
+	}
+	if _, err := celEnvironment.Validate(cel.Rule); err != nil {
+		// The compile errors also quote the rule in the following lines to point the issue
+		msg := strings.Split(strings.TrimSpace(err.Error()), "\n")[0]
+		return fmt.Errorf("has an invalid cel rule (%s): %s", cel.Rule, msg)
+	}
+	*rules = append(*rules, cel.Rule)
+	return nil
+}
+
+// validateCompoundConstraint returns an error when the compound (all, any or not) constraint informed
+// has no constraints or any of them is invalid
+func validateCompoundConstraint(value json.RawMessage, kind, packageName string, rules *[]string) error {
+	compound := struct {
+		Constraints []json.RawMessage `json:"constraints"`
+	}{}
+	if err := json.Unmarshal(value, &compound); err != nil {
+		return fmt.Errorf("has an invalid %s constraint: %s", kind, err)
+	}
+	if len(compound.Constraints) == 0 {
+		return fmt.Errorf("does not inform the constraints of the %s constraint", kind)
+	}
+	for i, nested := range compound.Constraints {
+		if err := validateConstraintDependency(nested, packageName, rules); err != nil {
+			return fmt.Errorf("has the nested constraint %s.constraints[%d] which %s", kind, i, err)
+		}
+	}
+	return nil
+}
+
+// checkConstraintDependency will verify the failureMessage of the constraint dependency [i] and the
+// property types compared in the rules of its cel constraints
+func checkConstraintDependency(checks OpenShiftOperatorChecks, i int, value json.RawMessage,
+	rules []string) OpenShiftOperatorChecks {
+	constraint := struct {
+		FailureMessage string `json:"failureMessage"`
+	}{}
+	if err := json.Unmarshal(value, &constraint); err == nil && len(strings.TrimSpace(constraint.FailureMessage)) == 0 {
+		checks = addStrictFinding(checks, withRemediation(fmt.Errorf("the dependency [%d] informed in %s does not "+
+			"inform the failureMessage. Note that it is shown to the users when the constraint cannot be satisfied", i,
+			dependenciesFile), "inform it"))
+	}
+
+	for _, rule := range rules {
+		for _, match := range celPropertyTypeRegexp.FindAllStringSubmatch(rule, -1) {
+			propertyType := match[1] + match[2]
+			if containsAny(olmPropertyTypes, propertyType) {
+				continue
+			}
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the dependency [%d] informed in %s has a "+
+				"cel rule which refers to the property type %s that is not provided by OLM. Note that the constraint "+
+				"will only be satisfied by the bundles which declare it in their properties", i, dependenciesFile,
+				propertyType), "ensure that it has no typos"))
+		}
+	}
+	return checks
+}
+
+// getBundleDependencies returns the dependencies informed in the dependencies.yaml of the bundle
+// when it is found
+func getBundleDependencies(checks OpenShiftOperatorChecks) ([]bundleDependency, error) {
+	path := getDependenciesFilePath(checks)
+	if len(path) == 0 {
+		return nil, nil
+	}
+	content, err := readFile(checks.fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the file %s: %s", path, err)
+	}
+	file := struct {
+		Dependencies []bundleDependency `json:"dependencies"`
+	}{}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse the file %s: %s", path, err)
+	}
+	return file.Dependencies, nil
+}
+
+// getDependenciesFilePath returns the path of the dependencies.yaml of the bundle when it is found
+func getDependenciesFilePath(checks OpenShiftOperatorChecks) string {
+	var candidates []string
+	if len(checks.filePath) > 0 && strings.HasSuffix(checks.filePath, ".yaml") {
+		candidates = append(candidates, filepath.Join(filepath.Dir(checks.filePath), dependenciesFile))
+	}
+	if len(checks.bundlePath) > 0 {
+		dir := filepath.Clean(checks.bundlePath)
+		if filepath.Base(dir) == "manifests" {
+			dir = filepath.Dir(dir)
+		}
+		candidates = append(candidates, filepath.Join(dir, "metadata", dependenciesFile))
+	}
+
+	for _, path := range candidates {
+		if info, err := statFile(checks.fsys, path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
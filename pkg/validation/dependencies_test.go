@@ -0,0 +1,237 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DependenciesValidator(t *testing.T) {
+	tests := []struct {
+		name         string
+		dependencies string
+		profile      string
+		wantError    bool
+		wantWarning  bool
+		errStrings   []string
+		warnStrings  []string
+	}{
+		{
+			name: "should pass when the dependencies are valid",
+			dependencies: `dependencies:
+- type: olm.package
+  value:
+    packageName: etcd
+    version: ">=0.9.0 <0.10.0"
+- type: olm.gvk
+  value:
+    group: etcd.database.coreos.com
+    kind: EtcdCluster
+    version: v1beta2
+- type: olm.constraint
+  value:
+    failureMessage: require the etcd operator
+    package:
+      packageName: etcd
+      versionRange: ">=0.9.0"
+`,
+		},
+		{
+			name:         "should fail when the file cannot be parsed",
+			dependencies: "dependencies: {",
+			wantError:    true,
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to parse the file " +
+				"metadata/dependencies.yaml: error converting YAML to JSON: yaml: line 1: did not find expected " +
+				"node content"},
+		},
+		{
+			name: "should fail when the dependencies are invalid",
+			dependencies: `dependencies:
+- type: olm.label
+  value:
+    label: example
+- type: olm.package
+  value:
+    packageName: etcd
+    version: latest
+- type: olm.package
+  value:
+    packageName: memcached-operator
+    version: ">=0.0.1"
+- type: olm.gvk
+  value:
+    group: etcd.database.coreos.com
+    kind: EtcdCluster
+- type: olm.constraint
+  value:
+    all:
+      constraints: []
+    any:
+      constraints: []
+`,
+			wantError: true,
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [0] informed in dependencies.yaml has " +
+					"the unknown type (olm.label). Please, use olm.package, olm.gvk or olm.constraint",
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [1] informed in dependencies.yaml has " +
+					"an invalid semver range (latest) for the package etcd: Could not get version from string: \"latest\"",
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [2] informed in dependencies.yaml " +
+					"refers to the package of the bundle (memcached-operator) which cannot depend on itself",
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [3] informed in dependencies.yaml does " +
+					"not inform the group, version and kind",
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [4] informed in dependencies.yaml " +
+					"informs 2 constraints (all, any). Please, inform exactly one of: cel, all, any, not, gvk, package",
+			},
+		},
+		{
+			name: "should pass when the compound and cel constraints are valid",
+			dependencies: `dependencies:
+- type: olm.constraint
+  value:
+    failureMessage: require etcd or the cluster monitoring
+    any:
+      constraints:
+      - package:
+          packageName: etcd
+          versionRange: ">=0.9.0"
+      - all:
+          constraints:
+          - cel:
+              rule: 'properties.exists(p, p.type == "olm.package" && semver_compare(p.value.version, "1.0.0") >= 0)'
+          - not:
+              constraints:
+              - gvk:
+                  group: etcd.database.coreos.com
+                  kind: EtcdCluster
+                  version: v1beta2
+`,
+		},
+		{
+			name: "should fail when the nested and cel constraints are invalid",
+			dependencies: `dependencies:
+- type: olm.constraint
+  value:
+    failureMessage: require etcd
+    all:
+      constraints:
+      - package:
+          packageName: etcd
+          versionRange: ">=0.9.0"
+      - any:
+          constraints: []
+- type: olm.constraint
+  value:
+    failureMessage: require etcd
+    not:
+      constraints:
+      - cel:
+          rule: 'properties.exists(p, p.type == "olm.package"'
+- type: olm.constraint
+  value:
+    failureMessage: require etcd
+    cel:
+      rule: 'properties.size()'
+- type: olm.constraint
+  value:
+    failureMessage: require etcd
+    cell:
+      rule: 'true'
+`,
+			wantError: true,
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [0] informed in dependencies.yaml has " +
+					"the nested constraint all.constraints[1] which does not inform the constraints of the any " +
+					"constraint",
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [1] informed in dependencies.yaml has " +
+					"the nested constraint not.constraints[0] which has an invalid cel rule " +
+					"(properties.exists(p, p.type == \"olm.package\"): ERROR: <input>:1:45: Syntax error: " +
+					"missing ')' at '<EOF>'",
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [2] informed in dependencies.yaml has " +
+					"an invalid cel rule (properties.size()): cel expressions must have type Bool",
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [3] informed in dependencies.yaml " +
+					"informs the unknown fields (cell). Note that OLM is unable to parse the constraint",
+			},
+		},
+		{
+			name: "should warn when the failureMessage is missing and the cel rule refers to unknown types",
+			dependencies: `dependencies:
+- type: olm.constraint
+  value:
+    cel:
+      rule: 'properties.exists(p, p.type == "olm.lable" && p.value == "example")'
+`,
+			wantWarning: true,
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the dependency [0] informed in dependencies.yaml " +
+					"does not inform the failureMessage. Note that it is shown to the users when the constraint " +
+					"cannot be satisfied. Please, inform it",
+				"Warning: Value : (memcached-operator.v0.0.1) the dependency [0] informed in dependencies.yaml " +
+					"has a cel rule which refers to the property type olm.lable that is not provided by OLM. Note " +
+					"that the constraint will only be satisfied by the bundles which declare it in their " +
+					"properties. Please, ensure that it has no typos",
+			},
+		},
+		{
+			name: "should fail when the failureMessage is missing with a strict profile",
+			dependencies: `dependencies:
+- type: olm.constraint
+  value:
+    gvk:
+      group: etcd.database.coreos.com
+      kind: EtcdCluster
+      version: v1beta2
+`,
+			profile:   CertifiedProfile,
+			wantError: true,
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the dependency [0] informed in dependencies.yaml " +
+					"does not inform the failureMessage. Note that it is shown to the users when the constraint " +
+					"cannot be satisfied. Please, inform it",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			bundle.Package = "memcached-operator"
+
+			dir := t.TempDir()
+			require.NoError(t, os.Mkdir(filepath.Join(dir, "metadata"), 0755))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "metadata", dependenciesFile),
+				[]byte(tt.dependencies), 0644))
+
+			// The paths are relative to the bundle directory to get reproducible messages
+			wd, err := os.Getwd()
+			require.NoError(t, err)
+			require.NoError(t, os.Chdir(dir))
+			defer func() {
+				require.NoError(t, os.Chdir(wd))
+			}()
+
+			results := DependenciesValidator.Validate(bundle, map[string]string{BundlePathKey: "manifests",
+				ProfileKey: tt.profile})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// DeploymentPracticesValidator validates the deployments defined in the CSV against the good practices
+// checked in the certification of the operators. Following its current checks:
+//
+// - Warn when the containers of the deployments do not define resource requests or limits. Note that
+// the pods might not be scheduled on the clusters which enforce ResourceQuotas
+//
+// - Warn when the deployments do not define liveness or readiness probes in any of their containers
+var DeploymentPracticesValidator interfaces.Validator = newBundleValidator("deployment-practices",
+	checkResourceRequirements, checkHealthProbes)
+
+// checkResourceRequirements will warn when the containers of the deployments do not define requests or limits
+func checkResourceRequirements(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			var missing []string
+			if len(c.Resources.Requests) == 0 {
+				missing = append(missing, "requests")
+			}
+			if len(c.Resources.Limits) == 0 {
+				missing = append(missing, "limits")
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the container %s of the "+
+				"deployment %s does not define resource %s. Note that its pods might not be scheduled on the clusters "+
+				"which enforce ResourceQuotas", c.Name, dep.Name, strings.Join(missing, " and ")),
+				"define the cpu and memory under resources"), "Deployment", dep.Name))
+		}
+	}
+	return checks
+}
+
+// checkHealthProbes will warn when the deployments do not define liveness or readiness probes
+func checkHealthProbes(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		hasLiveness, hasReadiness := false, false
+		for _, c := range dep.Spec.Template.Spec.Containers {
+			hasLiveness = hasLiveness || c.LivenessProbe != nil
+			hasReadiness = hasReadiness || c.ReadinessProbe != nil
+		}
+		var missing []string
+		if !hasLiveness {
+			missing = append(missing, "liveness")
+		}
+		if !hasReadiness {
+			missing = append(missing, "readiness")
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the deployment %s does not define "+
+			"%s probes in its containers. Note that the cluster will not be able to detect and restart unhealthy "+
+			"operator pods", dep.Name, strings.Join(missing, " and ")),
+			"define livenessProbe and readinessProbe (e.g. /healthz and /readyz)"), "Deployment", dep.Name))
+	}
+	return checks
+}
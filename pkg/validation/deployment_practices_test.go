@@ -0,0 +1,127 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func Test_DeploymentPracticesValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the deployments follow the good practices",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate:    setResourceRequirements,
+			},
+		},
+		{
+			name:        "should warn when the containers do not define requests or limits",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec
+					spec.Containers[1].Resources.Limits = nil
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the container kube-rbac-proxy of the deployment " +
+					"memcached-operator-controller-manager does not define resource requests and limits. Note that " +
+					"its pods might not be scheduled on the clusters which enforce ResourceQuotas. Please, define " +
+					"the cpu and memory under resources",
+				"Warning: Value : (memcached-operator.v0.0.1) the container manager of the deployment " +
+					"memcached-operator-controller-manager does not define resource limits. Note that its pods " +
+					"might not be scheduled on the clusters which enforce ResourceQuotas. Please, define the cpu and " +
+					"memory under resources",
+			},
+		},
+		{
+			name:        "should warn when the deployment does not define probes",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					setResourceRequirements(bundle)
+					spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec
+					spec.Containers[1].LivenessProbe = nil
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the deployment " +
+				"memcached-operator-controller-manager does not define liveness probes in its containers. Note " +
+				"that the cluster will not be able to detect and restart unhealthy operator pods. Please, define " +
+				"livenessProbe and readinessProbe (e.g. /healthz and /readyz)"},
+		},
+		{
+			name:        "should warn when the deployment does not define liveness and readiness probes",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					setResourceRequirements(bundle)
+					spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec
+					spec.Containers[1].LivenessProbe = nil
+					spec.Containers[1].ReadinessProbe = nil
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the deployment " +
+				"memcached-operator-controller-manager does not define liveness and readiness probes in its containers. Note that the cluster will not be able to " +
+				"detect and restart unhealthy operator pods. Please, define livenessProbe and readinessProbe " +
+				"(e.g. /healthz and /readyz)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := DeploymentPracticesValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// setResourceRequirements defines requests and limits for all containers of the memcached bundle
+func setResourceRequirements(bundle *manifests.Bundle) {
+	spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec
+	for i := range spec.Containers {
+		resources := corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("30Mi"),
+		}
+		spec.Containers[i].Resources = corev1.ResourceRequirements{Requests: resources, Limits: resources}
+	}
+}
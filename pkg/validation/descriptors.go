@@ -0,0 +1,181 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// knownXDescriptors defines the x-descriptors which are interpreted by the console
+var knownXDescriptors = []string{
+	"urn:alm:descriptor:com.tectonic.ui:podCount",
+	"urn:alm:descriptor:com.tectonic.ui:podStatuses",
+	"urn:alm:descriptor:com.tectonic.ui:endpointList",
+	"urn:alm:descriptor:com.tectonic.ui:label",
+	"urn:alm:descriptor:com.tectonic.ui:resourceRequirements",
+	"urn:alm:descriptor:com.tectonic.ui:namespaceSelector",
+	"urn:alm:descriptor:com.tectonic.ui:booleanSwitch",
+	"urn:alm:descriptor:com.tectonic.ui:password",
+	"urn:alm:descriptor:com.tectonic.ui:checkbox",
+	"urn:alm:descriptor:com.tectonic.ui:imagePullPolicy",
+	"urn:alm:descriptor:com.tectonic.ui:updateStrategy",
+	"urn:alm:descriptor:com.tectonic.ui:text",
+	"urn:alm:descriptor:com.tectonic.ui:number",
+	"urn:alm:descriptor:com.tectonic.ui:nodeAffinity",
+	"urn:alm:descriptor:com.tectonic.ui:podAffinity",
+	"urn:alm:descriptor:com.tectonic.ui:podAntiAffinity",
+	"urn:alm:descriptor:com.tectonic.ui:advanced",
+	"urn:alm:descriptor:com.tectonic.ui:hidden",
+	"urn:alm:descriptor:org.w3:link",
+	"urn:alm:descriptor:io.kubernetes.conditions",
+	"urn:alm:descriptor:io.kubernetes.phase",
+	"urn:alm:descriptor:io.kubernetes.phase:reason",
+	"urn:alm:descriptor:prometheusEndpoint",
+	"urn:alm:descriptor:text",
+}
+
+// knownXDescriptorPrefixes defines the prefixes of the x-descriptors which are interpreted by the console
+// and require an argument (e.g. urn:alm:descriptor:io.kubernetes:Secret)
+var knownXDescriptorPrefixes = []string{
+	"urn:alm:descriptor:io.kubernetes:",
+	"urn:alm:descriptor:com.tectonic.ui:selector:",
+	"urn:alm:descriptor:com.tectonic.ui:fieldGroup:",
+	"urn:alm:descriptor:com.tectonic.ui:arrayFieldGroup:",
+	"urn:alm:descriptor:com.tectonic.ui:select:",
+	"urn:alm:descriptor:com.tectonic.ui:fieldDependency:",
+}
+
+// consoleXDescriptorPrefixes defines the prefixes of the x-descriptors owned by the console. Note that the
+// x-descriptors out of them (e.g. urn:alm:descriptor:aws:s3:path) are informative and are not checked.
+var consoleXDescriptorPrefixes = []string{
+	"urn:alm:descriptor:com.tectonic.ui:",
+	"urn:alm:descriptor:io.kubernetes",
+	"urn:alm:descriptor:org.w3:",
+}
+
+// descriptorIndexRegexp matches the array indexes informed in the descriptor paths (e.g. containers[0])
+var descriptorIndexRegexp = regexp.MustCompile(`\[[0-9]*\]`)
+
+// DescriptorsValidator validates the spec and status descriptors of the owned CRDs which are used by the
+// console to generate the forms and the details pages of their instances. Following its current checks:
+//
+// - Warn when the x-descriptors are not known by the console (e.g. typos)
+//
+// - Warn when the paths of the descriptors are not found in the schema of the CRD
+var DescriptorsValidator interfaces.Validator = newBundleValidator("descriptors", checkDescriptors)
+
+// checkDescriptors will verify the x-descriptors and paths of the spec and status descriptors
+func checkDescriptors(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	crdsByName := map[string]bundleCRD{}
+	for _, crd := range getBundleCRDs(checks.bundle) {
+		crdsByName[crd.name] = crd
+	}
+
+	for _, owned := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+		var schema *apiextensionsv1.JSONSchemaProps
+		if crd, found := crdsByName[owned.Name]; found {
+			if version, found := crd.getVersion(owned.Version); found {
+				schema = version.schema
+			}
+		}
+
+		for _, d := range owned.SpecDescriptors {
+			checks = checkDescriptor(checks, owned.Name, "spec", d.Path, d.XDescriptors, schema)
+		}
+		for _, d := range owned.StatusDescriptors {
+			checks = checkDescriptor(checks, owned.Name, "status", d.Path, d.XDescriptors, schema)
+		}
+	}
+	return checks
+}
+
+// checkDescriptor will verify the x-descriptors and path of the spec or status (field) descriptor informed
+func checkDescriptor(checks OpenShiftOperatorChecks, crdName, field, path string, xDescriptors []string,
+	schema *apiextensionsv1.JSONSchemaProps) OpenShiftOperatorChecks {
+	for _, x := range xDescriptors {
+		if !isKnownXDescriptor(x) {
+			checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the %s descriptor %s of the "+
+				"owned CRD %s uses the x-descriptor %s which is not known by the console. Note that the field will be "+
+				"rendered without it", field, path, crdName, x), "ensure that it has no typos"),
+				"CustomResourceDefinition", crdName))
+		}
+	}
+
+	// The schema is optional for the v1beta1 CRDs
+	if schema == nil || len(path) == 0 {
+		return checks
+	}
+	if !hasSchemaPath(schema, append([]string{field}, strings.Split(path, ".")...)) {
+		checks.warns = append(checks.warns, withSubject(fmt.Errorf("the %s descriptor %s of the owned CRD %s was not "+
+			"found in the schema of the CRD. Note that the console will not be able to show or set this field", field,
+			path, crdName), "CustomResourceDefinition", crdName))
+	}
+	return checks
+}
+
+// isKnownXDescriptor returns true when the x-descriptor informed is interpreted by the console or it is
+// not owned by the console
+func isKnownXDescriptor(x string) bool {
+	owned := false
+	for _, prefix := range consoleXDescriptorPrefixes {
+		owned = owned || strings.HasPrefix(x, prefix)
+	}
+	if !owned {
+		return true
+	}
+	for _, known := range knownXDescriptors {
+		if x == known {
+			return true
+		}
+	}
+	for _, prefix := range knownXDescriptorPrefixes {
+		if strings.HasPrefix(x, prefix) && len(x) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSchemaPath returns true when the fields informed are found in the schema. Note that the fields
+// whose schema preserves the unknown fields or allows additional properties accept any path.
+func hasSchemaPath(schema *apiextensionsv1.JSONSchemaProps, fields []string) bool {
+	for _, field := range fields {
+		if schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields {
+			return true
+		}
+		if schema.AdditionalProperties != nil && (schema.AdditionalProperties.Allows ||
+			schema.AdditionalProperties.Schema != nil) {
+			return true
+		}
+
+		name := descriptorIndexRegexp.ReplaceAllString(field, "")
+		property, found := schema.Properties[name]
+		if !found {
+			return false
+		}
+		schema = &property
+		// The indexes refer to the items of the arrays
+		for i := len(descriptorIndexRegexp.FindAllString(field, -1)); i > 0 && schema.Items != nil &&
+			schema.Items.Schema != nil; i-- {
+			schema = schema.Items.Schema
+		}
+	}
+	return true
+}
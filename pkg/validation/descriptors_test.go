@@ -0,0 +1,105 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DescriptorsValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the descriptors are valid with v1beta1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+			},
+		},
+		{
+			name: "should pass when the descriptor paths are found in the schema",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					owned := &bundle.CSV.Spec.CustomResourceDefinitions.Owned[0]
+					owned.SpecDescriptors = []operatorsv1alpha1.SpecDescriptor{{
+						Path:         "size",
+						XDescriptors: []string{"urn:alm:descriptor:com.tectonic.ui:podCount"},
+					}}
+					owned.StatusDescriptors = []operatorsv1alpha1.StatusDescriptor{{
+						Path:         "nodes[0]",
+						XDescriptors: []string{"urn:alm:descriptor:io.kubernetes:Pod"},
+					}}
+				},
+			},
+		},
+		{
+			name:        "should warn when the x-descriptor is unknown",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate: func(bundle *manifests.Bundle) {
+					owned := &bundle.CSV.Spec.CustomResourceDefinitions.Owned[0]
+					owned.SpecDescriptors[0].XDescriptors = []string{"urn:alm:descriptor:com.tectonic.ui:podcount"}
+				},
+			},
+			warnStrings: []string{"Warning: Value : (etcdoperator.v0.9.4) the spec descriptor size of the owned " +
+				"CRD etcdclusters.etcd.database.coreos.com uses the x-descriptor " +
+				"urn:alm:descriptor:com.tectonic.ui:podcount which is not known by the console. Note that the " +
+				"field will be rendered without it. Please, ensure that it has no typos"},
+		},
+		{
+			name:        "should warn when the descriptor path is not found in the schema",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					owned := &bundle.CSV.Spec.CustomResourceDefinitions.Owned[0]
+					owned.SpecDescriptors = []operatorsv1alpha1.SpecDescriptor{{Path: "sise"}}
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the spec descriptor sise of the " +
+				"owned CRD memcacheds.cache.example.com was not found in the schema of the CRD. Note that the " +
+				"console will not be able to show or set this field"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := DescriptorsValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// almExamplesAnnotation defines the CSV annotation used to inform the examples of the owned CRs which are
+// used by the console to create their instances
+const almExamplesAnnotation = "alm-examples"
+
+// initializationResourceAnnotation defines the CSV annotation used to inform the CR which the console
+// suggests to create right after the installation of the operator
+const initializationResourceAnnotation = "operatorframework.io/initialization-resource"
+
+// ExamplesValidator validates the examples informed by the CSV which are used by the console to guide the
+// users to create the instances of the owned CRDs. Following its current checks:
+//
+// - Ensure that the alm-examples annotation is a JSON array of objects with apiVersion and kind
+//
+// - Warn when the owned CRDs defined under spec.customresourcedefinitions.owned in the CSV have no example
+//
+// - Ensure that the operatorframework.io/initialization-resource annotation is a JSON object whose apiVersion
+// and kind are owned by the CSV
+var ExamplesValidator interfaces.Validator = newBundleValidator("examples", checkALMExamples,
+	checkInitializationResource)
+
+// checkALMExamples will verify the alm-examples annotation against the owned CRDs
+func checkALMExamples(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	examples, err := getALMExamples(checks)
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+
+	found := map[string]bool{}
+	for i, example := range examples {
+		if len(example.GetAPIVersion()) == 0 || len(example.GetKind()) == 0 {
+			checks.errs = append(checks.errs, fmt.Errorf("the example [%d] informed via the annotation %s does "+
+				"not have the apiVersion and kind", i, almExamplesAnnotation))
+			continue
+		}
+		found[example.GetAPIVersion()+"/"+example.GetKind()] = true
+	}
+
+	for _, owned := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+		apiVersion := getOwnedAPIVersion(owned)
+		if !found[apiVersion+"/"+owned.Kind] {
+			checks.warns = append(checks.warns, withSubject(fmt.Errorf("the owned CRD %s has no example for the kind "+
+				"%s and apiVersion %s in the annotation %s. Note that the console uses it to create its instances",
+				owned.Name, owned.Kind, apiVersion, almExamplesAnnotation), "CustomResourceDefinition", owned.Name))
+		}
+	}
+	return checks
+}
+
+// checkInitializationResource will verify that the CR informed via the initialization-resource annotation
+// can be created by the console
+func checkInitializationResource(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	value, ok := checks.bundle.CSV.GetAnnotations()[initializationResourceAnnotation]
+	if !ok {
+		return checks
+	}
+
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("the annotation %s must be a JSON object: %s. Note that "+
+			"the console will not be able to guide the initialization of the operator",
+			initializationResourceAnnotation, err))
+		return checks
+	}
+	resource := unstructured.Unstructured{Object: obj}
+	if len(resource.GetAPIVersion()) == 0 || len(resource.GetKind()) == 0 {
+		checks.errs = append(checks.errs, fmt.Errorf("the resource informed via the annotation %s does not "+
+			"have the apiVersion and kind", initializationResourceAnnotation))
+		return checks
+	}
+
+	for _, owned := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+		if getOwnedAPIVersion(owned) == resource.GetAPIVersion() && owned.Kind == resource.GetKind() {
+			return checks
+		}
+	}
+	checks.errs = append(checks.errs, fmt.Errorf("the resource informed via the annotation %s has the kind %s "+
+		"and apiVersion %s which are not defined under spec.customresourcedefinitions.owned in the CSV",
+		initializationResourceAnnotation, resource.GetKind(), resource.GetAPIVersion()))
+	return checks
+}
+
+// getOwnedAPIVersion returns the apiVersion (group/version) of the owned CRD informed
+func getOwnedAPIVersion(owned operatorsv1alpha1.CRDDescription) string {
+	if nameParts := strings.SplitN(owned.Name, ".", 2); len(nameParts) == 2 {
+		return nameParts[1] + "/" + owned.Version
+	}
+	return owned.Version
+}
+
+// getALMExamples returns the examples informed via the alm-examples annotation
+func getALMExamples(checks OpenShiftOperatorChecks) ([]unstructured.Unstructured, error) {
+	value, ok := checks.bundle.CSV.GetAnnotations()[almExamplesAnnotation]
+	if !ok || len(strings.TrimSpace(value)) == 0 {
+		return nil, nil
+	}
+	var objs []map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &objs); err != nil {
+		return nil, fmt.Errorf("the annotation %s must be a JSON array of objects: %s", almExamplesAnnotation, err)
+	}
+	var examples []unstructured.Unstructured
+	for _, obj := range objs {
+		examples = append(examples, unstructured.Unstructured{Object: obj})
+	}
+	return examples, nil
+}
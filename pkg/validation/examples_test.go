@@ -0,0 +1,131 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ExamplesValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the owned CRDs have examples with v1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the owned CRDs have examples with v1beta1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+			},
+		},
+		{
+			name:      "should fail when the examples are not a JSON array",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[almExamplesAnnotation] = `{"kind": "Memcached"}`
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation alm-examples must be a " +
+				"JSON array of objects: json: cannot unmarshal object into Go value of type []map[string]interface {}"},
+		},
+		{
+			name:        "should fail when the example has no kind and the owned CRD has no example",
+			wantError:   true,
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[almExamplesAnnotation] = `[{"apiVersion": "cache.example.com/v1alpha1"}]`
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the example [0] informed via the " +
+				"annotation alm-examples does not have the apiVersion and kind"},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the owned CRD " +
+				"memcacheds.cache.example.com has no example for the kind Memcached and apiVersion " +
+				"cache.example.com/v1alpha1 in the annotation alm-examples. Note that the console uses it to " +
+				"create its instances"},
+		},
+		{
+			name: "should pass when the initialization resource is owned by the CSV",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[initializationResourceAnnotation] = `{"apiVersion": ` +
+						`"cache.example.com/v1alpha1", "kind": "Memcached", "metadata": {"name": "memcached-sample"}}`
+				},
+			},
+		},
+		{
+			name:      "should fail when the initialization resource is not a JSON object",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[initializationResourceAnnotation] = `[{"kind": "Memcached"}]`
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operatorframework.io/initialization-resource must be a JSON object: json: cannot unmarshal array " +
+				"into Go value of type map[string]interface {}. Note that the console will not be able to guide " +
+				"the initialization of the operator"},
+		},
+		{
+			name:      "should fail when the initialization resource is not owned by the CSV",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[initializationResourceAnnotation] = `{"apiVersion": ` +
+						`"cache.example.com/v1", "kind": "Memcached"}`
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the resource informed via the " +
+				"annotation operatorframework.io/initialization-resource has the kind Memcached and apiVersion " +
+				"cache.example.com/v1 which are not defined under spec.customresourcedefinitions.owned in the CSV"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := ExamplesValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// The annotations used by the CSV to inform that the operator supports disconnected environments
+const (
+	infrastructureFeaturesAnnotation = "operators.openshift.io/infrastructure-features"
+	disconnectedFeatureAnnotation    = "features.operators.openshift.io/disconnected"
+)
+
+// validSubscriptionAnnotation defines the annotation used to inform the subscriptions required to use the
+// operator which are shown in the console
+const validSubscriptionAnnotation = "operators.openshift.io/valid-subscription"
+
+// featureAnnotationPrefix defines the prefix of the annotations used to inform each feature supported
+const featureAnnotationPrefix = "features.operators.openshift.io/"
+
+// featureAnnotationsMinOCPVersion defines the OCP version from which the features annotations replace the
+// operators.openshift.io/infrastructure-features annotation
+const featureAnnotationsMinOCPVersion = "4.14"
+
+// knownFeatureAnnotations defines the features which can be informed via the
+// features.operators.openshift.io/<feature> annotations
+var knownFeatureAnnotations = []string{"disconnected", "fips-compliant", "proxy-aware", "tls-profiles",
+	"token-auth-aws", "token-auth-azure", "token-auth-gcp", "cnf", "cni", "csi"}
+
+// disconnectedFeature defines the value of the infrastructure features for the disconnected support
+const disconnectedFeature = "disconnected"
+
+// knownInfrastructureFeatures defines the values which can be informed via the
+// operators.openshift.io/infrastructure-features annotation
+var knownInfrastructureFeatures = []string{disconnectedFeature, "proxy-aware", "fips", "tls-profiles", "cnf",
+	"csi", "sno", "hcp"}
+
+// FeaturesValidator validates the annotations used by the CSV to inform the features supported by the
+// operator on OpenShift. Following its current checks:
+//
+// - Ensure that the operators.openshift.io/infrastructure-features annotation is a JSON array of strings
+// and warn when its values are unknown. Note that the disconnected claim is checked against the related
+// images by the RelatedImagesValidator.
+//
+// - Ensure that the features.operators.openshift.io/<feature> annotations are "true" or "false" and warn
+// when the feature is unknown or when only the deprecated operators.openshift.io/infrastructure-features
+// annotation is used by bundles which target OCP 4.14 or upper versions.
+//
+// - Ensure that the operators.openshift.io/valid-subscription annotation is a JSON array of non-empty strings
+// and warn when it is not informed by bundles checked with the certified profile.
+var FeaturesValidator interfaces.Validator = newBundleValidator("features", checkInfrastructureFeatures,
+	checkFeatureAnnotations, checkValidSubscription)
+
+// checkInfrastructureFeatures will verify the operators.openshift.io/infrastructure-features annotation
+func checkInfrastructureFeatures(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	value, ok := checks.bundle.CSV.GetAnnotations()[infrastructureFeaturesAnnotation]
+	if !ok {
+		return checks
+	}
+
+	var features []string
+	if err := json.Unmarshal([]byte(value), &features); err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("the annotation %s must be a JSON array of strings "+
+			"(e.g. '[\"disconnected\", \"proxy-aware\"]'): %s", infrastructureFeaturesAnnotation, err))
+		return checks
+	}
+
+	for _, feature := range features {
+		if !containsFold(knownInfrastructureFeatures, feature) {
+			checks.warns = append(checks.warns, fmt.Errorf("the feature (%s) informed via the annotation %s is "+
+				"unknown. The known features are: %s", feature, infrastructureFeaturesAnnotation,
+				strings.Join(knownInfrastructureFeatures, ", ")))
+		}
+	}
+	return checks
+}
+
+// checkFeatureAnnotations will verify the features.operators.openshift.io/<feature> annotations
+func checkFeatureAnnotations(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	annotations := checks.bundle.CSV.GetAnnotations()
+
+	var keys []string
+	for key := range annotations {
+		if strings.HasPrefix(key, featureAnnotationPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		feature := strings.TrimPrefix(key, featureAnnotationPrefix)
+		if !containsAny(knownFeatureAnnotations, feature) {
+			checks.warns = append(checks.warns, fmt.Errorf("the annotation %s informs an unknown feature. "+
+				"The known features are: %s", key, strings.Join(knownFeatureAnnotations, ", ")))
+		}
+		if value := annotations[key]; value != "true" && value != "false" {
+			checks.errs = append(checks.errs, fmt.Errorf("the annotation %s has the value (%s) but it must be "+
+				"\"true\" or \"false\"", key, value))
+		}
+	}
+
+	if _, ok := annotations[infrastructureFeaturesAnnotation]; !ok || len(keys) > 0 {
+		return checks
+	}
+
+	// when the OCP range is not informed we assume that the bundle targets the latest versions
+	if ocpRange := getOCPRange(checks); len(ocpRange) > 0 {
+		upper, err := rangeAllowsVersionOrUpper(ocpRange, featureAnnotationsMinOCPVersion)
+		if err != nil || !upper {
+			return checks
+		}
+	}
+	checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the annotation %s is deprecated for bundles which "+
+		"target OCP %s or upper versions", infrastructureFeaturesAnnotation, featureAnnotationsMinOCPVersion),
+		"use the %s<feature> annotations instead (e.g. %sdisconnected: \"true\")", featureAnnotationPrefix,
+		featureAnnotationPrefix))
+	return checks
+}
+
+// checkValidSubscription will verify the operators.openshift.io/valid-subscription annotation
+func checkValidSubscription(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	value, ok := checks.bundle.CSV.GetAnnotations()[validSubscriptionAnnotation]
+	if !ok {
+		if checks.profile == CertifiedProfile {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the annotation %s was not found",
+				validSubscriptionAnnotation),
+				"inform the subscriptions required to use the operator (e.g. '[\"OpenShift Container Platform\"]') "+
+					"since they are shown in the console"))
+		}
+		return checks
+	}
+
+	var subscriptions []string
+	if err := json.Unmarshal([]byte(value), &subscriptions); err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("the annotation %s must be a JSON array of strings "+
+			"(e.g. '[\"OpenShift Container Platform\"]'): %s", validSubscriptionAnnotation, err))
+		return checks
+	}
+	for i, subscription := range subscriptions {
+		if len(strings.TrimSpace(subscription)) == 0 {
+			checks.errs = append(checks.errs, fmt.Errorf("the annotation %s has an empty value at the index %d",
+				validSubscriptionAnnotation, i))
+		}
+	}
+	return checks
+}
+
+// supportsDisconnected returns true when the CSV claims to support disconnected environments
+func supportsDisconnected(csv *operatorsv1alpha1.ClusterServiceVersion) bool {
+	return claimsFeature(csv, disconnectedFeature, disconnectedFeature)
+}
+
+// claimsFeature returns true when the CSV claims to support the feature informed via the
+// features.operators.openshift.io/<feature> annotation or the legacy feature informed via the
+// operators.openshift.io/infrastructure-features annotation
+func claimsFeature(csv *operatorsv1alpha1.ClusterServiceVersion, feature, legacyFeature string) bool {
+	annotations := csv.GetAnnotations()
+	if strings.EqualFold(annotations[featureAnnotationPrefix+feature], "true") {
+		return true
+	}
+	var features []string
+	if err := json.Unmarshal([]byte(annotations[infrastructureFeaturesAnnotation]), &features); err != nil {
+		return false
+	}
+	return containsFold(features, legacyFeature)
+}
+
+// containsFold returns true when the list has the value informed ignoring the case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
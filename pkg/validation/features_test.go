@@ -0,0 +1,180 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FeaturesValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		ocpRange  string
+		profile   string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the CSV does not inform the features",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the infrastructure features are known",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				ocpRange:  "v4.10-v4.13",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["Disconnected", "proxy-aware", "FIPS"]`
+				},
+			},
+		},
+		{
+			name:      "should fail when the infrastructure features are not a JSON array",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				ocpRange:  "v4.10-v4.13",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = "disconnected, proxy-aware"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operators.openshift.io/infrastructure-features must be a JSON array of strings " +
+				"(e.g. '[\"disconnected\", \"proxy-aware\"]'): invalid character 'd' looking for beginning of value"},
+		},
+		{
+			name:        "should warn when the infrastructure feature is unknown",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				ocpRange:  "v4.10-v4.13",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["disconnected", "proxy"]`
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the feature (proxy) informed via " +
+				"the annotation operators.openshift.io/infrastructure-features is unknown. The known features are: " +
+				"disconnected, proxy-aware, fips, tls-profiles, cnf, csi, sno, hcp"},
+		},
+		{
+			name: "should pass when the features annotations are valid",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				ocpRange:  "v4.14",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["disconnected"]`
+					bundle.CSV.Annotations[disconnectedFeatureAnnotation] = "true"
+					bundle.CSV.Annotations["features.operators.openshift.io/token-auth-aws"] = "false"
+				},
+			},
+		},
+		{
+			name:        "should fail when the features annotations are not boolean or unknown",
+			wantError:   true,
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[disconnectedFeatureAnnotation] = "yes"
+					bundle.CSV.Annotations["features.operators.openshift.io/sno"] = "true"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"features.operators.openshift.io/disconnected has the value (yes) but it must be \"true\" or \"false\""},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the annotation " +
+				"features.operators.openshift.io/sno informs an unknown feature. The known features are: " +
+				"disconnected, fips-compliant, proxy-aware, tls-profiles, token-auth-aws, token-auth-azure, " +
+				"token-auth-gcp, cnf, cni, csi"},
+		},
+		{
+			name:        "should warn when only the deprecated annotation is used to target OCP 4.14",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				ocpRange:  "v4.12-v4.14",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["disconnected"]`
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operators.openshift.io/infrastructure-features is deprecated for bundles which target OCP 4.14 " +
+				"or upper versions. Please, use the features.operators.openshift.io/<feature> annotations " +
+				"instead (e.g. features.operators.openshift.io/disconnected: \"true\")"},
+		},
+		{
+			name: "should pass when the valid subscriptions are informed with the certified profile",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CertifiedProfile,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[validSubscriptionAnnotation] = `["OpenShift Container Platform"]`
+				},
+			},
+		},
+		{
+			name:      "should fail when the valid subscriptions are not a JSON array of non-empty strings",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[validSubscriptionAnnotation] = `["OpenShift Container Platform", " "]`
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operators.openshift.io/valid-subscription has an empty value at the index 1"},
+		},
+		{
+			name:        "should warn when the valid subscriptions are not informed with the certified profile",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CertifiedProfile,
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operators.openshift.io/valid-subscription was not found. Please, inform the subscriptions " +
+				"required to use the operator (e.g. '[\"OpenShift Container Platform\"]') since they are shown " +
+				"in the console"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := FeaturesValidator.Validate(bundle, map[string]string{
+				RangeKey:   tt.args.ocpRange,
+				ProfileKey: tt.args.profile,
+			})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// The features used to claim that the operator is FIPS compliant via the features.operators.openshift.io/<feature>
+// and the operators.openshift.io/infrastructure-features annotations
+const (
+	fipsCompliantFeature = "fips-compliant"
+	fipsLegacyFeature    = "fips"
+)
+
+// nonFIPSBases defines the base images which cannot provide the FIPS validated cryptographic modules
+var nonFIPSBases = []string{"alpine", "scratch", "busybox"}
+
+// redHatLabelPrefix defines the prefix of the labels defined by the Red Hat (RHEL and UBI) base images
+const redHatLabelPrefix = "com.redhat."
+
+// FIPSValidator validates the bundles which claim to be FIPS compliant via the
+// features.operators.openshift.io/fips-compliant or the operators.openshift.io/infrastructure-features
+// annotations. The findings are reported as warnings and as errors for the strict profiles (certified,
+// redhat and marketplace). Following its current checks:
+//
+// - Ensure that the images used by the deployments are pinned by digest
+//
+// - Ensure that the images used by the deployments are not based on images which cannot use the FIPS
+// validated modules of RHEL (e.g. alpine or scratch). Note that the base is inferred from the image name
+// and tag.
+//
+// - Ensure that the images used by the deployments have the labels of the Red Hat base images (com.redhat.*).
+// Note that this check is only performed when the optional value check-images=true is informed since it
+// requires access to the registries.
+var FIPSValidator interfaces.Validator = newBundleValidator("fips", checkFIPSClaim)
+
+// checkFIPSClaim will look for evidence that the FIPS compliance claimed is false
+func checkFIPSClaim(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if !claimsFeature(checks.bundle.CSV, fipsCompliantFeature, fipsLegacyFeature) {
+		return checks
+	}
+
+	checked := map[string]bool{}
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			if checked[c.Image] {
+				continue
+			}
+			checked[c.Image] = true
+
+			if !isDigestReference(c.Image) {
+				checks = addStrictFinding(checks, withSubject(fmt.Errorf("the operator claims to be FIPS compliant "+
+					"but the image %s of the deployment %s is not pinned by digest", c.Image, dep.Name),
+					"Deployment", dep.Name))
+			}
+			if base := getNonFIPSBase(c.Image); len(base) > 0 {
+				checks = addStrictFinding(checks, withSubject(withRemediation(fmt.Errorf("the operator claims to be "+
+					"FIPS compliant but the image %s of the deployment %s seems to be based on %s which cannot use "+
+					"the FIPS validated cryptographic modules", c.Image, dep.Name, base),
+					"use a RHEL or UBI based image"), "Deployment", dep.Name))
+			}
+			if checks.checkImages {
+				checks = checkFIPSImageLabels(checks, c.Image)
+			}
+		}
+	}
+	return checks
+}
+
+// checkFIPSImageLabels will verify that the image informed has the labels of the Red Hat base images
+func checkFIPSImageLabels(checks OpenShiftOperatorChecks, image string) OpenShiftOperatorChecks {
+	labels, err := getImageInspector().GetLabels(image)
+	if err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to inspect the labels of the image %s: %s",
+			image, err))
+		return checks
+	}
+	for label := range labels {
+		if strings.HasPrefix(label, redHatLabelPrefix) {
+			return checks
+		}
+	}
+	return addStrictFinding(checks, withRemediation(fmt.Errorf("the operator claims to be FIPS compliant but the "+
+		"image %s has none of the labels of the Red Hat base images (%s*)", image, redHatLabelPrefix),
+		"use a RHEL or UBI based image"))
+}
+
+// getNonFIPSBase returns the base which cannot provide the FIPS validated modules found in the name or tag
+// of the image informed or an empty value when none is found
+func getNonFIPSBase(image string) string {
+	value := image
+	if ref, err := reference.ParseNormalizedNamed(image); err == nil {
+		value = reference.Path(ref)
+		if tagged, ok := ref.(reference.Tagged); ok {
+			value = value + ":" + tagged.Tag()
+		}
+	}
+	for _, base := range nonFIPSBases {
+		if strings.Contains(strings.ToLower(value), base) {
+			return base
+		}
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+// etcdOperatorImage defines the image used by the deployments of the etcd bundle
+const etcdOperatorImage = "quay.io/coreos/etcd-operator@sha256:" +
+	"66a37fd61a06a43969854ee6d3e21087a98b93838e284a6086b13917f96b0d9b"
+
+// fakeLabelsInspector implements registry.Inspector with the labels informed by image
+type fakeLabelsInspector struct {
+	fakeInspector
+	labels map[string]map[string]string
+}
+
+func (f fakeLabelsInspector) GetLabels(image string) (map[string]string, error) {
+	if labels, ok := f.labels[image]; ok {
+		return labels, nil
+	}
+	return nil, fmt.Errorf("manifest unknown")
+}
+
+func Test_FIPSValidator(t *testing.T) {
+	type args struct {
+		bundleDir   string
+		checkImages bool
+		profile     string
+		labels      map[string]string
+		mutate      func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the FIPS compliance is not claimed",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the FIPS compliance is claimed and the images are pinned and RHEL based",
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1beta1",
+				checkImages: true,
+				labels:      map[string]string{"com.redhat.component": "ubi9-minimal-container"},
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations["features.operators.openshift.io/fips-compliant"] = "true"
+				},
+			},
+		},
+		{
+			name:      "should fail when the FIPS compliance is claimed with a tag of an alpine image",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				profile:   CertifiedProfile,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["fips"]`
+					for i := range bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+						spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[i].Spec.Template.Spec
+						for j := range spec.Containers {
+							spec.Containers[j].Image = "quay.io/coreos/etcd-operator:v0.9.4-alpine"
+						}
+					}
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (etcdoperator.v0.9.4) the operator claims to be FIPS compliant but the image " +
+					"quay.io/coreos/etcd-operator:v0.9.4-alpine of the deployment etcd-operator is not pinned by digest",
+				"Error: Value : (etcdoperator.v0.9.4) the operator claims to be FIPS compliant but the image " +
+					"quay.io/coreos/etcd-operator:v0.9.4-alpine of the deployment etcd-operator seems to be based " +
+					"on alpine which cannot use the FIPS validated cryptographic modules. Please, use a RHEL or UBI " +
+					"based image",
+			},
+		},
+		{
+			name:        "should warn when the FIPS compliance is claimed and the image is not RHEL based",
+			wantWarning: true,
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1beta1",
+				checkImages: true,
+				labels:      map[string]string{"maintainer": "CoreOS"},
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations["features.operators.openshift.io/fips-compliant"] = "true"
+				},
+			},
+			warnStrings: []string{"Warning: Value : (etcdoperator.v0.9.4) the operator claims to be FIPS compliant " +
+				"but the image " + etcdOperatorImage + " has none of the labels of the Red Hat base images " +
+				"(com.redhat.*). Please, use a RHEL or UBI based image"},
+		},
+	}
+
+	defaultInspector := imageInspector
+	defer func() { imageInspector = defaultInspector }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageInspector = fakeLabelsInspector{labels: map[string]map[string]string{etcdOperatorImage: tt.args.labels}}
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			optionalValues := map[string]string{ProfileKey: tt.args.profile}
+			if tt.args.checkImages {
+				optionalValues[CheckImagesKey] = "true"
+			}
+			results := FIPSValidator.Validate(bundle, optionalValues)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
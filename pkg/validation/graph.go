@@ -0,0 +1,183 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/graph"
+)
+
+// GetUpgradeGraph returns the upgrade graph of the package of the bundles informed by directory. The bundles
+// informed are colored by the results of the OpenShiftValidator, which checks their OCP compatibility. The
+// channels are read from the annotations of the bundles or, for the legacy PackageManifest format, from the
+// package manifest. The bundles of the package found in the File-Based Catalog informed via the CatalogKey are
+// also added and colored by their olm.maxOpenShiftVersion.
+func GetUpgradeGraph(bundles map[string]*manifests.Bundle, optionalValues map[string]string) (*graph.Graph,
+	error) {
+	var dirs []string
+	for dir := range bundles {
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("unable to build the upgrade graph because no bundle was informed")
+	}
+	sort.Strings(dirs)
+
+	var g *graph.Graph
+	var ocpRange string
+	for _, dir := range dirs {
+		checks := OpenShiftOperatorChecks{bundle: *bundles[dir], bundlePath: dir,
+			filePath: optionalValues[FilePathKey], labelRange: optionalValues[RangeKey]}
+		if g == nil {
+			var err error
+			if g, err = newUpgradeGraph(checks); err != nil {
+				return nil, err
+			}
+			ocpRange = getOCPRange(checks)
+		}
+		addGraphBundle(g, checks, optionalValues)
+	}
+
+	if len(optionalValues[CatalogKey]) > 0 && len(g.Package) > 0 {
+		if err := addGraphCatalog(g, optionalValues[CatalogKey], ocpRange); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// newUpgradeGraph returns the graph of the package of the bundle informed. The channels of the package are
+// added when the bundle is a version of the legacy PackageManifest format.
+func newUpgradeGraph(checks OpenShiftOperatorChecks) (*graph.Graph, error) {
+	if packageName := getPackageName(checks); len(packageName) > 0 {
+		return graph.New(packageName), nil
+	}
+	pkg, err := getPackageManifest(filepath.Dir(filepath.Clean(checks.bundlePath)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the package manifest of the bundle %s: %s", checks.bundlePath, err)
+	}
+	if pkg == nil {
+		return graph.New(""), nil
+	}
+	g := graph.New(pkg.PackageName)
+	g.DefaultChannel = pkg.DefaultChannelName
+	for _, ch := range pkg.Channels {
+		g.AddChannelEntry(ch.Name, ch.CurrentCSVName)
+	}
+	return g, nil
+}
+
+// addGraphBundle adds the bundle informed with the verdict of the OpenShiftValidator and its upgrades
+func addGraphBundle(g *graph.Graph, checks OpenShiftOperatorChecks, optionalValues map[string]string) {
+	values := map[string]string{BundlePathKey: checks.bundlePath}
+	for k, v := range optionalValues {
+		values[k] = v
+	}
+	verdict, reason := graph.VerdictCompatible, ""
+	for _, result := range OpenShiftValidator.Validate(&checks.bundle, values) {
+		switch {
+		case result.HasError():
+			verdict, reason = graph.VerdictIncompatible, result.Errors[0].Detail
+		case result.HasWarn() && verdict != graph.VerdictIncompatible:
+			verdict, reason = graph.VerdictWarning, result.Warnings[0].Detail
+		}
+	}
+	csv := checks.bundle.CSV
+	g.AddNode(csv.GetName(), verdict, reason)
+
+	channels, defaultChannel := getBundleChannels(checks)
+	if len(defaultChannel) > 0 && len(g.DefaultChannel) == 0 {
+		g.DefaultChannel = defaultChannel
+	}
+	if len(channels) == 0 {
+		channels = []string{""}
+	}
+	for _, ch := range channels {
+		if len(ch) > 0 {
+			g.AddChannelEntry(ch, csv.GetName())
+		}
+		if len(csv.Spec.Replaces) > 0 {
+			g.AddEdge(csv.Spec.Replaces, csv.GetName(), ch, false)
+		}
+		for _, skip := range csv.Spec.Skips {
+			g.AddEdge(skip, csv.GetName(), ch, true)
+		}
+	}
+}
+
+// addGraphCatalog adds the channels and bundles of the package of the graph found in the catalog informed. The
+// bundles which block the cluster upgrade via olm.maxOpenShiftVersion are reported as incompatible when the
+// package is distributed to upper OCP versions (ocpRange) and as warnings otherwise.
+func addGraphCatalog(g *graph.Graph, catalog, ocpRange string) error {
+	cfg, err := declcfg.LoadFS(os.DirFS(catalog))
+	if err != nil {
+		return fmt.Errorf("unable to load the catalog %s: %s", catalog, err)
+	}
+	for _, pkg := range cfg.Packages {
+		if pkg.Name == g.Package && len(g.DefaultChannel) == 0 {
+			g.DefaultChannel = pkg.DefaultChannel
+		}
+	}
+
+	for _, b := range cfg.Bundles {
+		if b.Package != g.Package {
+			continue
+		}
+		verdict, reason := graph.VerdictCompatible, ""
+		props, err := property.Parse(b.Properties)
+		if err != nil {
+			g.AddNode(b.Name, graph.VerdictUnknown, fmt.Sprintf("unable to parse its properties: %s", err))
+			continue
+		}
+		if maxVersion := getMaxOpenShiftVersionProperty(props); len(maxVersion) > 0 {
+			verdict, reason = graph.VerdictWarning, fmt.Sprintf("it blocks the cluster upgrade via %s (%s)",
+				maxOpenShiftVersionProperty, maxVersion)
+			if v, err := semver.ParseTolerant(maxVersion); err == nil && len(ocpRange) > 0 {
+				next := fmt.Sprintf("%d.%d", v.Major, v.Minor+1)
+				if upper, err := rangeAllowsVersionOrUpper(ocpRange, next); err == nil && upper {
+					verdict = graph.VerdictIncompatible
+					reason += fmt.Sprintf(" while the package is distributed to OCP %s", ocpRange)
+				}
+			}
+		}
+		g.AddNode(b.Name, verdict, reason)
+	}
+
+	for _, ch := range cfg.Channels {
+		if ch.Package != g.Package {
+			continue
+		}
+		for _, entry := range ch.Entries {
+			g.AddChannelEntry(ch.Name, entry.Name)
+			if len(entry.Replaces) > 0 {
+				g.AddEdge(entry.Replaces, entry.Name, ch.Name, false)
+			}
+			for _, skip := range entry.Skips {
+				g.AddEdge(strings.TrimSpace(skip), entry.Name, ch.Name, true)
+			}
+		}
+	}
+	return nil
+}
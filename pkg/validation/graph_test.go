@@ -0,0 +1,131 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/graph"
+)
+
+const graphCatalog = `---
+schema: olm.package
+name: memcached-operator
+defaultChannel: stable
+---
+schema: olm.channel
+name: alpha
+package: memcached-operator
+entries:
+- name: memcached-operator.v0.0.0
+- name: memcached-operator.v0.0.0-rc
+  skips:
+  - memcached-operator.v0.0.0
+---
+schema: olm.bundle
+name: memcached-operator.v0.0.0
+package: memcached-operator
+image: quay.io/example/memcached-operator-bundle:v0.0.0
+properties:
+- type: olm.package
+  value:
+    packageName: memcached-operator
+    version: 0.0.0
+- type: olm.maxOpenShiftVersion
+  value: "4.12"
+---
+schema: olm.bundle
+name: memcached-operator.v0.0.0-rc
+package: memcached-operator
+image: quay.io/example/memcached-operator-bundle:v0.0.0-rc
+properties:
+- type: olm.package
+  value:
+    packageName: memcached-operator
+    version: 0.0.0-rc
+`
+
+func TestGetUpgradeGraph(t *testing.T) {
+	catalog := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(catalog, "index.yaml"), []byte(graphCatalog), 0o644))
+
+	bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+	require.NoError(t, err)
+	bundle.Package = "memcached-operator"
+	bundle.Channels = []string{"alpha"}
+	bundle.CSV.Spec.Replaces = "memcached-operator.v0.0.0-rc"
+
+	g, err := GetUpgradeGraph(map[string]*manifests.Bundle{"./testdata/valid_bundle_v1": bundle},
+		map[string]string{CatalogKey: catalog, RangeKey: "v4.12-v4.15"})
+	require.NoError(t, err)
+	require.Equal(t, "memcached-operator", g.Package)
+	require.Equal(t, "stable", g.DefaultChannel)
+	require.Equal(t, []string{"memcached-operator.v0.0.1"}, g.Heads("alpha"))
+	require.Equal(t, []graph.Node{
+		{Name: "memcached-operator.v0.0.0", Verdict: graph.VerdictIncompatible, Reason: "it blocks the cluster " +
+			"upgrade via olm.maxOpenShiftVersion (4.12) while the package is distributed to OCP v4.12-v4.15"},
+		{Name: "memcached-operator.v0.0.0-rc", Verdict: graph.VerdictCompatible},
+		{Name: "memcached-operator.v0.0.1", Verdict: graph.VerdictCompatible},
+	}, g.Nodes())
+	require.Equal(t, []graph.Edge{
+		{From: "memcached-operator.v0.0.0", To: "memcached-operator.v0.0.0-rc", Channels: []string{"alpha"},
+			Skip: true},
+		{From: "memcached-operator.v0.0.0-rc", To: "memcached-operator.v0.0.1", Channels: []string{"alpha"}},
+	}, g.Edges())
+
+	// Without the range the bundles which block the cluster upgrade are reported as warnings
+	g, err = GetUpgradeGraph(map[string]*manifests.Bundle{"./testdata/valid_bundle_v1": bundle},
+		map[string]string{CatalogKey: catalog})
+	require.NoError(t, err)
+	require.Equal(t, graph.VerdictWarning, g.Nodes()[0].Verdict)
+
+	_, err = GetUpgradeGraph(map[string]*manifests.Bundle{"./testdata/valid_bundle_v1": bundle},
+		map[string]string{CatalogKey: filepath.Join(catalog, "invalid")})
+	require.Error(t, err)
+	_, err = GetUpgradeGraph(nil, nil)
+	require.EqualError(t, err, "unable to build the upgrade graph because no bundle was informed")
+}
+
+func TestGetUpgradeGraph_PackageManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "memcached.package.yaml"), []byte(`packageName: memcached
+defaultChannel: alpha
+channels:
+- name: alpha
+  currentCSV: memcached-operator.v0.0.1
+`), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "0.0.1"), 0o755))
+
+	bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+	require.NoError(t, err)
+	bundle.CSV.Spec.Replaces = "memcached-operator.v0.0.0"
+
+	g, err := GetUpgradeGraph(map[string]*manifests.Bundle{filepath.Join(dir, "0.0.1"): bundle}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "memcached", g.Package)
+	require.Equal(t, "alpha", g.DefaultChannel)
+	require.Equal(t, []string{"memcached-operator.v0.0.1"}, g.Heads("alpha"))
+	require.Equal(t, []graph.Node{
+		{Name: "memcached-operator.v0.0.0", Verdict: graph.VerdictUnknown},
+		{Name: "memcached-operator.v0.0.1", Verdict: graph.VerdictCompatible},
+	}, g.Nodes())
+	require.Equal(t, []graph.Edge{{From: "memcached-operator.v0.0.0", To: "memcached-operator.v0.0.1"}}, g.Edges())
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// machineConfigAPIGroup defines the apiGroup of the MachineConfig APIs which are not available to configure
+// the nodes of the hosted control planes clusters
+const machineConfigAPIGroup = "machineconfiguration.openshift.io"
+
+// topologyFeatures defines the features used to claim the support for the Single Node OpenShift (SNO)
+// and the hosted control planes (HCP) topologies
+var topologyFeatures = []string{"sno", "hcp"}
+
+// HostedControlPlanesValidator validates the bundles against the constraints of the hosted control planes
+// topology (HyperShift and ROSA with HCP), where the nodes are managed via NodePools outside the cluster.
+// Following its current checks:
+//
+// - Warn when the operator can only be installed cluster-scoped (AllNamespaces) and its deployments
+// require node-level privileges (hostNetwork, hostPID, hostPath volumes or privileged containers)
+//
+// - Warn when the operator relies on the MachineConfig APIs (machineconfiguration.openshift.io) via its
+// permissions or the manifests shipped in the bundle
+//
+// - Warn when the SNO or HCP support is claimed via the operators.openshift.io/infrastructure-features
+// annotation but the operator does not support the AllNamespaces or OwnNamespace install modes
+var HostedControlPlanesValidator interfaces.Validator = newBundleValidator("hosted-control-planes",
+	checkHostedNodePrivileges, checkMachineConfigAPIs, checkTopologyClaims)
+
+// checkHostedNodePrivileges will look for cluster-scoped operators which require node-level privileges
+func checkHostedNodePrivileges(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if !supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeAllNamespaces) ||
+		supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeOwnNamespace) ||
+		supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeSingleNamespace) ||
+		supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeMultiNamespace) {
+		return checks
+	}
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		privileged := getPrivilegedSettings(dep.Spec.Template.Spec)
+		if len(privileged) == 0 {
+			continue
+		}
+		checks.warns = append(checks.warns, withSubject(fmt.Errorf("the deployment %s requires node-level privileges "+
+			"(%s) and the operator can only be installed with the AllNamespaces install mode. Note that on hosted "+
+			"control planes (HyperShift and ROSA with HCP) the nodes are managed outside the cluster and node-level "+
+			"access might be restricted", dep.Name, strings.Join(privileged, ", ")), "Deployment", dep.Name))
+	}
+	return checks
+}
+
+// checkMachineConfigAPIs will look for permissions and manifests of the MachineConfig APIs
+func checkMachineConfigAPIs(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	strategy := checks.bundle.CSV.Spec.InstallStrategy.StrategySpec
+	checks = checkMachineConfigRules(checks, "permissions", strategy.Permissions)
+	checks = checkMachineConfigRules(checks, "clusterPermissions", strategy.ClusterPermissions)
+
+	for _, obj := range checks.bundle.Objects {
+		if !strings.HasPrefix(obj.GetAPIVersion(), machineConfigAPIGroup+"/") {
+			continue
+		}
+		checks.warns = append(checks.warns, fmt.Errorf("the %s %s shipped in the bundle uses the MachineConfig "+
+			"APIs (%s). Note that they are not available on hosted control planes (HyperShift and ROSA with HCP) "+
+			"where the nodes are configured via NodePools", obj.GetKind(), obj.GetName(), machineConfigAPIGroup))
+	}
+	return checks
+}
+
+// checkMachineConfigRules will look for rules of the MachineConfig APIs in the permissions informed
+func checkMachineConfigRules(checks OpenShiftOperatorChecks, field string,
+	permissions []operatorsv1alpha1.StrategyDeploymentPermissions) OpenShiftOperatorChecks {
+	for _, perm := range permissions {
+		for i, rule := range perm.Rules {
+			if !containsAny(rule.APIGroups, machineConfigAPIGroup) {
+				continue
+			}
+			checks.warns = append(checks.warns, withSubject(fmt.Errorf("the rule [%d] of the service account %s under "+
+				"spec.install.spec.%s in the CSV grants access to the MachineConfig APIs (%s). Note that they are not "+
+				"available on hosted control planes (HyperShift and ROSA with HCP) where the nodes are configured via "+
+				"NodePools", i, perm.ServiceAccountName, field, machineConfigAPIGroup),
+				"ServiceAccount", perm.ServiceAccountName))
+		}
+	}
+	return checks
+}
+
+// checkTopologyClaims will verify that the SNO and HCP claims are supported by the install modes
+func checkTopologyClaims(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeAllNamespaces) ||
+		supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeOwnNamespace) {
+		return checks
+	}
+	for _, feature := range topologyFeatures {
+		if !claimsFeature(checks.bundle.CSV, feature, feature) {
+			continue
+		}
+		checks.warns = append(checks.warns, fmt.Errorf("the operator claims to support the %s topology via "+
+			"the annotation %s but it does not support the AllNamespaces or OwnNamespace install modes. Note "+
+			"that the operators are expected to be installed with one of them on these clusters",
+			strings.ToUpper(feature), infrastructureFeaturesAnnotation))
+	}
+	return checks
+}
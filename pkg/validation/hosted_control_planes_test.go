@@ -0,0 +1,133 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_HostedControlPlanesValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the bundle is compatible with hosted control planes",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["sno", "hcp"]`
+				},
+			},
+		},
+		{
+			name: "should pass when the privileged operator can be installed namespace-scoped",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate:    makePrivileged,
+			},
+		},
+		{
+			name:        "should warn when the cluster-scoped operator requires node-level privileges",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate:    makePrivileged,
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the deployment " +
+				"memcached-operator-controller-manager requires node-level privileges (hostNetwork, hostPID, " +
+				"hostPath volume host, privileged container manager) and the operator can only be installed with " +
+				"the AllNamespaces install mode. Note that on hosted control planes (HyperShift and ROSA with HCP) " +
+				"the nodes are managed outside the cluster and node-level access might be restricted"},
+		},
+		{
+			name:        "should warn when the operator relies on the MachineConfig APIs",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					perms := &bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions[0]
+					perms.Rules = append(perms.Rules, rbacv1.PolicyRule{
+						APIGroups: []string{machineConfigAPIGroup},
+						Resources: []string{"machineconfigs"},
+						Verbs:     []string{"create", "get"},
+					})
+					mc := &unstructured.Unstructured{}
+					mc.SetAPIVersion(machineConfigAPIGroup + "/v1")
+					mc.SetKind("MachineConfig")
+					mc.SetName("99-worker-memcached")
+					bundle.Objects = append(bundle.Objects, mc)
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the rule [7] of the service account " +
+					"memcached-operator-controller-manager under spec.install.spec.clusterPermissions in the CSV " +
+					"grants access to the MachineConfig APIs (machineconfiguration.openshift.io). Note that they " +
+					"are not available on hosted control planes (HyperShift and ROSA with HCP) where the nodes are " +
+					"configured via NodePools",
+				"Warning: Value : (memcached-operator.v0.0.1) the MachineConfig 99-worker-memcached shipped in " +
+					"the bundle uses the MachineConfig APIs (machineconfiguration.openshift.io). Note that they are " +
+					"not available on hosted control planes (HyperShift and ROSA with HCP) where the nodes are " +
+					"configured via NodePools",
+			},
+		},
+		{
+			name:        "should warn when the topology claims are not supported by the install modes",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["SNO"]`
+					for i := range bundle.CSV.Spec.InstallModes {
+						mode := &bundle.CSV.Spec.InstallModes[i]
+						mode.Supported = mode.Type == operatorsv1alpha1.InstallModeTypeSingleNamespace
+					}
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the operator claims to support " +
+				"the SNO topology via the annotation operators.openshift.io/infrastructure-features but it does " +
+				"not support the AllNamespaces or OwnNamespace install modes. Note that the operators are " +
+				"expected to be installed with one of them on these clusters"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := HostedControlPlanesValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/registry"
+)
+
+// imageInspector defines the client used to inspect the images in their registries. Note that it is created by
+// getImageInspector the first time the images are inspected to only read the auth files when it is required.
+var imageInspector registry.Inspector
+
+// getImageInspector returns the imageInspector, creating it when it was not created yet
+func getImageInspector() registry.Inspector {
+	if imageInspector == nil {
+		imageInspector = registry.NewClient()
+	}
+	return imageInspector
+}
+
+// ImagesValidator validates the images referenced by the bundle. Following its current checks:
+//
+// - Ensure that the images used by the deployments and informed under spec.relatedImages in the CSV
+// exist in their registries. Note that this check is only performed when the optional value
+// check-images=true is informed since it requires access to the registries.
+//
+// - Ensure that the images are manifest lists which provide all architectures supported by the CSV
+// via the operatorframework.io/arch.<arch> labels when architectures other than amd64 are claimed.
+// Note that this check is also only performed when check-images=true is informed.
+//
+// - Warn when the image informed via the containerImage annotation of the CSV is not used by the
+// containers of the deployments. Note that it is an error for the strict profiles.
+//
+// - Ensure that the images referenced by the bundle are from the registries informed via the optional
+// value allowed-registries (e.g. registry.redhat.io,quay.io/example) when it is informed
+var ImagesValidator interfaces.Validator = newBundleValidator("images", checkImageManifests,
+	checkContainerImageAnnotation, checkAllowedRegistries)
+
+// containerImageAnnotation defines the CSV annotation used to inform the operator image
+const containerImageAnnotation = "containerImage"
+
+// archLabelPrefix defines the prefix of the CSV labels used to inform the architectures supported
+const archLabelPrefix = "operatorframework.io/arch."
+
+// defaultArch defines the architecture supported when the CSV has no architecture labels
+const defaultArch = "amd64"
+
+// checkImageManifests will verify that the images referenced by the bundle can be found in their
+// registries and that they provide the architectures supported by the CSV
+func checkImageManifests(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if !checks.checkImages {
+		return checks
+	}
+	archs := getSupportedArchs(checks)
+	for _, image := range getBundleImages(checks) {
+		manifest, err := getImageInspector().GetManifest(image)
+		if err != nil {
+			checks.errs = append(checks.errs, fmt.Errorf("unable to find the image %s in its registry: %s",
+				image, err))
+			continue
+		}
+		if len(archs) == 1 && archs[0] == defaultArch {
+			continue
+		}
+		if !manifest.IsList() {
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the image %s is not a manifest list but the "+
+				"CSV claims to support the architectures (%s) via the labels %s<arch>", image,
+				strings.Join(archs, ", "), archLabelPrefix), "publish a multi-arch image or remove the labels"))
+			continue
+		}
+		provided := map[string]bool{}
+		for _, platform := range manifest.Platforms() {
+			provided[platform.Architecture] = true
+		}
+		var missing []string
+		for _, arch := range archs {
+			if !provided[arch] {
+				missing = append(missing, arch)
+			}
+		}
+		if len(missing) > 0 {
+			checks.errs = append(checks.errs, fmt.Errorf("the image %s does not provide the architectures (%s) "+
+				"which the CSV claims to support via the labels %s<arch>", image, strings.Join(missing, ", "),
+				archLabelPrefix))
+		}
+	}
+	return checks
+}
+
+// checkContainerImageAnnotation will verify that the containerImage annotation matches the operator image
+func checkContainerImageAnnotation(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	containerImage, ok := checks.bundle.CSV.GetAnnotations()[containerImageAnnotation]
+	if !ok || len(containerImage) == 0 {
+		return checks
+	}
+	var images []string
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range dep.Spec.Template.Spec.Containers {
+			if c.Image == containerImage {
+				return checks
+			}
+			images = append(images, c.Image)
+		}
+	}
+	return addStrictFinding(checks, withRemediation(fmt.Errorf("the image %s informed via the annotation %s is not "+
+		"used by the containers of the deployments (%s)", containerImage, containerImageAnnotation,
+		strings.Join(images, ", ")), "ensure that the annotation informs the operator image"))
+}
+
+// checkAllowedRegistries will verify that the images referenced by the bundle are from the allowed registries
+func checkAllowedRegistries(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(checks.allowedRegistries) == 0 {
+		return checks
+	}
+	images := getBundleImages(checks)
+	if containerImage := checks.bundle.CSV.GetAnnotations()[containerImageAnnotation]; len(containerImage) > 0 {
+		images = append(images, containerImage)
+	}
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			for _, env := range c.Env {
+				if strings.HasPrefix(env.Name, relatedImageEnvPrefix) && len(env.Value) > 0 {
+					images = append(images, env.Value)
+				}
+			}
+		}
+	}
+
+	reported := map[string]bool{}
+	for _, image := range images {
+		if reported[image] || isAllowedRegistry(image, checks.allowedRegistries) {
+			continue
+		}
+		reported[image] = true
+		checks.errs = append(checks.errs, fmt.Errorf("the image %s is not from the allowed registries (%s)",
+			image, strings.Join(checks.allowedRegistries, ", ")))
+	}
+	return checks
+}
+
+// isAllowedRegistry returns true when the image is from one of the registries informed
+func isAllowedRegistry(image string, allowedRegistries []string) bool {
+	name := image
+	if ref, err := reference.ParseNormalizedNamed(image); err == nil {
+		name = ref.Name()
+	}
+	for _, registry := range allowedRegistries {
+		if name == registry || strings.HasPrefix(name, registry+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// getAllowedRegistries returns the registries informed in the comma separated list
+func getAllowedRegistries(value string) []string {
+	var registries []string
+	for _, registry := range strings.Split(value, ",") {
+		registry = strings.TrimSuffix(strings.TrimSpace(registry), "/")
+		if len(registry) > 0 {
+			registries = append(registries, registry)
+		}
+	}
+	return registries
+}
+
+// getSupportedArchs returns the sorted architectures supported by the CSV via the labels
+func getSupportedArchs(checks OpenShiftOperatorChecks) []string {
+	var archs []string
+	for label, value := range checks.bundle.CSV.GetLabels() {
+		if strings.HasPrefix(label, archLabelPrefix) && value == "supported" {
+			archs = append(archs, strings.TrimPrefix(label, archLabelPrefix))
+		}
+	}
+	if len(archs) == 0 {
+		return []string{defaultArch}
+	}
+	sort.Strings(archs)
+	return archs
+}
+
+// getBundleImages returns the images used by the deployments and the related images of the CSV
+func getBundleImages(checks OpenShiftOperatorChecks) []string {
+	var images []string
+	found := map[string]bool{}
+	add := func(image string) {
+		if len(image) > 0 && !found[image] {
+			found[image] = true
+			images = append(images, image)
+		}
+	}
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			add(c.Image)
+		}
+	}
+	for _, related := range checks.bundle.CSV.Spec.RelatedImages {
+		add(related.Image)
+	}
+	return images
+}
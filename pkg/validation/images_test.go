@@ -0,0 +1,229 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/registry"
+)
+
+// fakeInspector implements registry.Inspector with the manifests informed by image
+type fakeInspector map[string]*registry.Manifest
+
+func (f fakeInspector) GetManifest(image string) (*registry.Manifest, error) {
+	if manifest, ok := f[image]; ok {
+		return manifest, nil
+	}
+	return nil, fmt.Errorf("manifest unknown")
+}
+
+func (f fakeInspector) GetLabels(image string) (map[string]string, error) {
+	if _, ok := f[image]; ok {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("manifest unknown")
+}
+
+func Test_ImagesValidator(t *testing.T) {
+	defaultInspector := imageInspector
+	defer func() { imageInspector = defaultInspector }()
+	multiArch := &registry.Manifest{MediaType: registry.MediaTypeDockerManifestList}
+	for _, arch := range []string{"amd64", "arm64"} {
+		multiArch.Manifests = append(multiArch.Manifests,
+			registry.Descriptor{Platform: registry.Platform{Architecture: arch, OS: "linux"}})
+	}
+	imageInspector = fakeInspector{
+		"gcr.io/kubebuilder/kube-rbac-proxy:v0.8.0": {MediaType: registry.MediaTypeDockerManifest},
+		"quay.io/example/memcached-operator:v0.0.1": multiArch,
+	}
+
+	type args struct {
+		bundleDir   string
+		checkImages bool
+		profile     string
+		registries  string
+		mutate      func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the images are not checked",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+			},
+		},
+		{
+			name: "should pass when the images are found",
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1",
+				checkImages: true,
+			},
+		},
+		{
+			name:      "should fail when the related image is not found",
+			wantError: true,
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1",
+				checkImages: true,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.RelatedImages = []operatorsv1alpha1.RelatedImage{
+						{Name: "memcached", Image: "docker.io/memcached:1.6"},
+					}
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to find the image " +
+				"docker.io/memcached:1.6 in its registry: manifest unknown"},
+		},
+		{
+			name: "should pass when the images provide the architectures supported",
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1",
+				checkImages: true,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers =
+						bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers[1:]
+					bundle.CSV.SetLabels(map[string]string{
+						"operatorframework.io/arch.amd64": "supported",
+						"operatorframework.io/arch.arm64": "supported",
+					})
+				},
+			},
+		},
+		{
+			name:      "should fail when the images do not provide the architectures supported",
+			wantError: true,
+			args: args{
+				bundleDir:   "./testdata/valid_bundle_v1",
+				checkImages: true,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.SetLabels(map[string]string{
+						"operatorframework.io/arch.amd64":   "supported",
+						"operatorframework.io/arch.arm64":   "supported",
+						"operatorframework.io/arch.s390x":   "supported",
+						"operatorframework.io/arch.ppc64le": "unsupported",
+					})
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the image gcr.io/kubebuilder/kube-rbac-proxy:v0.8.0 is " +
+					"not a manifest list but the CSV claims to support the architectures (amd64, arm64, s390x) via " +
+					"the labels operatorframework.io/arch.<arch>. Please, publish a multi-arch image or remove the labels",
+				"Error: Value : (memcached-operator.v0.0.1) the image quay.io/example/memcached-operator:v0.0.1 does " +
+					"not provide the architectures (s390x) which the CSV claims to support via the labels " +
+					"operatorframework.io/arch.<arch>",
+			},
+		},
+		{
+			name: "should pass when the containerImage annotation informs the operator image",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[containerImageAnnotation] = "quay.io/example/memcached-operator:v0.0.1"
+				},
+			},
+		},
+		{
+			name:        "should warn when the containerImage annotation is not used by the deployments",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[containerImageAnnotation] = "quay.io/example/memcached-operator:v0.0.0"
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the image " +
+				"quay.io/example/memcached-operator:v0.0.0 informed via the annotation containerImage is not used " +
+				"by the containers of the deployments (gcr.io/kubebuilder/kube-rbac-proxy:v0.8.0, " +
+				"quay.io/example/memcached-operator:v0.0.1). Please, ensure that the annotation informs the " +
+				"operator image"},
+		},
+		{
+			name:      "should fail when the containerImage annotation is not used by the deployments with a strict profile",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CertifiedProfile,
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[containerImageAnnotation] = "quay.io/example/memcached-operator:v0.0.0"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the image " +
+				"quay.io/example/memcached-operator:v0.0.0 informed via the annotation containerImage is not used " +
+				"by the containers of the deployments (gcr.io/kubebuilder/kube-rbac-proxy:v0.8.0, " +
+				"quay.io/example/memcached-operator:v0.0.1). Please, ensure that the annotation informs the " +
+				"operator image"},
+		},
+		{
+			name: "should pass when the images are from the allowed registries",
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				registries: "gcr.io/kubebuilder, quay.io/example/",
+			},
+		},
+		{
+			name:      "should fail when the images are not from the allowed registries",
+			wantError: true,
+			args: args{
+				bundleDir:  "./testdata/valid_bundle_v1",
+				registries: "registry.redhat.io,quay.io/example",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.RelatedImages = []operatorsv1alpha1.RelatedImage{
+						{Name: "memcached", Image: "memcached:1.6"},
+						{Name: "manager", Image: "quay.io/example-other/memcached-operator:v0.0.1"},
+					}
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the image gcr.io/kubebuilder/kube-rbac-proxy:v0.8.0 is " +
+					"not from the allowed registries (registry.redhat.io, quay.io/example)",
+				"Error: Value : (memcached-operator.v0.0.1) the image memcached:1.6 is not from the allowed " +
+					"registries (registry.redhat.io, quay.io/example)",
+				"Error: Value : (memcached-operator.v0.0.1) the image quay.io/example-other/memcached-operator:v0.0.1 " +
+					"is not from the allowed registries (registry.redhat.io, quay.io/example)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := ImagesValidator.Validate(bundle,
+				map[string]string{
+					CheckImagesKey:       fmt.Sprintf("%t", tt.args.checkImages),
+					ProfileKey:           tt.args.profile,
+					AllowedRegistriesKey: tt.args.registries,
+				})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// dryRunNamespace defines the namespace where the namespaced resources are created in the dry-run
+const dryRunNamespace = "default"
+
+// InstallDryRunValidator simulates the install of the bundle on the cluster informed via the KubeconfigKey
+// by performing the server-side dry-run of the key resources which OLM would create. Nothing is installed
+// on the cluster. It is not part of the DefaultValidators. Following its current checks:
+//
+// - Ensure that the CRDs and the other manifests shipped in the bundle are accepted by the cluster
+//
+// - Ensure that the service accounts, roles and bindings generated from spec.install.spec.permissions and
+// clusterPermissions are accepted by the cluster (e.g. that the rules do not escalate the privileges of the
+// user of the kubeconfig)
+//
+// - Ensure that the deployments under spec.install.spec.deployments are accepted by the cluster (e.g. by the
+// pod security admission)
+var InstallDryRunValidator interfaces.Validator = newBundleValidator("install-dry-run", checkInstallDryRun)
+
+// checkInstallDryRun will verify that the resources created by OLM to install the bundle are accepted
+// by the cluster
+func checkInstallDryRun(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(checks.kubeconfig) == 0 {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("unable to simulate the install of the bundle"),
+			"inform the kubeconfig of the cluster via the optional key %s", KubeconfigKey))
+		return checks
+	}
+	inspector, err := newClusterInspector(checks.kubeconfig)
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+
+	objs, err := getInstallObjects(checks.bundle)
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+	for _, obj := range objs {
+		if err := inspector.DryRunCreate(obj, dryRunNamespace); err != nil {
+			checks.errs = append(checks.errs, fmt.Errorf("the dry-run of the %s %s was rejected by the "+
+				"cluster: %s. Note that OLM would fail to install the bundle", obj.GetKind(), obj.GetName(), err))
+		}
+	}
+	return checks
+}
+
+// getInstallObjects returns the resources which are created by OLM to install the bundle in the order
+// that they are created. Note that the names of the roles and bindings are generated from the CSV name.
+func getInstallObjects(bundle manifests.Bundle) ([]*unstructured.Unstructured, error) {
+	var objs []runtime.Object
+	// The CRDs are created before the other manifests
+	for _, crdFirst := range []bool{true, false} {
+		for _, obj := range bundle.Objects {
+			if obj == nil || obj.GetKind() == operatorsv1alpha1.ClusterServiceVersionKind {
+				continue
+			}
+			if (obj.GetKind() == "CustomResourceDefinition") == crdFirst {
+				objs = append(objs, obj)
+			}
+		}
+	}
+
+	// The service accounts shipped in the bundle are used by OLM instead of creating them
+	serviceAccounts := map[string]bool{}
+	for _, obj := range bundle.Objects {
+		if obj != nil && obj.GetKind() == "ServiceAccount" {
+			serviceAccounts[obj.GetName()] = true
+		}
+	}
+	strategy := bundle.CSV.Spec.InstallStrategy.StrategySpec
+	for _, perms := range [][]operatorsv1alpha1.StrategyDeploymentPermissions{strategy.Permissions,
+		strategy.ClusterPermissions} {
+		for _, perm := range perms {
+			if serviceAccounts[perm.ServiceAccountName] {
+				continue
+			}
+			serviceAccounts[perm.ServiceAccountName] = true
+			objs = append(objs, &corev1.ServiceAccount{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+				ObjectMeta: metav1.ObjectMeta{Name: perm.ServiceAccountName},
+			})
+		}
+	}
+	for _, perm := range strategy.Permissions {
+		name := fmt.Sprintf("%s-%s", bundle.CSV.GetName(), perm.ServiceAccountName)
+		objs = append(objs,
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Rules:      perm.Rules,
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+				Subjects: []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: perm.ServiceAccountName,
+					Namespace: dryRunNamespace}},
+			})
+	}
+	for _, perm := range strategy.ClusterPermissions {
+		name := fmt.Sprintf("%s-%s", bundle.CSV.GetName(), perm.ServiceAccountName)
+		objs = append(objs,
+			&rbacv1.ClusterRole{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Rules:      perm.Rules,
+			},
+			&rbacv1.ClusterRoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+				Subjects: []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: perm.ServiceAccountName,
+					Namespace: dryRunNamespace}},
+			})
+	}
+	for _, spec := range strategy.DeploymentSpecs {
+		objs = append(objs, &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Labels: spec.Label},
+			Spec:       spec.Spec,
+		})
+	}
+
+	var result []*unstructured.Unstructured
+	for _, obj := range objs {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			result = append(result, u)
+			continue
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert the resources to simulate the install: %s", err)
+		}
+		result = append(result, &unstructured.Unstructured{Object: content})
+	}
+	return result, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/cluster"
+)
+
+func Test_InstallDryRunValidator(t *testing.T) {
+	defaultInspector := newClusterInspector
+	defer func() { newClusterInspector = defaultInspector }()
+
+	type args struct {
+		kubeconfig string
+		inspector  cluster.Inspector
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the resources are accepted by the cluster",
+			args: args{
+				kubeconfig: "kubeconfig",
+				inspector:  ocp412Cluster,
+			},
+		},
+		{
+			name:      "should fail when the resources are rejected by the cluster",
+			wantError: true,
+			args: args{
+				kubeconfig: "kubeconfig",
+				inspector: fakeClusterInspector{rejected: map[string]string{
+					"ClusterRole memcached-operator.v0.0.1-memcached-operator-controller-manager": `clusterroles.` +
+						`rbac.authorization.k8s.io "memcached-operator.v0.0.1-memcached-operator-controller-manager" ` +
+						`is forbidden: user "developer" is attempting to grant RBAC permissions not currently held`,
+					"Deployment memcached-operator-controller-manager": `pods "memcached-operator-controller-` +
+						`manager" is forbidden: violates PodSecurity "restricted:latest"`,
+				}},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the dry-run of the ClusterRole " +
+					"memcached-operator.v0.0.1-memcached-operator-controller-manager was rejected by the cluster: " +
+					"clusterroles.rbac.authorization.k8s.io \"memcached-operator.v0.0.1-memcached-operator-" +
+					"controller-manager\" is forbidden: user \"developer\" is attempting to grant RBAC permissions " +
+					"not currently held. Note that OLM would fail to install the bundle",
+				"Error: Value : (memcached-operator.v0.0.1) the dry-run of the Deployment " +
+					"memcached-operator-controller-manager was rejected by the cluster: pods \"memcached-operator-" +
+					"controller-manager\" is forbidden: violates PodSecurity \"restricted:latest\". Note that OLM " +
+					"would fail to install the bundle",
+			},
+		},
+		{
+			name:      "should fail when the kubeconfig is not informed",
+			wantError: true,
+			args: args{
+				inspector: ocp412Cluster,
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to simulate the install of the " +
+				"bundle. Please, inform the kubeconfig of the cluster via the optional key kubeconfig"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			newClusterInspector = func(kubeconfig string) (cluster.Inspector, error) {
+				return tt.args.inspector, nil
+			}
+
+			results := InstallDryRunValidator.Validate(bundle, map[string]string{KubeconfigKey: tt.args.kubeconfig})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+func Test_getInstallObjects(t *testing.T) {
+	bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+	require.NoError(t, err)
+
+	objs, err := getInstallObjects(*bundle)
+	require.NoError(t, err)
+	require.Equal(t, "CustomResourceDefinition", objs[0].GetKind())
+
+	var got []string
+	for _, obj := range objs[len(bundle.Objects)-1:] {
+		got = append(got, fmt.Sprintf("%s %s", obj.GetKind(), obj.GetName()))
+	}
+	require.Equal(t, []string{
+		"Role memcached-operator.v0.0.1-memcached-operator-controller-manager",
+		"RoleBinding memcached-operator.v0.0.1-memcached-operator-controller-manager",
+		"ClusterRole memcached-operator.v0.0.1-memcached-operator-controller-manager",
+		"ClusterRoleBinding memcached-operator.v0.0.1-memcached-operator-controller-manager",
+		"Deployment memcached-operator-controller-manager",
+	}, got)
+	require.Equal(t, "apps/v1", objs[len(objs)-1].GetAPIVersion())
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// InstallModesValidator validates the install modes defined under spec.installModes in the CSV against
+// the policies of the catalog informed via the ProfileKey. Following its current checks:
+//
+// - Ensure that the AllNamespaces install mode is supported with the certified and marketplace profiles
+// and warn when it is not supported with the redhat profile
+//
+// - Warn when the MultiNamespace install mode is supported. Note that it is an error with the certified,
+// redhat and marketplace profiles.
+var InstallModesValidator interfaces.Validator = newBundleValidator("install-modes", checkInstallModesPolicy)
+
+// checkInstallModesPolicy will verify the install modes supported by the CSV according to the profile
+func checkInstallModesPolicy(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if !supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeAllNamespaces) {
+		err := fmt.Errorf("the CSV does not support the install mode %s which is expected for the bundles "+
+			"published on the %s catalog. Note that the operators are installed cluster-wide by default on "+
+			"OpenShift", operatorsv1alpha1.InstallModeTypeAllNamespaces, checks.profile)
+		switch checks.profile {
+		case CertifiedProfile, MarketplaceProfile:
+			checks.errs = append(checks.errs, err)
+		case RedHatProfile:
+			checks.warns = append(checks.warns, err)
+		}
+	}
+
+	if supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeMultiNamespace) {
+		checks = addStrictFinding(checks, withRemediation(fmt.Errorf("the CSV supports the install mode %s which is "+
+			"discouraged. Note that it is not supported by the OpenShift console and OLM plans to remove it",
+			operatorsv1alpha1.InstallModeTypeMultiNamespace), "use %s or %s instead",
+			operatorsv1alpha1.InstallModeTypeAllNamespaces, operatorsv1alpha1.InstallModeTypeOwnNamespace))
+	}
+	return checks
+}
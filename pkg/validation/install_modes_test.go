@@ -0,0 +1,123 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_InstallModesValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		profile   string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when AllNamespaces is supported with the certified profile",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CertifiedProfile,
+			},
+		},
+		{
+			name: "should pass when AllNamespaces is not supported with the community profile",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				profile:   CommunityProfile,
+			},
+		},
+		{
+			name:      "should fail when AllNamespaces is not supported with the marketplace profile",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				profile:   MarketplaceProfile,
+			},
+			errStrings: []string{"Error: Value : (etcdoperator.v0.9.4) the CSV does not support the install mode " +
+				"AllNamespaces which is expected for the bundles published on the marketplace catalog. Note that " +
+				"the operators are installed cluster-wide by default on OpenShift"},
+		},
+		{
+			name:        "should warn when AllNamespaces is not supported with the redhat profile",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				profile:   RedHatProfile,
+			},
+			warnStrings: []string{"Warning: Value : (etcdoperator.v0.9.4) the CSV does not support the install " +
+				"mode AllNamespaces which is expected for the bundles published on the redhat catalog. Note that " +
+				"the operators are installed cluster-wide by default on OpenShift"},
+		},
+		{
+			name:        "should warn when MultiNamespace is supported with the community profile",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CommunityProfile,
+				mutate:    supportMultiNamespace,
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the CSV supports the install mode " +
+				"MultiNamespace which is discouraged. Note that it is not supported by the OpenShift console and " +
+				"OLM plans to remove it. Please, use AllNamespaces or OwnNamespace instead"},
+		},
+		{
+			name:      "should fail when MultiNamespace is supported with the certified profile",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CertifiedProfile,
+				mutate:    supportMultiNamespace,
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the CSV supports the install mode " +
+				"MultiNamespace which is discouraged. Note that it is not supported by the OpenShift console and " +
+				"OLM plans to remove it. Please, use AllNamespaces or OwnNamespace instead"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := InstallModesValidator.Validate(bundle, map[string]string{ProfileKey: tt.args.profile})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// supportMultiNamespace sets the MultiNamespace install mode as supported in the CSV
+func supportMultiNamespace(bundle *manifests.Bundle) {
+	for i, mode := range bundle.CSV.Spec.InstallModes {
+		if mode.Type == operatorsv1alpha1.InstallModeTypeMultiNamespace {
+			bundle.CSV.Spec.InstallModes[i].Supported = true
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// defaultServiceAccountName defines the service account used by the pods which do not inform one
+const defaultServiceAccountName = "default"
+
+// InstallStrategyValidator validates the install strategy defined under spec.install in the CSV.
+// Following its current checks:
+//
+// - Ensure that the install strategy is deployment and that it has at least one deployment
+//
+// - Ensure that the deployment specs can be parsed without unknown fields and that each one has a name
+// and at least one container
+//
+// - Ensure that the service accounts used in spec.install.spec.permissions and clusterPermissions
+// are used by the deployments of the install strategy
+//
+// - Ensure that the service accounts used by the deployments are created by OLM from the permissions
+// or shipped in the bundle and warn when the deployments run with the default service account
+//
+// - Ensure that the deployment names are unique and that their label selectors match the labels of
+// their pod templates. Otherwise, the CSV will be stuck in the Pending phase.
+var InstallStrategyValidator interfaces.Validator = newBundleValidator("install-strategy", checkInstallStrategy,
+	checkDeployments, checkDeploymentsServiceAccounts)
+
+// checkInstallStrategy will verify the install strategy defined in the CSV
+func checkInstallStrategy(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	csv := checks.bundle.CSV
+	strategy := csv.Spec.InstallStrategy
+	if strategy.StrategyName != operatorsv1alpha1.InstallStrategyNameDeployment {
+		checks.errs = append(checks.errs, fmt.Errorf("the install strategy (%s) defined under "+
+			"spec.install.strategy in the CSV is not supported. It must be %s",
+			strategy.StrategyName, operatorsv1alpha1.InstallStrategyNameDeployment))
+		return checks
+	}
+
+	if len(strategy.StrategySpec.DeploymentSpecs) == 0 {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the install strategy of the CSV has no "+
+			"deployments"), "define them under spec.install.spec.deployments"))
+		return checks
+	}
+
+	checks = checkDeploymentSpecsParse(checks)
+
+	serviceAccounts := map[string]bool{}
+	for _, dep := range strategy.StrategySpec.DeploymentSpecs {
+		if len(dep.Name) == 0 {
+			checks.errs = append(checks.errs, fmt.Errorf("the install strategy of the CSV has a deployment "+
+				"without name"))
+		}
+		if len(dep.Spec.Template.Spec.Containers) == 0 {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the deployment %s of the install strategy has "+
+				"no containers", dep.Name), "Deployment", dep.Name))
+		}
+		sa := dep.Spec.Template.Spec.ServiceAccountName
+		if len(sa) == 0 {
+			sa = defaultServiceAccountName
+		}
+		serviceAccounts[sa] = true
+	}
+
+	checks = checkPermissionsServiceAccounts(checks, "permissions",
+		strategy.StrategySpec.Permissions, serviceAccounts)
+	checks = checkPermissionsServiceAccounts(checks, "clusterPermissions",
+		strategy.StrategySpec.ClusterPermissions, serviceAccounts)
+	return checks
+}
+
+// checkDeployments will verify that the deployment names are unique and that their selectors
+// match with the labels of their pod templates
+func checkDeployments(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	names := map[string]int{}
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		names[dep.Name]++
+		if names[dep.Name] == 2 {
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the deployment name %s is used more than "+
+				"once in the install strategy of the CSV", dep.Name), "ensure that the deployment names are unique"))
+		}
+
+		if dep.Spec.Selector == nil {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the deployment %s of the install strategy has "+
+				"no spec.selector", dep.Name), "Deployment", dep.Name))
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(dep.Spec.Selector)
+		if err != nil {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the deployment %s of the install strategy has "+
+				"an invalid spec.selector: %s", dep.Name, err), "Deployment", dep.Name))
+			continue
+		}
+		if selector.Empty() || !selector.Matches(labels.Set(dep.Spec.Template.Labels)) {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the spec.selector (%s) of the deployment %s "+
+				"does not match with the labels of its pod template (%s)", selector.String(), dep.Name,
+				labels.Set(dep.Spec.Template.Labels).String()), "Deployment", dep.Name))
+		}
+	}
+	return checks
+}
+
+// checkDeploymentsServiceAccounts will verify that the service accounts used by the deployments will exist
+func checkDeploymentsServiceAccounts(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	strategy := checks.bundle.CSV.Spec.InstallStrategy.StrategySpec
+	serviceAccounts := map[string]bool{}
+	for _, perm := range strategy.Permissions {
+		serviceAccounts[perm.ServiceAccountName] = true
+	}
+	for _, perm := range strategy.ClusterPermissions {
+		serviceAccounts[perm.ServiceAccountName] = true
+	}
+	for _, obj := range checks.bundle.Objects {
+		if obj.GetKind() == "ServiceAccount" {
+			serviceAccounts[obj.GetName()] = true
+		}
+	}
+
+	for _, dep := range strategy.DeploymentSpecs {
+		sa := dep.Spec.Template.Spec.ServiceAccountName
+		if len(sa) == 0 || sa == defaultServiceAccountName {
+			checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the deployment %s runs with "+
+				"the default service account", dep.Name),
+				"define a service account for the operator via serviceAccountName and its permissions under "+
+					"spec.install.spec.permissions or clusterPermissions in the CSV"), "Deployment", dep.Name))
+			continue
+		}
+		if !serviceAccounts[sa] {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the service account (%s) used by the deployment "+
+				"%s is not defined under spec.install.spec.permissions or clusterPermissions in the CSV and it is not "+
+				"shipped in the bundle. Note that the service account will not be created", sa, dep.Name),
+				"Deployment", dep.Name))
+		}
+	}
+	return checks
+}
+
+// checkPermissionsServiceAccounts will verify that the service accounts of the permissions are used
+// by the deployments of the install strategy
+func checkPermissionsServiceAccounts(checks OpenShiftOperatorChecks, field string,
+	permissions []operatorsv1alpha1.StrategyDeploymentPermissions,
+	serviceAccounts map[string]bool) OpenShiftOperatorChecks {
+	for _, perm := range permissions {
+		if !serviceAccounts[perm.ServiceAccountName] {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the service account (%s) defined under "+
+				"spec.install.spec.%s in the CSV is not used by any deployment of the install strategy",
+				perm.ServiceAccountName, field), "ServiceAccount", perm.ServiceAccountName))
+		}
+	}
+	return checks
+}
+
+// getContainers returns the init containers and containers of the pod spec informed
+func getContainers(spec corev1.PodSpec) []corev1.Container {
+	var containers []corev1.Container
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	return containers
+}
+
+// checkDeploymentSpecsParse will verify that the deployment specs of the CSV manifest can be parsed
+// without unknown fields. Note that the typed CSV silently drops them.
+func checkDeploymentSpecsParse(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	var csvObj *unstructured.Unstructured
+	for _, obj := range checks.bundle.Objects {
+		if obj.GetKind() == operatorsv1alpha1.ClusterServiceVersionKind {
+			csvObj = obj
+			break
+		}
+	}
+	if csvObj == nil {
+		return checks
+	}
+
+	deployments, found, err := unstructured.NestedSlice(csvObj.Object, "spec", "install", "spec", "deployments")
+	if err != nil || !found {
+		return checks
+	}
+
+	for i, dep := range deployments {
+		raw, err := json.Marshal(dep)
+		if err != nil {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		spec := operatorsv1alpha1.StrategyDeploymentSpec{}
+		if err := decoder.Decode(&spec); err != nil {
+			checks.errs = append(checks.errs, fmt.Errorf("unable to parse the deployment spec [%d] defined under "+
+				"spec.install.spec.deployments in the CSV: %s", i, err))
+		}
+	}
+	return checks
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_InstallStrategyValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the install strategy is valid with v1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the install strategy is valid with v1beta1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+			},
+		},
+		{
+			name:      "should fail when the install strategy is not deployment",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategyName = "helm"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the install strategy (helm) defined " +
+				"under spec.install.strategy in the CSV is not supported. It must be deployment"},
+		},
+		{
+			name:      "should fail when the install strategy has no deployments",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs = nil
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the install strategy of the CSV has " +
+				"no deployments. Please, define them under spec.install.spec.deployments"},
+		},
+		{
+			name:      "should fail when the deployment has no name and no containers",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Name = ""
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers = nil
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (etcdoperator.v0.9.4) the install strategy of the CSV has a deployment without name",
+				"Error: Value : (etcdoperator.v0.9.4) the deployment  of the install strategy has no containers",
+			},
+		},
+		{
+			name:      "should fail when the deployment spec has unknown fields",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					csv := findObject(bundle, "ClusterServiceVersion")
+					deployments, _, err := unstructured.NestedSlice(csv.Object, "spec", "install", "spec", "deployments")
+					require.NoError(t, err)
+					deployments[0].(map[string]interface{})["spec"].(map[string]interface{})["replica"] = int64(1)
+					require.NoError(t, unstructured.SetNestedSlice(csv.Object, deployments,
+						"spec", "install", "spec", "deployments"))
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to parse the deployment spec [0] " +
+				"defined under spec.install.spec.deployments in the CSV: json: unknown field \"replica\""},
+		},
+		{
+			name:      "should fail when the permissions use a service account not used by the deployments",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.Permissions[0].ServiceAccountName = "other"
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions[0].ServiceAccountName = "default"
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the service account (other) defined under " +
+					"spec.install.spec.permissions in the CSV is not used by any deployment of the install strategy",
+				"Error: Value : (memcached-operator.v0.0.1) the service account (default) defined under " +
+					"spec.install.spec.clusterPermissions in the CSV is not used by any deployment of the install strategy",
+			},
+		},
+		{
+			name:      "should fail when the deployment name is duplicated",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate: func(bundle *manifests.Bundle) {
+					specs := bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs = append(specs, specs[0])
+				},
+			},
+			errStrings: []string{"Error: Value : (etcdoperator.v0.9.4) the deployment name etcd-operator is used " +
+				"more than once in the install strategy of the CSV. Please, ensure that the deployment names are unique"},
+		},
+		{
+			name:      "should fail when the deployment selector does not match the pod template labels",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					dep := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0]
+					dep.Spec.Template.Labels = map[string]string{"control-plane": "manager"}
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the spec.selector " +
+				"(control-plane=controller-manager) of the deployment memcached-operator-controller-manager does " +
+				"not match with the labels of its pod template (control-plane=manager)"},
+		},
+		{
+			name:      "should fail when the deployment has no selector",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Selector = nil
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the deployment " +
+				"memcached-operator-controller-manager of the install strategy has no spec.selector"},
+		},
+		{
+			name:      "should fail when the service account of the deployment will not exist",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.Permissions = nil
+				},
+			},
+			errStrings: []string{"Error: Value : (etcdoperator.v0.9.4) the service account (etcd-operator) used by " +
+				"the deployment etcd-operator is not defined under spec.install.spec.permissions or " +
+				"clusterPermissions in the CSV and it is not shipped in the bundle. Note that the service account " +
+				"will not be created"},
+		},
+		{
+			name: "should pass when the service account of the deployment is shipped in the bundle",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.Permissions = nil
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions = nil
+				},
+			},
+		},
+		{
+			name:        "should warn when the deployment runs with the default service account",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.ServiceAccountName = ""
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.Permissions[0].ServiceAccountName = "default"
+				},
+			},
+			warnStrings: []string{"Warning: Value : (etcdoperator.v0.9.4) the deployment etcd-operator runs with " +
+				"the default service account. Please, define a service account for the operator via " +
+				"serviceAccountName and its permissions under spec.install.spec.permissions or clusterPermissions " +
+				"in the CSV"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := InstallStrategyValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	registrybundle "github.com/operator-framework/operator-registry/pkg/lib/bundle"
+)
+
+// additionalSupportedKinds defines the kinds which are supported by the OLM versions shipped with
+// OpenShift and are not yet known by the operator-registry library used by this project
+var additionalSupportedKinds = []string{"ConsolePlugin", "NetworkPolicy"}
+
+// InventoryItem defines a manifest shipped in the bundle which will be installed by OLM
+type InventoryItem struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	APIVersion string `json:"apiVersion"`
+	// Size is the size in bytes of the manifest serialized as JSON
+	Size int `json:"size"`
+	// Supported is false when OLM does not support to install the kind of the manifest
+	Supported bool `json:"supported"`
+}
+
+// String returns the description of the item used to report it
+// (e.g. ConfigMap example (v1): 120 bytes)
+func (i InventoryItem) String() string {
+	return fmt.Sprintf("%s %s (%s): %d bytes", i.Kind, i.Name, i.APIVersion, i.Size)
+}
+
+// GetInventory returns all manifests shipped in the bundle in the order they were found
+func GetInventory(bundle *manifests.Bundle) ([]InventoryItem, error) {
+	if bundle == nil {
+		return nil, fmt.Errorf("bundle is nil")
+	}
+	var items []InventoryItem
+	for _, obj := range bundle.Objects {
+		if obj == nil {
+			continue
+		}
+		b, err := json.Marshal(obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize the %s %s: %s", obj.GetKind(), obj.GetName(), err)
+		}
+		items = append(items, InventoryItem{
+			Kind:       obj.GetKind(),
+			Name:       obj.GetName(),
+			APIVersion: obj.GetAPIVersion(),
+			Size:       len(b),
+			Supported:  isSupportedKind(obj.GetKind()),
+		})
+	}
+	return items, nil
+}
+
+// isSupportedKind returns true when OLM supports to install the manifests of the kind informed
+func isSupportedKind(kind string) bool {
+	supported, _ := registrybundle.IsSupported(kind)
+	return supported || containsAny(additionalSupportedKinds, kind)
+}
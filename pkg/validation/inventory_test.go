@@ -0,0 +1,52 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetInventory(t *testing.T) {
+	bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+	require.NoError(t, err)
+	bundle.Objects = append(bundle.Objects, &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "example"},
+	}})
+
+	inventory, err := GetInventory(bundle)
+	require.NoError(t, err)
+	require.Equal(t, len(bundle.Objects), len(inventory))
+
+	var unsupported []string
+	for _, item := range inventory {
+		require.NotEmpty(t, item.Kind)
+		require.NotEmpty(t, item.APIVersion)
+		require.Greater(t, item.Size, 0)
+		if !item.Supported {
+			unsupported = append(unsupported, item.Kind)
+		}
+	}
+	require.Equal(t, []string{"Deployment"}, unsupported)
+	require.Equal(t, "Deployment example (apps/v1): 74 bytes", inventory[len(inventory)-1].String())
+
+	_, err = GetInventory(nil)
+	require.EqualError(t, err, "bundle is nil")
+}
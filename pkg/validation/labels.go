@@ -0,0 +1,108 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"strings"
+)
+
+// NormalizeLabelValue returns the value of a label as it is found after its key in the bundle.Dockerfile
+// (e.g. `="v4.6-v4.8"`) or metadata/annotations.yaml (e.g. `: 'v4.8'`) of the bundles. It is exported
+// so that the tools which read the same labels normalize them as the validators do:
+//
+// - the spaces, tabs and carriage returns (CRLF) around the value are removed
+//
+// - one leading separator (= for the Dockerfile labels and : for the annotations) is removed
+//
+// - the double and single quotes are removed. Note that only the quoted value is returned when it is
+// followed by other content (e.g. `="v4.8" other="value"`)
+//
+// - the value ends on the first space when = is the separator (e.g. `=v4.8 other=value`), on the
+// comments when : is the separator (e.g. `: v4.8 # comment`) and before the line continuations (\)
+func NormalizeLabelValue(value string) string {
+	value = strings.TrimSpace(value)
+	separator := ""
+	if strings.HasPrefix(value, "=") || strings.HasPrefix(value, ":") {
+		separator = value[:1]
+		value = strings.TrimSpace(value[1:])
+	}
+
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return strings.TrimSpace(value[1 : end+1])
+		}
+	}
+
+	switch separator {
+	case "=":
+		if fields := strings.Fields(value); len(fields) > 0 {
+			value = fields[0]
+		}
+	case ":":
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+	}
+	value = strings.TrimSpace(strings.TrimSuffix(value, "\\"))
+	// the quotes left are not paired (e.g. `="v4.8`)
+	value = strings.ReplaceAll(value, "\"", "")
+	value = strings.ReplaceAll(value, "'", "")
+	return strings.TrimSpace(value)
+}
+
+// GetLabelValue returns the normalized value (see NormalizeLabelValue) of the label informed found in the
+// content of a bundle.Dockerfile or metadata/annotations.yaml. The lines can end with LF or CRLF and
+// only the lines where the label is followed by = or : are considered. Note that false is returned
+// when the label is not found.
+func GetLabelValue(content, label string) (string, bool) {
+	for _, line := range splitLabelLines(content) {
+		if value, found := getLabelValueFromLine(line, label); found {
+			return NormalizeLabelValue(value), true
+		}
+	}
+	return "", false
+}
+
+// splitLabelLines returns the lines of the content informed removing the carriage returns of the
+// files with CRLF line endings
+func splitLabelLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	return lines
+}
+
+// getLabelValueFromLine returns the content found after the label informed in the line when the label
+// is a key (e.g. LABEL key=value, key: value or "key": value)
+func getLabelValueFromLine(line, label string) (string, bool) {
+	for offset := 0; offset < len(line); {
+		i := strings.Index(line[offset:], label)
+		if i < 0 {
+			return "", false
+		}
+		start := offset + i
+		offset = start + len(label)
+		if start > 0 && !strings.ContainsAny(line[start-1:start], " \t\"'") {
+			continue
+		}
+		rest := strings.TrimLeft(line[offset:], "\"'")
+		if trimmed := strings.TrimSpace(rest); strings.HasPrefix(trimmed, "=") ||
+			strings.HasPrefix(trimmed, ":") {
+			return rest, true
+		}
+	}
+	return "", false
+}
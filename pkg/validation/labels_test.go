@@ -0,0 +1,125 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLabelValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "should return the value of a Dockerfile label", value: `="v4.6-v4.8"`, want: "v4.6-v4.8"},
+		{name: "should return the value of an annotation", value: `: "v4.8"`, want: "v4.8"},
+		{name: "should return the value with single quotes", value: `: 'v4.8'`, want: "v4.8"},
+		{name: "should return the value without quotes", value: `=v4.8`, want: "v4.8"},
+		{name: "should keep the exact versions", value: `: "=v4.8"`, want: "=v4.8"},
+		{name: "should keep the exact versions without quotes", value: `==v4.8`, want: "=v4.8"},
+		{name: "should remove the spaces around the separator", value: " \t= \"v4.8\" ", want: "v4.8"},
+		{name: "should remove the carriage returns", value: ": v4.8\r", want: "v4.8"},
+		{name: "should return the quoted value followed by other labels", value: `="v4.8" other="value"`,
+			want: "v4.8"},
+		{name: "should return the value followed by other labels", value: `=v4.8 other=value`, want: "v4.8"},
+		{name: "should remove the line continuations", value: `=v4.8 \`, want: "v4.8"},
+		{name: "should remove the comments of the annotations", value: `: v4.8 # the supported versions`,
+			want: "v4.8"},
+		{name: "should keep the spaces of the annotations", value: `: My Operator`, want: "My Operator"},
+		{name: "should remove the quotes not paired", value: `="v4.8`, want: "v4.8"},
+		{name: "should return the value without separator", value: ` "4.8" `, want: "4.8"},
+		{name: "should return empty when the value is empty", value: `: ""`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, NormalizeLabelValue(tt.value))
+		})
+	}
+}
+
+func TestGetLabelValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		label     string
+		want      string
+		wantFound bool
+	}{
+		{
+			name:      "should return the value of the Dockerfile label",
+			content:   "FROM scratch\nLABEL com.redhat.openshift.versions=\"v4.6-v4.8\"\n",
+			label:     ocpLabel,
+			want:      "v4.6-v4.8",
+			wantFound: true,
+		},
+		{
+			name:      "should return the value of the annotation",
+			content:   "annotations:\n  com.redhat.openshift.versions: \"=v4.8\"\n",
+			label:     ocpLabel,
+			want:      "=v4.8",
+			wantFound: true,
+		},
+		{
+			name:      "should return the value of the files with CRLF line endings",
+			content:   "annotations:\r\n  com.redhat.openshift.versions: v4.8\r\n  other: value\r\n",
+			label:     ocpLabel,
+			want:      "v4.8",
+			wantFound: true,
+		},
+		{
+			name:      "should return the value of the quoted keys",
+			content:   "annotations:\n  \"com.redhat.openshift.versions\": \"v4.8\"\n",
+			label:     ocpLabel,
+			want:      "v4.8",
+			wantFound: true,
+		},
+		{
+			name:      "should return the value of a label informed with others",
+			content:   "LABEL name=\"operator\" com.redhat.openshift.versions=\"v4.9\" vendor=\"Red Hat\"\n",
+			label:     ocpLabel,
+			want:      "v4.9",
+			wantFound: true,
+		},
+		{
+			name:      "should ignore the labels which contain the label informed",
+			content:   "LABEL x.com.redhat.openshift.versions.old=v4.6\nLABEL com.redhat.openshift.versions=v4.8\n",
+			label:     ocpLabel,
+			want:      "v4.8",
+			wantFound: true,
+		},
+		{
+			name:      "should ignore the comments which mention the label",
+			content:   "# com.redhat.openshift.versions is required\nLABEL com.redhat.openshift.versions=v4.8\n",
+			label:     ocpLabel,
+			want:      "v4.8",
+			wantFound: true,
+		},
+		{
+			name:    "should return false when the label is not found",
+			content: "annotations:\n  operators.operatorframework.io.bundle.package.v1: memcached-operator\n",
+			label:   ocpLabel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, found := GetLabelValue(tt.content, tt.label)
+			require.Equal(t, tt.wantFound, found)
+			require.Equal(t, tt.want, value)
+		})
+	}
+}
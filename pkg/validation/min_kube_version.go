@@ -0,0 +1,175 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// introducedK8sAPI defines the Kubernetes version (minor) from which an API is served by default
+type introducedK8sAPI struct {
+	apiVersion string
+	kind       string
+	minor      int
+}
+
+// introducedK8sAPIs defines the APIs which are only served from Kubernetes versions upper than the ones of the
+// lowest OCP versions whose catalogs distribute the bundle format (e.g. policy/v1 from 1.21)
+var introducedK8sAPIs = []introducedK8sAPI{
+	{"apiextensions.k8s.io/v1", "CustomResourceDefinition", 16},
+	{"admissionregistration.k8s.io/v1", "ValidatingWebhookConfiguration", 16},
+	{"admissionregistration.k8s.io/v1", "MutatingWebhookConfiguration", 16},
+	{"networking.k8s.io/v1", "Ingress", 19},
+	{"networking.k8s.io/v1", "IngressClass", 19},
+	{"certificates.k8s.io/v1", "CertificateSigningRequest", 19},
+	{"node.k8s.io/v1", "RuntimeClass", 20},
+	{"policy/v1", "PodDisruptionBudget", 21},
+	{"batch/v1", "CronJob", 21},
+	{"discovery.k8s.io/v1", "EndpointSlice", 21},
+	{"autoscaling/v2", "HorizontalPodAutoscaler", 23},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "FlowSchema", 23},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "PriorityLevelConfiguration", 23},
+	{"storage.k8s.io/v1", "CSIStorageCapacity", 24},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema", 26},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "PriorityLevelConfiguration", 26},
+	{"flowcontrol.apiserver.k8s.io/v1", "FlowSchema", 29},
+	{"flowcontrol.apiserver.k8s.io/v1", "PriorityLevelConfiguration", 29},
+	{"admissionregistration.k8s.io/v1", "ValidatingAdmissionPolicy", 30},
+	{"admissionregistration.k8s.io/v1", "ValidatingAdmissionPolicyBinding", 30},
+}
+
+// celValidationsMinK8sMinor defines the Kubernetes version (minor) from which the validation rules of the CRDs
+// (x-kubernetes-validations) are enabled by default
+const celValidationsMinK8sMinor = 25
+
+// MinKubeVersionValidator validates the spec.minKubeVersion of the CSV, which is used by OLM to prevent the
+// installation of the operator on the clusters whose Kubernetes version is lower. Following its current checks:
+//
+// - Warn when the spec.minKubeVersion is not informed and the bundle uses APIs which are only served from a
+// Kubernetes version upper than the one of the lowest OCP version where the bundle is distributed (e.g. policy/v1
+// PodDisruptionBudget from 1.21 and the x-kubernetes-validations of the CRDs from 1.25)
+var MinKubeVersionValidator interfaces.Validator = newBundleValidator("min-kube-version", checkMinKubeVersion)
+
+// checkMinKubeVersion will recommend the spec.minKubeVersion when the bundle requires a Kubernetes version
+func checkMinKubeVersion(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(strings.TrimSpace(checks.bundle.CSV.Spec.MinKubeVersion)) > 0 {
+		return checks
+	}
+	minor, apis := getRequiredK8sMinor(&checks.bundle)
+	if minor == 0 {
+		return checks
+	}
+
+	// Only the APIs which are not served by the lowest OCP version where the bundle is distributed are reported
+	ocpVersion := fmt.Sprintf("4.%d", minor-k8sToOCPMinorOffset)
+	r := getOCPRange(checks)
+	if len(r) == 0 {
+		r = "v" + bundleFormatMinOCPVersion
+	}
+	if lower, err := rangeAllowsVersionLowerThan(r, ocpVersion); err == nil && !lower {
+		return checks
+	}
+
+	checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the spec.minKubeVersion is not informed but the "+
+		"bundle uses APIs which are only available from Kubernetes 1.%d (OCP %s): %s. Note that OLM allows to install "+
+		"the operator on the clusters which do not serve them", minor, ocpVersion, strings.Join(apis, ", ")),
+		"inform spec.minKubeVersion: 1.%d.0", minor))
+	return checks
+}
+
+// getRequiredK8sMinor returns the Kubernetes version (minor) required by the APIs used by the bundle with the
+// description of the APIs which require it. Note that 0 is returned when no known API requires a version.
+func getRequiredK8sMinor(bundle *manifests.Bundle) (int, []string) {
+	minor := 0
+	found := map[string]bool{}
+	var apis []string
+	require := func(version int, api string) {
+		if version > minor {
+			minor, apis, found = version, nil, map[string]bool{}
+		}
+		if version == minor && !found[api] {
+			found[api] = true
+			apis = append(apis, api)
+		}
+	}
+
+	for _, obj := range bundle.Objects {
+		if obj == nil {
+			continue
+		}
+		for _, api := range introducedK8sAPIs {
+			if obj.GetAPIVersion() == api.apiVersion && obj.GetKind() == api.kind {
+				require(api.minor, api.apiVersion+" "+api.kind)
+			}
+		}
+	}
+	for _, crd := range bundle.V1CRDs {
+		if crd == nil {
+			continue
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Schema != nil && hasValidationRules(v.Schema.OpenAPIV3Schema) {
+				require(celValidationsMinK8sMinor, "the x-kubernetes-validations of the CRD "+crd.GetName())
+				break
+			}
+		}
+	}
+	sort.Strings(apis)
+	return minor, apis
+}
+
+// hasValidationRules returns true when the schema informed or any nested schema has validation rules
+// (x-kubernetes-validations)
+func hasValidationRules(schema *apiextensionsv1.JSONSchemaProps) bool {
+	if schema == nil {
+		return false
+	}
+	if len(schema.XValidations) > 0 {
+		return true
+	}
+	for _, property := range schema.Properties {
+		property := property
+		if hasValidationRules(&property) {
+			return true
+		}
+	}
+	if schema.Items != nil {
+		if hasValidationRules(schema.Items.Schema) {
+			return true
+		}
+		for i := range schema.Items.JSONSchemas {
+			if hasValidationRules(&schema.Items.JSONSchemas[i]) {
+				return true
+			}
+		}
+	}
+	if schema.AdditionalProperties != nil && hasValidationRules(schema.AdditionalProperties.Schema) {
+		return true
+	}
+	for _, schemas := range [][]apiextensionsv1.JSONSchemaProps{schema.AllOf, schema.AnyOf, schema.OneOf} {
+		for i := range schemas {
+			if hasValidationRules(&schemas[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_MinKubeVersionValidator(t *testing.T) {
+	newObject := func(apiVersion, kind string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetName("memcached")
+		return obj
+	}
+
+	type args struct {
+		bundleDir      string
+		minKubeVersion string
+		objects        []*unstructured.Unstructured
+		optionalValues map[string]string
+		mutate         func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantWarning bool
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the APIs used are served by the lowest OCP version of the bundle format",
+			args: args{bundleDir: "./testdata/valid_bundle_v1"},
+		},
+		{
+			name: "should pass when the spec.minKubeVersion is informed",
+			args: args{
+				bundleDir:      "./testdata/valid_bundle_v1",
+				minKubeVersion: "1.21.0",
+				objects:        []*unstructured.Unstructured{newObject("policy/v1", "PodDisruptionBudget")},
+			},
+		},
+		{
+			name: "should pass when the APIs used are served by the lowest OCP version of the range",
+			args: args{
+				bundleDir:      "./testdata/valid_bundle_v1",
+				objects:        []*unstructured.Unstructured{newObject("policy/v1", "PodDisruptionBudget")},
+				optionalValues: map[string]string{RangeKey: "v4.8"},
+			},
+		},
+		{
+			name:        "should warn when the APIs used require a Kubernetes version which is not informed",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				objects: []*unstructured.Unstructured{
+					newObject("policy/v1", "PodDisruptionBudget"),
+					newObject("batch/v1", "CronJob"),
+					newObject("networking.k8s.io/v1", "Ingress"),
+				},
+				optionalValues: map[string]string{RangeKey: "v4.6-v4.12"},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the spec.minKubeVersion is not " +
+				"informed but the bundle uses APIs which are only available from Kubernetes 1.21 (OCP 4.8): " +
+				"batch/v1 CronJob, policy/v1 PodDisruptionBudget. Note that OLM allows to install the operator on " +
+				"the clusters which do not serve them. Please, inform spec.minKubeVersion: 1.21.0"},
+		},
+		{
+			name:        "should warn when the CRDs use validation rules and the Kubernetes version is not informed",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					schema := bundle.V1CRDs[0].Spec.Versions[0].Schema.OpenAPIV3Schema
+					spec := schema.Properties["spec"]
+					size := spec.Properties["size"]
+					size.XValidations = apiextensionsv1.ValidationRules{{Rule: "self >= 0"}}
+					spec.Properties["size"] = size
+					schema.Properties["spec"] = spec
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the spec.minKubeVersion is not " +
+				"informed but the bundle uses APIs which are only available from Kubernetes 1.25 (OCP 4.12): " +
+				"the x-kubernetes-validations of the CRD memcacheds.cache.example.com. Note that OLM allows to " +
+				"install the operator on the clusters which do not serve them. Please, inform " +
+				"spec.minKubeVersion: 1.25.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			bundle.CSV.Spec.MinKubeVersion = tt.args.minKubeVersion
+			bundle.Objects = append(bundle.Objects, tt.args.objects...)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := MinKubeVersionValidator.Validate(bundle, tt.args.optionalValues)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], false, tt.wantWarning, nil, tt.warnStrings)
+		})
+	}
+}
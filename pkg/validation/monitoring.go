@@ -0,0 +1,117 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// clusterMonitoringAnnotation defines the CSV annotation used to inform that the namespace suggested
+// should be labeled to be monitored by the cluster monitoring stack
+const clusterMonitoringAnnotation = "operatorframework.io/cluster-monitoring"
+
+// clusterMonitoringNamespacePrefix defines the prefix of the namespaces monitored by the cluster monitoring
+const clusterMonitoringNamespacePrefix = "openshift-"
+
+// The service account used by the Prometheus of the cluster monitoring to scrape the metrics
+const (
+	prometheusServiceAccount = "prometheus-k8s"
+	monitoringNamespace      = "openshift-monitoring"
+)
+
+// monitorKinds defines the kinds of the manifests used to configure the metrics which are scraped
+var monitorKinds = []string{"ServiceMonitor", "PodMonitor"}
+
+// MonitoringValidator validates the configuration of the bundles to be monitored by the cluster monitoring
+// stack via the operatorframework.io/cluster-monitoring annotation. Following its current checks:
+//
+// - Ensure that the annotation is "true" or "false" and that the namespace suggested via the
+// operatorframework.io/suggested-namespace or suggested-namespace-template annotations is an openshift-*
+// namespace when it is "true". Note that only these namespaces are scraped by the cluster monitoring.
+//
+// - Warn when the annotation is "true" but the bundle does not ship a ServiceMonitor or PodMonitor or a
+// RoleBinding which allows the prometheus-k8s service account (openshift-monitoring) to scrape the metrics
+var MonitoringValidator interfaces.Validator = newBundleValidator("monitoring", checkClusterMonitoring)
+
+// checkClusterMonitoring will verify the operatorframework.io/cluster-monitoring annotation
+func checkClusterMonitoring(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	value, ok := checks.bundle.CSV.GetAnnotations()[clusterMonitoringAnnotation]
+	if !ok || value == "false" {
+		return checks
+	}
+	if value != "true" {
+		checks.errs = append(checks.errs, fmt.Errorf("the annotation %s has the value (%s) but it must be "+
+			"\"true\" or \"false\"", clusterMonitoringAnnotation, value))
+		return checks
+	}
+
+	ns := getSuggestedNamespace(checks)
+	if len(ns) == 0 {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the annotation %s is true but no namespace is "+
+			"suggested", clusterMonitoringAnnotation),
+			"suggest an %s* namespace via the annotation %s or %s since the namespace is only labeled to be monitored "+
+				"when the operator is installed in the suggested namespace", clusterMonitoringNamespacePrefix,
+			suggestedNamespaceAnnotation, suggestedNamespaceTemplateAnnotation))
+	} else if !strings.HasPrefix(ns, clusterMonitoringNamespacePrefix) {
+		checks.errs = append(checks.errs, fmt.Errorf("the annotation %s is true but the suggested namespace (%s) "+
+			"is not an %s* namespace. Note that the cluster monitoring will never scrape the metrics of the "+
+			"operator", clusterMonitoringAnnotation, ns, clusterMonitoringNamespacePrefix))
+	}
+
+	hasMonitor := false
+	hasPrometheusBinding := false
+	for _, obj := range checks.bundle.Objects {
+		if containsAny(monitorKinds, obj.GetKind()) {
+			hasMonitor = true
+		}
+		if obj.GetKind() == "RoleBinding" && bindsPrometheus(obj) {
+			hasPrometheusBinding = true
+		}
+	}
+	if !hasMonitor {
+		checks.warns = append(checks.warns, fmt.Errorf("the annotation %s is true but the bundle does not ship "+
+			"a %s. Note that no metrics will be scraped by the cluster monitoring", clusterMonitoringAnnotation,
+			strings.Join(monitorKinds, " or ")))
+	}
+	if !hasPrometheusBinding {
+		checks.warns = append(checks.warns, withSubject(fmt.Errorf("the annotation %s is true but the bundle does not "+
+			"ship a RoleBinding for the service account %s of the namespace %s. Note that the cluster monitoring will "+
+			"not be allowed to discover the metrics endpoints", clusterMonitoringAnnotation, prometheusServiceAccount,
+			monitoringNamespace), "ServiceAccount", prometheusServiceAccount))
+	}
+	return checks
+}
+
+// bindsPrometheus returns true when the binding informed has the service account of the cluster monitoring
+// as a subject
+func bindsPrometheus(obj *unstructured.Unstructured) bool {
+	subjects, _, _ := unstructured.NestedSlice(obj.Object, "subjects")
+	for _, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(subject, "name")
+		ns, _, _ := unstructured.NestedString(subject, "namespace")
+		if name == prometheusServiceAccount && ns == monitoringNamespace {
+			return true
+		}
+	}
+	return false
+}
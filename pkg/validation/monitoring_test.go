@@ -0,0 +1,150 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_MonitoringValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the cluster monitoring is not enabled",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the cluster monitoring is configured",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[clusterMonitoringAnnotation] = "true"
+					bundle.CSV.Annotations[suggestedNamespaceAnnotation] = "openshift-memcached-operator"
+					addPrometheusRoleBinding(bundle)
+				},
+			},
+		},
+		{
+			name:      "should fail when the annotation is not a boolean",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[clusterMonitoringAnnotation] = "enabled"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operatorframework.io/cluster-monitoring has the value (enabled) but it must be \"true\" or \"false\""},
+		},
+		{
+			name:        "should fail when no namespace is suggested",
+			wantError:   true,
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[clusterMonitoringAnnotation] = "true"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operatorframework.io/cluster-monitoring is true but no namespace is suggested. Please, suggest an " +
+				"openshift-* namespace via the annotation operatorframework.io/suggested-namespace or " +
+				"operatorframework.io/suggested-namespace-template since the namespace is only labeled to be " +
+				"monitored when the operator is installed in the suggested namespace"},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operatorframework.io/cluster-monitoring is true but the bundle does not ship a RoleBinding for " +
+				"the service account prometheus-k8s of the namespace openshift-monitoring. Note that the cluster " +
+				"monitoring will not be allowed to discover the metrics endpoints"},
+		},
+		{
+			name:        "should fail when the suggested namespace is not monitored",
+			wantError:   true,
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[clusterMonitoringAnnotation] = "true"
+					setSuggestedNamespaceTemplate(bundle, podSecurityRestricted)
+					addPrometheusRoleBinding(bundle)
+					for i, obj := range bundle.Objects {
+						if obj.GetKind() == "ServiceMonitor" {
+							bundle.Objects = append(bundle.Objects[:i], bundle.Objects[i+1:]...)
+							break
+						}
+					}
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operatorframework.io/cluster-monitoring is true but the suggested namespace " +
+				"(memcached-operator-system) is not an openshift-* namespace. Note that the cluster monitoring " +
+				"will never scrape the metrics of the operator"},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operatorframework.io/cluster-monitoring is true but the bundle does not ship a ServiceMonitor or " +
+				"PodMonitor. Note that no metrics will be scraped by the cluster monitoring"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := MonitoringValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// addPrometheusRoleBinding ships a RoleBinding for the service account of the cluster monitoring
+func addPrometheusRoleBinding(bundle *manifests.Bundle) {
+	binding := &unstructured.Unstructured{Object: map[string]interface{}{
+		"roleRef": map[string]interface{}{
+			"apiGroup": "rbac.authorization.k8s.io",
+			"kind":     "Role",
+			"name":     "prometheus-k8s",
+		},
+		"subjects": []interface{}{
+			map[string]interface{}{
+				"kind":      "ServiceAccount",
+				"name":      prometheusServiceAccount,
+				"namespace": monitoringNamespace,
+			},
+		},
+	}}
+	binding.SetAPIVersion("rbac.authorization.k8s.io/v1")
+	binding.SetKind("RoleBinding")
+	binding.SetName("prometheus-k8s")
+	bundle.Objects = append(bundle.Objects, binding)
+}
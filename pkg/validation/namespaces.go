@@ -0,0 +1,195 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NamespaceValidator looks for namespaces hard-coded in the bundle. Note that OLM installs the
+// operators in the namespace chosen by the cluster admin. Following its current checks:
+//
+// - Warn when the manifests shipped in the bundle (other than the CSV) define metadata.namespace
+// or when the RoleBindings and ClusterRoleBindings have subjects with a namespace
+//
+// - Warn when the containers of the deployments defined in the CSV have environment variables
+// for namespaces (e.g. WATCH_NAMESPACE) with a literal value instead of using the downward API
+// or the olm.targetNamespaces annotation
+//
+// - Ensure that the namespaces suggested via the operatorframework.io/suggested-namespace and
+// operatorframework.io/suggested-namespace-template annotations are valid and warn when they are
+// inconsistent or when the operator supports the OwnNamespace or SingleNamespace install modes and a
+// reserved namespace (openshift-*, kube-* or default) is suggested
+var NamespaceValidator interfaces.Validator = newBundleValidator("namespaces", checkHardCodedNamespaces,
+	checkSuggestedNamespace)
+
+// reservedNamespacePrefixes defines the prefixes of the namespaces reserved for the platform
+var reservedNamespacePrefixes = []string{"openshift-", "kube-"}
+
+// checkHardCodedNamespaces will look for namespaces hard-coded in the manifests and deployments of the bundle
+func checkHardCodedNamespaces(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, obj := range checks.bundle.Objects {
+		if obj.GetKind() == operatorsv1alpha1.ClusterServiceVersionKind {
+			continue
+		}
+
+		if ns := obj.GetNamespace(); len(ns) > 0 {
+			checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the %s %s defines the "+
+				"namespace (%s) which will be ignored or will break the install when the operator is installed in "+
+				"another namespace", obj.GetKind(), obj.GetName(), ns), "remove metadata.namespace"), "Namespace", ns))
+		}
+
+		if obj.GetKind() == "RoleBinding" || obj.GetKind() == "ClusterRoleBinding" {
+			checks = checkBindingSubjectsNamespaces(checks, obj)
+		}
+	}
+
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			for _, env := range c.Env {
+				if env.ValueFrom != nil || len(env.Value) == 0 ||
+					!strings.Contains(strings.ToUpper(env.Name), "NAMESPACE") {
+					continue
+				}
+				checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the container %s of the "+
+					"deployment %s has the environment variable %s with the hard-coded value (%s). Note that OLM "+
+					"installs the operator in the namespace chosen by the user which breaks the AllNamespaces and "+
+					"OwnNamespace install modes", c.Name, dep.Name, env.Name, env.Value),
+					"use the downward API with metadata.namespace or metadata.annotations['olm.targetNamespaces'] "+
+						"instead"), "Deployment", dep.Name))
+			}
+		}
+	}
+	return checks
+}
+
+// checkSuggestedNamespace will verify the namespace suggested via the annotations
+func checkSuggestedNamespace(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	annotations := checks.bundle.CSV.GetAnnotations()
+	name, hasName := annotations[suggestedNamespaceAnnotation]
+	template, hasTemplate := annotations[suggestedNamespaceTemplateAnnotation]
+	if !hasName && !hasTemplate {
+		return checks
+	}
+
+	if hasName {
+		if errs := k8svalidation.IsDNS1123Label(name); len(errs) > 0 {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the namespace (%s) informed via the annotation "+
+				"%s is not a valid name: %s", name, suggestedNamespaceAnnotation, strings.Join(errs, ", ")),
+				"Namespace", name))
+			return checks
+		}
+	}
+
+	if hasTemplate {
+		ns := corev1.Namespace{}
+		if err := json.Unmarshal([]byte(template), &ns); err != nil {
+			checks.errs = append(checks.errs, fmt.Errorf("unable to parse the annotation %s: %s",
+				suggestedNamespaceTemplateAnnotation, err))
+			return checks
+		}
+		if ns.Kind != "Namespace" || ns.APIVersion != "v1" {
+			checks.errs = append(checks.errs, fmt.Errorf("the annotation %s must inform a v1 Namespace but it "+
+				"informs the %s %s", suggestedNamespaceTemplateAnnotation, ns.APIVersion, ns.Kind))
+			return checks
+		}
+		if errs := k8svalidation.IsDNS1123Label(ns.Name); len(errs) > 0 {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the namespace (%s) informed via the annotation "+
+				"%s is not a valid name: %s", ns.Name, suggestedNamespaceTemplateAnnotation, strings.Join(errs, ", ")),
+				"Namespace", ns.Name))
+			return checks
+		}
+		if hasName && ns.Name != name {
+			checks.warns = append(checks.warns, withSubject(fmt.Errorf("the namespace (%s) informed via the "+
+				"annotation %s does not match the namespace (%s) informed via the annotation %s. Note that the "+
+				"console uses the template", name, suggestedNamespaceAnnotation, ns.Name,
+				suggestedNamespaceTemplateAnnotation), "Namespace", name))
+		}
+		name = ns.Name
+	}
+
+	if isReservedNamespace(name) &&
+		(supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeOwnNamespace) ||
+			supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeSingleNamespace)) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the suggested namespace (%s) is reserved for "+
+			"the platform (%s* or default) and the operator supports the OwnNamespace or SingleNamespace install "+
+			"modes, which would create its operands in this namespace", name,
+			strings.Join(reservedNamespacePrefixes, "*, ")), "suggest a namespace without a reserved prefix"))
+	}
+	return checks
+}
+
+// getSuggestedNamespace returns the namespace suggested via the annotations or an empty value when it is
+// not informed. Note that the template is used by the console when both annotations are informed.
+func getSuggestedNamespace(checks OpenShiftOperatorChecks) string {
+	annotations := checks.bundle.CSV.GetAnnotations()
+	if template, ok := annotations[suggestedNamespaceTemplateAnnotation]; ok {
+		ns := corev1.Namespace{}
+		if err := json.Unmarshal([]byte(template), &ns); err == nil && len(ns.Name) > 0 {
+			return ns.Name
+		}
+	}
+	return annotations[suggestedNamespaceAnnotation]
+}
+
+// isReservedNamespace returns true when the namespace informed is reserved for the platform
+func isReservedNamespace(name string) bool {
+	if name == "default" {
+		return true
+	}
+	for _, prefix := range reservedNamespacePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBindingSubjectsNamespaces will look for namespaces hard-coded in the subjects of the binding informed
+func checkBindingSubjectsNamespaces(checks OpenShiftOperatorChecks,
+	obj *unstructured.Unstructured) OpenShiftOperatorChecks {
+	subjects, _, err := unstructured.NestedSlice(obj.Object, "subjects")
+	if err != nil {
+		return checks
+	}
+	for _, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ns, _, _ := unstructured.NestedString(subject, "namespace")
+		if len(ns) == 0 {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(subject, "name")
+		if name == prometheusServiceAccount && ns == monitoringNamespace {
+			// the service account of the cluster monitoring is expected to be bound with its namespace
+			continue
+		}
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s %s has the subject %s with the "+
+			"hard-coded namespace (%s). Note that OLM installs the operator in the namespace chosen by the user",
+			obj.GetKind(), obj.GetName(), name, ns),
+			"prefer defining the permissions under spec.install.spec.permissions or clusterPermissions in the CSV"))
+	}
+	return checks
+}
@@ -0,0 +1,209 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_NamespaceValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the bundle has no hard-coded namespaces",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the namespace is obtained via the downward API",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+			},
+		},
+		{
+			name: "should pass when the RoleBinding subject is the service account of the cluster monitoring",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate:    addPrometheusRoleBinding,
+			},
+		},
+		{
+			name:        "should warn when the manifest defines the namespace",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					findObject(bundle, "ServiceAccount").SetNamespace("my-operator")
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the ServiceAccount " +
+				"memcached-operator-controller-manager defines the namespace (my-operator) which will be ignored " +
+				"or will break the install when the operator is installed in another namespace. Please, remove " +
+				"metadata.namespace"},
+		},
+		{
+			name:        "should warn when the RoleBinding subject defines the namespace",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					binding := &unstructured.Unstructured{Object: map[string]interface{}{
+						"apiVersion": "rbac.authorization.k8s.io/v1",
+						"kind":       "RoleBinding",
+						"metadata":   map[string]interface{}{"name": "memcached-operator-binding"},
+						"subjects": []interface{}{map[string]interface{}{
+							"kind":      "ServiceAccount",
+							"name":      "memcached-operator-controller-manager",
+							"namespace": "my-operator",
+						}},
+					}}
+					bundle.Objects = append(bundle.Objects, binding)
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the RoleBinding " +
+				"memcached-operator-binding has the subject memcached-operator-controller-manager with the " +
+				"hard-coded namespace (my-operator). Note that OLM installs the operator in the namespace chosen " +
+				"by the user. Please, prefer defining the permissions under spec.install.spec.permissions or " +
+				"clusterPermissions in the CSV"},
+		},
+		{
+			name:        "should warn when the deployment has a hard-coded namespace in the env vars",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					c := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers[0]
+					c.Env = append(c.Env, corev1.EnvVar{Name: "WATCH_NAMESPACE", Value: "my-operator"})
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the container kube-rbac-proxy of " +
+				"the deployment memcached-operator-controller-manager has the environment variable WATCH_NAMESPACE " +
+				"with the hard-coded value (my-operator). Note that OLM installs the operator in the namespace " +
+				"chosen by the user which breaks the AllNamespaces and OwnNamespace install modes. Please, use the " +
+				"downward API with metadata.namespace or metadata.annotations['olm.targetNamespaces'] instead"},
+		},
+		{
+			name: "should pass when the suggested namespaces are valid",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[suggestedNamespaceAnnotation] = "memcached-operator-system"
+					setSuggestedNamespaceTemplate(bundle, podSecurityRestricted)
+				},
+			},
+		},
+		{
+			name:      "should fail when the suggested namespace is invalid",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[suggestedNamespaceAnnotation] = "Memcached"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the namespace (Memcached) informed " +
+				"via the annotation operatorframework.io/suggested-namespace is not a valid name: a lowercase " +
+				"RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end " +
+				"with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is " +
+				"'[a-z0-9]([-a-z0-9]*[a-z0-9])?')"},
+		},
+		{
+			name:      "should fail when the suggested namespace template is invalid",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[suggestedNamespaceTemplateAnnotation] = "{invalid"
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to parse the annotation " +
+				"operatorframework.io/suggested-namespace-template: invalid character 'i' looking for beginning " +
+				"of object key string"},
+		},
+		{
+			name:      "should fail when the suggested namespace template is not a Namespace",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[suggestedNamespaceTemplateAnnotation] =
+						`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"memcached-operator"}}`
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operatorframework.io/suggested-namespace-template must inform a v1 Namespace but it informs the " +
+				"v1 ConfigMap"},
+		},
+		{
+			name:        "should warn when the suggested namespaces do not match",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[suggestedNamespaceAnnotation] = "memcached"
+					setSuggestedNamespaceTemplate(bundle, podSecurityRestricted)
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the namespace (memcached) " +
+				"informed via the annotation operatorframework.io/suggested-namespace does not match the namespace " +
+				"(memcached-operator-system) informed via the annotation operatorframework.io/suggested-namespace-template. " +
+				"Note that the console uses the template"},
+		},
+		{
+			name:        "should warn when the OwnNamespace operator suggests a reserved namespace",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[suggestedNamespaceAnnotation] = "openshift-etcd-operator"
+				},
+			},
+			warnStrings: []string{"Warning: Value : (etcdoperator.v0.9.4) the suggested namespace " +
+				"(openshift-etcd-operator) is reserved for the platform (openshift-*, kube-* or default) and the " +
+				"operator supports the OwnNamespace or SingleNamespace install modes, which would create its " +
+				"operands in this namespace. Please, suggest a namespace without a reserved prefix"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := NamespaceValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
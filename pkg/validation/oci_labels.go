@@ -0,0 +1,133 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// The OCI standard labels (annotations) which describe the bundle images
+const (
+	ociSourceLabel   = "org.opencontainers.image.source"
+	ociRevisionLabel = "org.opencontainers.image.revision"
+	ociVersionLabel  = "org.opencontainers.image.version"
+	ociLicensesLabel = "org.opencontainers.image.licenses"
+)
+
+// licenseIDRegexp matches the SPDX license identifiers (e.g. Apache-2.0, GPL-2.0+ or LicenseRef-Custom)
+var licenseIDRegexp = regexp.MustCompile(`^[A-Za-z0-9]+([.-][A-Za-z0-9]+)*\+?$`)
+
+// OCILabelsValidator validates the OCI standard labels (org.opencontainers.image.*) of the bundle. They are
+// read from the bundle.Dockerfile informed via the optional key value file and from the bundle image informed
+// via the optional key value bundle-image when the optional value check-images=true is informed since it
+// requires access to the registries. Note that the labels are optional and only checked when they are informed.
+// Following its current checks:
+//
+// - Warn when the org.opencontainers.image.source is not the URL of the source code
+//
+// - Warn when the org.opencontainers.image.revision has spaces
+//
+// - Warn when the org.opencontainers.image.version is not a semantic version or does not match with the
+// spec.version of the CSV
+//
+// - Warn when the org.opencontainers.image.licenses is not a SPDX license expression (e.g. Apache-2.0)
+var OCILabelsValidator interfaces.Validator = newBundleValidator("oci-labels", checkOCILabels)
+
+// checkOCILabels will verify the OCI labels of the bundle.Dockerfile and the bundle image
+func checkOCILabels(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(checks.filePath) > 0 {
+		// Note that issues to read the file are reported by the OpenShiftValidator
+		content, err := readFile(checks.fsys, checks.filePath)
+		if err == nil && isDockerfile(string(content)) {
+			checks = checkOCILabelValues(checks, checks.filePath, parseDockerfileLabels(string(content)))
+		}
+	}
+
+	if checks.checkImages && len(checks.bundle.BundleImage) > 0 {
+		labels, err := getImageInspector().GetLabels(checks.bundle.BundleImage)
+		if err != nil {
+			checks.errs = append(checks.errs, fmt.Errorf("unable to inspect the labels of the image %s: %s",
+				checks.bundle.BundleImage, err))
+			return checks
+		}
+		checks = checkOCILabelValues(checks, "the image "+checks.bundle.BundleImage, labels)
+	}
+	return checks
+}
+
+// checkOCILabelValues will verify the OCI labels informed which were found in the source informed
+func checkOCILabelValues(checks OpenShiftOperatorChecks, source string,
+	labels map[string]string) OpenShiftOperatorChecks {
+	if value, found := labels[ociSourceLabel]; found && !isSourceURL(value) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the label %s with the value %q in %s is not "+
+			"the URL of the source code", ociSourceLabel, value, source),
+			"inform the URL of the repository used to build the bundle (e.g. https://github.com/my-org/my-operator)"))
+	}
+
+	if value, found := labels[ociRevisionLabel]; found && (len(strings.TrimSpace(value)) == 0 ||
+		strings.ContainsAny(value, " \t")) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the label %s with the value %q in %s is not a "+
+			"valid revision", ociRevisionLabel, value, source),
+			"inform the revision of the source code used to build the bundle (e.g. the commit SHA)"))
+	}
+
+	if value, found := labels[ociVersionLabel]; found {
+		specVersion := checks.bundle.CSV.Spec.Version.String()
+		if version, ok := parseVersionReference(value); !ok {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the label %s with the value %q in %s is "+
+				"not a semantic version", ociVersionLabel, value, source),
+				"inform the version of the bundle (e.g. v%s)", specVersion))
+		} else if version.String() != specVersion {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the label %s with the value %q in %s does "+
+				"not match with the spec.version (%s) of the CSV", ociVersionLabel, value, source, specVersion),
+				"ensure that the labels describe the bundle which is published"))
+		}
+	}
+
+	if value, found := labels[ociLicensesLabel]; found && !isLicenseExpression(value) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the label %s with the value %q in %s is not a "+
+			"SPDX license expression", ociLicensesLabel, value, source),
+			"inform the licenses of the bundle with their SPDX identifiers (e.g. Apache-2.0 or MIT OR Apache-2.0)"))
+	}
+	return checks
+}
+
+// isSourceURL returns true when the value informed is an absolute URL (e.g. https://github.com/my-org/my-operator)
+func isSourceURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && len(u.Scheme) > 0 && len(u.Host) > 0
+}
+
+// isLicenseExpression returns true when the value informed is a SPDX license expression
+// (e.g. Apache-2.0, (MIT OR Apache-2.0) or GPL-2.0-only WITH Classpath-exception-2.0)
+func isLicenseExpression(value string) bool {
+	fields := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(value))
+	if len(fields) == 0 || strings.Count(value, "(") != strings.Count(value, ")") {
+		return false
+	}
+	for i, field := range fields {
+		isOperator := field == "AND" || field == "OR" || field == "WITH"
+		// the licenses and the operators are expected to alternate (e.g. MIT OR Apache-2.0)
+		if isOperator != (i%2 == 1) || (!isOperator && !licenseIDRegexp.MatchString(field)) {
+			return false
+		}
+	}
+	return len(fields)%2 == 1
+}
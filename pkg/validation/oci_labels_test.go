@@ -0,0 +1,158 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OCILabelsValidator(t *testing.T) {
+	const bundleImage = "quay.io/example/memcached-operator-bundle:v0.0.1"
+
+	type args struct {
+		dockerfile  string
+		checkImages bool
+		labels      map[string]string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the OCI labels are not informed",
+			args: args{
+				dockerfile: "FROM scratch\nLABEL com.redhat.openshift.versions=v4.12\n",
+			},
+		},
+		{
+			name: "should pass when the OCI labels are valid",
+			args: args{
+				dockerfile: "FROM scratch\n" +
+					"LABEL org.opencontainers.image.source=https://github.com/example/memcached-operator \\\n" +
+					"      org.opencontainers.image.revision=3f2c1a9 \\\n" +
+					"      org.opencontainers.image.version=v0.0.1 \\\n" +
+					"      org.opencontainers.image.licenses=\"(MIT OR Apache-2.0) AND LicenseRef-Example\"\n",
+				checkImages: true,
+				labels: map[string]string{
+					ociVersionLabel:  "0.0.1",
+					ociLicensesLabel: "GPL-2.0-only WITH Classpath-exception-2.0",
+				},
+			},
+		},
+		{
+			name:        "should warn when the OCI labels of the Dockerfile are invalid",
+			wantWarning: true,
+			args: args{
+				dockerfile: "FROM scratch\n" +
+					"LABEL org.opencontainers.image.source=github.com/example/memcached-operator \\\n" +
+					"      org.opencontainers.image.revision=\"main branch\" \\\n" +
+					"      org.opencontainers.image.version=latest \\\n" +
+					"      org.opencontainers.image.licenses=\"Apache License 2.0\"\n",
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the label org.opencontainers.image.source with the " +
+					"value \"github.com/example/memcached-operator\" in bundle.Dockerfile is not the URL of the " +
+					"source code. Please, inform the URL of the repository used to build the bundle (e.g. " +
+					"https://github.com/my-org/my-operator)",
+				"Warning: Value : (memcached-operator.v0.0.1) the label org.opencontainers.image.revision with the " +
+					"value \"main branch\" in bundle.Dockerfile is not a valid revision. Please, inform the " +
+					"revision of the source code used to build the bundle (e.g. the commit SHA)",
+				"Warning: Value : (memcached-operator.v0.0.1) the label org.opencontainers.image.version with the " +
+					"value \"latest\" in bundle.Dockerfile is not a semantic version. Please, inform the version of " +
+					"the bundle (e.g. v0.0.1)",
+				"Warning: Value : (memcached-operator.v0.0.1) the label org.opencontainers.image.licenses with the " +
+					"value \"Apache License 2.0\" in bundle.Dockerfile is not a SPDX license expression. Please, " +
+					"inform the licenses of the bundle with their SPDX identifiers (e.g. Apache-2.0 or MIT OR " +
+					"Apache-2.0)",
+			},
+		},
+		{
+			name:        "should warn when the version of the bundle image does not match with the CSV",
+			wantWarning: true,
+			args: args{
+				checkImages: true,
+				labels:      map[string]string{ociVersionLabel: "v0.0.2"},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the label " +
+				"org.opencontainers.image.version with the value \"v0.0.2\" in the image " + bundleImage +
+				" does not match with the spec.version (0.0.1) of the CSV. Please, ensure that the labels " +
+				"describe the bundle which is published"},
+		},
+		{
+			name:      "should fail when the labels of the bundle image cannot be inspected",
+			wantError: true,
+			args: args{
+				checkImages: true,
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to inspect the labels of the " +
+				"image " + bundleImage + ": manifest unknown"},
+		},
+	}
+
+	defaultInspector := imageInspector
+	defer func() { imageInspector = defaultInspector }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspector := fakeLabelsInspector{labels: map[string]map[string]string{}}
+			if tt.args.labels != nil {
+				inspector.labels[bundleImage] = tt.args.labels
+			}
+			imageInspector = inspector
+
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+
+			objs := []interface{}{bundle}
+			optionalValues := map[string]string{BundleImageKey: bundleImage}
+			if len(tt.args.dockerfile) > 0 {
+				optionalValues[FilePathKey] = "bundle.Dockerfile"
+				objs = append(objs, fstest.MapFS{"bundle.Dockerfile": {Data: []byte(tt.args.dockerfile)}})
+			}
+			if tt.args.checkImages {
+				optionalValues[CheckImagesKey] = "true"
+			}
+			objs = append(objs, optionalValues)
+
+			results := OCILabelsValidator.Validate(objs...)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+func Test_isLicenseExpression(t *testing.T) {
+	for value, want := range map[string]bool{
+		"Apache-2.0":                           true,
+		"GPL-2.0+":                             true,
+		"MIT OR Apache-2.0":                    true,
+		"(MIT OR Apache-2.0) AND BSD-3-Clause": true,
+		"LicenseRef-Custom":                    true,
+		"":                                     false,
+		"Apache License":                       false,
+		"MIT OR":                               false,
+		"(MIT OR Apache-2.0":                   false,
+		"MIT, Apache-2.0":                      false,
+	} {
+		require.Equal(t, want, isLicenseExpression(value), value)
+	}
+}
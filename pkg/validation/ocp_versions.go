@@ -0,0 +1,100 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/cincinnati"
+)
+
+// updateService defines the client used to get the OCP versions released
+var updateService cincinnati.Inspector = cincinnati.NewClient()
+
+// OCPVersionsValidator checks the OCP versions informed by the bundle against the versions released which are
+// obtained from the OpenShift Update Service (Cincinnati) and cached locally. It is not part of the
+// DefaultValidators. The update graph can be informed via OCP_UPDATE_SERVICE_URL. Following its current checks:
+//
+// - Warn when the versions of the com.redhat.openshift.versions label (e.g. v4.8-v4.10) or the
+// olm.maxOpenShiftVersion are not OCP versions released (e.g. typos) or are higher than the next version which
+// will be released. Note that the bundle is not distributed to the versions which were not released.
+var OCPVersionsValidator interfaces.Validator = newBundleValidator("ocp-versions", checkOCPVersions)
+
+// checkOCPVersions will verify the OCP versions informed by the bundle against the versions released
+func checkOCPVersions(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	ocpRange := getOCPRange(checks)
+	maxValue := getMaxAnnotationValue(OpenShiftOperatorChecks{bundle: checks.bundle}).maxValue
+	if len(ocpRange) == 0 && len(maxValue) == 0 {
+		return checks
+	}
+
+	versions, err := updateService.GetVersions()
+	if err != nil {
+		checks.warns = append(checks.warns, fmt.Errorf("unable to get the OCP versions released from the update "+
+			"service: %s. Note that the OCP versions informed were not checked", err))
+		return checks
+	}
+	latest := versions[len(versions)-1]
+	latestVersion, _ := semver.ParseTolerant(latest)
+	next := semver.Version{Major: latestVersion.Major, Minor: latestVersion.Minor + 1}
+
+	// informed maps the versions found to where they were informed
+	var informed [][2]string
+	for _, v := range getRangeVersions(ocpRange) {
+		informed = append(informed, [2]string{v, fmt.Sprintf("the %s label (%s)", ocpLabel, ocpRange)})
+	}
+	if len(maxValue) > 0 {
+		informed = append(informed, [2]string{NormalizeLabelValue(maxValue), olmmaxOcpVersion})
+	}
+
+	for _, v := range informed {
+		version, err := semver.ParseTolerant(v[0])
+		if err != nil {
+			// Note that the invalid versions are reported by the OpenShiftValidator
+			continue
+		}
+		version = semver.Version{Major: version.Major, Minor: version.Minor}
+		switch {
+		case version.GT(next):
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the version %s informed via %s is higher "+
+				"than the next OCP version (%d.%d) which will be released. Note that the latest version released is %s",
+				v[0], v[1], next.Major, next.Minor, latest), "ensure that it has no typos"))
+		case version.LT(next) && !containsAny(versions, fmt.Sprintf("%d.%d", version.Major, version.Minor)):
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the version %s informed via %s is not an "+
+				"OCP version released. Note that the versions released are %s to %s", v[0], v[1], versions[0], latest),
+				"ensure that it has no typos"))
+		}
+	}
+	return checks
+}
+
+// getRangeVersions returns the versions informed in the OCP range (e.g. v4.8 and v4.10 for v4.8-v4.10)
+func getRangeVersions(r string) []string {
+	if len(r) == 0 {
+		return nil
+	}
+	r = strings.TrimPrefix(NormalizeLabelValue(r), "=")
+	var versions []string
+	for _, v := range strings.FieldsFunc(r, func(c rune) bool { return c == '-' || c == ',' }) {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUpdateService struct {
+	versions []string
+	err      error
+}
+
+func (f fakeUpdateService) GetVersions() ([]string, error) {
+	return f.versions, f.err
+}
+
+func Test_OCPVersionsValidator(t *testing.T) {
+	defaultService := updateService
+	defer func() { updateService = defaultService }()
+
+	type args struct {
+		labelRange string
+		maxVersion string
+		err        error
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantWarning bool
+		warnStrings []string
+	}{
+		{
+			name: "should pass when no OCP version is informed",
+		},
+		{
+			name: "should pass when the versions were released or are the next version",
+			args: args{
+				labelRange: "v4.12-v4.15",
+				maxVersion: "4.15",
+			},
+		},
+		{
+			name: "should pass when the range informs a single version",
+			args: args{
+				labelRange: "=v4.12",
+			},
+		},
+		{
+			name:        "should warn when the versions were not released",
+			wantWarning: true,
+			args: args{
+				labelRange: "v4.0-v4.41",
+				maxVersion: "4.16",
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the version v4.0 informed via the " +
+					"com.redhat.openshift.versions label (v4.0-v4.41) is not an OCP version released. Note that the " +
+					"versions released are 4.1 to 4.14. Please, ensure that it has no typos",
+				"Warning: Value : (memcached-operator.v0.0.1) the version v4.41 informed via the " +
+					"com.redhat.openshift.versions label (v4.0-v4.41) is higher than the next OCP version (4.15) " +
+					"which will be released. Note that the latest version released is 4.14. Please, ensure that it " +
+					"has no typos",
+				"Warning: Value : (memcached-operator.v0.0.1) the version 4.16 informed via " +
+					"olm.maxOpenShiftVersion is higher than the next OCP version (4.15) which will be released. " +
+					"Note that the latest version released is 4.14. Please, ensure that it has no typos",
+			},
+		},
+		{
+			name:        "should warn when the versions released cannot be obtained",
+			wantWarning: true,
+			args: args{
+				labelRange: "v4.12",
+				err:        fmt.Errorf("unexpected status code 500"),
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) unable to get the OCP versions " +
+				"released from the update service: unexpected status code 500. Note that the OCP versions informed " +
+				"were not checked"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateService = fakeUpdateService{err: tt.args.err}
+			if tt.args.err == nil {
+				var versions []string
+				for minor := 1; minor <= 14; minor++ {
+					versions = append(versions, fmt.Sprintf("4.%d", minor))
+				}
+				updateService = fakeUpdateService{versions: versions}
+			}
+
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			if len(tt.args.maxVersion) > 0 {
+				bundle.CSV.Annotations["olm.properties"] = fmt.Sprintf(
+					`[{"type": "olm.maxOpenShiftVersion", "value": "%s"}]`, tt.args.maxVersion)
+			}
+
+			results := OCPVersionsValidator.Validate(bundle, map[string]string{RangeKey: tt.args.labelRange})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], false, tt.wantWarning, nil, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// olmPropertiesExample defines an example of a valid value for the olm.properties annotation
+const olmPropertiesExample = "\"olm.properties\": '[{\"type\": \"key name\", \"value\": \"key value\"}]'"
+
+// trailingCommaRegexp matches the commas which are followed by the end of an array or object
+var trailingCommaRegexp = regexp.MustCompile(`,(\s*[\]}])`)
+
+// parseOLMProperties returns the properties of the value informed for the olm.properties annotation and the
+// errors found to parse it. The common mistakes (trailing commas and a single object instead of an array)
+// are reported but tolerated, so the properties are returned to allow the other checks to be performed.
+func parseOLMProperties(value string) ([]propertiesAnnotation, []error) {
+	var errs []error
+	if loc := trailingCommaRegexp.FindStringIndex(value); loc != nil {
+		line, column := getLineAndColumn(value, loc[0])
+		errs = append(errs, withRemediation(fmt.Errorf("csv.Annotations.%s has a trailing comma at line %d, column %d "+
+			"(near `%s`) which is not allowed in JSON", olmproperties, line, column, getNear(value, loc[0])),
+			"remove it"))
+		value = trailingCommaRegexp.ReplaceAllString(value, "$1")
+	}
+
+	var properties []propertiesAnnotation
+	err := json.Unmarshal([]byte(value), &properties)
+	if err == nil {
+		return properties, errs
+	}
+
+	// A single object is commonly informed instead of an array with it
+	var property propertiesAnnotation
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Value == "object" && len(typeErr.Field) == 0 {
+		if err := json.Unmarshal([]byte(value), &property); err == nil {
+			errs = append(errs, withRemediation(fmt.Errorf("csv.Annotations.%s must be an array of properties but a "+
+				"single object was informed", olmproperties), "wrap it with [] such as: %s", olmPropertiesExample))
+			return []propertiesAnnotation{property}, errs
+		}
+	}
+
+	var reason string
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		line, column := getLineAndColumn(value, int(e.Offset))
+		reason = fmt.Sprintf("%s at line %d, column %d (near `%s`)", e, line, column,
+			getNear(value, int(e.Offset)))
+	case *json.UnmarshalTypeError:
+		line, column := getLineAndColumn(value, int(e.Offset))
+		reason = fmt.Sprintf("the %s is a %s but must be %s at line %d, column %d",
+			describeField(e.Field), e.Value, describeType(e.Type), line, column)
+	default:
+		reason = err.Error()
+	}
+	errs = append(errs, withRemediation(fmt.Errorf("csv.Annotations has an invalid value specified for %s: %s",
+		olmproperties, reason), "check the value (%s) and ensure that it is an array such as: %s", value,
+		olmPropertiesExample))
+	return nil, errs
+}
+
+// getLineAndColumn returns the line and column (starting at 1) of the offset informed in the value
+func getLineAndColumn(value string, offset int) (int, int) {
+	if offset > len(value) {
+		offset = len(value)
+	}
+	before := value[:offset]
+	line := strings.Count(before, "\n") + 1
+	return line, offset - strings.LastIndex(before, "\n")
+}
+
+// getNear returns the text around the offset informed of the value
+func getNear(value string, offset int) string {
+	start, end := offset-10, offset+10
+	if start < 0 {
+		start = 0
+	}
+	if end > len(value) {
+		end = len(value)
+	}
+	if start > end {
+		start = end
+	}
+	return strings.Join(strings.Fields(value[start:end]), " ")
+}
+
+// describeType returns the description of the type expected for a value of the properties
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice:
+		return "an array of properties"
+	case reflect.Struct:
+		return "an object"
+	}
+	return "a " + t.String()
+}
+
+// describeField returns the description of the field informed (e.g. 0.value is described as
+// the field value of the property [0])
+func describeField(field string) string {
+	parts := strings.SplitN(field, ".", 2)
+	switch {
+	case len(field) == 0:
+		return "value"
+	case len(parts) == 2:
+		return fmt.Sprintf("field %s of the property [%s]", parts[1], parts[0])
+	}
+	return fmt.Sprintf("property [%s]", field)
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseOLMProperties(t *testing.T) {
+	maxVersion := []propertiesAnnotation{{Type: olmmaxOcpVersion, Value: "4.8"}}
+	tests := []struct {
+		name       string
+		value      string
+		want       []propertiesAnnotation
+		errStrings []string
+	}{
+		{
+			name:  "should parse an array of properties",
+			value: `[{"type": "olm.maxOpenShiftVersion", "value": "4.8"}]`,
+			want:  maxVersion,
+		},
+		{
+			name:  "should tolerate and report a trailing comma",
+			value: "[\n  {\"type\": \"olm.maxOpenShiftVersion\", \"value\": \"4.8\"},\n]",
+			want:  maxVersion,
+			errStrings: []string{"csv.Annotations.olm.properties has a trailing comma at line 2, column 54 " +
+				"(near `e\": \"4.8\"}, ]`) which is not allowed in JSON. Please, remove it"},
+		},
+		{
+			name:  "should tolerate and report a single object",
+			value: `{"type": "olm.maxOpenShiftVersion", "value": "4.8"}`,
+			want:  maxVersion,
+			errStrings: []string{"csv.Annotations.olm.properties must be an array of properties but a single " +
+				"object was informed. Please, wrap it with [] such as: " + olmPropertiesExample},
+		},
+		{
+			name:  "should report the position of the syntax errors",
+			value: `[{"type": "olm.maxOpenShiftVersion" "value": "4.8"}]`,
+			errStrings: []string{"csv.Annotations has an invalid value specified for olm.properties: " +
+				"invalid character '\"' after object key:value pair at line 1, column 38 " +
+				"(near `Version\" \"value\": \"4`). Please, check the value " +
+				"([{\"type\": \"olm.maxOpenShiftVersion\" \"value\": \"4.8\"}]) and ensure that it is an array " +
+				"such as: " + olmPropertiesExample},
+		},
+		{
+			name:  "should report the values with invalid types",
+			value: `[{"type": "olm.maxOpenShiftVersion", "value": 4.8}]`,
+			errStrings: []string{"csv.Annotations has an invalid value specified for olm.properties: " +
+				"the field value of the property [0] is a number but must be a string at line 1, column 50. " +
+				"Please, check the value ([{\"type\": \"olm.maxOpenShiftVersion\", \"value\": 4.8}]) and ensure " +
+				"that it is an array such as: " + olmPropertiesExample},
+		},
+		{
+			name:  "should report the values which are not arrays",
+			value: `"4.8"`,
+			errStrings: []string{"csv.Annotations has an invalid value specified for olm.properties: " +
+				"the value is a string but must be an array of properties at line 1, column 6. " +
+				"Please, check the value (\"4.8\") and ensure that it is an array such as: " + olmPropertiesExample},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			properties, errs := parseOLMProperties(tt.value)
+			require.Equal(t, tt.want, properties)
+			var errStrings []string
+			for _, err := range errs {
+				errStrings = append(errStrings, err.Error())
+			}
+			require.Equal(t, tt.errStrings, errStrings)
+		})
+	}
+}
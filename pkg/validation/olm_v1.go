@@ -0,0 +1,120 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// operatorGroupAnnotations defines the annotations set by OLM v0 from the OperatorGroup in the CSV
+// and in the pods of the operator
+var operatorGroupAnnotations = []string{"olm.targetNamespaces", "olm.operatorGroup", "olm.operatorNamespace"}
+
+// OLMv1Validator validates the constraints to install the bundle via a ClusterExtension with OLM v1.
+// It is not part of the DefaultValidators and is intended to let the authors know in advance whether
+// their bundles will work with OLM v1. Following its current checks:
+//
+// - Ensure that the AllNamespaces install mode is supported
+//
+// - Ensure that the bundle has no dependencies (metadata/dependencies.yaml or required CRDs and APIServices)
+// since OLM v1 does not resolve them
+//
+// - Ensure that the CSV does not own APIServices and warn when it defines webhooks
+//
+// - Ensure that the operator does not rely on the OperatorGroups
+var OLMv1Validator interfaces.Validator = newBundleValidator("olm-v1", checkOLMv1InstallModes, checkOLMv1Dependencies,
+	checkOLMv1APIs, checkOLMv1OperatorGroups)
+
+// checkOLMv1InstallModes will verify that the bundle can be installed by OLM v1 which watches all namespaces
+func checkOLMv1InstallModes(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if !supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeAllNamespaces) {
+		checks.errs = append(checks.errs, fmt.Errorf("the CSV does not support the install mode %s. Note "+
+			"that OLM v1 installs the operators to watch all namespaces",
+			operatorsv1alpha1.InstallModeTypeAllNamespaces))
+	}
+	return checks
+}
+
+// checkOLMv1Dependencies will verify that the bundle does not depend on other packages or APIs
+func checkOLMv1Dependencies(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, dep := range checks.bundle.Dependencies {
+		if dep == nil {
+			continue
+		}
+		checks.errs = append(checks.errs, fmt.Errorf("the bundle defines the dependency %s (%s) in "+
+			"metadata/dependencies.yaml. Note that OLM v1 does not resolve the dependencies and they must be "+
+			"installed by the cluster admin", dep.Type, dep.Value))
+	}
+	for _, required := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Required {
+		checks.errs = append(checks.errs, withSubject(fmt.Errorf("the CSV requires the CRD %s. Note that OLM v1 does "+
+			"not resolve the dependencies and it must be installed by the cluster admin", required.Name),
+			"CustomResourceDefinition", required.Name))
+	}
+	for _, required := range checks.bundle.CSV.Spec.APIServiceDefinitions.Required {
+		checks.errs = append(checks.errs, fmt.Errorf("the CSV requires the APIService %s.%s. Note that OLM v1 "+
+			"does not resolve the dependencies and it must be installed by the cluster admin",
+			required.Version, required.Group))
+	}
+	return checks
+}
+
+// checkOLMv1APIs will verify the APIServices and webhooks defined in the CSV which are limited with OLM v1
+func checkOLMv1APIs(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, owned := range checks.bundle.CSV.Spec.APIServiceDefinitions.Owned {
+		checks.errs = append(checks.errs, fmt.Errorf("the CSV owns the APIService %s.%s which is not "+
+			"supported by OLM v1", owned.Version, owned.Group))
+	}
+	if len(checks.bundle.CSV.Spec.WebhookDefinitions) > 0 {
+		checks.warns = append(checks.warns, fmt.Errorf("the CSV defines %d webhook(s) under "+
+			"spec.webhookdefinitions. Note that OLM v1 only supports them when the cluster enables the feature "+
+			"and their certificates are provided by the OpenShift service CA",
+			len(checks.bundle.CSV.Spec.WebhookDefinitions)))
+	}
+	return checks
+}
+
+// checkOLMv1OperatorGroups will verify that the operator does not rely on the OperatorGroups which are
+// not used by OLM v1
+func checkOLMv1OperatorGroups(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, obj := range checks.bundle.Objects {
+		if obj.GetKind() == "OperatorGroup" {
+			checks.errs = append(checks.errs, fmt.Errorf("the bundle ships the OperatorGroup %s which is not "+
+				"used by OLM v1", obj.GetName()))
+		}
+	}
+
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			for _, env := range c.Env {
+				if env.ValueFrom == nil || env.ValueFrom.FieldRef == nil {
+					continue
+				}
+				for _, annotation := range operatorGroupAnnotations {
+					if strings.Contains(env.ValueFrom.FieldRef.FieldPath, "'"+annotation+"'") {
+						checks.errs = append(checks.errs, withSubject(fmt.Errorf("the container %s of the deployment "+
+							"%s reads the environment variable %s from the annotation %s which is set from the "+
+							"OperatorGroup and is not set by OLM v1", c.Name, dep.Name, env.Name, annotation),
+							"Deployment", dep.Name))
+					}
+				}
+			}
+		}
+	}
+	return checks
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_OLMv1Validator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the bundle can be installed by OLM v1",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.WebhookDefinitions = nil
+				},
+			},
+		},
+		{
+			name:        "should warn when the CSV defines webhooks",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the CSV defines 2 webhook(s) under " +
+				"spec.webhookdefinitions. Note that OLM v1 only supports them when the cluster enables the feature " +
+				"and their certificates are provided by the OpenShift service CA"},
+		},
+		{
+			name:      "should fail when AllNamespaces is not supported",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+			},
+			errStrings: []string{"Error: Value : (etcdoperator.v0.9.4) the CSV does not support the install mode " +
+				"AllNamespaces. Note that OLM v1 installs the operators to watch all namespaces"},
+		},
+		{
+			name:      "should fail when the bundle has dependencies",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.WebhookDefinitions = nil
+					bundle.Dependencies = []*manifests.Dependency{{Type: "olm.package",
+						Value: `{"packageName":"etcd","version":">0.9.0"}`}}
+					bundle.CSV.Spec.CustomResourceDefinitions.Required = []operatorsv1alpha1.CRDDescription{{
+						Name: "etcdclusters.etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}}
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the bundle defines the dependency olm.package " +
+					"({\"packageName\":\"etcd\",\"version\":\">0.9.0\"}) in metadata/dependencies.yaml. Note that " +
+					"OLM v1 does not resolve the dependencies and they must be installed by the cluster admin",
+				"Error: Value : (memcached-operator.v0.0.1) the CSV requires the CRD " +
+					"etcdclusters.etcd.database.coreos.com. Note that OLM v1 does not resolve the dependencies and " +
+					"it must be installed by the cluster admin",
+			},
+		},
+		{
+			name:      "should fail when the operator relies on the OperatorGroup",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.WebhookDefinitions = nil
+					setManagerEnv(bundle, corev1.EnvVar{Name: "WATCH_NAMESPACE", ValueFrom: &corev1.EnvVarSource{
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: "metadata.annotations['olm.targetNamespaces']",
+						},
+					}})
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the container manager of the " +
+				"deployment memcached-operator-controller-manager reads the environment variable WATCH_NAMESPACE " +
+				"from the annotation olm.targetNamespaces which is set from the OperatorGroup and is not set by " +
+				"OLM v1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := OLMv1Validator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
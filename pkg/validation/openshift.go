@@ -15,11 +15,10 @@
 package validation
 
 import (
-	"encoding/json"
 	golangerrors "errors"
 	"fmt"
-	"io/ioutil"
-	"os"
+	"io/fs"
+	"regexp"
 	"strings"
 
 	"github.com/blang/semver"
@@ -28,6 +27,8 @@ import (
 	"github.com/operator-framework/api/pkg/manifests"
 	"github.com/operator-framework/api/pkg/validation/errors"
 	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/cosign"
 )
 
 // FilePathKey defines the key which can be used by its consumers
@@ -40,6 +41,87 @@ const FilePathKey = "file"
 // (e.g. --optional-values="range==v4.5-v4.8")
 const RangeKey = "range"
 
+// BundlePathKey defines the key which can be used by its consumers
+// to inform the directory or image tag of the bundle which is validated
+// (e.g. --optional-values="bundle-path=bundles/0.0.1")
+const BundlePathKey = "bundle-path"
+
+// BundleImageKey defines the key which can be used by its consumers
+// to inform the image of the bundle which is validated when it was not pulled from it
+// (e.g. --optional-values="bundle-image=quay.io/example/memcached-operator-bundle:v0.0.1")
+const BundleImageKey = "bundle-image"
+
+// ProfileKey defines the key which can be used by its consumers
+// to inform the catalog profile where the bundle is intended to be published
+// (e.g. --optional-values="profile=certified")
+const ProfileKey = "profile"
+
+// CheckImagesKey defines the key which can be used by its consumers
+// to enable the checks which inspect the images in their registries
+// (e.g. --optional-values="check-images=true")
+const CheckImagesKey = "check-images"
+
+// AllowedRegistriesKey defines the key which can be used by its consumers
+// to inform the comma separated list of registries from which the images can be used
+// (e.g. --allowed-registries="registry.redhat.io,quay.io/example")
+const AllowedRegistriesKey = "allowed-registries"
+
+// CatalogKey defines the key which can be used by its consumers
+// to inform the directory of the File-Based Catalog used to resolve the dependencies of the bundle
+// (e.g. --optional-values="catalog=catalog/")
+const CatalogKey = "catalog"
+
+// KubeconfigKey defines the key which can be used by its consumers
+// to inform the kubeconfig of the cluster which the bundle is validated against
+// (e.g. --optional-values="kubeconfig=/home/user/.kube/config")
+const KubeconfigKey = "kubeconfig"
+
+// PyxisProjectKey defines the key which can be used by its consumers
+// to inform the ID of the certification project where the bundle will be submitted
+// (e.g. --optional-values="pyxis-project=ospid-62423-f26c2a7b")
+const PyxisProjectKey = "pyxis-project"
+
+// DocsVersionKey defines the key which can be used by its consumers
+// to inform the OpenShift version of the documentation linked in the messages. By default, the
+// documentation of the highest OpenShift version where the bundle is distributed is linked.
+// (e.g. --optional-values="docs-version=4.14")
+const DocsVersionKey = "docs-version"
+
+// StrictLabelsKey defines the key which can be used by its consumers
+// to require the OCP label com.redhat.openshift.versions to be informed via the file or range keys
+// (e.g. --optional-values="strict-labels=true")
+const StrictLabelsKey = "strict-labels"
+
+// SignedImagesKey defines the key which can be used by its consumers
+// to inform the bundle and index images whose signatures are verified by the SignatureValidator
+// (e.g. --optional-values="signed-images=quay.io/example/memcached-operator-bundle:v0.0.1")
+const SignedImagesKey = "signed-images"
+
+// CosignKeyKey defines the key which can be used by its consumers
+// to inform the path or the KMS URI of the public key used to verify the signatures of the images
+// (e.g. --optional-values="cosign-key=cosign.pub")
+const CosignKeyKey = "cosign-key"
+
+// KeylessKey defines the key which can be used by its consumers
+// to verify the signatures of the images via the certificates issued by Fulcio instead of a key
+// (e.g. --optional-values="keyless=true")
+const KeylessKey = "keyless"
+
+// CertificateIdentityKey defines the key which can be used by its consumers
+// to inform the regular expression which the identity of the keyless signatures must match
+// (e.g. --optional-values="certificate-identity=https://github.com/example/.*")
+const CertificateIdentityKey = "certificate-identity"
+
+// CertificateOIDCIssuerKey defines the key which can be used by its consumers
+// to inform the regular expression which the OIDC issuer of the keyless signatures must match
+// (e.g. --optional-values="certificate-oidc-issuer=https://token.actions.githubusercontent.com")
+const CertificateOIDCIssuerKey = "certificate-oidc-issuer"
+
+// WatchesFileKey defines the key which can be used by its consumers
+// to inform the watches.yaml of the Ansible-based operators whose roles and playbooks are checked
+// (e.g. --optional-values="watches-file=watches.yaml")
+const WatchesFileKey = "watches-file"
+
 // ocpLabel defines the OCP label which allow configure the OCP versions
 // where the bundle will be distributed
 const ocpLabel = "com.redhat.openshift.versions"
@@ -53,8 +135,17 @@ const deprecateOcpLabelMsg1_22 = "this bundle is using APIs which were deprecate
 // OCP version where the apis v1beta1 is no longer supported
 const ocpVerV1beta1Unsupported = "4.9"
 
-// OCP docs with the information to manage versions
-const ocpDocLinkManagingVersions = "https://docs.openshift.com/container-platform/4.8/operators/operator_sdk/osdk-working-bundle-images.html#osdk-control-compat_osdk-working-bundle-images"
+// OCP docs URL by version and page
+const ocpDocsURL = "https://docs.openshift.com/container-platform/%s/%s"
+
+// OCP docs version used when the bundle has no maximum OpenShift version where it is distributed
+const ocpDocsLatestVersion = "latest"
+
+// OCP docs page with the information to manage versions
+const ocpDocPageManagingVersions = "operators/operator_sdk/osdk-working-bundle-images.html#osdk-control-compat_osdk-working-bundle-images"
+
+// ocpDocsVersionRegexp matches the OpenShift versions with docs (e.g. 4.14)
+var ocpDocsVersionRegexp = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
 
 // Ensure that has the OCPMaxAnnotation
 const olmproperties = "olm.properties"
@@ -83,29 +174,15 @@ const olmmaxOcpVersion = "olm.maxOpenShiftVersion"
 //
 // Note the OCP label has been only be checked when the file is informed via the optional key values and with the file key. (Be aware
 // that we might want to begin to check the metadata/annotations.yaml by default)
-var OpenShiftValidator interfaces.Validator = interfaces.ValidatorFunc(openShiftValidator)
+var OpenShiftValidator interfaces.Validator = Named("openshift", interfaces.ValidatorFunc(openShiftValidator))
 
 func openShiftValidator(objs ...interface{}) (results []errors.ManifestResult) {
-	var filePath = ""
-	var labelRange = ""
-	for _, obj := range objs {
-		switch obj := obj.(type) {
-		case map[string]string:
-			filePath = obj[FilePathKey]
-			if len(filePath) > 0 {
-				break
-			}
-			labelRange = obj[RangeKey]
-			if len(labelRange) > 0 {
-				break
-			}
-		}
-	}
-
+	optionalValues := getOptionalValues(objs...)
+	fsys := getFS(objs...)
 	for _, obj := range objs {
 		switch v := obj.(type) {
 		case *manifests.Bundle:
-			results = append(results, validateOpenShiftBundle(v, filePath, labelRange))
+			results = append(results, validateOpenShiftBundle(v, optionalValues, fsys))
 		}
 	}
 
@@ -114,18 +191,32 @@ func openShiftValidator(objs ...interface{}) (results []errors.ManifestResult) {
 
 // OpenShiftOperatorChecks defines the attributes used to perform the checks
 type OpenShiftOperatorChecks struct {
-	bundle           manifests.Bundle
-	filePath         string
-	labelRange       string
-	rangeValue       string
-	maxValue         string
-	deprecateAPIsMsg string
-	errs             []error
-	warns            []error
+	bundle            manifests.Bundle
+	fsys              fs.FS
+	filePath          string
+	bundlePath        string
+	profile           string
+	checkImages       bool
+	allowedRegistries []string
+	catalog           string
+	kubeconfig        string
+	pyxisProject      string
+	docsVersion       string
+	strictLabels      bool
+	signedImages      []string
+	cosignOptions     cosign.Options
+	watchesFile       string
+	labelRange        string
+	rangeValue        string
+	maxValue          string
+	deprecateAPIsMsg  string
+	errs              []error
+	warns             []error
 }
 
 // validateOpenShiftBundle will check the bundle against the criteria to publish into OpenShift Catalog
-func validateOpenShiftBundle(bundle *manifests.Bundle, indexImagePath string, labelRange string) errors.ManifestResult {
+func validateOpenShiftBundle(bundle *manifests.Bundle, optionalValues map[string]string,
+	fsys fs.FS) errors.ManifestResult {
 	result := errors.ManifestResult{}
 	if bundle == nil {
 		result.Add(errors.ErrInvalidBundle("Bundle is nil", nil))
@@ -138,7 +229,17 @@ func validateOpenShiftBundle(bundle *manifests.Bundle, indexImagePath string, la
 		return result
 	}
 
-	checks := OpenShiftOperatorChecks{bundle: *bundle, filePath: indexImagePath, labelRange: labelRange, rangeValue: labelRange, errs: []error{}, warns: []error{}}
+	checks := OpenShiftOperatorChecks{
+		bundle:       *bundle,
+		fsys:         fsys,
+		filePath:     optionalValues[FilePathKey],
+		labelRange:   optionalValues[RangeKey],
+		rangeValue:   optionalValues[RangeKey],
+		docsVersion:  optionalValues[DocsVersionKey],
+		strictLabels: optionalValues[StrictLabelsKey] == "true",
+		errs:         []error{},
+		warns:        []error{},
+	}
 
 	objs := bundle.ObjectsToValidate()
 	for _, obj := range bundle.Objects {
@@ -148,9 +249,10 @@ func validateOpenShiftBundle(bundle *manifests.Bundle, indexImagePath string, la
 	// pass the objects to the validator
 	resultDeprecation := validation.AlphaDeprecatedAPIsValidator.Validate(objs...)
 
+	var deprecations []string
 	for _, res := range resultDeprecation {
 		for _, res := range res.Warnings {
-			result.Add(errors.WarnFailedValidation(res.Detail, bundle.CSV.GetName()))
+			deprecations = append(deprecations, res.Detail)
 			checks.deprecateAPIsMsg = res.Detail
 		}
 	}
@@ -160,16 +262,34 @@ func validateOpenShiftBundle(bundle *manifests.Bundle, indexImagePath string, la
 	checks = getOCPLabel(checks)
 	checks = checkOCPLabel(checks)
 	checks = validateOCPLabelWithMaxVersion(checks)
+	checks = checkSingleVersionRange(checks)
+	// The deprecations are only reported once. Then, they are not reported as warnings when they are
+	// already informed as the context of an error.
+	for _, deprecation := range deprecations {
+		if !containsDetail(checks.errs, deprecation) {
+			result.Add(errors.WarnFailedValidation(deprecation, bundle.CSV.GetName()))
+		}
+	}
 	for _, err := range checks.errs {
-		result.Add(errors.ErrInvalidCSV(err.Error(), bundle.CSV.GetName()))
+		result.Add(toManifestError(errors.LevelError, err, bundle.CSV.GetName()))
 	}
 	for _, warn := range checks.warns {
-		result.Add(errors.WarnInvalidCSV(warn.Error(), bundle.CSV.GetName()))
+		result.Add(toManifestError(errors.LevelWarn, warn, bundle.CSV.GetName()))
 	}
 
 	return result
 }
 
+// containsDetail returns true when the detail informed is part of any of the errors
+func containsDetail(errs []error, detail string) bool {
+	for _, err := range errs {
+		if strings.Contains(err.Error(), detail) {
+			return true
+		}
+	}
+	return false
+}
+
 type propertiesAnnotation struct {
 	Type  string
 	Value string
@@ -183,14 +303,8 @@ func getMaxAnnotationValue(checks OpenShiftOperatorChecks) OpenShiftOperatorChec
 		return checks
 	}
 
-	var properList []propertiesAnnotation
-	if err := json.Unmarshal([]byte(properties), &properList); err != nil {
-		checks.errs = append(checks.errs, fmt.Errorf("csv.Annotations has an invalid value specified for %s. "+
-			"Please, check the value (%s) and ensure that it is an array such as: "+
-			"\"olm.properties\": '[{\"type\": \"key name\", \"value\": \"key value\"}]'",
-			olmproperties, properties))
-		return checks
-	}
+	properList, errs := parseOLMProperties(properties)
+	checks.errs = append(checks.errs, errs...)
 
 	for _, v := range properList {
 		if v.Type == olmmaxOcpVersion {
@@ -211,7 +325,7 @@ func checkMaxVersionAnnotation(checks OpenShiftOperatorChecks) OpenShiftOperator
 			olmmaxOcpVersion,
 			ocpVerV1beta1Unsupported,
 			ocpVerV1beta1Unsupported,
-			ocpDocLinkManagingVersions))
+			getOCPDocLink(getDocsVersion(checks, ""), ocpDocPageManagingVersions)))
 		return checks
 	}
 
@@ -262,7 +376,11 @@ func getOCPLabel(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
 
 // checkOCPLabels will ensure that OCP labels are set and with a ocp targetVersion < 4.9
 func checkOCPLabel(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
-	// Note that we cannot make mandatory because the package format still valid
+	// Note that we cannot make mandatory because the package format still valid. The catalogs whose
+	// pipelines require it can use the strict labels mode.
+	if checks.strictLabels {
+		checks = checkRequiredOCPLabel(checks)
+	}
 	if hasOCPLabelInfo(checks) && len(checks.rangeValue) == 0 {
 		if len(checks.deprecateAPIsMsg) > 0 {
 			checks.errs = append(checks.errs, fmt.Errorf(deprecateOcpLabelMsg1_22,
@@ -280,7 +398,7 @@ func hasOCPLabelInfo(checks OpenShiftOperatorChecks) bool {
 
 func getOCPLabelFromFile(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
 	if len(checks.filePath) > 0 {
-		info, err := os.Stat(checks.filePath)
+		info, err := statFile(checks.fsys, checks.filePath)
 		if err != nil {
 			checks.errs = append(checks.errs, fmt.Errorf("the file path informed (%s) was not found. "+
 				"Error : %s", checks.filePath, err))
@@ -292,7 +410,7 @@ func getOCPLabelFromFile(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks
 			return checks
 		}
 
-		b, err := ioutil.ReadFile(checks.filePath)
+		b, err := readFile(checks.fsys, checks.filePath)
 		if err != nil {
 			checks.errs = append(checks.errs, fmt.Errorf("unable to read the index image in the path "+
 				"(%s). Error : %s", checks.filePath, err))
@@ -302,7 +420,7 @@ func getOCPLabelFromFile(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks
 		indexPathContent := string(b)
 		hasOCPLabel := strings.Contains(indexPathContent, ocpLabel)
 		if hasOCPLabel {
-			line := strings.Split(indexPathContent, "\n")
+			line := splitLabelLines(indexPathContent)
 			for i := 0; i < len(line); i++ {
 				if strings.Contains(line[i], ocpLabel) {
 					if !strings.Contains(line[i], "=") && !strings.Contains(line[i], ":") {
@@ -319,7 +437,7 @@ func getOCPLabelFromFile(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks
 							ocpLabel))
 						return checks
 					}
-					checks.rangeValue = cleanStringToGetTheVersionToParse(value[1])
+					checks.rangeValue = NormalizeLabelValue(value[1])
 					break
 				}
 			}
@@ -328,9 +446,45 @@ func getOCPLabelFromFile(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks
 	return checks
 }
 
+// checkRequiredOCPLabel will verify that the OCP label is informed via the range or found in the file informed
+func checkRequiredOCPLabel(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if !hasOCPLabelInfo(checks) {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the OpenShift versions where the bundle is "+
+			"distributed are required but the %s label could not be resolved", ocpLabel),
+			"inform the bundle.Dockerfile or metadata/annotations.yaml of the bundle via the %s key or the versions "+
+				"via the %s key (e.g. --optional-values=\"%s=bundle/metadata/annotations.yaml\")", FilePathKey,
+			RangeKey, FilePathKey))
+		return checks
+	}
+	if len(checks.labelRange) > 0 {
+		return checks
+	}
+	// The errors to read the file or with the syntax of the label are reported when the label is read
+	content, err := readFile(checks.fsys, checks.filePath)
+	if err != nil || strings.Contains(string(content), ocpLabel) {
+		return checks
+	}
+	checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the %s label is required but it was not found in %s",
+		ocpLabel, checks.filePath),
+		"inform the OpenShift versions where the bundle is distributed (e.g. %s: \"v4.12\")", ocpLabel))
+	return checks
+}
+
+// getLabelValueFromFile returns the value of the label found in the index image (bundle.Dockerfile)
+// or annotations path informed. Note that an empty value is returned when the label is not found.
+func getLabelValueFromFile(fsys fs.FS, filePath string, label string) (string, error) {
+	b, err := readFile(fsys, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	value, _ := GetLabelValue(string(b), label)
+	return value, nil
+}
+
 func validateOCPLabelWithMaxVersion(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
 	if len(checks.maxValue) > 0 && len(checks.rangeValue) > 0 {
-		isPartOfTarget, err := rangeContainsVersion(checks.rangeValue, cleanStringToGetTheVersionToParse(checks.maxValue), true)
+		isPartOfTarget, err := rangeContainsVersion(checks.rangeValue, NormalizeLabelValue(checks.maxValue), true)
 		if err != nil {
 			checks.errs = append(checks.errs, fmt.Errorf("error invalid label range %s",
 				err))
@@ -344,13 +498,52 @@ func validateOCPLabelWithMaxVersion(checks OpenShiftOperatorChecks) OpenShiftOpe
 				checks.maxValue,
 				ocpLabel,
 				checks.rangeValue,
-				ocpDocLinkManagingVersions))
+				getOCPDocLink(getDocsVersion(checks, ""), ocpDocPageManagingVersions)))
 			return checks
 		}
 	}
 	return checks
 }
 
+// checkSingleVersionRange will warn when the OCP label informs a single version (e.g. v4.10), which means
+// that version and all later ones, but the maxOpenShiftVersion is the same version which suggests that the
+// bundle was meant to be distributed only on it
+func checkSingleVersionRange(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	min, openEnded := getOpenEndedRangeMin(checks.rangeValue)
+	if !openEnded || len(checks.maxValue) == 0 {
+		return checks
+	}
+	// the invalid versions are reported by the checks of the label and annotation
+	minVersion, err := semver.ParseTolerant(min)
+	if err != nil {
+		return checks
+	}
+	maxVersion, err := semver.ParseTolerant(NormalizeLabelValue(checks.maxValue))
+	if err != nil {
+		return checks
+	}
+	if minVersion.Major != maxVersion.Major || minVersion.Minor != maxVersion.Minor {
+		return checks
+	}
+	checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s label with the value %s means OpenShift %s "+
+		"and all later versions but the %s annotation with the value %s blocks the cluster upgrades after it. Note "+
+		"that the bundle will also be distributed in the catalogs of the later versions", ocpLabel, checks.rangeValue,
+		min, olmmaxOcpVersion, checks.maxValue),
+		"use the value =v%s if the bundle should be distributed only on OpenShift %s. For further information see %s",
+		min, min, getOCPDocLink(getDocsVersion(checks, ""), ocpDocPageManagingVersions)))
+	return checks
+}
+
+// getOpenEndedRangeMin returns the minimum version (without the v prefix) when the range informs a single
+// version without the = prefix (e.g. v4.10), which means that version and all later ones. Note that false
+// is returned for the exact versions (e.g. =v4.10), the ranges (e.g. v4.8-v4.10) and the legacy values.
+func getOpenEndedRangeMin(r string) (string, bool) {
+	if len(r) == 0 || strings.HasPrefix(r, "=") || strings.ContainsAny(r, "-,") {
+		return "", false
+	}
+	return strings.TrimPrefix(r, "v"), true
+}
+
 // todo: the ocp targetVersion version ought to be passed as parameter
 // this code needs to be improved with the check for deprecated apis before/for 1.25
 func checkOCPLabelFor4_9(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
@@ -377,6 +570,93 @@ func checkOCPLabelFor4_9(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks
 	return checks
 }
 
+// getDocsVersion returns the OpenShift version of the docs linked in the messages. It is the version informed
+// via the docs-version key, the fallback version informed (e.g. the version of the cluster) or the maximum
+// version of the range where the bundle is distributed. Otherwise, the latest docs are linked.
+func getDocsVersion(checks OpenShiftOperatorChecks, fallback string) string {
+	if len(checks.docsVersion) > 0 {
+		return strings.TrimPrefix(checks.docsVersion, "v")
+	}
+	if len(fallback) > 0 {
+		return fallback
+	}
+	r := checks.rangeValue
+	if len(r) == 0 {
+		r = getOCPRange(checks)
+	}
+	version := strings.TrimPrefix(r, "=")
+	if rs := strings.SplitN(r, "-", 2); len(rs) == 2 {
+		version = rs[1]
+	} else if _, openEnded := getOpenEndedRangeMin(r); openEnded {
+		// the range only specifies the minimum version
+		return ocpDocsLatestVersion
+	}
+	version = strings.TrimPrefix(NormalizeLabelValue(version), "v")
+	if !ocpDocsVersionRegexp.MatchString(version) {
+		return ocpDocsLatestVersion
+	}
+	return version
+}
+
+// getOCPDocLink returns the link of the page informed of the OCP docs for the version informed
+func getOCPDocLink(version, page string) string {
+	return fmt.Sprintf(ocpDocsURL, version, page)
+}
+
+// getOCPRange returns the value of the OCP label informed via the range key or found in the index image
+// or annotations path informed. Note that an empty value is returned when it was not informed.
+func getOCPRange(checks OpenShiftOperatorChecks) string {
+	if len(checks.labelRange) > 0 {
+		return checks.labelRange
+	}
+	if len(checks.filePath) == 0 {
+		return ""
+	}
+	value, err := getLabelValueFromFile(checks.fsys, checks.filePath, ocpLabel)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// rangeAllowsVersionOrUpper returns true when the range contains the version informed or upper versions
+func rangeAllowsVersionOrUpper(r string, v string) (bool, error) {
+	if len(r) == 0 {
+		return false, golangerrors.New("range is empty")
+	}
+	if r == "v4.5,v4.6" || r == "v4.6,v4.5" {
+		return rangeContainsVersion(r, v, true)
+	}
+	rs := strings.SplitN(r, "-", 2)
+	switch {
+	case len(rs) == 2:
+		return rangeContainsVersion(fmt.Sprintf("%s-%s", v, rs[1]), v, true)
+	case strings.HasPrefix(r, "="):
+		return rangeContainsVersion(fmt.Sprintf("%s-%s", v, strings.TrimPrefix(r, "=")), v, true)
+	}
+	// the range specifies the minimum version
+	return true, nil
+}
+
+// rangeAllowsVersionLowerThan returns true when the range contains versions lower than the version informed
+func rangeAllowsVersionLowerThan(r string, v string) (bool, error) {
+	if len(r) == 0 {
+		return false, golangerrors.New("range is empty")
+	}
+	min := r
+	if r == "v4.5,v4.6" || r == "v4.6,v4.5" {
+		min = "v4.5"
+	} else if rs := strings.SplitN(r, "-", 2); len(rs) == 2 {
+		min = rs[0]
+	}
+	min = strings.TrimPrefix(min, "=")
+	upper, err := rangeContainsVersion("v"+strings.TrimPrefix(v, "v"), min, true)
+	if err != nil {
+		return false, err
+	}
+	return !upper, nil
+}
+
 // rangeContainsVersion expected the range and the targetVersion version and returns true
 // when the targetVersion version contains in the range
 func rangeContainsVersion(r string, v string, tolerantParse bool) (bool, error) {
@@ -411,14 +691,13 @@ func rangeContainsVersion(r string, v string, tolerantParse bool) (bool, error)
 	rs := strings.SplitN(r, "-", 2)
 	switch len(rs) {
 	case 1:
-		// Range specify exact version
-		if strings.HasPrefix(r, "=") {
+		if min, openEnded := getOpenEndedRangeMin(r); openEnded {
+			// Range specifies minimum version (e.g. v4.10 means 4.10 and all later versions)
+			semverRange, err = semver.ParseRange(fmt.Sprintf(">=%s.0", min))
+		} else {
+			// Range specify exact version
 			trimmed := strings.TrimPrefix(r, "=v")
 			semverRange, err = semver.ParseRange(fmt.Sprintf("%s.0", trimmed))
-		} else {
-			trimmed := strings.TrimPrefix(r, "v")
-			// Range specifies minimum version
-			semverRange, err = semver.ParseRange(fmt.Sprintf(">=%s.0", trimmed))
 		}
 		if err != nil {
 			return false, fmt.Errorf("invalid range %q: %v", r, err)
@@ -441,20 +720,3 @@ func rangeContainsVersion(r string, v string, tolerantParse bool) (bool, error)
 	}
 	return semverRange(compV), nil
 }
-
-// cleanStringToGetTheVersionToParse will remove the expected characters for
-// we are able to parse the version informed.
-func cleanStringToGetTheVersionToParse(value string) string {
-	// requires remove the possible double and single quotes which
-	// are faced after read/parse the file.
-	doubleQuote := "\""
-	singleQuote := "'"
-	value = strings.ReplaceAll(value, singleQuote, "")
-	value = strings.ReplaceAll(value, doubleQuote, "")
-	// requires remove = when the file informed is a index image
-	value = strings.TrimPrefix(value, "=")
-	// requires remove : and spaces when the file informed is annotation
-	value = strings.TrimPrefix(value, ":")
-	value = strings.TrimSpace(value)
-	return value
-}
@@ -0,0 +1,130 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// openShiftDeprecatedAPI defines an API of the OpenShift groups which is deprecated
+type openShiftDeprecatedAPI struct {
+	apiVersion string
+	kind       string
+	// deprecatedIn is the OCP version where the API was deprecated. Note that it is empty for the legacy APIs.
+	deprecatedIn string
+	// removedIn is the OCP version where the API is no longer served. Note that it is empty when no removal
+	// was announced.
+	removedIn   string
+	replacement string
+}
+
+// legacyOpenShiftKinds defines the kinds of the OpenShift APIs which were served without group (apiVersion v1)
+// and their group
+var legacyOpenShiftKinds = map[string]string{
+	"Route":            "route.openshift.io",
+	"ImageStream":      "image.openshift.io",
+	"ImageStreamTag":   "image.openshift.io",
+	"DeploymentConfig": "apps.openshift.io",
+	"BuildConfig":      "build.openshift.io",
+	"Template":         "template.openshift.io",
+	"Project":          "project.openshift.io",
+}
+
+// openShiftDeprecatedAPIs defines the deprecated APIs of the OpenShift groups
+var openShiftDeprecatedAPIs = []openShiftDeprecatedAPI{
+	{apiVersion: "apps.openshift.io/v1", kind: "DeploymentConfig", deprecatedIn: "4.14",
+		replacement: "apps/v1 Deployment"},
+	{apiVersion: "operator.openshift.io/v1alpha1", kind: "ImageContentSourcePolicy", deprecatedIn: "4.13",
+		replacement: "config.openshift.io/v1 ImageDigestMirrorSet or ImageTagMirrorSet"},
+	{apiVersion: "config.openshift.io/v1", kind: "ImageContentPolicy", deprecatedIn: "4.13",
+		replacement: "config.openshift.io/v1 ImageDigestMirrorSet or ImageTagMirrorSet"},
+	{apiVersion: "network.openshift.io/v1", kind: "EgressNetworkPolicy", deprecatedIn: "4.14", removedIn: "4.17",
+		replacement: "k8s.ovn.org/v1 EgressFirewall"},
+	{apiVersion: "authorization.openshift.io/v1", kind: "Role", replacement: "rbac.authorization.k8s.io/v1 Role"},
+	{apiVersion: "authorization.openshift.io/v1", kind: "RoleBinding",
+		replacement: "rbac.authorization.k8s.io/v1 RoleBinding"},
+	{apiVersion: "authorization.openshift.io/v1", kind: "ClusterRole",
+		replacement: "rbac.authorization.k8s.io/v1 ClusterRole"},
+	{apiVersion: "authorization.openshift.io/v1", kind: "ClusterRoleBinding",
+		replacement: "rbac.authorization.k8s.io/v1 ClusterRoleBinding"},
+}
+
+// OpenShiftAPIsValidator looks for the deprecated and removed APIs of the OpenShift groups (e.g.
+// apps.openshift.io or route.openshift.io) in the manifests shipped in the bundle and in the examples
+// informed via the alm-examples annotation, as it is done for the Kubernetes APIs. Following its current
+// checks:
+//
+// - Ensure that the APIs removed in the OCP versions where the bundle is distributed are not used. Note that
+// the versions are read from the com.redhat.openshift.versions label via the range or file optional values.
+//
+// - Warn when the deprecated APIs are used, including the legacy APIs served without group (e.g. apiVersion
+// v1 with the kind Route)
+var OpenShiftAPIsValidator interfaces.Validator = newBundleValidator("openshift-apis", checkOpenShiftAPIs)
+
+// checkOpenShiftAPIs will verify the APIs of the OpenShift groups used by the manifests and examples
+func checkOpenShiftAPIs(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, obj := range checks.bundle.Objects {
+		if obj == nil {
+			continue
+		}
+		checks = checkOpenShiftAPI(checks, obj, "shipped in the bundle")
+	}
+	// Note that the invalid examples are reported by the ExamplesValidator
+	examples, _ := getALMExamples(checks)
+	for i := range examples {
+		checks = checkOpenShiftAPI(checks, &examples[i], "informed via the "+almExamplesAnnotation+" annotation")
+	}
+	return checks
+}
+
+// checkOpenShiftAPI will verify that the object informed does not use a deprecated or removed OpenShift API
+func checkOpenShiftAPI(checks OpenShiftOperatorChecks, obj *unstructured.Unstructured,
+	source string) OpenShiftOperatorChecks {
+	apiVersion, kind := obj.GetAPIVersion(), obj.GetKind()
+	if group, found := legacyOpenShiftKinds[kind]; found && apiVersion == "v1" {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s %s %s uses the legacy API %s without "+
+			"group which is deprecated", kind, obj.GetName(), source, apiVersion), "use %s/v1 instead", group))
+		return checks
+	}
+
+	api, found := getOpenShiftDeprecatedAPI(obj)
+	if !found {
+		return checks
+	}
+	ocpRange := getOCPRange(checks)
+	if len(api.removedIn) > 0 && len(ocpRange) > 0 {
+		if upper, err := rangeAllowsVersionOrUpper(ocpRange, api.removedIn); err == nil && upper {
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the %s %s %s uses the API %s which is "+
+				"removed in OCP %s while the bundle is distributed to OCP %s (%s)", kind, obj.GetName(), source,
+				apiVersion, api.removedIn, ocpRange, ocpLabel),
+				"migrate it to %s or provide compatible versions via the %s label", api.replacement, ocpLabel))
+			return checks
+		}
+	}
+
+	deprecation := "deprecated"
+	if len(api.deprecatedIn) > 0 {
+		deprecation = "deprecated in OCP " + api.deprecatedIn
+	}
+	if len(api.removedIn) > 0 {
+		deprecation += " and removed in OCP " + api.removedIn
+	}
+	checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s %s %s uses the API %s which is %s", kind,
+		obj.GetName(), source, apiVersion, deprecation), "migrate it to %s", api.replacement))
+	return checks
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_OpenShiftAPIsValidator(t *testing.T) {
+	newObject := func(apiVersion, kind, name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetName(name)
+		return obj
+	}
+
+	type args struct {
+		ocpLabelRange string
+		objects       []*unstructured.Unstructured
+		examples      string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when no deprecated OpenShift APIs are used",
+			args: args{
+				objects: []*unstructured.Unstructured{newObject("route.openshift.io/v1", "Route", "memcached")},
+			},
+		},
+		{
+			name:        "should warn when the deprecated OpenShift APIs are shipped",
+			wantWarning: true,
+			args: args{
+				objects: []*unstructured.Unstructured{
+					newObject("v1", "Route", "memcached"),
+					newObject("apps.openshift.io/v1", "DeploymentConfig", "memcached"),
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the Route memcached shipped in the bundle uses the " +
+					"legacy API v1 without group which is deprecated. Please, use route.openshift.io/v1 instead",
+				"Warning: Value : (memcached-operator.v0.0.1) the DeploymentConfig memcached shipped in the bundle " +
+					"uses the API apps.openshift.io/v1 which is deprecated in OCP 4.14. Please, migrate it to " +
+					"apps/v1 Deployment",
+			},
+		},
+		{
+			name:        "should warn when the deprecated OpenShift APIs are used by the examples",
+			wantWarning: true,
+			args: args{
+				examples: `[{"apiVersion": "v1", "kind": "ImageStream", "metadata": {"name": "memcached"}}]`,
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the ImageStream memcached " +
+				"informed via the alm-examples annotation uses the legacy API v1 without group which is deprecated. " +
+				"Please, use image.openshift.io/v1 instead"},
+		},
+		{
+			name:        "should warn when the removed OpenShift APIs are used and the versions are not informed",
+			wantWarning: true,
+			args: args{
+				objects: []*unstructured.Unstructured{
+					newObject("network.openshift.io/v1", "EgressNetworkPolicy", "default"),
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the EgressNetworkPolicy default " +
+				"shipped in the bundle uses the API network.openshift.io/v1 which is deprecated in OCP 4.14 and " +
+				"removed in OCP 4.17. Please, migrate it to k8s.ovn.org/v1 EgressFirewall"},
+		},
+		{
+			name:        "should warn when the removed OpenShift APIs are used and the bundle is distributed before",
+			wantWarning: true,
+			args: args{
+				ocpLabelRange: "v4.12-v4.16",
+				objects: []*unstructured.Unstructured{
+					newObject("network.openshift.io/v1", "EgressNetworkPolicy", "default"),
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the EgressNetworkPolicy default " +
+				"shipped in the bundle uses the API network.openshift.io/v1 which is deprecated in OCP 4.14 and " +
+				"removed in OCP 4.17. Please, migrate it to k8s.ovn.org/v1 EgressFirewall"},
+		},
+		{
+			name:      "should fail when the removed OpenShift APIs are used and the bundle is distributed after",
+			wantError: true,
+			args: args{
+				ocpLabelRange: "v4.12",
+				objects: []*unstructured.Unstructured{
+					newObject("network.openshift.io/v1", "EgressNetworkPolicy", "default"),
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the EgressNetworkPolicy default " +
+				"shipped in the bundle uses the API network.openshift.io/v1 which is removed in OCP 4.17 while the " +
+				"bundle is distributed to OCP v4.12 (com.redhat.openshift.versions). Please, migrate it to " +
+				"k8s.ovn.org/v1 EgressFirewall or provide compatible versions via the " +
+				"com.redhat.openshift.versions label"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			bundle.Objects = append(bundle.Objects, tt.args.objects...)
+			if len(tt.args.examples) > 0 {
+				bundle.CSV.Annotations[almExamplesAnnotation] = tt.args.examples
+			}
+
+			results := OpenShiftAPIsValidator.Validate(bundle, map[string]string{RangeKey: tt.args.ocpLabelRange})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -16,6 +16,7 @@ package validation
 
 import (
 	"fmt"
+	"strconv"
 	"testing"
 
 	"github.com/operator-framework/api/pkg/manifests"
@@ -28,6 +29,7 @@ func Test_OpenShiftValidator(t *testing.T) {
 		bundleDir     string
 		filePath      string
 		ocpLabelRange string
+		strictLabels  bool
 	}
 	tests := []struct {
 		name        string
@@ -106,16 +108,13 @@ func Test_OpenShiftValidator(t *testing.T) {
 				"csv.Annotations not specified with an OCP version lower than 4.9. "+
 				"This annotation is required to prevent the user from upgrading their OCP cluster before they "+
 				"have installed a version of their operator which is compatible with 4.9. "+
-				"For further information see %s", ocpDocLinkManagingVersions)},
+				"For further information see %s", getOCPDocLink("4.8", ocpDocPageManagingVersions))},
 		},
 		{
-			name:        "should fail when the olm annotation is set with a value >= 4.9 and has deprecated apis",
-			wantError:   true,
-			wantWarning: true,
-			warnStrings: []string{"Warning: Value etcdoperator.v0.9.4: this bundle is using APIs which were " +
-				"deprecated and removed in v1.22. " +
-				"More info: https://kubernetes.io/docs/reference/using-api/deprecation-guide/#v1-22. " +
-				"Migrate the API(s) for CRD: ([\"etcdbackups.etcd.database.coreos.com\" \"etcdclusters.etcd.database.coreos.com\" \"etcdrestores.etcd.database.coreos.com\"])"},
+			name:      "should fail when the olm annotation is set with a value >= 4.9 and has deprecated apis",
+			wantError: true,
+			// The deprecated apis are only reported in the error
+			wantWarning: false,
 			args: args{
 				bundleDir: "./testdata/valid_bundle_v1beta1",
 				filePath:  "./testdata/dockerfile/valid_bundle.Dockerfile",
@@ -131,7 +130,7 @@ func Test_OpenShiftValidator(t *testing.T) {
 				fmt.Sprintf("Error: Value : (etcdoperator.v0.9.4) the olm.maxOpenShiftVersion annotation with the "+
 					"value 4.9 to block the cluster upgrade is incompatible with the versions where this solutions should "+
 					"be distributed (com.redhat.openshift.versions with the value v4.6-v4.8). "+
-					"For further information see %s", ocpDocLinkManagingVersions),
+					"For further information see %s", getOCPDocLink("4.8", ocpDocPageManagingVersions)),
 			},
 		},
 		{
@@ -176,6 +175,86 @@ func Test_OpenShiftValidator(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "should fail in the strict labels mode when the OCP label cannot be resolved",
+			wantError: true,
+			args: args{
+				bundleDir:    "./testdata/valid_bundle_v1",
+				strictLabels: true,
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the OpenShift versions where the " +
+				"bundle is distributed are required but the com.redhat.openshift.versions label could not be " +
+				"resolved. Please, inform the bundle.Dockerfile or metadata/annotations.yaml of the bundle via the " +
+				"file key or the versions via the range key " +
+				"(e.g. --optional-values=\"file=bundle/metadata/annotations.yaml\")"},
+		},
+		{
+			name:      "should fail in the strict labels mode when the OCP label is not found in the file",
+			wantError: true,
+			args: args{
+				bundleDir:    "./testdata/valid_bundle_v1",
+				filePath:     "./testdata/dockerfile/bundle_without_label.Dockerfile",
+				strictLabels: true,
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the com.redhat.openshift.versions " +
+				"label is required but it was not found in ./testdata/dockerfile/bundle_without_label.Dockerfile. " +
+				"Please, inform the OpenShift versions where the bundle is distributed " +
+				"(e.g. com.redhat.openshift.versions: \"v4.12\")"},
+		},
+		{
+			name: "should pass in the strict labels mode when the OCP label is informed",
+			args: args{
+				bundleDir:     "./testdata/valid_bundle_v1",
+				ocpLabelRange: "v4.8",
+				strictLabels:  true,
+			},
+		},
+		{
+			name: "should warn when the OCP label informs a single version and the max version is the same",
+			args: args{
+				bundleDir:     "./testdata/valid_bundle_v1",
+				ocpLabelRange: "v4.10",
+				annotations: map[string]string{
+					"olm.properties": `[{"type": "olm.maxOpenShiftVersion", "value": "4.10"}]`,
+				},
+			},
+			wantWarning: true,
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the com.redhat.openshift.versions label " +
+				"with the value v4.10 means OpenShift 4.10 and all later versions but the olm.maxOpenShiftVersion " +
+				"annotation with the value 4.10 blocks the cluster upgrades after it. Note that the bundle will also " +
+				"be distributed in the catalogs of the later versions. Please, use the value =v4.10 if the bundle " +
+				"should be distributed only on OpenShift 4.10. For further information see " +
+				"https://docs.openshift.com/container-platform/latest/operators/operator_sdk/" +
+				"osdk-working-bundle-images.html#osdk-control-compat_osdk-working-bundle-images"},
+		},
+		{
+			name: "should pass when the OCP label informs the exact version and the max version is the same",
+			args: args{
+				bundleDir:     "./testdata/valid_bundle_v1",
+				ocpLabelRange: "=v4.10",
+				annotations: map[string]string{
+					"olm.properties": `[{"type": "olm.maxOpenShiftVersion", "value": "4.10"}]`,
+				},
+			},
+		},
+		{
+			name: "should pass when the OCP label informs a single version lower than the max version",
+			args: args{
+				bundleDir:     "./testdata/valid_bundle_v1",
+				ocpLabelRange: "v4.8",
+				annotations: map[string]string{
+					"olm.properties": `[{"type": "olm.maxOpenShiftVersion", "value": "4.10"}]`,
+				},
+			},
+		},
+		{
+			name: "should pass in the strict labels mode when the OCP label is found in the file",
+			args: args{
+				bundleDir:    "./testdata/valid_bundle_v1",
+				filePath:     "./testdata/dockerfile/valid_bundle.Dockerfile",
+				strictLabels: true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -189,7 +268,8 @@ func Test_OpenShiftValidator(t *testing.T) {
 				bundle.CSV.Annotations = tt.args.annotations
 			}
 
-			results := validateOpenShiftBundle(bundle, tt.args.filePath, tt.args.ocpLabelRange)
+			results := validateOpenShiftBundle(bundle, map[string]string{FilePathKey: tt.args.filePath,
+				RangeKey: tt.args.ocpLabelRange, StrictLabelsKey: strconv.FormatBool(tt.args.strictLabels)}, nil)
 			require.Equal(t, tt.wantWarning, len(results.Warnings) > 0)
 			if tt.wantWarning {
 				require.Equal(t, len(tt.warnStrings), len(results.Warnings))
@@ -335,3 +415,81 @@ func Test_rangeContainsVersion(t *testing.T) {
 		})
 	}
 }
+
+func Test_rangeAllowsVersion(t *testing.T) {
+	tests := []struct {
+		rangeValue string
+		version    string
+		wantUpper  bool
+		wantLower  bool
+	}{
+		{rangeValue: "v4.12", version: "4.14", wantUpper: true, wantLower: true},
+		{rangeValue: "v4.15", version: "4.14", wantUpper: true, wantLower: false},
+		{rangeValue: "v4.10-v4.13", version: "4.14", wantUpper: false, wantLower: true},
+		{rangeValue: "v4.12-v4.14", version: "4.14", wantUpper: true, wantLower: true},
+		{rangeValue: "=v4.9", version: "4.10", wantUpper: false, wantLower: true},
+		{rangeValue: "=v4.11", version: "4.10", wantUpper: true, wantLower: false},
+		{rangeValue: "v4.5,v4.6", version: "4.9", wantUpper: true, wantLower: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rangeValue+" "+tt.version, func(t *testing.T) {
+			upper, err := rangeAllowsVersionOrUpper(tt.rangeValue, tt.version)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantUpper, upper)
+
+			lower, err := rangeAllowsVersionLowerThan(tt.rangeValue, tt.version)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantLower, lower)
+		})
+	}
+}
+
+func Test_getOpenEndedRangeMin(t *testing.T) {
+	tests := []struct {
+		rangeValue    string
+		wantMin       string
+		wantOpenEnded bool
+	}{
+		{rangeValue: "v4.10", wantMin: "4.10", wantOpenEnded: true},
+		{rangeValue: "4.10", wantMin: "4.10", wantOpenEnded: true},
+		{rangeValue: "=v4.10"},
+		{rangeValue: "v4.8-v4.10"},
+		{rangeValue: "v4.5,v4.6"},
+		{rangeValue: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rangeValue, func(t *testing.T) {
+			min, openEnded := getOpenEndedRangeMin(tt.rangeValue)
+			require.Equal(t, tt.wantOpenEnded, openEnded)
+			require.Equal(t, tt.wantMin, min)
+		})
+	}
+}
+
+func Test_getDocsVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   OpenShiftOperatorChecks
+		fallback string
+		want     string
+	}{
+		{name: "max version of the range", checks: OpenShiftOperatorChecks{rangeValue: "v4.6-v4.8"}, want: "4.8"},
+		{name: "exact version", checks: OpenShiftOperatorChecks{rangeValue: "=v4.14"}, want: "4.14"},
+		{name: "minimum version", checks: OpenShiftOperatorChecks{rangeValue: "v4.13"}, want: "latest"},
+		{name: "legacy range", checks: OpenShiftOperatorChecks{rangeValue: "v4.5,v4.6"}, want: "latest"},
+		{name: "no range", checks: OpenShiftOperatorChecks{}, want: "latest"},
+		{name: "range in the file", checks: OpenShiftOperatorChecks{
+			filePath: "./testdata/dockerfile/valid_bundle.Dockerfile"}, want: "4.8"},
+		{name: "fallback version", checks: OpenShiftOperatorChecks{rangeValue: "v4.6-v4.8"}, fallback: "4.12",
+			want: "4.12"},
+		{name: "docs version informed", checks: OpenShiftOperatorChecks{rangeValue: "v4.6-v4.8",
+			docsVersion: "v4.15"}, fallback: "4.12", want: "4.15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, getDocsVersion(tt.checks, tt.fallback))
+		})
+	}
+	require.Equal(t, "https://docs.openshift.com/container-platform/latest/"+ocpDocPageManagingVersions,
+		getOCPDocLink("latest", ocpDocPageManagingVersions))
+}
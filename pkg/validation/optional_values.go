@@ -0,0 +1,86 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ParseOptionalValues returns the key=values informed in a comma-separated string
+// (e.g. "k8s-version=1.22,range=v4.8"). Note that the items without a key are appended to the value of
+// the previous key which allows to inform values with commas (e.g. "allowed-registries=quay.io,docker.io").
+func ParseOptionalValues(value string) (map[string]string, error) {
+	values := map[string]string{}
+	key := ""
+	for _, item := range strings.Split(value, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) == 2 && len(strings.TrimSpace(kv[0])) > 0 {
+			key = strings.TrimSpace(kv[0])
+			values[key] = kv[1]
+			continue
+		}
+		if len(key) == 0 {
+			return nil, fmt.Errorf("%s must be formatted as key=value", item)
+		}
+		values[key] += "," + item
+	}
+	return values, nil
+}
+
+// optionalValue defines a value of the values file which can be informed as a scalar or as a list
+// whose items are joined with commas
+type optionalValue string
+
+// UnmarshalYAML keeps the scalars as they are informed (e.g. 4.10 is not parsed as a float)
+func (v *optionalValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var items []string
+	if err := unmarshal(&items); err == nil {
+		*v = optionalValue(strings.Join(items, ","))
+		return nil
+	}
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	*v = optionalValue(value)
+	return nil
+}
+
+// LoadOptionalValues returns the key=values defined in the YAML file informed, for example:
+//
+//	k8s-version: "1.25"
+//	range: v4.10-v4.14
+//	allowed-registries:
+//	- registry.redhat.io
+//	- quay.io/example
+func LoadOptionalValues(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the values file: %s", err)
+	}
+	file := map[string]optionalValue{}
+	if err := yaml.UnmarshalStrict(content, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse the values file %s: %s", path, err)
+	}
+	values := map[string]string{}
+	for k, v := range file {
+		values[k] = string(v)
+	}
+	return values, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptionalValues(t *testing.T) {
+	values, err := ParseOptionalValues("k8s-version=1.22,range=v4.5,v4.6,allowed-registries=quay.io,docker.io")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"k8s-version":        "1.22",
+		"range":              "v4.5,v4.6",
+		"allowed-registries": "quay.io,docker.io",
+	}, values)
+
+	values, err = ParseOptionalValues("file=bundle.Dockerfile")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"file": "bundle.Dockerfile"}, values)
+
+	_, err = ParseOptionalValues("bundle.Dockerfile")
+	require.EqualError(t, err, "bundle.Dockerfile must be formatted as key=value")
+}
+
+func TestLoadOptionalValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "values.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`k8s-version: 1.25
+range: v4.10-v4.14
+docs-version: 4.10
+allowed-registries:
+- registry.redhat.io
+- quay.io/example
+`), 0600))
+	values, err := LoadOptionalValues(path)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"k8s-version":        "1.25",
+		"range":              "v4.10-v4.14",
+		"docs-version":       "4.10",
+		"allowed-registries": "registry.redhat.io,quay.io/example",
+	}, values)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`range:
+  min: v4.10
+`), 0600))
+	_, err = LoadOptionalValues(path)
+	require.Error(t, err)
+
+	_, err = LoadOptionalValues(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
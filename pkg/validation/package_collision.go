@@ -0,0 +1,164 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+)
+
+// The labels used to inform the channels of the bundle
+const (
+	channelsLabel       = "operators.operatorframework.io.bundle.channels.v1"
+	defaultChannelLabel = "operators.operatorframework.io.bundle.channel.default.v1"
+)
+
+// PackageCollisionValidator checks the package of the bundle against the File-Based Catalog informed via the
+// CatalogKey to catch the accidental collisions with the packages already published. Note that the index
+// images must be rendered into a directory first (e.g. opm render <index-image> > catalog/index.json).
+// Following its current checks:
+//
+// - Ensure that the package, when it already exists in the catalog, is provided by the same spec.provider.name
+//
+// - Warn when the default channel of the bundle differs from the one of the package, when none of the
+// channels of the bundle exist in the package or when the bundle replaced (spec.replaces) is not found in
+// them. Note that the channels are read from the bundle annotations.
+//
+// - Warn when a bundle with the same name already exists in the package
+var PackageCollisionValidator interfaces.Validator = newBundleValidator("package-collision",
+	checkPackageCollision)
+
+// checkPackageCollision will verify the package of the bundle against the package with the same name in
+// the catalog
+func checkPackageCollision(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	packageName := getPackageName(checks)
+	if len(checks.catalog) == 0 || len(packageName) == 0 {
+		return checks
+	}
+	// Note that issues to load the catalog are reported by the CatalogDependenciesValidator
+	cfg, err := declcfg.LoadFS(os.DirFS(checks.catalog))
+	if err != nil {
+		return checks
+	}
+	var pkg *declcfg.Package
+	for i := range cfg.Packages {
+		if cfg.Packages[i].Name == packageName {
+			pkg = &cfg.Packages[i]
+			break
+		}
+	}
+	if pkg == nil {
+		return checks
+	}
+
+	csv := checks.bundle.CSV
+	var providers []string
+	for _, b := range cfg.Bundles {
+		if b.Package != packageName {
+			continue
+		}
+		if b.Name == csv.GetName() {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the bundle %s already exists in the "+
+				"package %s of the catalog %s", b.Name, packageName, checks.catalog),
+				"ensure that the bundle is not published twice"))
+		}
+		provider := strings.TrimSpace(getCatalogCSVMetadata(b).Provider.Name)
+		if len(provider) > 0 && !containsFold(providers, provider) {
+			providers = append(providers, provider)
+		}
+	}
+	provider := strings.TrimSpace(csv.Spec.Provider.Name)
+	if len(provider) > 0 && len(providers) > 0 && !containsFold(providers, provider) {
+		sort.Strings(providers)
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the package %s already exists in the catalog %s "+
+			"and is provided by %s while the bundle is provided by %s", packageName, checks.catalog,
+			strings.Join(providers, ", "), provider), "ensure that the package name is not taken by another operator"))
+	}
+
+	return checkCatalogChannels(checks, cfg, pkg)
+}
+
+// checkCatalogChannels will verify the channels of the bundle against the channels of the package of the catalog
+func checkCatalogChannels(checks OpenShiftOperatorChecks, cfg *declcfg.DeclarativeConfig,
+	pkg *declcfg.Package) OpenShiftOperatorChecks {
+	channels, defaultChannel := getBundleChannels(checks)
+	if len(channels) == 0 {
+		return checks
+	}
+
+	if len(defaultChannel) > 0 && len(pkg.DefaultChannel) > 0 && defaultChannel != pkg.DefaultChannel {
+		checks.warns = append(checks.warns, fmt.Errorf("the default channel (%s) of the bundle differs from the "+
+			"default channel (%s) of the package %s of the catalog %s. Note that the default channel of the "+
+			"package will be changed when the bundle is published", defaultChannel, pkg.DefaultChannel, pkg.Name,
+			checks.catalog))
+	}
+
+	var found []declcfg.Channel
+	for _, ch := range cfg.Channels {
+		if ch.Package == pkg.Name && containsAny(channels, ch.Name) {
+			found = append(found, ch)
+		}
+	}
+	if len(found) == 0 {
+		checks.warns = append(checks.warns, fmt.Errorf("none of the channels (%s) of the bundle exist in the "+
+			"package %s of the catalog %s. Note that there will be no upgrade path from the bundles already "+
+			"published", strings.Join(channels, ", "), pkg.Name, checks.catalog))
+		return checks
+	}
+
+	replaces := checks.bundle.CSV.Spec.Replaces
+	if len(replaces) == 0 {
+		return checks
+	}
+	for _, ch := range found {
+		if !hasChannelEntry(ch, replaces) {
+			checks.warns = append(checks.warns, fmt.Errorf("the bundle replaced (%s) via spec.replaces is not "+
+				"found in the channel %s of the package %s of the catalog %s. Note that the upgrade graph of the "+
+				"channel will be broken", replaces, ch.Name, pkg.Name, checks.catalog))
+		}
+	}
+	return checks
+}
+
+// hasChannelEntry returns true when the channel informed has the bundle informed
+func hasChannelEntry(ch declcfg.Channel, bundleName string) bool {
+	for _, entry := range ch.Entries {
+		if entry.Name == bundleName {
+			return true
+		}
+	}
+	return false
+}
+
+// getBundleChannels returns the channels and the default channel of the bundle. If they are not
+// set in the bundle then, they will be looked for in the bundle annotations.
+func getBundleChannels(checks OpenShiftOperatorChecks) ([]string, string) {
+	channels, defaultChannel := checks.bundle.Channels, checks.bundle.DefaultChannel
+	if len(channels) > 0 {
+		return channels, defaultChannel
+	}
+	annotations := getBundleAnnotations(checks, getBundleDir(checks))
+	for _, ch := range strings.Split(annotations[channelsLabel], ",") {
+		if ch = strings.TrimSpace(ch); len(ch) > 0 {
+			channels = append(channels, ch)
+		}
+	}
+	return channels, strings.TrimSpace(annotations[defaultChannelLabel])
+}
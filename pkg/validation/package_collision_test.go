@@ -0,0 +1,146 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PackageCollisionValidator(t *testing.T) {
+	type args struct {
+		packageName string
+		provider    string
+		name        string
+		replaces    string
+		channels    []string
+		annotations string
+		catalog     string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the catalog is not informed",
+			args: args{
+				packageName: "etcd",
+			},
+		},
+		{
+			name: "should pass when the package does not exist in the catalog",
+			args: args{
+				packageName: "memcached-operator",
+				catalog:     "./testdata/catalog",
+			},
+		},
+		{
+			name: "should pass when the package is provided by the same provider with the same channels",
+			args: args{
+				packageName: "etcd",
+				provider:    "cncf",
+				replaces:    "etcdoperator.v0.9.4",
+				channels:    []string{"singlenamespace-alpha"},
+				catalog:     "./testdata/catalog",
+			},
+		},
+		{
+			name:      "should fail when the package is provided by another provider",
+			wantError: true,
+			args: args{
+				packageName: "etcd",
+				catalog:     "./testdata/catalog",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the package etcd already exists in " +
+				"the catalog ./testdata/catalog and is provided by CNCF while the bundle is provided by Provider " +
+				"Name. Please, ensure that the package name is not taken by another operator"},
+		},
+		{
+			name:        "should warn when the bundle already exists and its channels are not found",
+			wantWarning: true,
+			args: args{
+				packageName: "etcd",
+				provider:    "CNCF",
+				name:        "etcdoperator.v0.9.4",
+				channels:    []string{"alpha"},
+				catalog:     "./testdata/catalog",
+			},
+			warnStrings: []string{
+				"Warning: Value : (etcdoperator.v0.9.4) the bundle etcdoperator.v0.9.4 already exists in the " +
+					"package etcd of the catalog ./testdata/catalog. Please, ensure that the bundle is not published " +
+					"twice",
+				"Warning: Value : (etcdoperator.v0.9.4) none of the channels (alpha) of the bundle exist in the " +
+					"package etcd of the catalog ./testdata/catalog. Note that there will be no upgrade path from " +
+					"the bundles already published",
+			},
+		},
+		{
+			name:        "should warn when the default channel differs and the bundle replaced is not found",
+			wantWarning: true,
+			args: args{
+				packageName: "etcd",
+				provider:    "CNCF",
+				replaces:    "etcdoperator.v0.9.2",
+				annotations: "annotations:\n" +
+					"  operators.operatorframework.io.bundle.channels.v1: singlenamespace-alpha,clusterwide-alpha\n" +
+					"  operators.operatorframework.io.bundle.channel.default.v1: clusterwide-alpha\n",
+				catalog: "./testdata/catalog",
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the default channel (clusterwide-alpha) of the " +
+					"bundle differs from the default channel (singlenamespace-alpha) of the package etcd of the " +
+					"catalog ./testdata/catalog. Note that the default channel of the package will be changed when " +
+					"the bundle is published",
+				"Warning: Value : (memcached-operator.v0.0.1) the bundle replaced (etcdoperator.v0.9.2) via " +
+					"spec.replaces is not found in the channel singlenamespace-alpha of the package etcd of the " +
+					"catalog ./testdata/catalog. Note that the upgrade graph of the channel will be broken",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			bundle.Package = tt.args.packageName
+			bundle.Channels = tt.args.channels
+			bundle.CSV.Spec.Replaces = tt.args.replaces
+			if len(tt.args.provider) > 0 {
+				bundle.CSV.Spec.Provider.Name = tt.args.provider
+			}
+			if len(tt.args.name) > 0 {
+				bundle.CSV.SetName(tt.args.name)
+			}
+
+			optionalValues := map[string]string{CatalogKey: tt.args.catalog}
+			fsys := fstest.MapFS{}
+			if len(tt.args.annotations) > 0 {
+				fsys["metadata/annotations.yaml"] = &fstest.MapFile{Data: []byte(tt.args.annotations)}
+				optionalValues[FilePathKey] = "metadata/annotations.yaml"
+			}
+
+			results := PackageCollisionValidator.Validate(bundle, fsys, optionalValues)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"sigs.k8s.io/yaml"
+)
+
+// GetPackageManifestVersionDirs returns the directories of the versions when the directory informed is in
+// the legacy PackageManifest format (e.g. memcached.package.yaml and a directory per version). Otherwise,
+// it returns nil.
+func GetPackageManifestVersionDirs(dir string) ([]string, error) {
+	pkg, err := getPackageManifest(dir)
+	if err != nil || pkg == nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var versionDirs []string
+	for _, f := range files {
+		if f.IsDir() && !strings.HasPrefix(f.Name(), ".") {
+			versionDirs = append(versionDirs, filepath.Join(dir, f.Name()))
+		}
+	}
+	return versionDirs, nil
+}
+
+// getPackageManifest returns the package manifest (e.g. memcached.package.yaml) found in the directory informed
+// or nil when the directory is not in the legacy PackageManifest format
+func getPackageManifest(dir string) (*manifests.PackageManifest, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		if !strings.HasSuffix(f.Name(), "package.yaml") && !strings.HasSuffix(f.Name(), "package.yml") {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		pkg := manifests.PackageManifest{}
+		if err := yaml.Unmarshal(content, &pkg); err == nil && !pkg.IsEmpty() {
+			return &pkg, nil
+		}
+	}
+	return nil, nil
+}
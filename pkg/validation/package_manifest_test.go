@@ -0,0 +1,45 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPackageManifestVersionDirs(t *testing.T) {
+	dir := t.TempDir()
+	for _, version := range []string{"0.0.1", "0.0.2", ".git"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "memcached-operator.package.yaml"),
+		[]byte("packageName: memcached-operator\nchannels:\n- name: alpha\n  currentCSV: "+
+			"memcached-operator.v0.0.2\ndefaultChannel: alpha\n"), 0644))
+
+	versionDirs, err := GetPackageManifestVersionDirs(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "0.0.1"), filepath.Join(dir, "0.0.2")}, versionDirs)
+
+	versionDirs, err = GetPackageManifestVersionDirs("./testdata/valid_bundle_v1")
+	require.NoError(t, err)
+	require.Nil(t, versionDirs)
+
+	_, err = GetPackageManifestVersionDirs("./testdata/not_found")
+	require.Error(t, err)
+}
@@ -0,0 +1,486 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// preBundleSkippedValidators defines the validators which do not apply to the pre-bundle manifests since
+// they check what is only added when the bundle is generated (e.g. its metadata and size)
+var preBundleSkippedValidators = []string{"certification-labels", "oci-labels", "scorecard", "created-at",
+	"size"}
+
+// preBundleSkippedGroups defines the groups of the objects of the pre-bundle layouts which are not shipped in
+// the bundle (e.g. the kustomize and scorecard configurations)
+var preBundleSkippedGroups = []string{"kustomize.config.k8s.io", "scorecard.operatorframework.io"}
+
+// kustomizePatchFields defines the fields of the kustomization files which inform patches instead of resources
+var kustomizePatchFields = []string{"patches", "patchesStrategicMerge", "patchesJson6902"}
+
+// LoadPreBundle returns the bundle which would be generated (e.g. operator-sdk generate bundle) from the
+// pre-bundle manifests found in the path informed, which can be the output of kustomize (e.g. kustomize build
+// config/manifests > manifests.yaml) or a directory with the CSV template and the CRDs (e.g. config). The
+// manifests are mapped onto the bundle layout as the generation does:
+//
+// - The Deployments are mapped onto the install strategy of the CSV when it does not inform them
+//
+// - The Roles and ClusterRoles bound to the service accounts of the Deployments are mapped onto its
+// permissions and clusterPermissions, respectively
+//
+// - The webhook configurations served by the Deployments are mapped onto its webhookdefinitions
+//
+// Note that the Namespaces, the kustomize patches and the samples of the owned CRDs (which are informed via the
+// alm-examples annotation) are not shipped.
+func LoadPreBundle(path string) (*manifests.Bundle, error) {
+	objs, size, err := readPreBundleObjects(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &manifests.Bundle{Size: size}
+	var others []*unstructured.Unstructured
+	for _, obj := range objs {
+		switch obj.GetKind() {
+		case operatorsv1alpha1.ClusterServiceVersionKind:
+			if bundle.CSV != nil {
+				return nil, fmt.Errorf("invalid pre-bundle manifests: contains multiple CSVs (%s and %s)",
+					bundle.CSV.GetName(), obj.GetName())
+			}
+			bundle.CSV = &operatorsv1alpha1.ClusterServiceVersion{}
+			if err := fromUnstructured(obj, bundle.CSV); err != nil {
+				return nil, fmt.Errorf("unable to parse CSV %s: %s", obj.GetName(), err)
+			}
+		case "CustomResourceDefinition":
+			if err := addPreBundleCRD(bundle, obj); err != nil {
+				return nil, err
+			}
+			bundle.Objects = append(bundle.Objects, obj)
+		default:
+			others = append(others, obj)
+		}
+	}
+	if bundle.CSV == nil {
+		return nil, fmt.Errorf("unable to find a csv (e.g. config/manifests/bases) in the pre-bundle manifests %s",
+			path)
+	}
+	bundle.Name = bundle.CSV.GetName()
+
+	others, err = mapPreBundleObjects(bundle, others)
+	if err != nil {
+		return nil, err
+	}
+	csv, err := runtime.DefaultUnstructuredConverter.ToUnstructured(bundle.CSV)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Objects = append(append([]*unstructured.Unstructured{{Object: csv}}, bundle.Objects...), others...)
+	return bundle, nil
+}
+
+// GetPreBundleValidators returns the validators informed which apply to the pre-bundle manifests
+func GetPreBundleValidators(validators interfaces.Validators) interfaces.Validators {
+	var applicable interfaces.Validators
+	for _, v := range validators {
+		if !containsAny(preBundleSkippedValidators, GetValidatorName(v)) {
+			applicable = append(applicable, v)
+		}
+	}
+	return applicable
+}
+
+// readPreBundleObjects returns the objects and the size of the YAML and JSON files found in the path informed.
+// Note that the kustomize patches and the files of the hidden directories are ignored.
+func readPreBundleObjects(path string) ([]*unstructured.Unstructured, int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = getPreBundleFiles(path); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var objs []*unstructured.Unstructured
+	var size int64
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to load file %s: %s", file, err)
+		}
+		size += int64(len(content))
+		decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+		for {
+			obj := &unstructured.Unstructured{}
+			if err := decoder.Decode(&obj.Object); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, 0, fmt.Errorf("unable to decode the file %s: %s", file, err)
+			}
+			if len(obj.GetKind()) == 0 || containsAny(preBundleSkippedGroups, obj.GroupVersionKind().Group) {
+				continue
+			}
+			objs = append(objs, obj)
+		}
+	}
+	return objs, size, nil
+}
+
+// getPreBundleFiles returns the YAML and JSON files found in the directory informed which are not kustomize
+// patches (e.g. manager_auth_proxy_patch.yaml) or kustomization files
+func getPreBundleFiles(dir string) ([]string, error) {
+	patches := map[string]bool{}
+	var files []string
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && name != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(name)
+		if d.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			return nil
+		}
+		if base := strings.TrimSuffix(d.Name(), ext); base == "kustomization" || base == "Kustomization" {
+			return addKustomizePatches(patches, name)
+		}
+		files = append(files, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []string
+	for _, file := range files {
+		if !patches[filepath.Clean(file)] {
+			resources = append(resources, file)
+		}
+	}
+	return resources, nil
+}
+
+// addKustomizePatches adds the paths of the patches informed by the kustomization file informed
+func addKustomizePatches(patches map[string]bool, kustomization string) error {
+	content, err := ioutil.ReadFile(kustomization)
+	if err != nil {
+		return fmt.Errorf("unable to load file %s: %s", kustomization, err)
+	}
+	fields := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &fields); err != nil {
+		return fmt.Errorf("unable to decode the file %s: %s", kustomization, err)
+	}
+	for _, field := range kustomizePatchFields {
+		entries, _ := fields[field].([]interface{})
+		for _, entry := range entries {
+			// The patches can be informed as paths or as objects with the path
+			patch, ok := entry.(string)
+			if m, isMap := entry.(map[string]interface{}); isMap {
+				patch, ok = m["path"].(string)
+			}
+			if ok && len(patch) > 0 {
+				patches[filepath.Join(filepath.Dir(kustomization), patch)] = true
+			}
+		}
+	}
+	return nil
+}
+
+// addPreBundleCRD adds the CRD informed to the bundle
+func addPreBundleCRD(bundle *manifests.Bundle, obj *unstructured.Unstructured) error {
+	switch version := obj.GetAPIVersion(); version {
+	case apiextensionsv1beta1.SchemeGroupVersion.String():
+		crd := &apiextensionsv1beta1.CustomResourceDefinition{}
+		if err := fromUnstructured(obj, crd); err != nil {
+			return fmt.Errorf("unable to parse CRD %s: %s", obj.GetName(), err)
+		}
+		bundle.V1beta1CRDs = append(bundle.V1beta1CRDs, crd)
+	case apiextensionsv1.SchemeGroupVersion.String():
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := fromUnstructured(obj, crd); err != nil {
+			return fmt.Errorf("unable to parse CRD %s: %s", obj.GetName(), err)
+		}
+		bundle.V1CRDs = append(bundle.V1CRDs, crd)
+	default:
+		return fmt.Errorf("unsupported CRD version %s for %s", version, obj.GetName())
+	}
+	return nil
+}
+
+// mapPreBundleObjects maps the Deployments, their RBAC and webhook configurations onto the CSV of the bundle
+// and returns the objects which are shipped in the bundle
+func mapPreBundleObjects(bundle *manifests.Bundle,
+	objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	owned := map[string]bool{}
+	for _, crd := range bundle.CSV.Spec.CustomResourceDefinitions.Owned {
+		owned[crd.Kind] = true
+	}
+
+	strategy := &bundle.CSV.Spec.InstallStrategy
+	mapDeployments := len(strategy.StrategySpec.DeploymentSpecs) == 0
+	mapWebhooks := len(bundle.CSV.Spec.WebhookDefinitions) == 0
+	var deployments []appsv1.Deployment
+	var shipped []*unstructured.Unstructured
+	for _, obj := range objs {
+		switch {
+		case obj.GetKind() == "Namespace" || owned[obj.GetKind()]:
+			continue
+		case obj.GetKind() == "Deployment" && mapDeployments:
+			dep := appsv1.Deployment{}
+			if err := fromUnstructured(obj, &dep); err != nil {
+				return nil, fmt.Errorf("unable to parse Deployment %s: %s", obj.GetName(), err)
+			}
+			deployments = append(deployments, dep)
+			strategy.StrategySpec.DeploymentSpecs = append(strategy.StrategySpec.DeploymentSpecs,
+				operatorsv1alpha1.StrategyDeploymentSpec{Name: dep.Name, Spec: dep.Spec,
+					Label: labels.Set(dep.Labels)})
+		default:
+			shipped = append(shipped, obj)
+		}
+	}
+	if len(deployments) == 0 {
+		return shipped, nil
+	}
+	if len(strategy.StrategyName) == 0 {
+		strategy.StrategyName = operatorsv1alpha1.InstallStrategyNameDeployment
+	}
+
+	shipped, err := mapPreBundleRBAC(bundle.CSV, deployments, shipped)
+	if err != nil || !mapWebhooks {
+		return shipped, err
+	}
+	return mapPreBundleWebhooks(bundle.CSV, deployments, shipped)
+}
+
+// mapPreBundleRBAC maps the Roles and ClusterRoles bound to the service accounts of the Deployments informed onto
+// the permissions of the CSV and returns the objects which are not mapped
+func mapPreBundleRBAC(csv *operatorsv1alpha1.ClusterServiceVersion, deployments []appsv1.Deployment,
+	objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	serviceAccounts := map[string]bool{}
+	for _, dep := range deployments {
+		name := dep.Spec.Template.Spec.ServiceAccountName
+		if len(name) == 0 {
+			name = "default"
+		}
+		serviceAccounts[name] = true
+	}
+
+	roles := map[string]*unstructured.Unstructured{}
+	for _, obj := range objs {
+		if obj.GetKind() == "Role" || obj.GetKind() == "ClusterRole" {
+			roles[obj.GetKind()+"/"+obj.GetName()] = obj
+		}
+	}
+
+	mapped := map[*unstructured.Unstructured]bool{}
+	spec := &csv.Spec.InstallStrategy.StrategySpec
+	for _, obj := range objs {
+		if obj.GetKind() != "RoleBinding" && obj.GetKind() != "ClusterRoleBinding" {
+			continue
+		}
+		binding := rbacv1.RoleBinding{}
+		if err := fromUnstructured(obj, &binding); err != nil {
+			return nil, fmt.Errorf("unable to parse %s %s: %s", obj.GetKind(), obj.GetName(), err)
+		}
+		role, found := roles[binding.RoleRef.Kind+"/"+binding.RoleRef.Name]
+		if !found {
+			continue
+		}
+		rules := rbacv1.ClusterRole{}
+		if err := fromUnstructured(role, &rules); err != nil {
+			return nil, fmt.Errorf("unable to parse %s %s: %s", role.GetKind(), role.GetName(), err)
+		}
+		for _, subject := range binding.Subjects {
+			if subject.Kind != rbacv1.ServiceAccountKind || !serviceAccounts[subject.Name] {
+				continue
+			}
+			permissions := operatorsv1alpha1.StrategyDeploymentPermissions{ServiceAccountName: subject.Name,
+				Rules: rules.Rules}
+			if obj.GetKind() == "ClusterRoleBinding" {
+				spec.ClusterPermissions = append(spec.ClusterPermissions, permissions)
+			} else {
+				spec.Permissions = append(spec.Permissions, permissions)
+			}
+			mapped[obj], mapped[role] = true, true
+		}
+	}
+
+	var shipped []*unstructured.Unstructured
+	for _, obj := range objs {
+		// The service accounts of the deployments are created by OLM
+		if mapped[obj] || (obj.GetKind() == "ServiceAccount" && serviceAccounts[obj.GetName()]) {
+			continue
+		}
+		shipped = append(shipped, obj)
+	}
+	return shipped, nil
+}
+
+// mapPreBundleWebhooks maps the admission webhooks served by the Deployments informed onto the
+// webhookdefinitions of the CSV and returns the objects which are not mapped
+func mapPreBundleWebhooks(csv *operatorsv1alpha1.ClusterServiceVersion, deployments []appsv1.Deployment,
+	objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	services := map[string]corev1.Service{}
+	for _, obj := range objs {
+		if obj.GetKind() != "Service" {
+			continue
+		}
+		svc := corev1.Service{}
+		if err := fromUnstructured(obj, &svc); err != nil {
+			return nil, fmt.Errorf("unable to parse Service %s: %s", obj.GetName(), err)
+		}
+		services[svc.Name] = svc
+	}
+
+	mapped := map[string]bool{}
+	var shipped []*unstructured.Unstructured
+	for _, obj := range objs {
+		var webhooks []operatorsv1alpha1.WebhookDescription
+		switch obj.GetKind() {
+		case "ValidatingWebhookConfiguration":
+			cfg := admissionregistrationv1.ValidatingWebhookConfiguration{}
+			if err := fromUnstructured(obj, &cfg); err != nil {
+				return nil, fmt.Errorf("unable to parse %s %s: %s", obj.GetKind(), obj.GetName(), err)
+			}
+			for _, wh := range cfg.Webhooks {
+				webhooks = append(webhooks, operatorsv1alpha1.WebhookDescription{GenerateName: wh.Name,
+					Type: operatorsv1alpha1.ValidatingAdmissionWebhook, Rules: wh.Rules,
+					FailurePolicy: wh.FailurePolicy, MatchPolicy: wh.MatchPolicy, ObjectSelector: wh.ObjectSelector,
+					SideEffects: wh.SideEffects, TimeoutSeconds: wh.TimeoutSeconds,
+					AdmissionReviewVersions: wh.AdmissionReviewVersions, WebhookPath: getWebhookPath(wh.ClientConfig)})
+			}
+		case "MutatingWebhookConfiguration":
+			cfg := admissionregistrationv1.MutatingWebhookConfiguration{}
+			if err := fromUnstructured(obj, &cfg); err != nil {
+				return nil, fmt.Errorf("unable to parse %s %s: %s", obj.GetKind(), obj.GetName(), err)
+			}
+			for _, wh := range cfg.Webhooks {
+				webhooks = append(webhooks, operatorsv1alpha1.WebhookDescription{GenerateName: wh.Name,
+					Type: operatorsv1alpha1.MutatingAdmissionWebhook, Rules: wh.Rules,
+					FailurePolicy: wh.FailurePolicy, MatchPolicy: wh.MatchPolicy, ObjectSelector: wh.ObjectSelector,
+					SideEffects: wh.SideEffects, TimeoutSeconds: wh.TimeoutSeconds,
+					AdmissionReviewVersions: wh.AdmissionReviewVersions, WebhookPath: getWebhookPath(wh.ClientConfig),
+					ReinvocationPolicy: wh.ReinvocationPolicy})
+			}
+		default:
+			shipped = append(shipped, obj)
+			continue
+		}
+
+		// The webhooks whose service does not select a deployment are not mapped
+		for i, wh := range webhooks {
+			name := getWebhookServiceName(obj, i)
+			svc, found := services[name]
+			if !found {
+				continue
+			}
+			port := getWebhookServicePort(obj, i)
+			for _, dep := range deployments {
+				selector := labels.SelectorFromSet(svc.Spec.Selector)
+				if len(svc.Spec.Selector) == 0 || !selector.Matches(labels.Set(dep.Spec.Template.Labels)) {
+					continue
+				}
+				wh.DeploymentName = dep.Name
+				wh.ContainerPort = port
+				for _, p := range svc.Spec.Ports {
+					if p.Port == port {
+						targetPort := p.TargetPort
+						if targetPort == (intstr.IntOrString{}) {
+							targetPort = intstr.FromInt(int(port))
+						}
+						wh.TargetPort = &targetPort
+					}
+				}
+				csv.Spec.WebhookDefinitions = append(csv.Spec.WebhookDefinitions, wh)
+				mapped[name] = true
+				break
+			}
+		}
+	}
+
+	// The services of the webhooks are created by OLM
+	var result []*unstructured.Unstructured
+	for _, obj := range shipped {
+		if obj.GetKind() == "Service" && mapped[obj.GetName()] {
+			continue
+		}
+		result = append(result, obj)
+	}
+	return result, nil
+}
+
+// getWebhookPath returns the path of the service of the client config informed (if any)
+func getWebhookPath(cfg admissionregistrationv1.WebhookClientConfig) *string {
+	if cfg.Service == nil {
+		return nil
+	}
+	return cfg.Service.Path
+}
+
+// getWebhookServiceName returns the name of the service of the webhook informed by index
+func getWebhookServiceName(obj *unstructured.Unstructured, index int) string {
+	webhooks, _, _ := unstructured.NestedSlice(obj.Object, "webhooks")
+	if index >= len(webhooks) {
+		return ""
+	}
+	webhook, _ := webhooks[index].(map[string]interface{})
+	name, _, _ := unstructured.NestedString(webhook, "clientConfig", "service", "name")
+	return name
+}
+
+// getWebhookServicePort returns the port of the service of the webhook informed by index (443 by default)
+func getWebhookServicePort(obj *unstructured.Unstructured, index int) int32 {
+	webhooks, _, _ := unstructured.NestedSlice(obj.Object, "webhooks")
+	if index < len(webhooks) {
+		webhook, _ := webhooks[index].(map[string]interface{})
+		if port, found, _ := unstructured.NestedInt64(webhook, "clientConfig", "service", "port"); found {
+			return int32(port)
+		}
+	}
+	return 443
+}
+
+// fromUnstructured converts the object informed into the typed object informed
+func fromUnstructured(obj *unstructured.Unstructured, into interface{}) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, into)
+}
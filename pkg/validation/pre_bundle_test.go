@@ -0,0 +1,105 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestLoadPreBundle(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		wantErr     string
+		wantObjects []string
+	}{
+		{
+			name:        "should map the manifests of the config directory onto the bundle",
+			path:        "./testdata/pre_bundle/config",
+			wantObjects: []string{"ClusterServiceVersion", "CustomResourceDefinition"},
+		},
+		{
+			name:        "should map the manifests of the kustomize output onto the bundle",
+			path:        "./testdata/pre_bundle/manifests.yaml",
+			wantObjects: []string{"ClusterServiceVersion", "CustomResourceDefinition"},
+		},
+		{
+			name: "should fail when the manifests have no CSV",
+			path: "./testdata/pre_bundle/config/crd",
+			wantErr: "unable to find a csv (e.g. config/manifests/bases) in the pre-bundle manifests " +
+				"./testdata/pre_bundle/config/crd",
+		},
+		{
+			name:    "should fail when the path is not found",
+			path:    "./testdata/pre_bundle/not-found",
+			wantErr: "stat ./testdata/pre_bundle/not-found: no such file or directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := LoadPreBundle(tt.path)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "memcached-operator.v0.0.0", bundle.Name)
+			require.Len(t, bundle.V1CRDs, 1)
+
+			var kinds []string
+			for _, obj := range bundle.Objects {
+				kinds = append(kinds, obj.GetKind())
+			}
+			require.Equal(t, tt.wantObjects, kinds)
+
+			strategy := bundle.CSV.Spec.InstallStrategy
+			require.Equal(t, operatorsv1alpha1.InstallStrategyNameDeployment, strategy.StrategyName)
+			require.Len(t, strategy.StrategySpec.DeploymentSpecs, 1)
+			require.Equal(t, "controller-manager", strategy.StrategySpec.DeploymentSpecs[0].Name)
+			require.Len(t, strategy.StrategySpec.ClusterPermissions, 1)
+			require.Equal(t, "controller-manager", strategy.StrategySpec.ClusterPermissions[0].ServiceAccountName)
+			require.Len(t, strategy.StrategySpec.Permissions, 1)
+
+			require.Len(t, bundle.CSV.Spec.WebhookDefinitions, 1)
+			webhook := bundle.CSV.Spec.WebhookDefinitions[0]
+			require.Equal(t, "vmemcached.kb.io", webhook.GenerateName)
+			require.Equal(t, operatorsv1alpha1.ValidatingAdmissionWebhook, webhook.Type)
+			require.Equal(t, "controller-manager", webhook.DeploymentName)
+			require.Equal(t, int32(443), webhook.ContainerPort)
+			require.Equal(t, intstr.FromInt(9443), *webhook.TargetPort)
+			require.Equal(t, "/validate-cache-example-com-v1alpha1-memcached", *webhook.WebhookPath)
+		})
+	}
+}
+
+func TestGetPreBundleValidators(t *testing.T) {
+	validators := GetPreBundleValidators(DefaultValidators)
+	require.Len(t, validators, len(DefaultValidators)-len(preBundleSkippedValidators))
+	for _, v := range validators {
+		require.NotContains(t, preBundleSkippedValidators, GetValidatorName(v))
+	}
+
+	bundle, err := LoadPreBundle("./testdata/pre_bundle/config")
+	require.NoError(t, err)
+	for _, v := range validators {
+		for _, result := range v.Validate(bundle, map[string]string{}) {
+			require.False(t, result.HasError(), "%s: %v", GetValidatorName(v), result.Errors)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// The profiles which can be informed via the ProfileKey. They define the catalog
+// where the bundle is intended to be published.
+const (
+	CertifiedProfile   = "certified"
+	RedHatProfile      = "redhat"
+	MarketplaceProfile = "marketplace"
+	CommunityProfile   = "community"
+)
+
+// profileSuites defines the suites run for each profile when no suites are selected. Note that the
+// community catalog requires the bundles to pass the upstream operatorhub and community suites.
+var profileSuites = map[string][]string{
+	CertifiedProfile:   {OpenShiftSuite, BundleSuite},
+	RedHatProfile:      {OpenShiftSuite, BundleSuite},
+	MarketplaceProfile: {OpenShiftSuite, BundleSuite},
+	CommunityProfile:   {OpenShiftSuite, BundleSuite, OperatorHubSuite, CommunitySuite},
+}
+
+// The annotations required by the marketplace catalog to link the operator with its workflows
+const (
+	remoteWorkflowAnnotation  = "marketplace.openshift.io/remote-workflow"
+	supportWorkflowAnnotation = "marketplace.openshift.io/support-workflow"
+)
+
+// ProfileValidator validates the requirements which are only mandatory for the catalog of the profile
+// informed via the ProfileKey. Following its current checks:
+//
+// - Ensure that the profile informed is supported
+//
+// - Ensure that the CSV has the marketplace.openshift.io/remote-workflow and
+// marketplace.openshift.io/support-workflow annotations for the marketplace profile
+//
+// - Ensure that the CSV has the operators.openshift.io/valid-subscription annotation for the redhat profile
+var ProfileValidator interfaces.Validator = newBundleValidator("profile", checkProfile)
+
+// GetProfileSuites returns the suites run for the profile informed
+func GetProfileSuites(profile string) ([]string, error) {
+	suites, found := profileSuites[profile]
+	if !found {
+		return nil, withRemediation(fmt.Errorf("the profile %s is not supported", profile), "inform one of: %s",
+			strings.Join(getProfileNames(), ", "))
+	}
+	return suites, nil
+}
+
+// getProfileNames returns the names of the profiles supported
+func getProfileNames() []string {
+	return []string{CertifiedProfile, RedHatProfile, MarketplaceProfile, CommunityProfile}
+}
+
+// checkProfile will verify the requirements of the catalog of the profile
+func checkProfile(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if len(checks.profile) == 0 {
+		return checks
+	}
+	if _, err := GetProfileSuites(checks.profile); err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+
+	annotations := checks.bundle.CSV.GetAnnotations()
+	var required []string
+	switch checks.profile {
+	case MarketplaceProfile:
+		required = []string{remoteWorkflowAnnotation, supportWorkflowAnnotation}
+	case RedHatProfile:
+		required = []string{validSubscriptionAnnotation}
+	}
+	for _, annotation := range required {
+		if len(strings.TrimSpace(annotations[annotation])) == 0 {
+			checks.errs = append(checks.errs, fmt.Errorf("the annotation %s was not found in the CSV. Note that "+
+				"it is required to publish the bundle on the %s catalog", annotation, checks.profile))
+		}
+	}
+	return checks
+}
+
+// isStrictProfile returns true when the profile informed requires the bundle to respect
+// the criteria which are only recommended for the community catalog
+func isStrictProfile(profile string) bool {
+	switch profile {
+	case CertifiedProfile, RedHatProfile, MarketplaceProfile:
+		return true
+	}
+	return false
+}
+
+// addStrictFinding will add the error informed as an error when a strict profile is used
+// and as a warning otherwise
+func addStrictFinding(checks OpenShiftOperatorChecks, err error) OpenShiftOperatorChecks {
+	if isStrictProfile(checks.profile) {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+	checks.warns = append(checks.warns, err)
+	return checks
+}
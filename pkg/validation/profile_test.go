@@ -0,0 +1,149 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_addStrictFinding(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     string
+		wantError   bool
+		wantWarning bool
+	}{
+		{
+			name:        "should warn when no profile is informed",
+			wantWarning: true,
+		},
+		{
+			name:        "should warn with the community profile",
+			profile:     CommunityProfile,
+			wantWarning: true,
+		},
+		{
+			name:      "should fail with the certified profile",
+			profile:   CertifiedProfile,
+			wantError: true,
+		},
+		{
+			name:      "should fail with the redhat profile",
+			profile:   RedHatProfile,
+			wantError: true,
+		},
+		{
+			name:      "should fail with the marketplace profile",
+			profile:   MarketplaceProfile,
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := addStrictFinding(OpenShiftOperatorChecks{profile: tt.profile}, fmt.Errorf("finding"))
+			require.Equal(t, tt.wantError, len(checks.errs) > 0)
+			require.Equal(t, tt.wantWarning, len(checks.warns) > 0)
+		})
+	}
+}
+
+func Test_ProfileValidator(t *testing.T) {
+	type args struct {
+		profile     string
+		annotations map[string]string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when no profile is informed",
+		},
+		{
+			name: "should pass with the community profile",
+			args: args{profile: CommunityProfile},
+		},
+		{
+			name: "should pass when the marketplace annotations are informed",
+			args: args{
+				profile: MarketplaceProfile,
+				annotations: map[string]string{
+					remoteWorkflowAnnotation:  "https://marketplace.redhat.com/en-us/operators/memcached/pricing",
+					supportWorkflowAnnotation: "https://marketplace.redhat.com/en-us/operators/memcached/support",
+				},
+			},
+		},
+		{
+			name:      "should fail when the marketplace annotations are not informed",
+			wantError: true,
+			args:      args{profile: MarketplaceProfile},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the annotation marketplace.openshift.io/remote-workflow " +
+					"was not found in the CSV. Note that it is required to publish the bundle on the marketplace catalog",
+				"Error: Value : (memcached-operator.v0.0.1) the annotation marketplace.openshift.io/support-workflow " +
+					"was not found in the CSV. Note that it is required to publish the bundle on the marketplace catalog",
+			},
+		},
+		{
+			name:      "should fail when the valid subscription is not informed with the redhat profile",
+			wantError: true,
+			args:      args{profile: RedHatProfile},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the annotation " +
+				"operators.openshift.io/valid-subscription was not found in the CSV. Note that it is required to " +
+				"publish the bundle on the redhat catalog"},
+		},
+		{
+			name:      "should fail when the profile is not supported",
+			wantError: true,
+			args:      args{profile: "certifed"},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the profile certifed is not " +
+				"supported. Please, inform one of: certified, redhat, marketplace, community"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			for k, v := range tt.args.annotations {
+				bundle.CSV.Annotations[k] = v
+			}
+
+			results := ProfileValidator.Validate(bundle, map[string]string{ProfileKey: tt.args.profile})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+func TestGetProfileSuites(t *testing.T) {
+	suites, err := GetProfileSuites(CommunityProfile)
+	require.NoError(t, err)
+	require.Equal(t, []string{OpenShiftSuite, BundleSuite, OperatorHubSuite, CommunitySuite}, suites)
+
+	suites, err = GetProfileSuites(CertifiedProfile)
+	require.NoError(t, err)
+	require.Equal(t, []string{OpenShiftSuite, BundleSuite}, suites)
+
+	_, err = GetProfileSuites("")
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// proxyAwareFeature defines the feature used to claim that the operator supports clusters behind a proxy
+const proxyAwareFeature = "proxy-aware"
+
+// proxyEnvs defines the environment variables injected by OLM in the operator pods when the cluster
+// has a proxy configured
+var proxyEnvs = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"}
+
+// The apiGroup and resource of the cluster-wide Proxy configuration
+const (
+	configAPIGroup = "config.openshift.io"
+	proxyResource  = "proxies"
+)
+
+// ProxyValidator validates the bundles which claim to support clusters behind a proxy via the
+// features.operators.openshift.io/proxy-aware or the operators.openshift.io/infrastructure-features
+// annotations. Following its current checks:
+//
+// - Warn when the proxy support is claimed but no evidence of it is found in the bundle. That is, the
+// containers do not reference the HTTP_PROXY, HTTPS_PROXY or NO_PROXY environment variables and the
+// operator is not allowed to read the cluster Proxy object (proxies.config.openshift.io). Note that it is
+// a heuristic since the operator may read the environment variables injected by OLM without defining them.
+var ProxyValidator interfaces.Validator = newBundleValidator("proxy", checkProxyAware)
+
+// checkProxyAware will look for evidence of the proxy support when it is claimed
+func checkProxyAware(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	if !claimsFeature(checks.bundle.CSV, proxyAwareFeature, proxyAwareFeature) {
+		return checks
+	}
+
+	strategy := checks.bundle.CSV.Spec.InstallStrategy.StrategySpec
+	if hasPermission(strategy.Permissions, configAPIGroup, proxyResource, "get", "list", "watch") ||
+		hasPermission(strategy.ClusterPermissions, configAPIGroup, proxyResource, "get", "list", "watch") {
+		return checks
+	}
+	for _, dep := range strategy.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			for _, env := range c.Env {
+				if containsFold(proxyEnvs, env.Name) {
+					return checks
+				}
+			}
+		}
+	}
+
+	checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the operator claims to be %s but no evidence was "+
+		"found in the bundle", proxyAwareFeature),
+		"ensure that the operator propagates the environment variables (%s) injected by OLM to its operands or reads "+
+			"the cluster Proxy object, which requires permissions to get %s.%s. Note that false proxy-aware claims "+
+			"are a common reason to reject the certification", strings.Join(proxyEnvs, ", "), proxyResource,
+		configAPIGroup))
+	return checks
+}
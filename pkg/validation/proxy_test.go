@@ -0,0 +1,100 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func Test_ProxyValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the proxy support is not claimed",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the proxy support is claimed and the proxy environment variables are used",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations["features.operators.openshift.io/proxy-aware"] = "true"
+					setManagerEnv(bundle, corev1.EnvVar{Name: "https_proxy"})
+				},
+			},
+		},
+		{
+			name: "should pass when the proxy support is claimed and the cluster Proxy can be read",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["proxy-aware"]`
+					perms := &bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions[0]
+					perms.Rules = append(perms.Rules, rbacv1.PolicyRule{
+						APIGroups: []string{configAPIGroup},
+						Resources: []string{proxyResource},
+						Verbs:     []string{"get"},
+					})
+				},
+			},
+		},
+		{
+			name:        "should warn when the proxy support is claimed without evidence",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["Proxy-Aware"]`
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the operator claims to be " +
+				"proxy-aware but no evidence was found in the bundle. Please, ensure that the operator propagates " +
+				"the environment variables (HTTP_PROXY, HTTPS_PROXY, NO_PROXY) injected by OLM to its operands or " +
+				"reads the cluster Proxy object, which requires permissions to get proxies.config.openshift.io. " +
+				"Note that false proxy-aware claims are a common reason to reject the certification"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := ProxyValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
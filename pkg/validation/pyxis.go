@@ -0,0 +1,155 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/pyxis"
+)
+
+// pyxisInspector defines the client used to inspect the Red Hat Catalog
+var pyxisInspector pyxis.Inspector = pyxis.NewClient()
+
+// pyxisOrganizations defines the organizations of the Red Hat Catalog where the bundles are published by profile
+var pyxisOrganizations = map[string]string{
+	CertifiedProfile:   "certified-operators",
+	MarketplaceProfile: "redhat-marketplace",
+	RedHatProfile:      "redhat-operators",
+	CommunityProfile:   "community-operators",
+}
+
+// pyxisProjectStatusActive defines the status of the certification projects which accept submissions
+const pyxisProjectStatusActive = "active"
+
+// pyxisProjectTypeBundle defines the type of the certification projects of the operator bundles
+const pyxisProjectTypeBundle = "operator bundle"
+
+// PyxisValidator validates the bundle against the packages, bundles and certification projects registered in
+// the Red Hat Catalog (Pyxis) to fail fast before the submission is rejected by the pipeline. It is not part of
+// the DefaultValidators. The API key can be informed via PYXIS_API_KEY. Following its current checks:
+//
+// - Ensure that the version of the bundle (CSV name) was not already published in its package
+//
+// - Ensure that the package, when it already exists, is owned by the certification project informed via the
+// PyxisProjectKey
+//
+// - Ensure that the certification project informed is an active operator bundle project
+//
+// - Ensure that the OCP versions informed via the com.redhat.openshift.versions label include at least one
+// OpenShift version supported by the catalog of the profile (certified by default)
+var PyxisValidator interfaces.Validator = newBundleValidator("pyxis", checkPyxis)
+
+// checkPyxis will verify the bundle against the Red Hat Catalog
+func checkPyxis(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	packageName := getPackageName(checks)
+	if len(packageName) == 0 {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("unable to check the package in the Red Hat "+
+			"Catalog because its name was not found"), "inform the annotations via the optional key %s", FilePathKey))
+	} else {
+		checks = checkPyxisPackage(checks, packageName)
+	}
+
+	if len(checks.pyxisProject) > 0 {
+		checks = checkPyxisProject(checks)
+	}
+	return checks
+}
+
+// checkPyxisPackage will verify that the package is owned by the project informed and that the bundle
+// was not already published
+func checkPyxisPackage(checks OpenShiftOperatorChecks, packageName string) OpenShiftOperatorChecks {
+	pkg, err := pyxisInspector.GetPackage(packageName)
+	if err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to check the package %s in the Red Hat Catalog: %s",
+			packageName, err))
+		return checks
+	}
+	if pkg == nil {
+		// The package will be created with the first bundle submitted
+		return checks
+	}
+	if len(checks.pyxisProject) > 0 && len(pkg.Association) > 0 && pkg.Association != checks.pyxisProject {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the package %s is owned by the certification "+
+			"project %s in the Red Hat Catalog", packageName, pkg.Association),
+			"ensure that the package name is not used by another operator"))
+	}
+
+	bundle, err := pyxisInspector.GetBundle(packageName, checks.bundle.CSV.GetName())
+	if err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to check the bundle %s in the Red Hat Catalog: %s",
+			checks.bundle.CSV.GetName(), err))
+		return checks
+	}
+	if bundle != nil {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the bundle %s was already published in the "+
+			"package %s of the Red Hat Catalog", checks.bundle.CSV.GetName(), packageName),
+			"bump the version of the bundle"))
+	}
+	return checks
+}
+
+// checkPyxisProject will verify the certification project informed and the OCP versions of the bundle
+// against the catalog where it will be published
+func checkPyxisProject(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	project, err := pyxisInspector.GetProject(checks.pyxisProject)
+	if err != nil {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+	if project.ProjectStatus != pyxisProjectStatusActive {
+		checks.errs = append(checks.errs, fmt.Errorf("the certification project %s has the status %q. "+
+			"Note that only the %s projects accept submissions", checks.pyxisProject, project.ProjectStatus,
+			pyxisProjectStatusActive))
+	}
+	if project.Container.Type != pyxisProjectTypeBundle {
+		checks.errs = append(checks.errs, fmt.Errorf("the certification project %s has the type %q. "+
+			"Note that the bundles can only be submitted to the %s projects", checks.pyxisProject,
+			project.Container.Type, pyxisProjectTypeBundle))
+	}
+
+	r := getOCPRange(checks)
+	if len(r) == 0 {
+		return checks
+	}
+	organization, found := pyxisOrganizations[checks.profile]
+	if !found {
+		organization = pyxisOrganizations[CertifiedProfile]
+	}
+	indices, err := pyxisInspector.GetIndices(organization)
+	if err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to get the OpenShift versions supported by the "+
+			"catalog %s: %s", organization, err))
+		return checks
+	}
+	var supported []string
+	for _, index := range indices {
+		// The indices which reached their end of life no longer receive bundles
+		if len(index.EndOfLife) > 0 {
+			continue
+		}
+		if found, err := rangeContainsVersion(r, index.OCPVersion, true); err == nil && found {
+			return checks
+		}
+		supported = append(supported, index.OCPVersion)
+	}
+	checks.errs = append(checks.errs, fmt.Errorf("the OCP versions informed via the label %s (%s) do not include "+
+		"any OpenShift version supported by the catalog %s (%s). Note that the bundle would not be published",
+		ocpLabel, r, organization, strings.Join(supported, ", ")))
+	return checks
+}
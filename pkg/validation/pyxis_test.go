@@ -0,0 +1,183 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/pyxis"
+)
+
+// fakePyxisInspector implements pyxis.Inspector with the packages, bundles (package/csv name),
+// projects and indices informed
+type fakePyxisInspector struct {
+	packages map[string]pyxis.Package
+	bundles  map[string]bool
+	projects map[string]pyxis.Project
+	indices  []pyxis.Index
+}
+
+func (f fakePyxisInspector) GetPackage(name string) (*pyxis.Package, error) {
+	if pkg, ok := f.packages[name]; ok {
+		return &pkg, nil
+	}
+	return nil, nil
+}
+
+func (f fakePyxisInspector) GetBundle(packageName, csvName string) (*pyxis.Bundle, error) {
+	if f.bundles[packageName+"/"+csvName] {
+		return &pyxis.Bundle{Package: packageName, CSVName: csvName}, nil
+	}
+	return nil, nil
+}
+
+func (f fakePyxisInspector) GetProject(id string) (*pyxis.Project, error) {
+	if project, ok := f.projects[id]; ok {
+		return &project, nil
+	}
+	return nil, fmt.Errorf("unable to get the certification project %s: not found", id)
+}
+
+func (f fakePyxisInspector) GetIndices(organization string) ([]pyxis.Index, error) {
+	return f.indices, nil
+}
+
+func Test_PyxisValidator(t *testing.T) {
+	defaultInspector := pyxisInspector
+	defer func() { pyxisInspector = defaultInspector }()
+
+	activeProject := pyxis.Project{ID: "ospid-1", ProjectStatus: "active"}
+	activeProject.Container.Type = "operator bundle"
+	archivedProject := pyxis.Project{ID: "ospid-2", ProjectStatus: "archived"}
+	archivedProject.Container.Type = "container"
+	pyxisInspector = fakePyxisInspector{
+		packages: map[string]pyxis.Package{
+			"memcached-operator": {PackageName: "memcached-operator", Association: "ospid-1"},
+		},
+		bundles:  map[string]bool{"memcached-operator/memcached-operator.v0.0.1": true},
+		projects: map[string]pyxis.Project{"ospid-1": activeProject, "ospid-2": archivedProject},
+		indices: []pyxis.Index{
+			{OCPVersion: "4.8", EndOfLife: "2023-01-27T00:00:00+00:00"},
+			{OCPVersion: "4.12"},
+			{OCPVersion: "4.13"},
+		},
+	}
+
+	type args struct {
+		packageName string
+		project     string
+		labelRange  string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the package does not exist yet",
+			args: args{
+				packageName: "new-operator",
+				project:     "ospid-1",
+				labelRange:  "v4.12",
+			},
+		},
+		{
+			name:      "should fail when the bundle was already published",
+			wantError: true,
+			args: args{
+				packageName: "memcached-operator",
+				project:     "ospid-1",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the bundle memcached-operator.v0.0.1 " +
+				"was already published in the package memcached-operator of the Red Hat Catalog. Please, bump the " +
+				"version of the bundle"},
+		},
+		{
+			name:      "should fail when the project is not active or not an operator bundle project",
+			wantError: true,
+			args: args{
+				packageName: "new-operator",
+				project:     "ospid-2",
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the certification project ospid-2 has the status " +
+					"\"archived\". Note that only the active projects accept submissions",
+				"Error: Value : (memcached-operator.v0.0.1) the certification project ospid-2 has the type " +
+					"\"container\". Note that the bundles can only be submitted to the operator bundle projects",
+			},
+		},
+		{
+			name:      "should fail when the package is owned by another project",
+			wantError: true,
+			args: args{
+				packageName: "memcached-operator",
+				project:     "ospid-2",
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the package memcached-operator is owned by the " +
+					"certification project ospid-1 in the Red Hat Catalog. Please, ensure that the package name " +
+					"is not used by another operator",
+				"Error: Value : (memcached-operator.v0.0.1) the bundle memcached-operator.v0.0.1 " +
+					"was already published in the package memcached-operator of the Red Hat Catalog. Please, bump the " +
+					"version of the bundle",
+				"Error: Value : (memcached-operator.v0.0.1) the certification project ospid-2 has the status " +
+					"\"archived\". Note that only the active projects accept submissions",
+				"Error: Value : (memcached-operator.v0.0.1) the certification project ospid-2 has the type " +
+					"\"container\". Note that the bundles can only be submitted to the operator bundle projects",
+			},
+		},
+		{
+			name:      "should fail when the range does not include any supported OpenShift version",
+			wantError: true,
+			args: args{
+				packageName: "new-operator",
+				project:     "ospid-1",
+				labelRange:  "v4.6-v4.8",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the OCP versions informed via the label " +
+				"com.redhat.openshift.versions (v4.6-v4.8) do not include any OpenShift version supported by the " +
+				"catalog certified-operators (4.12, 4.13). Note that the bundle would not be published"},
+		},
+		{
+			name:        "should warn when the package name is not found",
+			wantWarning: true,
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) unable to check the package in the " +
+				"Red Hat Catalog because its name was not found. Please, inform the annotations via the optional " +
+				"key file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			bundle.Package = tt.args.packageName
+
+			results := PyxisValidator.Validate(bundle, map[string]string{
+				PyxisProjectKey: tt.args.project,
+				RangeKey:        tt.args.labelRange,
+			})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// RBACValidator validates the permissions requested by the operator in the CSV against the least-privilege
+// principle. The findings are reported as warnings and as errors for the strict profiles (certified, redhat
+// and marketplace). Following its current checks:
+//
+// - Ensure that the rules under spec.install.spec.permissions and clusterPermissions do not use
+// wildcards (*) for apiGroups, resources or verbs
+//
+// - Ensure that the rules do not grant permissions equivalent to cluster-admin (all verbs on all resources
+// of all apiGroups)
+//
+// - Ensure that the rules under spec.install.spec.clusterPermissions do not grant read access to the secrets
+// of all namespaces
+var RBACValidator interfaces.Validator = newBundleValidator("rbac", checkLeastPrivilege)
+
+// checkLeastPrivilege will look for rules which grant more permissions than required
+func checkLeastPrivilege(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	strategy := checks.bundle.CSV.Spec.InstallStrategy.StrategySpec
+	checks = checkPermissionsRules(checks, "permissions", strategy.Permissions)
+	checks = checkPermissionsRules(checks, "clusterPermissions", strategy.ClusterPermissions)
+
+	for _, perm := range strategy.ClusterPermissions {
+		for _, rule := range perm.Rules {
+			if containsAny(rule.APIGroups, "", rbacv1.APIGroupAll) &&
+				containsAny(rule.Resources, "secrets", rbacv1.ResourceAll) &&
+				containsAny(rule.Verbs, "get", "list", "watch", rbacv1.VerbAll) {
+				checks = addStrictFinding(checks, withSubject(withRemediation(fmt.Errorf("the service account %s is "+
+					"allowed to read the secrets of all namespaces via spec.install.spec.clusterPermissions in the CSV",
+					perm.ServiceAccountName),
+					"consider requesting this permission under spec.install.spec.permissions instead"),
+					"ServiceAccount", perm.ServiceAccountName))
+				break
+			}
+		}
+	}
+	return checks
+}
+
+// checkPermissionsRules will look for wildcards in the rules of the permissions informed
+func checkPermissionsRules(checks OpenShiftOperatorChecks, field string,
+	permissions []operatorsv1alpha1.StrategyDeploymentPermissions) OpenShiftOperatorChecks {
+	for _, perm := range permissions {
+		for i, rule := range perm.Rules {
+			if len(rule.NonResourceURLs) > 0 && len(rule.Resources) == 0 {
+				continue
+			}
+			allGroups := containsAny(rule.APIGroups, rbacv1.APIGroupAll)
+			allResources := containsAny(rule.Resources, rbacv1.ResourceAll)
+			allVerbs := containsAny(rule.Verbs, rbacv1.VerbAll)
+			if allGroups && allResources && allVerbs {
+				checks = addStrictFinding(checks, withSubject(withRemediation(fmt.Errorf("the rule [%d] of the "+
+					"service account %s under spec.install.spec.%s in the CSV grants all verbs on all resources which "+
+					"is equivalent to cluster-admin", i, perm.ServiceAccountName, field),
+					"request only the permissions required by the operator"),
+					"ServiceAccount", perm.ServiceAccountName))
+				continue
+			}
+
+			var wildcards []string
+			if allGroups {
+				wildcards = append(wildcards, "apiGroups")
+			}
+			if allResources {
+				wildcards = append(wildcards, "resources")
+			}
+			if allVerbs {
+				wildcards = append(wildcards, "verbs")
+			}
+			if len(wildcards) > 0 {
+				checks = addStrictFinding(checks, withSubject(withRemediation(fmt.Errorf("the rule [%d] of the "+
+					"service account %s under spec.install.spec.%s in the CSV uses wildcards (*) for %s", i,
+					perm.ServiceAccountName, field, strings.Join(wildcards, ", ")),
+					"request only the permissions required by the operator"),
+					"ServiceAccount", perm.ServiceAccountName))
+			}
+		}
+	}
+	return checks
+}
+
+// hasPermission returns true when the permissions informed allow any of the verbs on the resource of
+// the apiGroup informed
+func hasPermission(permissions []operatorsv1alpha1.StrategyDeploymentPermissions, apiGroup, resource string,
+	verbs ...string) bool {
+	for _, perm := range permissions {
+		for _, rule := range perm.Rules {
+			if containsAny(rule.APIGroups, apiGroup, rbacv1.APIGroupAll) &&
+				containsAny(rule.Resources, resource, rbacv1.ResourceAll) &&
+				containsAny(rule.Verbs, append(append([]string{}, verbs...), rbacv1.VerbAll)...) {
+				return true
+			}
+		}
+	}
+	return false
+}
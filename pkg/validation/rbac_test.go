@@ -0,0 +1,132 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func Test_RBACValidator(t *testing.T) {
+	const clusterAdminMsg = "Value : (memcached-operator.v0.0.1) the rule [0] of the service account " +
+		"memcached-operator-controller-manager under spec.install.spec.permissions in the CSV grants all verbs " +
+		"on all resources which is equivalent to cluster-admin. Please, request only the permissions required " +
+		"by the operator"
+
+	type args struct {
+		bundleDir string
+		profile   string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the permissions follow the least-privilege principle",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name:        "should warn when the permissions are equivalent to cluster-admin",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate:    grantClusterAdmin,
+			},
+			warnStrings: []string{"Warning: " + clusterAdminMsg},
+		},
+		{
+			name:      "should fail when the permissions are equivalent to cluster-admin with a strict profile",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   MarketplaceProfile,
+				mutate:    grantClusterAdmin,
+			},
+			errStrings: []string{"Error: " + clusterAdminMsg},
+		},
+		{
+			name:        "should warn when the cluster permissions use wildcards and read all secrets",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					perms := &bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions[0]
+					perms.Rules = append(perms.Rules, rbacv1.PolicyRule{
+						APIGroups: []string{""},
+						Resources: []string{"*"},
+						Verbs:     []string{"get", "list"},
+					})
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the rule [7] of the service account " +
+					"memcached-operator-controller-manager under spec.install.spec.clusterPermissions in the CSV " +
+					"uses wildcards (*) for resources. Please, request only the permissions required by the operator",
+				"Warning: Value : (memcached-operator.v0.0.1) the service account memcached-operator-controller-manager " +
+					"is allowed to read the secrets of all namespaces via spec.install.spec.clusterPermissions in the " +
+					"CSV. Please, consider requesting this permission under spec.install.spec.permissions instead",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := RBACValidator.Validate(bundle, map[string]string{ProfileKey: tt.args.profile})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// grantClusterAdmin grants all verbs on all resources to the service account of the memcached bundle
+func grantClusterAdmin(bundle *manifests.Bundle) {
+	perms := &bundle.CSV.Spec.InstallStrategy.StrategySpec.Permissions[0]
+	perms.Rules = []rbacv1.PolicyRule{{
+		APIGroups: []string{"*"},
+		Resources: []string{"*"},
+		Verbs:     []string{"*"},
+	}}
+}
+
+func Test_hasPermission(t *testing.T) {
+	permissions := []operatorsv1alpha1.StrategyDeploymentPermissions{{
+		Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"*"}}},
+	}}
+	require.True(t, hasPermission(permissions, "", "secrets", "get"))
+	require.False(t, hasPermission(permissions, "", "configmaps", "get"))
+
+	// The verbs informed are not modified
+	verbs := make([]string, 1, 2)
+	verbs[0] = "list"
+	require.True(t, hasPermission(permissions, "", "secrets", verbs...))
+	require.Equal(t, []string{"list", ""}, verbs[:2])
+}
@@ -0,0 +1,197 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/operator-framework/api/pkg/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// bundleFormatMinOCPVersion defines the lowest OCP version whose catalogs distribute the bundle format. It is
+// recommended when the bundle does not require an upper version.
+const bundleFormatMinOCPVersion = "4.6"
+
+// k8sToOCPMinorOffset defines the offset between the minor versions of Kubernetes and OCP (e.g. 1.22 is 4.9)
+const k8sToOCPMinorOffset = 13
+
+// removedK8sAPIsPrefix defines the prefix of the APIs informed by the warnings of the removed APIs
+const removedK8sAPIsPrefix = "Migrate the API(s) for "
+
+// removedK8sAPIsRegexp matches the Kubernetes version informed by the warnings of the removed APIs
+var removedK8sAPIsRegexp = regexp.MustCompile(`removed in v1\.([0-9]+)`)
+
+// Recommendation defines the OCP versions recommended for the bundle based on the APIs that it uses
+type Recommendation struct {
+	// Range is the value recommended for the com.redhat.openshift.versions label. Note that it is empty when
+	// no OCP version can be recommended until the APIs used are migrated.
+	Range string
+	// MaxOpenShiftVersion is the value recommended for the olm.maxOpenShiftVersion annotation. Note that it is
+	// empty when the annotation should not be set.
+	MaxOpenShiftVersion string
+	// CurrentRange and CurrentMaxOpenShiftVersion are the values currently informed (if any)
+	CurrentRange               string
+	CurrentMaxOpenShiftVersion string
+	// Reasons explains the versions recommended
+	Reasons []string
+}
+
+// GetRecommendation returns the com.redhat.openshift.versions and olm.maxOpenShiftVersion values recommended
+// for the bundle informed. The lowest version is the highest of the versions required by the spec.minKubeVersion,
+// the APIs used, the ConsolePlugins shipped and the range currently informed via the range or file optional values. The
+// highest version is the last one which serves the Kubernetes and OpenShift APIs used by the bundle. Note that
+// nothing is written.
+func GetRecommendation(bundle *manifests.Bundle, optionalValues map[string]string) Recommendation {
+	rec := Recommendation{}
+	rec.CurrentRange, rec.CurrentMaxOpenShiftVersion = GetTargetOCPVersions(bundle, optionalValues)
+
+	// Only the reasons of the lowest and highest versions recommended are reported
+	min, _ := semver.ParseTolerant(bundleFormatMinOCPVersion)
+	minReason := fmt.Sprintf("the catalogs distribute the bundle format from OCP %s", bundleFormatMinOCPVersion)
+	raise := func(version, reason string) {
+		if v, err := semver.ParseTolerant(version); err == nil && v.GT(min) {
+			min, minReason = semver.Version{Major: v.Major, Minor: v.Minor}, reason
+		}
+	}
+	if versions := getRangeVersions(rec.CurrentRange); len(versions) > 0 {
+		raise(versions[0], fmt.Sprintf("the lowest OCP version currently informed via the %s label is %s",
+			ocpLabel, versions[0]))
+	}
+	if kube, err := semver.ParseTolerant(bundle.CSV.Spec.MinKubeVersion); err == nil && kube.Major == 1 {
+		version := fmt.Sprintf("4.%d", int(kube.Minor)-k8sToOCPMinorOffset)
+		raise(version, fmt.Sprintf("the spec.minKubeVersion (%s) requires OCP %s", bundle.CSV.Spec.MinKubeVersion,
+			version))
+	}
+	if minor, apis := getRequiredK8sMinor(bundle); minor > 0 {
+		version := fmt.Sprintf("4.%d", minor-k8sToOCPMinorOffset)
+		raise(version, fmt.Sprintf("the APIs used are only available from OCP %s: %s", version,
+			strings.Join(apis, ", ")))
+	}
+	for _, obj := range bundle.Objects {
+		if obj == nil || obj.GetKind() != consolePluginKind {
+			continue
+		}
+		version := consolePluginMinOCPVersion
+		if obj.GetAPIVersion() == "console.openshift.io/v1" {
+			version = consolePluginV1MinOCPVersion
+		}
+		raise(version, fmt.Sprintf("the ConsolePlugin %s (%s) is only available from OCP %s", obj.GetName(),
+			obj.GetAPIVersion(), version))
+	}
+
+	var max *semver.Version
+	var maxReason string
+	lower := func(removedIn, reason string) {
+		v, err := semver.ParseTolerant(removedIn)
+		if err != nil || v.Minor == 0 {
+			return
+		}
+		v = semver.Version{Major: v.Major, Minor: v.Minor - 1}
+		if max == nil || v.LT(*max) {
+			max, maxReason = &v, reason
+		}
+	}
+	for _, removal := range getRemovedK8sAPIs(bundle) {
+		lower(removal[0], fmt.Sprintf("the Kubernetes APIs removed in OCP %s are used: %s", removal[0],
+			removal[1]))
+	}
+	for _, removal := range getRemovedOpenShiftAPIs(bundle) {
+		lower(removal[0], fmt.Sprintf("the OpenShift API %s removed in OCP %s is used", removal[1], removal[0]))
+	}
+
+	rec.Reasons = append(rec.Reasons, minReason)
+	switch {
+	case max == nil:
+		rec.Range = fmt.Sprintf("v%d.%d", min.Major, min.Minor)
+		rec.Reasons = append(rec.Reasons, fmt.Sprintf("no API used is removed in the later OCP versions. Note "+
+			"that the %s annotation is not required", olmmaxOcpVersion))
+	case max.LT(min):
+		rec.Reasons = append(rec.Reasons, maxReason, fmt.Sprintf("the APIs used are removed before OCP %d.%d, "+
+			"which is the lowest version required. Please, migrate them", min.Major, min.Minor))
+	default:
+		rec.Reasons = append(rec.Reasons, maxReason)
+		rec.Range = fmt.Sprintf("v%d.%d-v%d.%d", min.Major, min.Minor, max.Major, max.Minor)
+		if min.EQ(*max) {
+			rec.Range = fmt.Sprintf("=v%d.%d", min.Major, min.Minor)
+		}
+		rec.MaxOpenShiftVersion = fmt.Sprintf("%d.%d", max.Major, max.Minor)
+	}
+	return rec
+}
+
+// GetTargetOCPVersions returns the com.redhat.openshift.versions range where the bundle informed is distributed,
+// which is read from the range or file optional values, and the olm.maxOpenShiftVersion of its CSV. Note that
+// empty values are returned when they are not informed.
+func GetTargetOCPVersions(bundle *manifests.Bundle, optionalValues map[string]string) (string, string) {
+	checks := getMaxAnnotationValue(OpenShiftOperatorChecks{bundle: *bundle,
+		filePath: optionalValues[FilePathKey], labelRange: optionalValues[RangeKey]})
+	return getOCPRange(checks), checks.maxValue
+}
+
+// getRemovedK8sAPIs returns the OCP versions where the Kubernetes APIs used by the bundle are removed
+// with the description of the APIs
+func getRemovedK8sAPIs(bundle *manifests.Bundle) [][2]string {
+	objs := bundle.ObjectsToValidate()
+	for _, obj := range bundle.Objects {
+		objs = append(objs, obj)
+	}
+	var removals [][2]string
+	for _, result := range validation.AlphaDeprecatedAPIsValidator.Validate(objs...) {
+		for _, warn := range append(result.Warnings, result.Errors...) {
+			match := removedK8sAPIsRegexp.FindStringSubmatch(warn.Detail)
+			if match == nil {
+				continue
+			}
+			minor, _ := strconv.Atoi(match[1])
+			apis := warn.Detail
+			if i := strings.Index(apis, removedK8sAPIsPrefix); i >= 0 {
+				apis = strings.TrimSuffix(apis[i+len(removedK8sAPIsPrefix):], ",")
+			}
+			removals = append(removals, [2]string{fmt.Sprintf("4.%d", minor-k8sToOCPMinorOffset), apis})
+		}
+	}
+	return removals
+}
+
+// getRemovedOpenShiftAPIs returns the OCP versions where the OpenShift APIs used by the bundle are removed
+// with the APIs
+func getRemovedOpenShiftAPIs(bundle *manifests.Bundle) [][2]string {
+	var removals [][2]string
+	for _, obj := range bundle.Objects {
+		if obj == nil {
+			continue
+		}
+		if api, found := getOpenShiftDeprecatedAPI(obj); found && len(api.removedIn) > 0 {
+			removals = append(removals, [2]string{api.removedIn, fmt.Sprintf("%s %s", api.apiVersion, api.kind)})
+		}
+	}
+	return removals
+}
+
+// getOpenShiftDeprecatedAPI returns the deprecated OpenShift API used by the object informed (if any)
+func getOpenShiftDeprecatedAPI(obj *unstructured.Unstructured) (openShiftDeprecatedAPI, bool) {
+	for _, api := range openShiftDeprecatedAPIs {
+		if api.apiVersion == obj.GetAPIVersion() && api.kind == obj.GetKind() {
+			return api, true
+		}
+	}
+	return openShiftDeprecatedAPI{}, false
+}
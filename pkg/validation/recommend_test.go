@@ -0,0 +1,130 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetRecommendation(t *testing.T) {
+	newObject := func(apiVersion, kind, name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetName(name)
+		return obj
+	}
+
+	type args struct {
+		bundleDir      string
+		minKubeVersion string
+		objects        []*unstructured.Unstructured
+		optionalValues map[string]string
+	}
+	tests := []struct {
+		name string
+		args args
+		want Recommendation
+	}{
+		{
+			name: "should recommend all versions when no API used is removed",
+			args: args{bundleDir: "./testdata/valid_bundle_v1"},
+			want: Recommendation{Range: "v4.6", Reasons: []string{
+				"the catalogs distribute the bundle format from OCP 4.6",
+				"no API used is removed in the later OCP versions. Note that the olm.maxOpenShiftVersion " +
+					"annotation is not required"}},
+		},
+		{
+			name: "should recommend the versions before the removal of the Kubernetes APIs used",
+			args: args{bundleDir: "./testdata/valid_bundle_v1beta1"},
+			want: Recommendation{Range: "v4.6-v4.8", MaxOpenShiftVersion: "4.8", Reasons: []string{
+				"the catalogs distribute the bundle format from OCP 4.6",
+				"the Kubernetes APIs removed in OCP 4.9 are used: CRD: ([\"etcdbackups.etcd.database.coreos.com\" " +
+					"\"etcdclusters.etcd.database.coreos.com\" \"etcdrestores.etcd.database.coreos.com\"])"}},
+		},
+		{
+			name: "should recommend a single version when the lowest version informed is the highest one",
+			args: args{bundleDir: "./testdata/valid_bundle_v1beta1",
+				optionalValues: map[string]string{RangeKey: "v4.8"}},
+			want: Recommendation{Range: "=v4.8", MaxOpenShiftVersion: "4.8", CurrentRange: "v4.8", Reasons: []string{
+				"the lowest OCP version currently informed via the com.redhat.openshift.versions label is v4.8",
+				"the Kubernetes APIs removed in OCP 4.9 are used: CRD: ([\"etcdbackups.etcd.database.coreos.com\" " +
+					"\"etcdclusters.etcd.database.coreos.com\" \"etcdrestores.etcd.database.coreos.com\"])"}},
+		},
+		{
+			name: "should not recommend versions when the APIs are removed before the lowest version required",
+			args: args{bundleDir: "./testdata/valid_bundle_v1beta1", minKubeVersion: "1.23.0"},
+			want: Recommendation{Reasons: []string{
+				"the spec.minKubeVersion (1.23.0) requires OCP 4.10",
+				"the Kubernetes APIs removed in OCP 4.9 are used: CRD: ([\"etcdbackups.etcd.database.coreos.com\" " +
+					"\"etcdclusters.etcd.database.coreos.com\" \"etcdrestores.etcd.database.coreos.com\"])",
+				"the APIs used are removed before OCP 4.10, which is the lowest version required. Please, " +
+					"migrate them"}},
+		},
+		{
+			name: "should recommend the versions which serve the Kubernetes APIs used",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				objects:   []*unstructured.Unstructured{newObject("policy/v1", "PodDisruptionBudget", "memcached")},
+			},
+			want: Recommendation{Range: "v4.8", Reasons: []string{
+				"the APIs used are only available from OCP 4.8: policy/v1 PodDisruptionBudget",
+				"no API used is removed in the later OCP versions. Note that the olm.maxOpenShiftVersion " +
+					"annotation is not required"}},
+		},
+		{
+			name: "should recommend the versions which serve the ConsolePlugin and the OpenShift APIs used",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				objects: []*unstructured.Unstructured{
+					newObject("console.openshift.io/v1", "ConsolePlugin", "memcached-plugin"),
+					newObject("network.openshift.io/v1", "EgressNetworkPolicy", "default"),
+				},
+				optionalValues: map[string]string{RangeKey: "v4.10"},
+			},
+			want: Recommendation{Range: "v4.12-v4.16", MaxOpenShiftVersion: "4.16", CurrentRange: "v4.10",
+				Reasons: []string{
+					"the ConsolePlugin memcached-plugin (console.openshift.io/v1) is only available from OCP 4.12",
+					"the OpenShift API network.openshift.io/v1 EgressNetworkPolicy removed in OCP 4.17 is used"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			bundle.CSV.Spec.MinKubeVersion = tt.args.minKubeVersion
+			bundle.Objects = append(bundle.Objects, tt.args.objects...)
+			require.Equal(t, tt.want, GetRecommendation(bundle, tt.args.optionalValues))
+		})
+	}
+}
+
+func TestGetTargetOCPVersions(t *testing.T) {
+	bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+	require.NoError(t, err)
+	ocpRange, maxValue := GetTargetOCPVersions(bundle, map[string]string{RangeKey: "v4.8-v4.10"})
+	require.Equal(t, "v4.8-v4.10", ocpRange)
+	require.Empty(t, maxValue)
+
+	bundle.CSV.Annotations[olmproperties] = `[{"type": "olm.maxOpenShiftVersion", "value": "4.10"}]`
+	ocpRange, maxValue = GetTargetOCPVersions(bundle, nil)
+	require.Empty(t, ocpRange)
+	require.Equal(t, "4.10", maxValue)
+}
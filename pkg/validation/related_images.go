@@ -0,0 +1,162 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// RelatedImagesValidator validates the images informed under spec.relatedImages in the CSV.
+// Following its current checks:
+//
+// - Ensure that when the CSV claims to support disconnected environments via the annotations
+// operators.openshift.io/infrastructure-features or features.operators.openshift.io/disconnected
+// the spec.relatedImages is informed and all of its images are pinned by digest
+//
+// - Ensure that the images used by the containers, init containers and environment variables of the
+// deployments are informed in spec.relatedImages. Note that the images missing are not mirrored. It is
+// a warning unless the CSV claims to support disconnected environments.
+//
+// - Ensure that the RELATED_IMAGE_* environment variables of the deployments are valid image references
+// pinned by digest and informed in spec.relatedImages. It is a warning unless the CSV claims to support
+// disconnected environments.
+var RelatedImagesValidator interfaces.Validator = newBundleValidator("related-images", checkRelatedImagesDigests,
+	checkRelatedImagesCompleteness, checkRelatedImageEnvs)
+
+// relatedImageEnvPrefix defines the prefix of the environment variables used to inform the operand images
+const relatedImageEnvPrefix = "RELATED_IMAGE_"
+
+// checkRelatedImagesDigests will verify that the related images are pinned by digest for disconnected support
+func checkRelatedImagesDigests(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	csv := checks.bundle.CSV
+	if !supportsDisconnected(csv) {
+		return checks
+	}
+
+	if len(csv.Spec.RelatedImages) == 0 {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the CSV claims to support disconnected "+
+			"environments but spec.relatedImages is not informed"),
+			"inform all images used by the operator and its operands pinned by digest"))
+		return checks
+	}
+
+	for _, related := range csv.Spec.RelatedImages {
+		if !isDigestReference(related.Image) {
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the related image %s (%s) is not pinned by "+
+				"digest. Note that the images referenced by tag cannot be mirrored for disconnected environments",
+				related.Name, related.Image), "use the digest (e.g. image@sha256:...)"))
+		}
+	}
+	return checks
+}
+
+// checkRelatedImagesCompleteness will verify that the images used by the deployments are related images
+func checkRelatedImagesCompleteness(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	csv := checks.bundle.CSV
+	if len(csv.Spec.RelatedImages) == 0 {
+		return checks
+	}
+	disconnected := supportsDisconnected(csv)
+
+	related := map[string]bool{}
+	for _, r := range csv.Spec.RelatedImages {
+		related[r.Image] = true
+	}
+
+	reported := map[string]bool{}
+	for _, dep := range csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			images := []string{c.Image}
+			for _, env := range c.Env {
+				// the RELATED_IMAGE_* environment variables are checked by checkRelatedImageEnvs
+				if !strings.HasPrefix(env.Name, relatedImageEnvPrefix) && isDigestReference(env.Value) {
+					images = append(images, env.Value)
+				}
+			}
+			for _, image := range images {
+				if len(image) == 0 || related[image] || reported[image] {
+					continue
+				}
+				reported[image] = true
+				checks = addDisconnectedFinding(checks, disconnected, withSubject(fmt.Errorf("the image %s used by "+
+					"the container %s of the deployment %s is not informed under spec.relatedImages in the CSV. Note "+
+					"that it will not be mirrored for disconnected environments", image, c.Name, dep.Name),
+					"Deployment", dep.Name))
+			}
+		}
+	}
+	return checks
+}
+
+// checkRelatedImageEnvs will verify the images informed via the RELATED_IMAGE_* environment variables
+func checkRelatedImageEnvs(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	csv := checks.bundle.CSV
+	disconnected := supportsDisconnected(csv)
+
+	related := map[string]bool{}
+	for _, r := range csv.Spec.RelatedImages {
+		related[r.Image] = true
+	}
+
+	for _, dep := range csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			for _, env := range c.Env {
+				if !strings.HasPrefix(env.Name, relatedImageEnvPrefix) || env.ValueFrom != nil {
+					continue
+				}
+				ref, err := reference.ParseNormalizedNamed(env.Value)
+				if err != nil {
+					checks = addDisconnectedFinding(checks, disconnected, withSubject(fmt.Errorf("the environment "+
+						"variable %s of the container %s of the deployment %s has an invalid image reference (%s): %s",
+						env.Name, c.Name, dep.Name, env.Value, err), "Deployment", dep.Name))
+					continue
+				}
+				if _, ok := ref.(reference.Digested); !ok {
+					checks = addDisconnectedFinding(checks, disconnected, withSubject(withRemediation(fmt.Errorf("the "+
+						"image %s informed via the environment variable %s of the deployment %s is not pinned by "+
+						"digest", env.Value, env.Name, dep.Name), "use the digest (e.g. image@sha256:...)"),
+						"Deployment", dep.Name))
+				}
+				if !related[env.Value] {
+					checks = addDisconnectedFinding(checks, disconnected, withSubject(fmt.Errorf("the image %s "+
+						"informed via the environment variable %s of the deployment %s is not informed under "+
+						"spec.relatedImages in the CSV. Note that it will not be mirrored for disconnected "+
+						"environments", env.Value, env.Name, dep.Name), "Deployment", dep.Name))
+				}
+			}
+		}
+	}
+	return checks
+}
+
+// addDisconnectedFinding will add the error informed as an error when the CSV claims to support
+// disconnected environments and as a warning otherwise
+func addDisconnectedFinding(checks OpenShiftOperatorChecks, disconnected bool, err error) OpenShiftOperatorChecks {
+	if disconnected {
+		checks.errs = append(checks.errs, err)
+		return checks
+	}
+	checks.warns = append(checks.warns, err)
+	return checks
+}
+
+// isDigestReference returns true when the image informed is referenced by digest
+func isDigestReference(image string) bool {
+	return strings.Contains(image, "@sha256:")
+}
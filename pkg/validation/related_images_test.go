@@ -0,0 +1,199 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// testDigest is a valid digest used to pin the images in the tests
+const testDigest = "@sha256:2a5e3b0b0ed36b2eb2a4d5e7b6c0f6c3f5d0f8c1f0e1e0a1a2a3a4a5a6a7a8a9"
+
+func Test_RelatedImagesValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the CSV does not claim to support disconnected environments",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the related images are pinned by digest",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[disconnectedFeatureAnnotation] = "true"
+					setRelatedImagesByDigest(bundle)
+				},
+			},
+		},
+		{
+			name:      "should fail when the related images are not informed",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[infrastructureFeaturesAnnotation] = `["Disconnected", "proxy-aware"]`
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the CSV claims to support disconnected " +
+				"environments but spec.relatedImages is not informed. Please, inform all images used by the " +
+				"operator and its operands pinned by digest"},
+		},
+		{
+			name:      "should fail when the related images are referenced by tag",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[disconnectedFeatureAnnotation] = "true"
+					bundle.CSV.Spec.RelatedImages = []operatorsv1alpha1.RelatedImage{
+						{Name: "kube-rbac-proxy", Image: "gcr.io/kubebuilder/kube-rbac-proxy:v0.8.0"},
+						{Name: "manager", Image: "quay.io/example/memcached-operator:v0.0.1"},
+					}
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the related image kube-rbac-proxy " +
+					"(gcr.io/kubebuilder/kube-rbac-proxy:v0.8.0) is not pinned by digest. Note that the images " +
+					"referenced by tag cannot be mirrored for disconnected environments. Please, use the digest " +
+					"(e.g. image@sha256:...)",
+				"Error: Value : (memcached-operator.v0.0.1) the related image manager " +
+					"(quay.io/example/memcached-operator:v0.0.1) is not pinned by digest. Note that the images " +
+					"referenced by tag cannot be mirrored for disconnected environments. Please, use the digest " +
+					"(e.g. image@sha256:...)",
+			},
+		},
+		{
+			name:        "should warn when the images used by the deployment are not related images",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.RelatedImages = []operatorsv1alpha1.RelatedImage{
+						{Name: "manager", Image: "quay.io/example/memcached-operator:v0.0.1"},
+					}
+					c := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers[1]
+					c.Env = append(c.Env, corev1.EnvVar{Name: "RELATED_IMAGE_MEMCACHED", Value: "docker.io/memcached:1.6"})
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the image gcr.io/kubebuilder/kube-rbac-proxy:v0.8.0 " +
+					"used by the container kube-rbac-proxy of the deployment memcached-operator-controller-manager is " +
+					"not informed under spec.relatedImages in the CSV. Note that it will not be mirrored for " +
+					"disconnected environments",
+				"Warning: Value : (memcached-operator.v0.0.1) the image docker.io/memcached:1.6 informed via the " +
+					"environment variable RELATED_IMAGE_MEMCACHED of the deployment memcached-operator-controller-manager " +
+					"is not pinned by digest. Please, use the digest (e.g. image@sha256:...)",
+				"Warning: Value : (memcached-operator.v0.0.1) the image docker.io/memcached:1.6 informed via the " +
+					"environment variable RELATED_IMAGE_MEMCACHED of the deployment memcached-operator-controller-manager " +
+					"is not informed under spec.relatedImages in the CSV. Note that it will not be mirrored for " +
+					"disconnected environments",
+			},
+		},
+		{
+			name:      "should fail when the operand image is not a related image with disconnected support",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[disconnectedFeatureAnnotation] = "true"
+					setRelatedImagesByDigest(bundle)
+					c := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers[1]
+					c.Env = append(c.Env, corev1.EnvVar{Name: "MEMCACHED_IMAGE", Value: "docker.io/memcached" + testDigest})
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the image docker.io/memcached" +
+				testDigest + " used by the container manager of the deployment memcached-operator-controller-manager " +
+				"is not informed under spec.relatedImages in the CSV. Note that it will not be mirrored for " +
+				"disconnected environments"},
+		},
+		{
+			name: "should pass when the RELATED_IMAGE_* environment variables are related images",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[disconnectedFeatureAnnotation] = "true"
+					setRelatedImagesByDigest(bundle)
+					image := "docker.io/library/memcached" + testDigest
+					c := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers[1]
+					c.Env = append(c.Env, corev1.EnvVar{Name: "RELATED_IMAGE_MEMCACHED", Value: image})
+					bundle.CSV.Spec.RelatedImages = append(bundle.CSV.Spec.RelatedImages,
+						operatorsv1alpha1.RelatedImage{Name: "memcached", Image: image})
+				},
+			},
+		},
+		{
+			name:      "should fail when the RELATED_IMAGE_* environment variable is invalid with disconnected support",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations[disconnectedFeatureAnnotation] = "true"
+					setRelatedImagesByDigest(bundle)
+					c := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers[1]
+					c.Env = append(c.Env, corev1.EnvVar{Name: "RELATED_IMAGE_MEMCACHED", Value: "Memcached:latest"})
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the environment variable " +
+				"RELATED_IMAGE_MEMCACHED of the container manager of the deployment " +
+				"memcached-operator-controller-manager has an invalid image reference (Memcached:latest): " +
+				"invalid reference format: repository name must be lowercase"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := RelatedImagesValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// setRelatedImagesByDigest pins the images of the memcached bundle by digest and informs them as related images
+func setRelatedImagesByDigest(bundle *manifests.Bundle) {
+	spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec
+	bundle.CSV.Spec.RelatedImages = nil
+	for i := range spec.Containers {
+		image := strings.Split(spec.Containers[i].Image, ":")[0] + testDigest
+		spec.Containers[i].Image = image
+		bundle.CSV.Spec.RelatedImages = append(bundle.CSV.Spec.RelatedImages,
+			operatorsv1alpha1.RelatedImage{Name: spec.Containers[i].Name, Image: image})
+	}
+}
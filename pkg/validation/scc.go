@@ -0,0 +1,271 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// requiredSCCAnnotation defines the pod annotation used to inform the SCC which must be used to admit the pod
+const requiredSCCAnnotation = "openshift.io/required-scc"
+
+// sccKind defines the kind of the SecurityContextConstraints
+const sccKind = "SecurityContextConstraints"
+
+// sccRequirements defines the privileges required by a pod or allowed by a SecurityContextConstraints
+type sccRequirements struct {
+	privileged   bool
+	hostNetwork  bool
+	hostPID      bool
+	hostPath     bool
+	runAsRoot    bool
+	capabilities bool
+}
+
+// missing returns the requirements informed which are not allowed
+func (allowed sccRequirements) missing(required sccRequirements) []string {
+	var missing []string
+	if required.privileged && !allowed.privileged {
+		missing = append(missing, "privileged containers")
+	}
+	if required.hostNetwork && !allowed.hostNetwork {
+		missing = append(missing, "hostNetwork")
+	}
+	if required.hostPID && !allowed.hostPID {
+		missing = append(missing, "hostPID")
+	}
+	if required.hostPath && !allowed.hostPath {
+		missing = append(missing, "hostPath volumes")
+	}
+	if required.runAsRoot && !allowed.runAsRoot {
+		missing = append(missing, "running as root")
+	}
+	if required.capabilities && !allowed.capabilities {
+		missing = append(missing, "adding capabilities")
+	}
+	return missing
+}
+
+// builtInSCCs defines the SCCs provided by OpenShift ordered from the least to the most privileged
+var builtInSCCs = []struct {
+	name    string
+	allowed sccRequirements
+}{
+	{name: "restricted-v2"},
+	{name: "restricted"},
+	{name: "nonroot-v2"},
+	{name: "nonroot"},
+	{name: "hostnetwork-v2", allowed: sccRequirements{hostNetwork: true}},
+	{name: "hostnetwork", allowed: sccRequirements{hostNetwork: true}},
+	{name: "anyuid", allowed: sccRequirements{runAsRoot: true}},
+	{name: "hostaccess", allowed: sccRequirements{hostNetwork: true, hostPID: true, hostPath: true}},
+	{name: "hostmount-anyuid", allowed: sccRequirements{hostPath: true, runAsRoot: true}},
+	{name: "privileged", allowed: sccRequirements{privileged: true, hostNetwork: true, hostPID: true,
+		hostPath: true, runAsRoot: true, capabilities: true}},
+}
+
+// SCCValidator validates the SecurityContextConstraints (SCC) required by the deployments defined in
+// the CSV. Following its current checks:
+//
+// - Warn when the deployments require a SCC other than restricted-v2 which is not declared via the
+// openshift.io/required-scc annotation in their pod templates or granted to their service accounts
+// under spec.install.spec.clusterPermissions in the CSV
+//
+// - Warn when the SCC declared is not built-in and not shipped in the bundle or when it does not
+// allow the privileges required by the deployment
+var SCCValidator interfaces.Validator = newBundleValidator("scc", checkSCCRequirements)
+
+// checkSCCRequirements will verify that the SCCs required by the deployments are declared
+func checkSCCRequirements(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	shipped := getShippedSCCs(checks)
+	strategy := checks.bundle.CSV.Spec.InstallStrategy.StrategySpec
+	for _, dep := range strategy.DeploymentSpecs {
+		required := getSCCRequirements(dep.Spec.Template.Spec)
+		inferred := inferSCC(required)
+
+		if scc, ok := dep.Spec.Template.Annotations[requiredSCCAnnotation]; ok {
+			allowed, found := getSCC(scc, shipped)
+			if !found {
+				checks.warns = append(checks.warns, withSubject(fmt.Errorf("the deployment %s requires the SCC %s via "+
+					"the annotation %s which is not a built-in SCC and it was not found in the bundle", dep.Name, scc,
+					requiredSCCAnnotation), "Deployment", dep.Name))
+				continue
+			}
+			if missing := allowed.missing(required); len(missing) > 0 {
+				checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the deployment %s "+
+					"requires the SCC %s via the annotation %s but it does not allow %s", dep.Name, scc,
+					requiredSCCAnnotation, strings.Join(missing, ", ")), "consider using the SCC %s", inferred),
+					"Deployment", dep.Name))
+			}
+			continue
+		}
+
+		if inferred == builtInSCCs[0].name {
+			continue
+		}
+
+		sa := dep.Spec.Template.Spec.ServiceAccountName
+		if len(sa) == 0 {
+			sa = defaultServiceAccountName
+		}
+		granted := getGrantedSCCs(strategy.ClusterPermissions, sa)
+		if len(granted) == 0 {
+			checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the deployment %s requires "+
+				"the SCC %s but it is not declared", dep.Name, inferred),
+				"set the annotation %s: %s in its pod template and grant the use of the SCC to the service account %s "+
+					"under spec.install.spec.clusterPermissions in the CSV", requiredSCCAnnotation, inferred, sa),
+				"Deployment", dep.Name))
+			continue
+		}
+
+		covered := false
+		for _, scc := range granted {
+			if scc == "*" {
+				covered = true
+				break
+			}
+			if allowed, found := getSCC(scc, shipped); found && len(allowed.missing(required)) == 0 {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			checks.warns = append(checks.warns, withSubject(fmt.Errorf("the deployment %s requires the SCC %s but the "+
+				"SCCs (%s) granted to the service account %s do not allow the privileges required", dep.Name, inferred,
+				strings.Join(granted, ", "), sa), "Deployment", dep.Name))
+		}
+	}
+	return checks
+}
+
+// getSCCRequirements returns the privileges required by the pod spec informed
+func getSCCRequirements(spec corev1.PodSpec) sccRequirements {
+	required := sccRequirements{hostNetwork: spec.HostNetwork, hostPID: spec.HostPID}
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			required.hostPath = true
+		}
+	}
+	if spec.SecurityContext != nil && spec.SecurityContext.RunAsUser != nil && *spec.SecurityContext.RunAsUser == 0 {
+		required.runAsRoot = true
+	}
+	for _, c := range getContainers(spec) {
+		sc := c.SecurityContext
+		if sc == nil {
+			continue
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			required.privileged = true
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			required.runAsRoot = true
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if capability != netBindServiceCapability {
+					required.capabilities = true
+				}
+			}
+		}
+	}
+	return required
+}
+
+// inferSCC returns the least privileged built-in SCC which allows the requirements informed
+func inferSCC(required sccRequirements) string {
+	for _, scc := range builtInSCCs {
+		if len(scc.allowed.missing(required)) == 0 {
+			return scc.name
+		}
+	}
+	return builtInSCCs[len(builtInSCCs)-1].name
+}
+
+// getSCC returns the privileges allowed by the built-in or shipped SCC with the name informed
+func getSCC(name string, shipped map[string]sccRequirements) (sccRequirements, bool) {
+	for _, scc := range builtInSCCs {
+		if scc.name == name {
+			return scc.allowed, true
+		}
+	}
+	allowed, found := shipped[name]
+	return allowed, found
+}
+
+// getShippedSCCs returns the privileges allowed by the SCCs shipped in the bundle by name
+func getShippedSCCs(checks OpenShiftOperatorChecks) map[string]sccRequirements {
+	shipped := map[string]sccRequirements{}
+	for _, obj := range checks.bundle.Objects {
+		if obj.GetKind() != sccKind {
+			continue
+		}
+		allowed := sccRequirements{}
+		allowed.privileged, _, _ = unstructured.NestedBool(obj.Object, "allowPrivilegedContainer")
+		allowed.hostNetwork, _, _ = unstructured.NestedBool(obj.Object, "allowHostNetwork")
+		allowed.hostPID, _, _ = unstructured.NestedBool(obj.Object, "allowHostPID")
+		allowed.hostPath, _, _ = unstructured.NestedBool(obj.Object, "allowHostDirVolumePlugin")
+		runAsUser, _, _ := unstructured.NestedString(obj.Object, "runAsUser", "type")
+		allowed.runAsRoot = runAsUser == "RunAsAny"
+		capabilities, _, _ := unstructured.NestedStringSlice(obj.Object, "allowedCapabilities")
+		for _, capability := range capabilities {
+			if capability != netBindServiceCapability {
+				allowed.capabilities = true
+			}
+		}
+		shipped[obj.GetName()] = allowed
+	}
+	return shipped
+}
+
+// getGrantedSCCs returns the names of the SCCs which the service account informed is allowed to use
+func getGrantedSCCs(permissions []operatorsv1alpha1.StrategyDeploymentPermissions, serviceAccount string) []string {
+	var granted []string
+	for _, perm := range permissions {
+		if perm.ServiceAccountName != serviceAccount {
+			continue
+		}
+		for _, rule := range perm.Rules {
+			if !containsAny(rule.APIGroups, "security.openshift.io", "*") ||
+				!containsAny(rule.Resources, "securitycontextconstraints", "*") ||
+				!containsAny(rule.Verbs, "use", "*") {
+				continue
+			}
+			if len(rule.ResourceNames) == 0 {
+				granted = append(granted, "*")
+				continue
+			}
+			granted = append(granted, rule.ResourceNames...)
+		}
+	}
+	return granted
+}
+
+// containsAny returns true when the list has any of the values informed
+func containsAny(list []string, values ...string) bool {
+	for _, item := range list {
+		for _, value := range values {
+			if item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_SCCValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the deployments only require the restricted-v2 SCC",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the SCC required is declared via the annotation",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makePrivileged(bundle)
+					setRequiredSCC(bundle, "privileged")
+				},
+			},
+		},
+		{
+			name: "should pass when the SCC required is granted to the service account",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.HostNetwork = true
+					grantSCC(bundle, "hostnetwork-v2")
+				},
+			},
+		},
+		{
+			name:        "should warn when the SCC required is not declared",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate:    makePrivileged,
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the deployment " +
+				"memcached-operator-controller-manager requires the SCC privileged but it is not declared. " +
+				"Please, set the annotation openshift.io/required-scc: privileged in its pod template and grant " +
+				"the use of the SCC to the service account memcached-operator-controller-manager under " +
+				"spec.install.spec.clusterPermissions in the CSV"},
+		},
+		{
+			name:        "should warn when the SCC granted does not allow the privileges required",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makePrivileged(bundle)
+					grantSCC(bundle, "hostaccess")
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the deployment " +
+				"memcached-operator-controller-manager requires the SCC privileged but the SCCs (hostaccess) " +
+				"granted to the service account memcached-operator-controller-manager do not allow the " +
+				"privileges required"},
+		},
+		{
+			name:        "should warn when the SCC declared does not allow the privileges required",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makePrivileged(bundle)
+					setRequiredSCC(bundle, "hostnetwork-v2")
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the deployment " +
+				"memcached-operator-controller-manager requires the SCC hostnetwork-v2 via the annotation " +
+				"openshift.io/required-scc but it does not allow privileged containers, hostPID, hostPath volumes. " +
+				"Please, consider using the SCC privileged"},
+		},
+		{
+			name: "should pass when the custom SCC declared is shipped in the bundle",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.HostPID = true
+					setRequiredSCC(bundle, "memcached-scc")
+					bundle.Objects = append(bundle.Objects, &unstructured.Unstructured{Object: map[string]interface{}{
+						"apiVersion":   "security.openshift.io/v1",
+						"kind":         "SecurityContextConstraints",
+						"metadata":     map[string]interface{}{"name": "memcached-scc"},
+						"allowHostPID": true,
+					}})
+				},
+			},
+		},
+		{
+			name:        "should warn when the custom SCC declared is not shipped in the bundle",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					setRequiredSCC(bundle, "memcached-scc")
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the deployment " +
+				"memcached-operator-controller-manager requires the SCC memcached-scc via the annotation " +
+				"openshift.io/required-scc which is not a built-in SCC and it was not found in the bundle"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := SCCValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// setRequiredSCC sets the openshift.io/required-scc annotation in the pod template of the memcached bundle
+func setRequiredSCC(bundle *manifests.Bundle, scc string) {
+	template := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template
+	template.Annotations = map[string]string{requiredSCCAnnotation: scc}
+}
+
+// grantSCC grants the use of the SCC to the service account of the memcached bundle
+func grantSCC(bundle *manifests.Bundle, scc string) {
+	perms := &bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions[0]
+	perms.Rules = append(perms.Rules, rbacv1.PolicyRule{
+		APIGroups:     []string{"security.openshift.io"},
+		Resources:     []string{"securitycontextconstraints"},
+		ResourceNames: []string{scc},
+		Verbs:         []string{"use"},
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"sigs.k8s.io/yaml"
+)
+
+// The annotations used to inform the tests shipped in the bundle
+const (
+	testMediaTypeAnnotation = "operators.operatorframework.io.test.mediatype.v1"
+	testConfigAnnotation    = "operators.operatorframework.io.test.config.v1"
+)
+
+// The test media type, directory and config of the scorecard tests shipped in the bundles
+const (
+	scorecardMediaType  = "scorecard+v1"
+	scorecardDir        = "tests/scorecard"
+	scorecardConfigFile = "config.yaml"
+)
+
+// The apiVersion and kind of the scorecard configs supported by the certification pipeline
+const (
+	scorecardAPIVersion = "scorecard.operatorframework.io/v1alpha3"
+	scorecardKind       = "Configuration"
+)
+
+// scorecardConfig defines the attributes of the scorecard config which are checked
+type scorecardConfig struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Stages     []struct {
+		Tests []struct {
+			Image string `json:"image"`
+		} `json:"tests"`
+	} `json:"stages"`
+}
+
+// ScorecardValidator validates the tests shipped in the bundle when the operators.operatorframework.io.test.*
+// annotations are informed or the bundle ships the tests/scorecard directory. The annotations are read from
+// the metadata/annotations.yaml of the bundle directory informed via the optional key value bundle-path and
+// from the file informed via the optional key value file. Following its current checks:
+//
+// - Ensure that the directory informed via the operators.operatorframework.io.test.config.v1 annotation is
+// shipped in the bundle and has a config.yaml which can be parsed
+//
+// - Warn when the media type informed via the operators.operatorframework.io.test.mediatype.v1 annotation is
+// not scorecard+v1
+//
+// - Warn when the scorecard config is stale (e.g. apiVersion scorecard.operatorframework.io/v1alpha2) or has
+// no tests since the certification pipeline will reject it
+//
+// - Warn when the bundle ships the tests/scorecard directory but the annotations are not informed
+var ScorecardValidator interfaces.Validator = newBundleValidator("scorecard", checkScorecard)
+
+// checkScorecard will verify the test annotations and the scorecard config shipped in the bundle
+func checkScorecard(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	bundleDir := getBundleDir(checks)
+	annotations := getBundleAnnotations(checks, bundleDir)
+	mediaType, hasMediaType := annotations[testMediaTypeAnnotation]
+	configDir, hasConfigDir := annotations[testConfigAnnotation]
+
+	if !hasMediaType && !hasConfigDir {
+		if len(bundleDir) == 0 {
+			return checks
+		}
+		if info, err := statFile(checks.fsys, filepath.Join(bundleDir, scorecardDir)); err == nil && info.IsDir() {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the bundle ships the %s directory but the "+
+				"%s and %s annotations are not informed", scorecardDir, testMediaTypeAnnotation, testConfigAnnotation),
+				"add them to the metadata/annotations.yaml (e.g. %s: %s and %s: %s/) so that the tests are found",
+				testMediaTypeAnnotation, scorecardMediaType, testConfigAnnotation, scorecardDir))
+		}
+		return checks
+	}
+
+	if hasMediaType && mediaType != scorecardMediaType {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the test media type %s informed via the %s "+
+			"annotation is not supported", mediaType, testMediaTypeAnnotation), "use %s", scorecardMediaType))
+	}
+	if !hasConfigDir {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the %s annotation is informed but the %s "+
+			"annotation is not", testMediaTypeAnnotation, testConfigAnnotation),
+			"inform the directory of the tests shipped in the bundle (e.g. %s: %s/)", testConfigAnnotation,
+			scorecardDir))
+		return checks
+	}
+	// The files of the bundle can only be checked when its directory is informed
+	if len(bundleDir) == 0 {
+		return checks
+	}
+	return checkScorecardConfig(checks, bundleDir, configDir)
+}
+
+// checkScorecardConfig will verify the scorecard config found in the directory informed of the bundle
+func checkScorecardConfig(checks OpenShiftOperatorChecks, bundleDir, configDir string) OpenShiftOperatorChecks {
+	dir := filepath.Join(bundleDir, filepath.FromSlash(strings.TrimSuffix(configDir, "/")))
+	if info, err := statFile(checks.fsys, dir); err != nil || !info.IsDir() {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the directory %s informed via the %s annotation "+
+			"was not found in the bundle", configDir, testConfigAnnotation),
+			"ensure that it is shipped in the bundle or remove the annotation"))
+		return checks
+	}
+
+	configPath := path.Join(strings.TrimSuffix(configDir, "/"), scorecardConfigFile)
+	content, err := readFile(checks.fsys, filepath.Join(dir, scorecardConfigFile))
+	if err != nil {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the scorecard config %s was not found in the "+
+			"bundle", configPath), "ensure that the directory informed via the %s annotation has the config",
+			testConfigAnnotation))
+		return checks
+	}
+	config := scorecardConfig{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to parse the scorecard config %s: %s", configPath,
+			err))
+		return checks
+	}
+
+	if config.APIVersion != scorecardAPIVersion || config.Kind != scorecardKind {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the scorecard config %s is stale (apiVersion: "+
+			"%s, kind: %s) and will be rejected by the certification pipeline", configPath, config.APIVersion,
+			config.Kind), "regenerate it with a recent operator-sdk version (apiVersion: %s, kind: %s)",
+			scorecardAPIVersion, scorecardKind))
+		return checks
+	}
+	for _, stage := range config.Stages {
+		if len(stage.Tests) > 0 {
+			return checks
+		}
+	}
+	checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the scorecard config %s has no tests and will be "+
+		"rejected by the certification pipeline", configPath),
+		"inform the tests or remove the config and the %s and %s annotations", testMediaTypeAnnotation,
+		testConfigAnnotation))
+	return checks
+}
+
+// getBundleDir returns the directory of the bundle informed via the bundle path or inferred from the
+// metadata/annotations.yaml informed via the file. Note that an empty value is returned when it is unknown.
+func getBundleDir(checks OpenShiftOperatorChecks) string {
+	if len(checks.bundlePath) > 0 {
+		dir := filepath.Clean(checks.bundlePath)
+		if filepath.Base(dir) == "manifests" {
+			dir = filepath.Dir(dir)
+		}
+		return dir
+	}
+	if len(checks.filePath) > 0 && filepath.Base(filepath.Dir(filepath.Clean(checks.filePath))) == "metadata" {
+		return filepath.Dir(filepath.Dir(filepath.Clean(checks.filePath)))
+	}
+	return ""
+}
+
+// getBundleAnnotations returns the annotations found in the metadata/annotations.yaml of the bundle
+// directory informed and in the file informed via the optional values (bundle.Dockerfile or annotations)
+func getBundleAnnotations(checks OpenShiftOperatorChecks, bundleDir string) map[string]string {
+	annotations := map[string]string{}
+	var paths []string
+	if len(bundleDir) > 0 {
+		paths = append(paths, filepath.Join(bundleDir, "metadata", "annotations.yaml"))
+	}
+	if len(checks.filePath) > 0 {
+		paths = append(paths, checks.filePath)
+	}
+
+	// Note that issues to read the file informed are reported by the OpenShiftValidator
+	for _, p := range paths {
+		content, err := readFile(checks.fsys, p)
+		if err != nil {
+			continue
+		}
+		if isDockerfile(string(content)) {
+			for k, v := range parseDockerfileLabels(string(content)) {
+				annotations[k] = v
+			}
+			continue
+		}
+		file := struct {
+			Annotations map[string]string `json:"annotations"`
+		}{}
+		if err := yaml.Unmarshal(content, &file); err != nil {
+			continue
+		}
+		for k, v := range file.Annotations {
+			annotations[k] = v
+		}
+	}
+	return annotations
+}
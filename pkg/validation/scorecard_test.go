@@ -0,0 +1,219 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ScorecardValidator(t *testing.T) {
+	const testAnnotations = "annotations:\n" +
+		"  operators.operatorframework.io.bundle.mediatype.v1: registry+v1\n" +
+		"  operators.operatorframework.io.test.mediatype.v1: scorecard+v1\n" +
+		"  operators.operatorframework.io.test.config.v1: tests/scorecard/\n"
+	const validConfig = "apiVersion: scorecard.operatorframework.io/v1alpha3\n" +
+		"kind: Configuration\n" +
+		"metadata:\n" +
+		"  name: config\n" +
+		"stages:\n" +
+		"- parallel: true\n" +
+		"  tests:\n" +
+		"  - entrypoint: [scorecard-test, basic-check-spec]\n" +
+		"    image: quay.io/operator-framework/scorecard-test:v1.31.0\n"
+
+	type args struct {
+		files      map[string]string
+		bundlePath string
+		filePath   string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the bundle has no tests",
+			args: args{
+				bundlePath: "bundle",
+				files: map[string]string{
+					"bundle/metadata/annotations.yaml": "annotations:\n" +
+						"  operators.operatorframework.io.bundle.mediatype.v1: registry+v1\n",
+				},
+			},
+		},
+		{
+			name: "should pass when the scorecard config is valid",
+			args: args{
+				bundlePath: "bundle/manifests",
+				files: map[string]string{
+					"bundle/metadata/annotations.yaml":   testAnnotations,
+					"bundle/tests/scorecard/config.yaml": validConfig,
+				},
+			},
+		},
+		{
+			name: "should pass when the bundle directory is inferred from the annotations informed",
+			args: args{
+				filePath: "bundle/metadata/annotations.yaml",
+				files: map[string]string{
+					"bundle/metadata/annotations.yaml":   testAnnotations,
+					"bundle/tests/scorecard/config.yaml": validConfig,
+				},
+			},
+		},
+		{
+			name:      "should fail when the directory of the tests is not shipped",
+			wantError: true,
+			args: args{
+				bundlePath: "bundle",
+				files: map[string]string{
+					"bundle/metadata/annotations.yaml": testAnnotations,
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the directory tests/scorecard/ " +
+				"informed via the operators.operatorframework.io.test.config.v1 annotation was not found in the " +
+				"bundle. Please, ensure that it is shipped in the bundle or remove the annotation"},
+		},
+		{
+			name:      "should fail when the directory of the tests has no config",
+			wantError: true,
+			args: args{
+				bundlePath: "bundle",
+				files: map[string]string{
+					"bundle/metadata/annotations.yaml":  testAnnotations,
+					"bundle/tests/scorecard/kuttl.yaml": "kind: TestSuite\n",
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the scorecard config " +
+				"tests/scorecard/config.yaml was not found in the bundle. Please, ensure that the directory " +
+				"informed via the operators.operatorframework.io.test.config.v1 annotation has the config"},
+		},
+		{
+			name:      "should fail when the scorecard config cannot be parsed",
+			wantError: true,
+			args: args{
+				bundlePath: "bundle",
+				files: map[string]string{
+					"bundle/metadata/annotations.yaml":   testAnnotations,
+					"bundle/tests/scorecard/config.yaml": "stages: {invalid\n",
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to parse the scorecard config " +
+				"tests/scorecard/config.yaml: error converting YAML to JSON: yaml: line 1: did not find expected " +
+				"',' or '}'"},
+		},
+		{
+			name:        "should warn when the scorecard config is stale and the media type is not supported",
+			wantWarning: true,
+			args: args{
+				bundlePath: "bundle",
+				files: map[string]string{
+					"bundle/metadata/annotations.yaml": "annotations:\n" +
+						"  operators.operatorframework.io.test.mediatype.v1: scorecard+v2\n" +
+						"  operators.operatorframework.io.test.config.v1: tests/scorecard/\n",
+					"bundle/tests/scorecard/config.yaml": "kind: Configuration\n" +
+						"apiVersion: scorecard.operatorframework.io/v1alpha2\n" +
+						"tests:\n" +
+						"- image: quay.io/operator-framework/scorecard-test:master\n",
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the test media type scorecard+v2 informed via the " +
+					"operators.operatorframework.io.test.mediatype.v1 annotation is not supported. Please, use " +
+					"scorecard+v1",
+				"Warning: Value : (memcached-operator.v0.0.1) the scorecard config tests/scorecard/config.yaml is " +
+					"stale (apiVersion: scorecard.operatorframework.io/v1alpha2, kind: Configuration) and will be " +
+					"rejected by the certification pipeline. Please, regenerate it with a recent operator-sdk " +
+					"version (apiVersion: " +
+					"scorecard.operatorframework.io/v1alpha3, kind: Configuration)",
+			},
+		},
+		{
+			name:        "should warn when the scorecard config has no tests",
+			wantWarning: true,
+			args: args{
+				bundlePath: "bundle",
+				files: map[string]string{
+					"bundle/metadata/annotations.yaml": testAnnotations,
+					"bundle/tests/scorecard/config.yaml": "apiVersion: scorecard.operatorframework.io/v1alpha3\n" +
+						"kind: Configuration\n" +
+						"stages:\n" +
+						"- parallel: true\n",
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the scorecard config " +
+				"tests/scorecard/config.yaml has no tests and will be rejected by the certification pipeline. " +
+				"Please, inform the tests or remove the config and the " +
+				"operators.operatorframework.io.test.mediatype.v1 and operators.operatorframework.io.test.config.v1 " +
+				"annotations"},
+		},
+		{
+			name:        "should warn when the tests are shipped without the annotations",
+			wantWarning: true,
+			args: args{
+				bundlePath: "bundle",
+				files: map[string]string{
+					"bundle/tests/scorecard/config.yaml": validConfig,
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the bundle ships the tests/scorecard " +
+				"directory but the operators.operatorframework.io.test.mediatype.v1 and " +
+				"operators.operatorframework.io.test.config.v1 annotations are not informed. Please, add them to " +
+				"the metadata/annotations.yaml (e.g. operators.operatorframework.io.test.mediatype.v1: scorecard+v1 " +
+				"and operators.operatorframework.io.test.config.v1: tests/scorecard/) so that the tests are found"},
+		},
+		{
+			name:      "should fail when the test config is not informed in the Dockerfile",
+			wantError: true,
+			args: args{
+				filePath: "bundle.Dockerfile",
+				files: map[string]string{
+					"bundle.Dockerfile": "FROM scratch\n" +
+						"LABEL operators.operatorframework.io.test.mediatype.v1=scorecard+v1\n",
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the " +
+				"operators.operatorframework.io.test.mediatype.v1 annotation is informed but the " +
+				"operators.operatorframework.io.test.config.v1 annotation is not. Please, inform the directory of " +
+				"the tests shipped in the bundle (e.g. operators.operatorframework.io.test.config.v1: " +
+				"tests/scorecard/)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+
+			fsys := fstest.MapFS{}
+			for name, content := range tt.args.files {
+				fsys[name] = &fstest.MapFile{Data: []byte(content)}
+			}
+			results := ScorecardValidator.Validate(bundle, fsys, map[string]string{
+				BundlePathKey: tt.args.bundlePath,
+				FilePathKey:   tt.args.filePath,
+			})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
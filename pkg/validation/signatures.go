@@ -0,0 +1,97 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/cosign"
+)
+
+// verifySignature and verifyAttestation define the functions used to verify the images via cosign
+var verifySignature = cosign.VerifySignature
+var verifyAttestation = cosign.VerifyAttestation
+
+// SignatureValidator verifies the signatures and the provenance attestations of the bundle and index images via
+// cosign, since the promotion to the catalogs requires signed images. It is not part of the DefaultValidators.
+// The images are informed via the SignedImagesKey (the image of the bundle informed via the BundleImageKey is also
+// verified) and are verified with the key informed via the CosignKeyKey or keyless via the KeylessKey.
+// The binary can be informed via COSIGN_BIN. Following its current checks:
+//
+// - Ensure that the signatures of the images are verified
+//
+// - Warn when the provenance attestations (SLSA) of the images are not found or not verified. Note that it is
+// reported as an error for the certified, redhat and marketplace profiles.
+var SignatureValidator interfaces.Validator = newBundleValidator("signature", checkSignatures)
+
+// checkSignatures will verify the signatures and the provenance attestations of the images informed
+func checkSignatures(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	images := checks.signedImages
+	if len(checks.bundle.BundleImage) > 0 && !containsAny(images, checks.bundle.BundleImage) {
+		images = append([]string{checks.bundle.BundleImage}, images...)
+	}
+	if len(images) == 0 {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("unable to verify the signatures because no "+
+			"image was informed"), "inform the bundle and index images via the optional key %s", SignedImagesKey))
+		return checks
+	}
+	if err := checks.cosignOptions.Validate(); err != nil {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("unable to verify the signatures of the images: "+
+			"%s", err),
+			"inform the public key via the optional key %s or the keyless verification via the optional keys %s, %s "+
+				"and %s", CosignKeyKey, KeylessKey, CertificateIdentityKey, CertificateOIDCIssuerKey))
+		return checks
+	}
+
+	for _, image := range images {
+		if err := verifySignature(image, checks.cosignOptions); err != nil {
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the signatures of the image %s were not "+
+				"verified: %s. Note that the catalogs require signed images", image, err),
+				"ensure that the image is signed (e.g. cosign sign) with the key or identity informed"))
+			continue
+		}
+		if err := verifyAttestation(image, cosign.ProvenanceType, checks.cosignOptions); err != nil {
+			checks = addStrictFinding(checks, withRemediation(fmt.Errorf("the provenance attestation (%s) of the "+
+				"image %s was not verified: %s", cosign.ProvenanceType, image, err),
+				"ensure that the provenance of the build is attested (e.g. cosign attest --type %s)",
+				cosign.ProvenanceType))
+		}
+	}
+	return checks
+}
+
+// getSignedImages returns the images informed via the SignedImagesKey
+func getSignedImages(value string) []string {
+	var images []string
+	for _, image := range strings.Split(value, ",") {
+		if image = strings.TrimSpace(image); len(image) > 0 {
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+// getCosignOptions returns how the signatures are verified via the optional values
+func getCosignOptions(optionalValues map[string]string) cosign.Options {
+	return cosign.Options{
+		Key:                   optionalValues[CosignKeyKey],
+		Keyless:               optionalValues[KeylessKey] == "true",
+		CertificateIdentity:   optionalValues[CertificateIdentityKey],
+		CertificateOIDCIssuer: optionalValues[CertificateOIDCIssuerKey],
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-openshift-ecosystem/ocp-olm-catalog-validator/pkg/cosign"
+)
+
+func Test_SignatureValidator(t *testing.T) {
+	defaultVerifySignature, defaultVerifyAttestation := verifySignature, verifyAttestation
+	defer func() { verifySignature, verifyAttestation = defaultVerifySignature, defaultVerifyAttestation }()
+
+	// The images are signed by the key cosign.pub and only the bundle images have provenance attestations
+	verifySignature = func(image string, opts cosign.Options) error {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		if image == "quay.io/example/unsigned:v0.0.1" || opts.Key != "cosign.pub" {
+			return fmt.Errorf("no matching signatures")
+		}
+		return nil
+	}
+	verifyAttestation = func(image, predicateType string, opts cosign.Options) error {
+		require.Equal(t, cosign.ProvenanceType, predicateType)
+		if image == "quay.io/example/index:v4.14" {
+			return fmt.Errorf("no matching attestations")
+		}
+		return nil
+	}
+
+	type args struct {
+		bundleImage    string
+		optionalValues map[string]string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the signatures and attestations are verified",
+			args: args{
+				bundleImage: "quay.io/example/memcached-operator-bundle:v0.0.1",
+				optionalValues: map[string]string{
+					CosignKeyKey: "cosign.pub",
+				},
+			},
+		},
+		{
+			name:        "should warn when no image is informed",
+			wantWarning: true,
+			args: args{
+				optionalValues: map[string]string{
+					CosignKeyKey: "cosign.pub",
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) unable to verify the signatures " +
+				"because no image was informed. Please, inform the bundle and index images via the optional key " +
+				"signed-images"},
+		},
+		{
+			name:      "should fail when neither the key nor the keyless verification is informed",
+			wantError: true,
+			args: args{
+				optionalValues: map[string]string{
+					SignedImagesKey: "quay.io/example/index:v4.14",
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to verify the signatures of " +
+				"the images: neither the key nor the keyless verification was informed. Please, inform the public " +
+				"key via the optional key cosign-key or the keyless verification via the optional keys keyless, " +
+				"certificate-identity and certificate-oidc-issuer"},
+		},
+		{
+			name:      "should fail when the keyless verification is not restricted to an identity and issuer",
+			wantError: true,
+			args: args{
+				optionalValues: map[string]string{
+					SignedImagesKey: "quay.io/example/index:v4.14",
+					KeylessKey:      "true",
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) unable to verify the signatures of " +
+				"the images: the keyless verification requires the certificate identity and OIDC issuer. Please, " +
+				"inform the public key via the optional key cosign-key or the keyless verification via the optional " +
+				"keys keyless, certificate-identity and certificate-oidc-issuer"},
+		},
+		{
+			name:        "should fail when the signatures are not verified and warn without attestations",
+			wantError:   true,
+			wantWarning: true,
+			args: args{
+				bundleImage: "quay.io/example/memcached-operator-bundle:v0.0.1",
+				optionalValues: map[string]string{
+					SignedImagesKey: "quay.io/example/unsigned:v0.0.1, quay.io/example/index:v4.14",
+					CosignKeyKey:    "cosign.pub",
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the signatures of the image " +
+				"quay.io/example/unsigned:v0.0.1 were not verified: no matching signatures. Note that the catalogs " +
+				"require signed images. Please, ensure that the image is signed (e.g. cosign sign) with the key or " +
+				"identity informed"},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the provenance attestation " +
+				"(slsaprovenance) of the image quay.io/example/index:v4.14 was not verified: no matching " +
+				"attestations. Please, ensure that the provenance of the build is attested (e.g. cosign attest " +
+				"--type slsaprovenance)"},
+		},
+		{
+			name:      "should fail when the attestations are not verified for the certified profile",
+			wantError: true,
+			args: args{
+				optionalValues: map[string]string{
+					SignedImagesKey: "quay.io/example/index:v4.14",
+					CosignKeyKey:    "cosign.pub",
+					ProfileKey:      CertifiedProfile,
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the provenance attestation " +
+				"(slsaprovenance) of the image quay.io/example/index:v4.14 was not verified: no matching " +
+				"attestations. Please, ensure that the provenance of the build is attested (e.g. cosign attest " +
+				"--type slsaprovenance)"},
+		},
+		{
+			name:      "should fail when the keyless signatures are not verified",
+			wantError: true,
+			args: args{
+				bundleImage: "quay.io/example/memcached-operator-bundle:v0.0.1",
+				optionalValues: map[string]string{
+					KeylessKey:               "true",
+					CertificateIdentityKey:   "https://github.com/example/.*",
+					CertificateOIDCIssuerKey: "https://token.actions.githubusercontent.com",
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the signatures of the image " +
+				"quay.io/example/memcached-operator-bundle:v0.0.1 were not verified: no matching signatures. Note " +
+				"that the catalogs require signed images. Please, ensure that the image is signed (e.g. cosign " +
+				"sign) with the key or identity informed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			bundle.BundleImage = tt.args.bundleImage
+
+			results := SignatureValidator.Validate(bundle, tt.args.optionalValues)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
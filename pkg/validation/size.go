@@ -0,0 +1,130 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// The limits of the bundles which can be installed by OLM and served by the catalogs. Note that the
+// CSV is stored in etcd and the bundle is unpacked by OLM into a ConfigMap which are both limited to
+// about 1MiB.
+const (
+	maxCSVSize              = 1024 * 1024
+	maxCompressedBundleSize = 1024 * 1024
+)
+
+// maxSizeContributors defines how many of the largest manifests are informed when a limit is exceeded
+const maxSizeContributors = 3
+
+// SizeValidator validates the size of the manifests of the bundle. Following its current checks:
+//
+// - Ensure that the serialized CSV does not exceed 1MiB
+//
+// - Ensure that the bundle manifests compressed with gzip do not exceed 1MiB
+var SizeValidator interfaces.Validator = newBundleValidator("size", checkBundleSize)
+
+// manifestSize defines the serialized size of a manifest shipped in the bundle
+type manifestSize struct {
+	kind string
+	name string
+	size int
+}
+
+// checkBundleSize will verify the size of the CSV and of the bundle against the limits
+func checkBundleSize(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	sizes, content, err := getManifestSizes(checks)
+	if err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to compute the size of the bundle: %s", err))
+		return checks
+	}
+
+	for _, s := range sizes {
+		if s.kind == operatorsv1alpha1.ClusterServiceVersionKind && s.size > maxCSVSize {
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the CSV has %d bytes which exceeds the "+
+				"limit of %d bytes. Note that it will not be stored in the cluster", s.size, maxCSVSize),
+				"reduce it (e.g. the icon, the description or the alm-examples)"))
+		}
+	}
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(content); err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to compress the bundle: %s", err))
+		return checks
+	}
+	if err := gz.Close(); err != nil {
+		checks.errs = append(checks.errs, fmt.Errorf("unable to compress the bundle: %s", err))
+		return checks
+	}
+	if compressed.Len() > maxCompressedBundleSize {
+		checks.errs = append(checks.errs, fmt.Errorf("the bundle has %d bytes compressed with gzip which exceeds "+
+			"the limit of %d bytes. Note that OLM will not be able to unpack it. The largest manifests are: %s",
+			compressed.Len(), maxCompressedBundleSize, formatLargestManifests(sizes)))
+	}
+	return checks
+}
+
+// getManifestSizes returns the serialized size of each manifest of the bundle sorted from the largest
+// and the content of all of them
+func getManifestSizes(checks OpenShiftOperatorChecks) ([]manifestSize, []byte, error) {
+	var sizes []manifestSize
+	var content []byte
+	add := func(kind, name string, obj interface{}) error {
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		sizes = append(sizes, manifestSize{kind: kind, name: name, size: len(b)})
+		content = append(content, b...)
+		return nil
+	}
+
+	if err := add(operatorsv1alpha1.ClusterServiceVersionKind, checks.bundle.CSV.GetName(),
+		checks.bundle.CSV); err != nil {
+		return nil, nil, err
+	}
+	for _, obj := range checks.bundle.Objects {
+		if obj == nil || obj.GetKind() == operatorsv1alpha1.ClusterServiceVersionKind {
+			continue
+		}
+		if err := add(obj.GetKind(), obj.GetName(), obj.Object); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return sizes[i].size > sizes[j].size
+	})
+	return sizes, content, nil
+}
+
+// formatLargestManifests returns the largest manifests informed with their sizes
+// (e.g. ClusterServiceVersion memcached-operator.v0.0.1 (1048576 bytes))
+func formatLargestManifests(sizes []manifestSize) string {
+	var largest []string
+	for i := 0; i < len(sizes) && i < maxSizeContributors; i++ {
+		largest = append(largest, fmt.Sprintf("%s %s (%d bytes)", sizes[i].kind, sizes[i].name, sizes[i].size))
+	}
+	return strings.Join(largest, ", ")
+}
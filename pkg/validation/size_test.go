@@ -0,0 +1,88 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_SizeValidator(t *testing.T) {
+	for _, bundleDir := range []string{"./testdata/valid_bundle_v1", "./testdata/valid_bundle_v1beta1"} {
+		t.Run("should pass when the bundle is within the limits with "+bundleDir, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(bundleDir)
+			require.NoError(t, err)
+
+			results := SizeValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], false, false, nil, nil)
+		})
+	}
+
+	t.Run("should fail when the CSV exceeds the limit", func(t *testing.T) {
+		bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+		require.NoError(t, err)
+		bundle.CSV.Spec.Description = strings.Repeat("a", maxCSVSize)
+		csv, err := json.Marshal(bundle.CSV)
+		require.NoError(t, err)
+
+		results := SizeValidator.Validate(bundle)
+		require.Equal(t, 1, len(results))
+		requireResult(t, results[0], true, false, []string{fmt.Sprintf("Error: Value : (memcached-operator.v0.0.1) "+
+			"the CSV has %d bytes which exceeds the limit of 1048576 bytes. Note that it will not be stored in the "+
+			"cluster. Please, reduce it (e.g. the icon, the description or the alm-examples)", len(csv))}, nil)
+	})
+
+	t.Run("should fail when the compressed bundle exceeds the limit", func(t *testing.T) {
+		bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+		require.NoError(t, err)
+		data := make([]byte, maxCompressedBundleSize)
+		_, err = rand.Read(data)
+		require.NoError(t, err)
+		configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "large"},
+			"binaryData": map[string]interface{}{"data": base64.StdEncoding.EncodeToString(data)},
+		}}
+		bundle.Objects = append(bundle.Objects, configMap)
+
+		results := SizeValidator.Validate(bundle)
+		require.Equal(t, 1, len(results))
+		require.Equal(t, 1, len(results[0].Errors))
+		require.Empty(t, results[0].Warnings)
+		require.Contains(t, results[0].Errors[0].Error(), "compressed with gzip which exceeds the limit of "+
+			"1048576 bytes. Note that OLM will not be able to unpack it. The largest manifests are: ConfigMap large (")
+	})
+}
+
+func Test_formatLargestManifests(t *testing.T) {
+	sizes := []manifestSize{
+		{kind: "ConfigMap", name: "large", size: 300},
+		{kind: "ClusterServiceVersion", name: "memcached-operator.v0.0.1", size: 200},
+		{kind: "Service", name: "metrics", size: 100},
+		{kind: "Role", name: "leader-election", size: 50},
+	}
+	require.Equal(t, "ConfigMap large (300 bytes), ClusterServiceVersion memcached-operator.v0.0.1 (200 bytes), "+
+		"Service metrics (100 bytes)", formatLargestManifests(sizes))
+}
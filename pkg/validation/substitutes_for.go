@@ -0,0 +1,116 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+// substitutesForAnnotation defines the CSV annotation which informs the CSV name of the bundle substituted
+const substitutesForAnnotation = "olm.substitutesFor"
+
+// fileBasedCatalogsVersion defines the OCP version since the default catalogs are File-Based Catalogs
+const fileBasedCatalogsVersion = "4.11"
+
+// SubstitutesForValidator validates the olm.substitutesFor annotation of the CSV when it is informed.
+// Following its current checks:
+//
+// - Ensure that the olm.substitutesFor informs a valid CSV name which is not the name of the bundle and warn
+// when it does not follow the convention <package>.vX.Y.Z of the same package
+//
+// - Warn when the bundle is distributed to OCP 4.11 or upper versions and the bundle substituted is not
+// replaced or skipped. Note that the substitution is only honored by the SQLite-based catalogs built with
+// the alpha features enabled and the File-Based Catalogs ignore it.
+//
+// - Warn when the bundle substituted is not found in the package of the File-Based Catalog informed via
+// the CatalogKey
+var SubstitutesForValidator interfaces.Validator = newBundleValidator("substitutes-for", checkSubstitutesFor)
+
+// checkSubstitutesFor will verify the CSV name informed via the olm.substitutesFor annotation
+func checkSubstitutesFor(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	csv := checks.bundle.CSV
+	substituted, found := csv.GetAnnotations()[substitutesForAnnotation]
+	if !found {
+		return checks
+	}
+	substituted = strings.TrimSpace(substituted)
+	if errs := k8svalidation.IsDNS1123Subdomain(substituted); len(errs) > 0 {
+		checks.errs = append(checks.errs, fmt.Errorf("the %s annotation (%s) is not a valid CSV name: %s",
+			substitutesForAnnotation, substituted, strings.Join(errs, ", ")))
+		return checks
+	}
+	if substituted == csv.GetName() {
+		checks.errs = append(checks.errs, fmt.Errorf("the %s annotation (%s) informs the name of the bundle "+
+			"which cannot substitute for itself", substitutesForAnnotation, substituted))
+		return checks
+	}
+
+	prefix, _, ok := splitCSVName(substituted)
+	ownPrefix, _, _ := splitCSVName(csv.GetName())
+	switch {
+	case !ok:
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s annotation (%s) does not follow the "+
+			"convention <package>.vX.Y.Z (e.g. memcached-operator.v0.0.1)", substitutesForAnnotation, substituted),
+			"ensure that it informs the CSV name of the bundle substituted"))
+	case len(ownPrefix) > 0 && prefix != ownPrefix:
+		checks.warns = append(checks.warns, fmt.Errorf("the %s annotation (%s) refers to a bundle of another "+
+			"package. Note that only the bundles of the same package can be substituted",
+			substitutesForAnnotation, substituted))
+	}
+
+	ocpRange := getOCPRange(checks)
+	upper := true
+	if len(ocpRange) > 0 {
+		upper, _ = rangeAllowsVersionOrUpper(ocpRange, fileBasedCatalogsVersion)
+	}
+	if upper && csv.Spec.Replaces != substituted && !containsAny(csv.Spec.Skips, substituted) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the %s annotation (%s) is only honored by the "+
+			"SQLite-based catalogs built with the alpha features enabled. Note that the File-Based Catalogs used "+
+			"since OCP %s ignore it", substitutesForAnnotation, substituted, fileBasedCatalogsVersion),
+			"inform the bundle substituted via spec.replaces or spec.skips to provide the upgrade path"))
+	}
+
+	if len(checks.catalog) == 0 {
+		return checks
+	}
+	// Note that issues to load the catalog are reported by the CatalogDependenciesValidator
+	cfg, err := declcfg.LoadFS(os.DirFS(checks.catalog))
+	if err != nil {
+		return checks
+	}
+	packageName := getPackageName(checks)
+	for _, b := range cfg.Bundles {
+		if b.Name != substituted {
+			continue
+		}
+		if len(packageName) > 0 && b.Package != packageName {
+			checks.warns = append(checks.warns, fmt.Errorf("the bundle %s substituted via the %s annotation "+
+				"belongs to the package %s of the catalog %s. Note that only the bundles of the same package (%s) "+
+				"can be substituted", substituted, substitutesForAnnotation, b.Package, checks.catalog,
+				packageName))
+		}
+		return checks
+	}
+	checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the bundle %s substituted via the %s annotation "+
+		"was not found in the catalog %s", substituted, substitutesForAnnotation, checks.catalog),
+		"ensure that it was published"))
+	return checks
+}
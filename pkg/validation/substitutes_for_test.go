@@ -0,0 +1,156 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SubstitutesForValidator(t *testing.T) {
+	type args struct {
+		substitutesFor string
+		replaces       string
+		packageName    string
+		ocpRange       string
+		catalog        string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the substitutesFor is not informed",
+		},
+		{
+			name: "should pass when the bundle substituted is also replaced",
+			args: args{
+				substitutesFor: "memcached-operator.v0.0.0",
+				replaces:       "memcached-operator.v0.0.0",
+			},
+		},
+		{
+			name: "should pass when the bundle is only distributed to OCP versions with SQLite-based catalogs",
+			args: args{
+				substitutesFor: "memcached-operator.v0.0.0",
+				ocpRange:       "v4.8-v4.10",
+			},
+		},
+		{
+			name:      "should fail when the substitutesFor is not a valid CSV name",
+			wantError: true,
+			args: args{
+				substitutesFor: "Memcached_Operator",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the olm.substitutesFor annotation " +
+				"(Memcached_Operator) is not a valid CSV name: a lowercase RFC 1123 subdomain must consist of lower " +
+				"case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character " +
+				"(e.g. 'example.com', regex used for validation is " +
+				"'[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')"},
+		},
+		{
+			name:      "should fail when the substitutesFor informs the name of the bundle",
+			wantError: true,
+			args: args{
+				substitutesFor: "memcached-operator.v0.0.1",
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the olm.substitutesFor annotation " +
+				"(memcached-operator.v0.0.1) informs the name of the bundle which cannot substitute for itself"},
+		},
+		{
+			name:        "should warn when the substitutesFor refers to another package and is not honored",
+			wantWarning: true,
+			args: args{
+				substitutesFor: "etcdoperator.v0.9.4",
+				ocpRange:       "v4.10",
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the olm.substitutesFor annotation " +
+					"(etcdoperator.v0.9.4) refers to a bundle of another package. Note that only the bundles of the " +
+					"same package can be substituted",
+				"Warning: Value : (memcached-operator.v0.0.1) the olm.substitutesFor annotation " +
+					"(etcdoperator.v0.9.4) is only honored by the SQLite-based catalogs built with the alpha " +
+					"features enabled. Note that the File-Based Catalogs used since OCP 4.11 ignore it. Please, " +
+					"inform the bundle substituted via spec.replaces or spec.skips to provide the upgrade path",
+			},
+		},
+		{
+			name:        "should warn when the substitutesFor does not follow the CSV name convention",
+			wantWarning: true,
+			args: args{
+				substitutesFor: "memcached-operator",
+				replaces:       "memcached-operator",
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the olm.substitutesFor annotation " +
+				"(memcached-operator) does not follow the convention <package>.vX.Y.Z (e.g. " +
+				"memcached-operator.v0.0.1). Please, ensure that it informs the CSV name of the bundle substituted"},
+		},
+		{
+			name:        "should warn when the bundle substituted is not found in the catalog",
+			wantWarning: true,
+			args: args{
+				substitutesFor: "memcached-operator.v0.0.0",
+				replaces:       "memcached-operator.v0.0.0",
+				catalog:        "./testdata/catalog",
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the bundle " +
+				"memcached-operator.v0.0.0 substituted via the olm.substitutesFor annotation was not found in the " +
+				"catalog ./testdata/catalog. Please, ensure that it was published"},
+		},
+		{
+			name:        "should warn when the bundle substituted belongs to another package of the catalog",
+			wantWarning: true,
+			args: args{
+				substitutesFor: "etcdoperator.v0.9.4",
+				replaces:       "etcdoperator.v0.9.4",
+				packageName:    "memcached-operator",
+				catalog:        "./testdata/catalog",
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the olm.substitutesFor annotation " +
+					"(etcdoperator.v0.9.4) refers to a bundle of another package. Note that only the bundles of the " +
+					"same package can be substituted",
+				"Warning: Value : (memcached-operator.v0.0.1) the bundle etcdoperator.v0.9.4 substituted via the " +
+					"olm.substitutesFor annotation belongs to the package etcd of the catalog ./testdata/catalog. " +
+					"Note that only the bundles of the same package (memcached-operator) can be substituted",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			bundle.Package = tt.args.packageName
+			bundle.CSV.Spec.Replaces = tt.args.replaces
+			if len(tt.args.substitutesFor) > 0 {
+				annotations := bundle.CSV.GetAnnotations()
+				annotations[substitutesForAnnotation] = tt.args.substitutesFor
+				bundle.CSV.SetAnnotations(annotations)
+			}
+
+			results := SubstitutesForValidator.Validate(bundle, map[string]string{RangeKey: tt.args.ocpRange,
+				CatalogKey: tt.args.catalog})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/validation"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// The upstream suites of operator-framework/api which can be run alongside the OpenShift checks
+const (
+	BundleSuite        = "bundle"
+	OperatorHubSuite   = "operatorhub"
+	GoodPracticesSuite = "good-practices"
+	CommunitySuite     = "community"
+)
+
+// OpenShiftSuite defines the suite which runs the DefaultValidators
+const OpenShiftSuite = "openshift"
+
+// UpstreamSuites defines the validators of operator-framework/api by suite. Note that the bundle suite
+// runs the same validators as `operator-sdk bundle validate` by default.
+var UpstreamSuites = map[string]interfaces.Validators{
+	BundleSuite: namedValidators(BundleSuite, append(interfaces.Validators{validation.ObjectValidator},
+		validation.DefaultBundleValidators...)...),
+	OperatorHubSuite:   namedValidators(OperatorHubSuite, validation.OperatorHubValidator),
+	GoodPracticesSuite: namedValidators(GoodPracticesSuite, validation.GoodPracticesValidator),
+	CommunitySuite:     namedValidators(CommunitySuite, validation.CommunityOperatorValidator),
+}
+
+// namedValidators returns the validators informed identified by the name of their suite
+func namedValidators(suite string, validators ...interfaces.Validator) interfaces.Validators {
+	var named interfaces.Validators
+	for _, v := range validators {
+		named = append(named, Named(suite, v))
+	}
+	return named
+}
+
+// Suites defines the validators of all suites which can be selected by name
+var Suites = newSuites()
+
+// newSuites returns the OpenShift suite and the upstream suites
+func newSuites() map[string]interfaces.Validators {
+	suites := map[string]interfaces.Validators{OpenShiftSuite: DefaultValidators}
+	for name, validators := range UpstreamSuites {
+		suites[name] = validators
+	}
+	return suites
+}
+
+// GetUpstreamValidators returns the validators of the upstream suites informed
+func GetUpstreamValidators(suites []string) (interfaces.Validators, error) {
+	return getSuiteValidators(UpstreamSuites, suites)
+}
+
+// GetSuiteValidators returns the validators of the suites informed (e.g. openshift,operatorhub)
+func GetSuiteValidators(suites []string) (interfaces.Validators, error) {
+	return getSuiteValidators(Suites, suites)
+}
+
+// getSuiteValidators returns the validators of the suites informed which are found in the available ones.
+// Note that the suites informed more than once are only run once.
+func getSuiteValidators(available map[string]interfaces.Validators,
+	suites []string) (interfaces.Validators, error) {
+	var validators interfaces.Validators
+	selected := map[string]bool{}
+	for _, suite := range suites {
+		suite = strings.TrimSpace(suite)
+		suiteValidators, found := available[suite]
+		if !found {
+			return nil, withRemediation(fmt.Errorf("the suite %s is not supported", suite), "inform one of: %s",
+				strings.Join(getSuiteNames(available), ", "))
+		}
+		if selected[suite] {
+			continue
+		}
+		selected[suite] = true
+		validators = append(validators, suiteValidators...)
+	}
+	return validators, nil
+}
+
+// getSuiteNames returns the sorted names of the suites informed
+func getSuiteNames(suites map[string]interfaces.Validators) []string {
+	var names []string
+	for name := range suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUpstreamValidators(t *testing.T) {
+	validators, err := GetUpstreamValidators([]string{OperatorHubSuite, GoodPracticesSuite})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(validators))
+
+	validators, err = GetUpstreamValidators([]string{BundleSuite})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(validators))
+
+	_, err = GetUpstreamValidators([]string{"scorecard"})
+	require.EqualError(t, err, "the suite scorecard is not supported. Please, inform one of: bundle, "+
+		"community, good-practices, operatorhub")
+
+	_, err = GetUpstreamValidators([]string{OpenShiftSuite})
+	require.Error(t, err)
+}
+
+func TestGetSuiteValidators(t *testing.T) {
+	validators, err := GetSuiteValidators([]string{OpenShiftSuite})
+	require.NoError(t, err)
+	require.Equal(t, len(DefaultValidators), len(validators))
+
+	validators, err = GetSuiteValidators([]string{OpenShiftSuite, OperatorHubSuite, OpenShiftSuite, CommunitySuite})
+	require.NoError(t, err)
+	require.Equal(t, len(DefaultValidators)+2, len(validators))
+
+	_, err = GetSuiteValidators([]string{"openshift", "scorecard"})
+	require.EqualError(t, err, "the suite scorecard is not supported. Please, inform one of: bundle, "+
+		"community, good-practices, openshift, operatorhub")
+}
+
+func Test_UpstreamSuites(t *testing.T) {
+	bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+	require.NoError(t, err)
+
+	validators, err := GetUpstreamValidators([]string{BundleSuite, OperatorHubSuite, GoodPracticesSuite})
+	require.NoError(t, err)
+	objs := append(bundle.ObjectsToValidate(), map[string]string{})
+
+	// The fixture ships the service account of the CSV and has no icon which are reported by the
+	// bundle and operatorhub suites
+	var errs []string
+	for _, result := range validators.Validate(objs...) {
+		for _, e := range result.Errors {
+			errs = append(errs, e.Detail)
+		}
+	}
+	require.Equal(t, 2, len(errs), "%v", errs)
+	require.Contains(t, errs[0], "invalid service account found in bundle")
+	require.Contains(t, errs[1], "csv.Spec.Icon elements should contain both data and mediatype")
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// thirdPartyAPI defines an outdated version of a third-party API which is not served on OpenShift
+type thirdPartyAPI struct {
+	apiVersion string
+	// kinds are the kinds of the API which are not served. Note that all kinds are matched when it is empty.
+	kinds []string
+	// provider is who serves the API on OpenShift
+	provider string
+	// replacement is the apiVersion which is served instead
+	replacement string
+}
+
+// The providers of the third-party APIs on OpenShift
+const (
+	monitoringProvider  = "the OpenShift monitoring stack"
+	certManagerProvider = "the cert-manager Operator for Red Hat OpenShift"
+)
+
+// thirdPartyAPIs defines the outdated versions of the common third-party APIs
+var thirdPartyAPIs = []thirdPartyAPI{
+	{apiVersion: "monitoring.coreos.com/v1alpha1", kinds: []string{"ServiceMonitor", "PodMonitor", "PrometheusRule",
+		"Probe"}, provider: monitoringProvider, replacement: "monitoring.coreos.com/v1"},
+	{apiVersion: "certmanager.k8s.io/v1alpha1", provider: certManagerProvider, replacement: "cert-manager.io/v1"},
+	{apiVersion: "cert-manager.io/v1alpha2", provider: certManagerProvider, replacement: "cert-manager.io/v1"},
+	{apiVersion: "cert-manager.io/v1alpha3", provider: certManagerProvider, replacement: "cert-manager.io/v1"},
+	{apiVersion: "cert-manager.io/v1beta1", provider: certManagerProvider, replacement: "cert-manager.io/v1"},
+	{apiVersion: "acme.cert-manager.io/v1alpha2", provider: certManagerProvider,
+		replacement: "acme.cert-manager.io/v1"},
+	{apiVersion: "acme.cert-manager.io/v1alpha3", provider: certManagerProvider,
+		replacement: "acme.cert-manager.io/v1"},
+	{apiVersion: "acme.cert-manager.io/v1beta1", provider: certManagerProvider,
+		replacement: "acme.cert-manager.io/v1"},
+}
+
+// ThirdPartyAPIsValidator looks for the outdated versions of the common third-party APIs (e.g.
+// monitoring.coreos.com/v1alpha1 ServiceMonitor or the cert-manager groups before cert-manager.io/v1) which
+// are not served on the OCP versions where the bundle is distributed. Following its current checks:
+//
+// - Ensure that the manifests shipped in the bundle do not use the outdated versions
+//
+// - Ensure that the CRDs required by the CSV (spec.customresourcedefinitions.required) are not informed
+// with the outdated versions
+var ThirdPartyAPIsValidator interfaces.Validator = newBundleValidator("third-party-apis", checkThirdPartyAPIs)
+
+// checkThirdPartyAPIs will verify the versions of the third-party APIs used by the bundle
+func checkThirdPartyAPIs(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, obj := range checks.bundle.Objects {
+		if obj == nil {
+			continue
+		}
+		if api, found := getOutdatedThirdPartyAPI(obj.GetAPIVersion(), obj.GetKind()); found {
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the %s %s shipped in the bundle uses the "+
+				"API %s which is not served by %s", obj.GetKind(), obj.GetName(), obj.GetAPIVersion(), api.provider),
+				"migrate it to %s", api.replacement))
+		}
+	}
+
+	for _, required := range checks.bundle.CSV.Spec.CustomResourceDefinitions.Required {
+		apiVersion := getOwnedAPIVersion(required)
+		if api, found := getOutdatedThirdPartyAPI(apiVersion, required.Kind); found {
+			checks.errs = append(checks.errs, withSubject(withRemediation(fmt.Errorf("the CRD %s required by the CSV "+
+				"is informed with the version %s which is not served by %s", required.Name, required.Version,
+				api.provider), "require the version %s instead", strings.SplitN(api.replacement, "/", 2)[1]),
+				"CustomResourceDefinition", required.Name))
+		}
+	}
+	return checks
+}
+
+// getOutdatedThirdPartyAPI returns the outdated third-party API which matches with the apiVersion and kind
+// informed
+func getOutdatedThirdPartyAPI(apiVersion, kind string) (thirdPartyAPI, bool) {
+	for _, api := range thirdPartyAPIs {
+		if api.apiVersion == apiVersion && (len(api.kinds) == 0 || containsAny(api.kinds, kind)) {
+			return api, true
+		}
+	}
+	return thirdPartyAPI{}, false
+}
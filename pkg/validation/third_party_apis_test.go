@@ -0,0 +1,104 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_ThirdPartyAPIsValidator(t *testing.T) {
+	newObject := func(apiVersion, kind, name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetName(name)
+		return obj
+	}
+
+	type args struct {
+		objects  []*unstructured.Unstructured
+		required []operatorsv1alpha1.CRDDescription
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the supported versions are used",
+			args: args{
+				objects: []*unstructured.Unstructured{
+					newObject("monitoring.coreos.com/v1", "ServiceMonitor", "memcached"),
+					newObject("cert-manager.io/v1", "Certificate", "memcached"),
+					newObject("monitoring.coreos.com/v1alpha1", "AlertmanagerConfig", "memcached"),
+				},
+				required: []operatorsv1alpha1.CRDDescription{
+					{Name: "certificates.cert-manager.io", Version: "v1", Kind: "Certificate"},
+				},
+			},
+		},
+		{
+			name:      "should fail when the outdated versions are shipped",
+			wantError: true,
+			args: args{
+				objects: []*unstructured.Unstructured{
+					newObject("monitoring.coreos.com/v1alpha1", "ServiceMonitor", "memcached"),
+					newObject("certmanager.k8s.io/v1alpha1", "Issuer", "memcached"),
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the ServiceMonitor memcached shipped in the bundle uses " +
+					"the API monitoring.coreos.com/v1alpha1 which is not served by the OpenShift monitoring stack. " +
+					"Please, migrate it to monitoring.coreos.com/v1",
+				"Error: Value : (memcached-operator.v0.0.1) the Issuer memcached shipped in the bundle uses the API " +
+					"certmanager.k8s.io/v1alpha1 which is not served by the cert-manager Operator for Red Hat " +
+					"OpenShift. Please, migrate it to cert-manager.io/v1",
+			},
+		},
+		{
+			name:      "should fail when the CRDs are required with the outdated versions",
+			wantError: true,
+			args: args{
+				required: []operatorsv1alpha1.CRDDescription{
+					{Name: "certificates.cert-manager.io", Version: "v1alpha2", Kind: "Certificate"},
+				},
+			},
+			errStrings: []string{"Error: Value : (memcached-operator.v0.0.1) the CRD certificates.cert-manager.io " +
+				"required by the CSV is informed with the version v1alpha2 which is not served by the cert-manager " +
+				"Operator for Red Hat OpenShift. Please, require the version v1 instead"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir("./testdata/valid_bundle_v1")
+			require.NoError(t, err)
+			bundle.Objects = append(bundle.Objects, tt.args.objects...)
+			bundle.CSV.Spec.CustomResourceDefinitions.Required = tt.args.required
+
+			results := ThirdPartyAPIsValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
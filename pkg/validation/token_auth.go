@@ -0,0 +1,107 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// tokenAuthProvider defines the annotation used to claim the support for the short-lived token
+// authentication of a cloud provider and the environment variables which are informed by the user
+// via the Subscription config when the operator is installed
+type tokenAuthProvider struct {
+	annotation string
+	envs       []string
+}
+
+// tokenAuthProviders defines the cloud providers which support the short-lived token authentication
+// (AWS STS, Azure Workload Identity and GCP Workload Identity Federation)
+var tokenAuthProviders = []tokenAuthProvider{
+	{annotation: featureAnnotationPrefix + "token-auth-aws", envs: []string{"ROLEARN"}},
+	{annotation: featureAnnotationPrefix + "token-auth-azure", envs: []string{"CLIENTID", "TENANTID",
+		"SUBSCRIPTIONID"}},
+	{annotation: featureAnnotationPrefix + "token-auth-gcp", envs: []string{"POOL_ID", "PROVIDER_ID",
+		"SERVICE_ACCOUNT_EMAIL", "PROJECT_NUMBER"}},
+}
+
+// The apiGroup and resource of the CredentialsRequests which are used to request the cloud credentials
+// to the Cloud Credential Operator (CCO)
+const (
+	cloudCredentialAPIGroup    = "cloudcredential.openshift.io"
+	credentialsRequestResource = "credentialsrequests"
+)
+
+// TokenAuthValidator validates the consistency of the bundles which claim to support the short-lived token
+// authentication of the cloud providers (features.operators.openshift.io/token-auth-aws, token-auth-azure
+// and token-auth-gcp). Following its current checks:
+//
+// - Warn when the token authentication is claimed but the operator is not allowed to create the
+// CredentialsRequests (cloudcredential.openshift.io) used to obtain the cloud credentials
+//
+// - Warn when the token authentication is claimed and the containers define the environment variables
+// informed via the Subscription config (e.g. ROLEARN) with a hard-coded value
+//
+// - Warn when the containers use the environment variables informed via the Subscription config but the
+// token authentication is not claimed. Note that the console will not ask the user for their values.
+var TokenAuthValidator interfaces.Validator = newBundleValidator("token-auth", checkTokenAuth)
+
+// checkTokenAuth will verify the consistency of the token authentication claims with the bundle
+func checkTokenAuth(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	annotations := checks.bundle.CSV.GetAnnotations()
+	strategy := checks.bundle.CSV.Spec.InstallStrategy.StrategySpec
+
+	for _, provider := range tokenAuthProviders {
+		claimed := annotations[provider.annotation] == "true"
+		if claimed &&
+			!hasPermission(strategy.Permissions, cloudCredentialAPIGroup, credentialsRequestResource, "create") &&
+			!hasPermission(strategy.ClusterPermissions, cloudCredentialAPIGroup, credentialsRequestResource,
+				"create") {
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the annotation %s is true but the "+
+				"operator is not allowed to create %s.%s", provider.annotation, credentialsRequestResource,
+				cloudCredentialAPIGroup),
+				"ensure that the operator requests its cloud credentials via a CredentialsRequest using the values "+
+					"informed with the environment variables (%s)", strings.Join(provider.envs, ", ")))
+		}
+
+		for _, dep := range strategy.DeploymentSpecs {
+			for _, c := range getContainers(dep.Spec.Template.Spec) {
+				for _, env := range c.Env {
+					if !containsAny(provider.envs, env.Name) {
+						continue
+					}
+					if !claimed {
+						checks.warns = append(checks.warns, withSubject(fmt.Errorf("the container %s of the "+
+							"deployment %s uses the environment variable %s but the annotation %s is not true. Note "+
+							"that the console will not ask for its value when the operator is installed on clusters "+
+							"with short-lived token authentication", c.Name, dep.Name, env.Name, provider.annotation),
+							"Deployment", dep.Name))
+						continue
+					}
+					if len(env.Value) > 0 {
+						checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the container %s "+
+							"of the deployment %s has the environment variable %s with the hard-coded value (%s)",
+							c.Name, dep.Name, env.Name, env.Value),
+							"remove it since its value is informed by the user via the Subscription config"),
+							"Deployment", dep.Name))
+					}
+				}
+			}
+		}
+	}
+	return checks
+}
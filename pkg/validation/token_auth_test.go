@@ -0,0 +1,133 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func Test_TokenAuthValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the token authentication is not claimed",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the token authentication is claimed and the CredentialsRequests can be created",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations["features.operators.openshift.io/token-auth-aws"] = "true"
+					allowCredentialsRequests(bundle)
+					setManagerEnv(bundle, corev1.EnvVar{Name: "ROLEARN"})
+				},
+			},
+		},
+		{
+			name:        "should warn when the token authentication is claimed but CredentialsRequests cannot be created",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations["features.operators.openshift.io/token-auth-azure"] = "true"
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the annotation " +
+				"features.operators.openshift.io/token-auth-azure is true but the operator is not allowed to " +
+				"create credentialsrequests.cloudcredential.openshift.io. Please, ensure that the operator " +
+				"requests its cloud credentials via a CredentialsRequest using the values informed with the " +
+				"environment variables (CLIENTID, TENANTID, SUBSCRIPTIONID)"},
+		},
+		{
+			name:        "should warn when the token authentication environment variables are hard-coded",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Annotations["features.operators.openshift.io/token-auth-aws"] = "true"
+					allowCredentialsRequests(bundle)
+					setManagerEnv(bundle, corev1.EnvVar{Name: "ROLEARN", Value: "arn:aws:iam::123:role/operator"})
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the container manager of the " +
+				"deployment memcached-operator-controller-manager has the environment variable ROLEARN with the " +
+				"hard-coded value (arn:aws:iam::123:role/operator). Please, remove it since its value is informed " +
+				"by the user via the Subscription config"},
+		},
+		{
+			name:        "should warn when the token authentication environment variables are used without the claim",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					setManagerEnv(bundle, corev1.EnvVar{Name: "POOL_ID"})
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the container manager of the " +
+				"deployment memcached-operator-controller-manager uses the environment variable POOL_ID but the " +
+				"annotation features.operators.openshift.io/token-auth-gcp is not true. Note that the console " +
+				"will not ask for its value when the operator is installed on clusters with short-lived token " +
+				"authentication"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := TokenAuthValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// allowCredentialsRequests allows the service account of the memcached bundle to create CredentialsRequests
+func allowCredentialsRequests(bundle *manifests.Bundle) {
+	perms := &bundle.CSV.Spec.InstallStrategy.StrategySpec.ClusterPermissions[0]
+	perms.Rules = append(perms.Rules, rbacv1.PolicyRule{
+		APIGroups: []string{cloudCredentialAPIGroup},
+		Resources: []string{credentialsRequestResource},
+		Verbs:     []string{"create", "get"},
+	})
+}
+
+// setManagerEnv adds the environment variable informed to the manager container of the memcached bundle
+func setManagerEnv(bundle *manifests.Bundle, env corev1.EnvVar) {
+	spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec
+	spec.Containers[1].Env = append(spec.Containers[1].Env, env)
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validation provides the Validator's used to check the bundles against the
+// criteria to publish them on the OpenShift catalogs. Each Validator runs an
+// independent set of checks. To run all of them use DefaultValidators.
+package validation
+
+import (
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+)
+
+// DefaultValidators implements Validator to run all checks performed by default
+// to publish bundles on the OpenShift catalogs.
+var DefaultValidators = interfaces.Validators{
+	OpenShiftValidator,
+	OpenShiftAPIsValidator,
+	ThirdPartyAPIsValidator,
+	ProfileValidator,
+	CSVNameValidator,
+	CertificationLabelsValidator,
+	OCILabelsValidator,
+	ScorecardValidator,
+	CreatedAtValidator,
+	SubstitutesForValidator,
+	PackageCollisionValidator,
+	BundleManifestsValidator,
+	SizeValidator,
+	CRDValidator,
+	WebhookValidator,
+	APIServiceValidator,
+	InstallStrategyValidator,
+	InstallModesValidator,
+	NamespaceValidator,
+	WorkloadSecurityValidator,
+	SCCValidator,
+	DeploymentPracticesValidator,
+	RBACValidator,
+	CredentialsValidator,
+	RelatedImagesValidator,
+	ImagesValidator,
+	FeaturesValidator,
+	TokenAuthValidator,
+	ProxyValidator,
+	AnsibleValidator,
+	FIPSValidator,
+	MinKubeVersionValidator,
+	HostedControlPlanesValidator,
+	ConsolePluginValidator,
+	MonitoringValidator,
+	CapabilitiesValidator,
+	ConsoleMetadataValidator,
+	ExamplesValidator,
+	DescriptorsValidator,
+	DependenciesValidator,
+	CatalogDependenciesValidator,
+	ClusterValidator,
+}
@@ -0,0 +1,273 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// OLM docs with the information about the webhooks managed via the CSV
+const olmDocLinkWebhooks = "https://olm.operatorframework.io/docs/advanced-tasks/adding-admission-and-conversion-webhooks/"
+
+// certManagerGroup defines the API group of the cert-manager resources
+const certManagerGroup = "cert-manager.io"
+
+// certManagerInjectAnnotations defines the annotations used by cert-manager to inject the CA
+var certManagerInjectAnnotations = []string{"cert-manager.io/inject-ca-from", "cert-manager.io/inject-ca-from-secret"}
+
+// webhookServerCertDir defines the default directory where the webhook servers built
+// with controller-runtime expect to find their certificates
+const webhookServerCertDir = "/tmp/k8s-webhook-server/serving-certs"
+
+// WebhookValidator validates the webhooks of the bundle against the constraints defined by OLM.
+// Following its current checks:
+//
+// - Ensure that the webhooks defined under spec.webhookdefinitions in the CSV use a valid containerPort and
+// targetPort, a deployment defined in the install strategy, supported admissionReviewVersions (v1 or v1beta1)
+// and sideEffects None or NoneOnDryRun
+//
+// - Ensure that the webhooks do not intercept all resources or the resources which OLM requires
+// (e.g. operators.coreos.com group, ValidatingWebhookConfigurations and MutatingWebhookConfigurations)
+//
+// - Ensure that the conversion webhooks inform the CRDs and that the AllNamespaces install mode is supported
+//
+// - Warn when the admission webhooks intercept resources of groups which are not owned by the operator and
+// the AllNamespaces install mode is supported. Note that OLM defines the namespaceSelector with the namespaces
+// of the OperatorGroup which means that the webhook will intercept the requests of all namespaces.
+//
+// - Warn when the operator deploys its webhooks outside of the CSV spec.webhookdefinitions relying on
+// cert-manager or certificates provided manually. Note that OLM on OpenShift will not provision them.
+var WebhookValidator interfaces.Validator = newBundleValidator("webhooks", checkWebhookDefinitions,
+	checkWebhookCertificates)
+
+// checkWebhookDefinitions will verify the webhooks defined in the CSV against the OLM constraints
+func checkWebhookDefinitions(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	csv := checks.bundle.CSV
+	// namedPorts has the named ports of the containers by deployment name
+	namedPorts := map[string]map[string]bool{}
+	for _, dep := range csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		namedPorts[dep.Name] = map[string]bool{}
+		for _, c := range dep.Spec.Template.Spec.Containers {
+			for _, p := range c.Ports {
+				if len(p.Name) > 0 {
+					namedPorts[dep.Name][p.Name] = true
+				}
+			}
+		}
+	}
+
+	ownedGroups := map[string]bool{}
+	for _, owned := range csv.Spec.CustomResourceDefinitions.Owned {
+		if i := strings.Index(owned.Name, "."); i >= 0 {
+			ownedGroups[owned.Name[i+1:]] = true
+		}
+	}
+
+	for _, webhook := range csv.Spec.WebhookDefinitions {
+		name := webhook.GenerateName
+
+		if webhook.ContainerPort < 0 || webhook.ContainerPort > 65535 {
+			checks.errs = append(checks.errs, fmt.Errorf("the webhook %s has an invalid containerPort (%d). "+
+				"It must be a value between 1 and 65535", name, webhook.ContainerPort))
+		}
+
+		if _, found := namedPorts[webhook.DeploymentName]; !found {
+			checks.errs = append(checks.errs, withSubject(fmt.Errorf("the webhook %s uses the deployment (%s) which "+
+				"was not found in the install strategy of the CSV", name, webhook.DeploymentName),
+				"Deployment", webhook.DeploymentName))
+		} else if webhook.TargetPort != nil {
+			checks = checkWebhookTargetPort(checks, name, *webhook.TargetPort, namedPorts[webhook.DeploymentName])
+		}
+
+		if webhook.Type == operatorsv1alpha1.ConversionWebhook {
+			checks = checkConversionWebhookDefinition(checks, webhook)
+			continue
+		}
+
+		checks = checkAdmissionWebhookDefinition(checks, webhook, ownedGroups)
+	}
+	return checks
+}
+
+// checkWebhookTargetPort will verify that the targetPort is valid and that named ports exist in the deployment
+func checkWebhookTargetPort(checks OpenShiftOperatorChecks, name string, targetPort intstr.IntOrString,
+	namedPorts map[string]bool) OpenShiftOperatorChecks {
+	if targetPort.Type == intstr.String {
+		if !namedPorts[targetPort.StrVal] {
+			checks.errs = append(checks.errs, fmt.Errorf("the webhook %s uses the targetPort (%s) which was not "+
+				"found in the containers ports of its deployment", name, targetPort.StrVal))
+		}
+		return checks
+	}
+	if targetPort.IntVal < 1 || targetPort.IntVal > 65535 {
+		checks.errs = append(checks.errs, fmt.Errorf("the webhook %s has an invalid targetPort (%d). "+
+			"It must be a value between 1 and 65535", name, targetPort.IntVal))
+	}
+	return checks
+}
+
+// checkConversionWebhookDefinition will verify the conversion webhooks against the OLM constraints
+func checkConversionWebhookDefinition(checks OpenShiftOperatorChecks,
+	webhook operatorsv1alpha1.WebhookDescription) OpenShiftOperatorChecks {
+	if len(webhook.ConversionCRDs) == 0 {
+		checks.errs = append(checks.errs, fmt.Errorf("the conversion webhook %s does not inform the "+
+			"conversionCRDs. For further information see %s", webhook.GenerateName, olmDocLinkWebhooks))
+	}
+	if !supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeAllNamespaces) {
+		checks.errs = append(checks.errs, fmt.Errorf("the conversion webhook %s requires the AllNamespaces "+
+			"install mode to be supported. Note that OLM does not allow install operators with conversion "+
+			"webhooks which do not support it. For further information see %s",
+			webhook.GenerateName, olmDocLinkWebhooks))
+	}
+	return checks
+}
+
+// checkAdmissionWebhookDefinition will verify the admission webhooks against the OLM constraints
+func checkAdmissionWebhookDefinition(checks OpenShiftOperatorChecks, webhook operatorsv1alpha1.WebhookDescription,
+	ownedGroups map[string]bool) OpenShiftOperatorChecks {
+	name := webhook.GenerateName
+
+	if len(webhook.AdmissionReviewVersions) == 0 {
+		checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the webhook %s does not inform the "+
+			"admissionReviewVersions", name), "use v1 and/or v1beta1"))
+	}
+	for _, v := range webhook.AdmissionReviewVersions {
+		if v != "v1" && v != "v1beta1" {
+			checks.errs = append(checks.errs, withRemediation(fmt.Errorf("the webhook %s has an unsupported "+
+				"admissionReviewVersions (%s)", name, v), "use v1 and/or v1beta1"))
+		}
+	}
+
+	if webhook.SideEffects == nil || (*webhook.SideEffects != admissionregistrationv1.SideEffectClassNone &&
+		*webhook.SideEffects != admissionregistrationv1.SideEffectClassNoneOnDryRun) {
+		checks.errs = append(checks.errs, fmt.Errorf("the webhook %s must define sideEffects as %s or %s",
+			name, admissionregistrationv1.SideEffectClassNone, admissionregistrationv1.SideEffectClassNoneOnDryRun))
+	}
+
+	interceptsNotOwned := false
+	for _, rule := range webhook.Rules {
+		for _, group := range rule.APIGroups {
+			if group == "*" || group == operatorsv1alpha1.GroupName {
+				checks.errs = append(checks.errs, fmt.Errorf("the webhook %s has rules which intercept the "+
+					"resources of the apiGroups (%s). Note that OLM does not allow webhooks which intercept all "+
+					"resources or the OLM resources. For further information see %s", name, group, olmDocLinkWebhooks))
+			} else if !ownedGroups[group] {
+				interceptsNotOwned = true
+			}
+		}
+		for _, resource := range rule.Resources {
+			if resource == "*" || resource == "*/*" || resource == "validatingwebhookconfigurations" ||
+				resource == "mutatingwebhookconfigurations" {
+				checks.errs = append(checks.errs, fmt.Errorf("the webhook %s has rules which intercept the "+
+					"resources (%s). Note that OLM does not allow webhooks which intercept all resources or the "+
+					"webhook configurations. For further information see %s", name, resource, olmDocLinkWebhooks))
+			}
+		}
+	}
+
+	if interceptsNotOwned && supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeAllNamespaces) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the webhook %s intercepts resources which are "+
+			"not owned by the operator and the AllNamespaces install mode is supported. Note that OLM defines the "+
+			"webhook namespaceSelector with the namespaces of the OperatorGroup and then, the webhook will intercept "+
+			"the requests in all namespaces, including the openshift-* ones", name),
+			"ensure that it will not block the cluster operations (e.g. use an objectSelector)"))
+	}
+	return checks
+}
+
+// supportsInstallMode returns true when the CSV supports the install mode informed
+func supportsInstallMode(checks OpenShiftOperatorChecks, installModeType operatorsv1alpha1.InstallModeType) bool {
+	for _, mode := range checks.bundle.CSV.Spec.InstallModes {
+		if mode.Type == installModeType {
+			return mode.Supported
+		}
+	}
+	return false
+}
+
+// checkWebhookCertificates will warn when the webhooks certificates will not be provisioned by OLM
+func checkWebhookCertificates(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	usesCertManager := false
+	for _, obj := range checks.bundle.Objects {
+		switch obj.GetKind() {
+		case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+			checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the bundle ships the %s %s. Note that OLM "+
+				"does not manage the certificates of the webhooks which are not defined under spec.webhookdefinitions "+
+				"in the CSV", obj.GetKind(), obj.GetName()),
+				"use the CSV spec.webhookdefinitions instead. For further information see %s", olmDocLinkWebhooks))
+		}
+		if obj.GroupVersionKind().Group == certManagerGroup {
+			usesCertManager = true
+		}
+		for _, annotation := range certManagerInjectAnnotations {
+			if _, found := obj.GetAnnotations()[annotation]; found {
+				usesCertManager = true
+			}
+		}
+	}
+
+	if usesCertManager && !hasPackageDependency(checks, "cert-manager") {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the bundle relies on cert-manager to provide "+
+			"the certificates. Note that cert-manager is not provided by OLM on OpenShift"),
+			"use the CSV spec.webhookdefinitions so that OLM will manage the certificates or declare a dependency on "+
+				"the cert-manager package. For further information see %s", olmDocLinkWebhooks))
+	}
+
+	withWebhooks := map[string]bool{}
+	for _, webhook := range checks.bundle.CSV.Spec.WebhookDefinitions {
+		withWebhooks[webhook.DeploymentName] = true
+	}
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		if withWebhooks[dep.Name] {
+			continue
+		}
+		for _, c := range dep.Spec.Template.Spec.Containers {
+			for _, mount := range c.VolumeMounts {
+				if mount.MountPath == webhookServerCertDir {
+					checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the deployment %s "+
+						"mounts the webhook server certificates (%s) but it has no webhooks defined under "+
+						"spec.webhookdefinitions in the CSV. Note that OLM on OpenShift will not provision these "+
+						"certificates", dep.Name, webhookServerCertDir),
+						"use the CSV spec.webhookdefinitions. For further information see %s", olmDocLinkWebhooks),
+						"Deployment", dep.Name))
+				}
+			}
+		}
+	}
+	return checks
+}
+
+// hasPackageDependency returns true when the bundle declares a dependency on the package informed
+func hasPackageDependency(checks OpenShiftOperatorChecks, packageName string) bool {
+	for _, dep := range checks.bundle.Dependencies {
+		if dep == nil || dep.Type != "olm.package" {
+			continue
+		}
+		value := struct {
+			PackageName string `json:"packageName"`
+		}{}
+		if err := json.Unmarshal([]byte(dep.Value), &value); err == nil && value.PackageName == packageName {
+			return true
+		}
+	}
+	return false
+}
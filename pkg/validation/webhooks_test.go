@@ -0,0 +1,221 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_WebhookValidator(t *testing.T) {
+	type args struct {
+		bundleDir string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the webhooks respect the OLM constraints",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+			},
+		},
+		{
+			name: "should pass when the bundle has no webhooks",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1beta1",
+			},
+		},
+		{
+			name:      "should fail when the webhook has invalid ports, deployment, review versions and side effects",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					webhook := &bundle.CSV.Spec.WebhookDefinitions[0]
+					webhook.ContainerPort = 70000
+					webhook.AdmissionReviewVersions = []string{"v2"}
+					sideEffects := admissionregistrationv1.SideEffectClassSome
+					webhook.SideEffects = &sideEffects
+					targetPort := intstr.FromString("webhook")
+					webhook.TargetPort = &targetPort
+
+					webhook = &bundle.CSV.Spec.WebhookDefinitions[1]
+					webhook.DeploymentName = "not-found"
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the webhook vmemcached.kb.io has an invalid " +
+					"containerPort (70000). It must be a value between 1 and 65535",
+				"Error: Value : (memcached-operator.v0.0.1) the webhook vmemcached.kb.io uses the targetPort " +
+					"(webhook) which was not found in the containers ports of its deployment",
+				"Error: Value : (memcached-operator.v0.0.1) the webhook vmemcached.kb.io has an unsupported " +
+					"admissionReviewVersions (v2). Please, use v1 and/or v1beta1",
+				"Error: Value : (memcached-operator.v0.0.1) the webhook vmemcached.kb.io must define sideEffects " +
+					"as None or NoneOnDryRun",
+				"Error: Value : (memcached-operator.v0.0.1) the webhook mmemcached.kb.io uses the deployment " +
+					"(not-found) which was not found in the install strategy of the CSV",
+			},
+		},
+		{
+			name:      "should fail when the webhook intercepts all resources",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					webhook := &bundle.CSV.Spec.WebhookDefinitions[0]
+					webhook.Rules[0].APIGroups = []string{"*"}
+					webhook.Rules[0].Resources = []string{"*"}
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the webhook vmemcached.kb.io has rules which " +
+					"intercept the resources of the apiGroups (*). Note that OLM does not allow webhooks which " +
+					"intercept all resources or the OLM resources. For further information see " + olmDocLinkWebhooks,
+				"Error: Value : (memcached-operator.v0.0.1) the webhook vmemcached.kb.io has rules which " +
+					"intercept the resources (*). Note that OLM does not allow webhooks which intercept all " +
+					"resources or the webhook configurations. For further information see " + olmDocLinkWebhooks,
+			},
+		},
+		{
+			name:        "should warn when the webhook intercepts resources not owned in AllNamespaces mode",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					webhook := &bundle.CSV.Spec.WebhookDefinitions[0]
+					webhook.Rules[0].APIGroups = []string{""}
+					webhook.Rules[0].Resources = []string{"pods"}
+				},
+			},
+			warnStrings: []string{"Warning: Value : (memcached-operator.v0.0.1) the webhook vmemcached.kb.io " +
+				"intercepts resources which are not owned by the operator and the AllNamespaces install mode is " +
+				"supported. Note that OLM defines the webhook namespaceSelector with the namespaces of the " +
+				"OperatorGroup and then, the webhook will intercept the requests in all namespaces, including " +
+				"the openshift-* ones. Please, ensure that it will not block the cluster operations " +
+				"(e.g. use an objectSelector)"},
+		},
+		{
+			name:      "should fail when the conversion webhook has no CRDs and AllNamespaces is not supported",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.WebhookDefinitions = append(bundle.CSV.Spec.WebhookDefinitions,
+						operatorsv1alpha1.WebhookDescription{
+							GenerateName:   "cmemcached.kb.io",
+							Type:           operatorsv1alpha1.ConversionWebhook,
+							DeploymentName: "memcached-operator-controller-manager",
+						})
+					for i := range bundle.CSV.Spec.InstallModes {
+						bundle.CSV.Spec.InstallModes[i].Supported =
+							bundle.CSV.Spec.InstallModes[i].Type == operatorsv1alpha1.InstallModeTypeOwnNamespace
+					}
+				},
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the conversion webhook cmemcached.kb.io does not " +
+					"inform the conversionCRDs. For further information see " + olmDocLinkWebhooks,
+				"Error: Value : (memcached-operator.v0.0.1) the conversion webhook cmemcached.kb.io requires the " +
+					"AllNamespaces install mode to be supported. Note that OLM does not allow install operators " +
+					"with conversion webhooks which do not support it. For further information see " +
+					olmDocLinkWebhooks,
+			},
+		},
+		{
+			name:        "should warn when the webhooks are deployed outside the CSV with cert-manager",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					bundle.CSV.Spec.WebhookDefinitions = nil
+					webhook := &unstructured.Unstructured{}
+					webhook.SetAPIVersion("admissionregistration.k8s.io/v1")
+					webhook.SetKind("ValidatingWebhookConfiguration")
+					webhook.SetName("validating-webhook-configuration")
+					webhook.SetAnnotations(map[string]string{
+						"cert-manager.io/inject-ca-from": "system/serving-cert",
+					})
+					bundle.Objects = append(bundle.Objects, webhook)
+
+					containers := bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec.Containers
+					containers[1].VolumeMounts = append(containers[1].VolumeMounts, corev1.VolumeMount{
+						Name:      "cert",
+						MountPath: webhookServerCertDir,
+					})
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the bundle ships the ValidatingWebhookConfiguration " +
+					"validating-webhook-configuration. Note that OLM does not manage the certificates of the " +
+					"webhooks which are not defined under spec.webhookdefinitions in the CSV. Please, use the CSV " +
+					"spec.webhookdefinitions instead. For further information see " + olmDocLinkWebhooks,
+				"Warning: Value : (memcached-operator.v0.0.1) the bundle relies on cert-manager to provide the " +
+					"certificates. Note that cert-manager is not provided by OLM on OpenShift. Please, use the CSV " +
+					"spec.webhookdefinitions so that OLM will manage the certificates or declare a dependency on " +
+					"the cert-manager package. For further information see " + olmDocLinkWebhooks,
+				"Warning: Value : (memcached-operator.v0.0.1) the deployment memcached-operator-controller-manager " +
+					"mounts the webhook server certificates (/tmp/k8s-webhook-server/serving-certs) but it has no " +
+					"webhooks defined under spec.webhookdefinitions in the CSV. Note that OLM on OpenShift will not " +
+					"provision these certificates. Please, use the CSV spec.webhookdefinitions. For further " +
+					"information see " + olmDocLinkWebhooks,
+			},
+		},
+		{
+			name: "should pass when the bundle uses cert-manager and declares the dependency",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					issuer := &unstructured.Unstructured{}
+					issuer.SetAPIVersion("cert-manager.io/v1")
+					issuer.SetKind("Issuer")
+					issuer.SetName("selfsigned-issuer")
+					bundle.Objects = append(bundle.Objects, issuer)
+					bundle.Dependencies = append(bundle.Dependencies, &manifests.Dependency{
+						Type:  "olm.package",
+						Value: `{"packageName": "cert-manager", "version": ">=1.0.0"}`,
+					})
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := WebhookValidator.Validate(bundle)
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
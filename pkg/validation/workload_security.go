@@ -0,0 +1,236 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	interfaces "github.com/operator-framework/api/pkg/validation/interfaces"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// WorkloadSecurityValidator validates the security settings of the deployments defined in the CSV
+// against the SecurityContextConstraints (SCC) of OpenShift. Following its current checks:
+//
+// - Warn when the deployments use hostNetwork, hostPID, hostPath volumes or privileged containers
+// which are only allowed by the privileged SCC. Note that it is an error for the strict profiles
+// (certified, redhat and marketplace)
+//
+// - Warn when the containers of the deployments do not comply with the restricted-v2 SCC and the
+// restricted Pod Security Admission (PSA) profile enforced from OCP 4.12 (runAsNonRoot, seccompProfile
+// RuntimeDefault, no privilege escalation and no capabilities added other than NET_BIND_SERVICE). Note
+// that it is an error for the strict profiles.
+//
+// - Warn when the Pod Security Admission level required by the deployments is not allowed by the
+// namespace suggested via the operatorframework.io/suggested-namespace-template annotation or when the
+// operator can be installed in namespaces chosen by the user which might enforce the restricted level
+var WorkloadSecurityValidator interfaces.Validator = newBundleValidator("workload-security", checkPrivilegedWorkloads,
+	checkRestrictedPodSecurity, checkPodSecurityNamespace)
+
+// The annotations used to suggest the namespace where the operator should be installed
+const (
+	suggestedNamespaceAnnotation         = "operatorframework.io/suggested-namespace"
+	suggestedNamespaceTemplateAnnotation = "operatorframework.io/suggested-namespace-template"
+)
+
+// podSecurityEnforceLabel defines the namespace label used to configure the level enforced by
+// the Pod Security Admission
+const podSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+// The Pod Security Admission levels ordered from the most to the least restrictive
+const (
+	podSecurityRestricted = "restricted"
+	podSecurityBaseline   = "baseline"
+	podSecurityPrivileged = "privileged"
+)
+
+// podSecurityLevels maps the Pod Security Admission levels to their order
+var podSecurityLevels = map[string]int{podSecurityRestricted: 0, podSecurityBaseline: 1, podSecurityPrivileged: 2}
+
+// netBindServiceCapability defines the only capability which can be added with the restricted profile
+const netBindServiceCapability = "NET_BIND_SERVICE"
+
+// checkPrivilegedWorkloads will look for the settings which require privileged access in the deployments
+func checkPrivilegedWorkloads(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		privileged := getPrivilegedSettings(dep.Spec.Template.Spec)
+		if len(privileged) == 0 {
+			continue
+		}
+		sa := dep.Spec.Template.Spec.ServiceAccountName
+		if len(sa) == 0 {
+			sa = defaultServiceAccountName
+		}
+		checks = addStrictFinding(checks, withSubject(withRemediation(fmt.Errorf("the deployment %s requires "+
+			"privileged access (%s) which on OpenShift is only allowed by the privileged SCC", dep.Name,
+			strings.Join(privileged, ", ")),
+			"avoid it or grant the use of the SCC required to the service account %s under "+
+				"spec.install.spec.clusterPermissions in the CSV (apiGroups: security.openshift.io, resources: "+
+				"securitycontextconstraints, verbs: use)", sa), "Deployment", dep.Name))
+	}
+	return checks
+}
+
+// getPrivilegedSettings returns the settings of the pod spec which require privileged access
+func getPrivilegedSettings(spec corev1.PodSpec) []string {
+	var privileged []string
+	if spec.HostNetwork {
+		privileged = append(privileged, "hostNetwork")
+	}
+	if spec.HostPID {
+		privileged = append(privileged, "hostPID")
+	}
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			privileged = append(privileged, fmt.Sprintf("hostPath volume %s", v.Name))
+		}
+	}
+	for _, c := range getContainers(spec) {
+		if c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged {
+			privileged = append(privileged, fmt.Sprintf("privileged container %s", c.Name))
+		}
+	}
+	return privileged
+}
+
+// checkRestrictedPodSecurity will verify the security context of the containers against the restricted profile
+func checkRestrictedPodSecurity(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		for _, c := range getContainers(dep.Spec.Template.Spec) {
+			violations := getRestrictedViolations(dep.Spec.Template.Spec.SecurityContext, c.SecurityContext)
+			if len(violations) == 0 {
+				continue
+			}
+			checks = addStrictFinding(checks, withSubject(withRemediation(fmt.Errorf("the container %s of the "+
+				"deployment %s does not comply with the restricted Pod Security Admission profile enforced from OCP "+
+				"4.12 (%s). Note that non-compliant pods fail to be admitted in the namespaces labeled as restricted",
+				c.Name, dep.Name, strings.Join(violations, ", ")),
+				"set runAsNonRoot: true, seccompProfile.type: RuntimeDefault, allowPrivilegeEscalation: false and "+
+					"capabilities.drop: [ALL] in its securityContext"), "Deployment", dep.Name))
+		}
+	}
+	return checks
+}
+
+// checkPodSecurityNamespace will verify that the Pod Security Admission level required by the deployments
+// is allowed by the namespaces where the operator is expected to be installed
+func checkPodSecurityNamespace(checks OpenShiftOperatorChecks) OpenShiftOperatorChecks {
+	required := getRequiredPodSecurityLevel(checks)
+	if required == podSecurityRestricted {
+		return checks
+	}
+
+	annotations := checks.bundle.CSV.GetAnnotations()
+	if template, ok := annotations[suggestedNamespaceTemplateAnnotation]; ok {
+		ns := corev1.Namespace{}
+		if err := json.Unmarshal([]byte(template), &ns); err != nil {
+			// the annotation is validated by the NamespaceValidator
+			return checks
+		}
+		enforced, found := ns.Labels[podSecurityEnforceLabel]
+		if found && podSecurityLevels[enforced] < podSecurityLevels[required] {
+			checks.warns = append(checks.warns, withSubject(withRemediation(fmt.Errorf("the deployments require the "+
+				"%s Pod Security Admission level but the namespace %s suggested via the annotation %s enforces the %s "+
+				"level. Note that the pods will not be admitted", required, ns.Name,
+				suggestedNamespaceTemplateAnnotation, enforced), "set the label %s: %s", podSecurityEnforceLabel,
+				required), "Namespace", ns.Name))
+		}
+		if found {
+			return checks
+		}
+	}
+
+	if _, ok := annotations[suggestedNamespaceAnnotation]; ok {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the deployments require the %s Pod Security "+
+			"Admission level but the namespace suggested via the annotation %s will be created without the label %s",
+			required, suggestedNamespaceAnnotation, podSecurityEnforceLabel),
+			"use the annotation %s to suggest a namespace with the label %s: %s", suggestedNamespaceTemplateAnnotation,
+			podSecurityEnforceLabel, required))
+		return checks
+	}
+
+	if supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeOwnNamespace) ||
+		supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeSingleNamespace) ||
+		supportsInstallMode(checks, operatorsv1alpha1.InstallModeTypeMultiNamespace) {
+		checks.warns = append(checks.warns, withRemediation(fmt.Errorf("the deployments require the %s Pod Security "+
+			"Admission level and the install modes supported allow the operator to be installed in any namespace, "+
+			"which might enforce the restricted level", required),
+			"consider suggesting a namespace with the label %s: %s via the annotation %s", podSecurityEnforceLabel,
+			required, suggestedNamespaceTemplateAnnotation))
+	}
+	return checks
+}
+
+// getRequiredPodSecurityLevel returns the Pod Security Admission level required by the deployments
+func getRequiredPodSecurityLevel(checks OpenShiftOperatorChecks) string {
+	required := podSecurityRestricted
+	for _, dep := range checks.bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+		spec := dep.Spec.Template.Spec
+		if len(getPrivilegedSettings(spec)) > 0 {
+			return podSecurityPrivileged
+		}
+		for _, c := range getContainers(spec) {
+			if len(getRestrictedViolations(spec.SecurityContext, c.SecurityContext)) > 0 {
+				required = podSecurityBaseline
+			}
+		}
+	}
+	return required
+}
+
+// getRestrictedViolations returns the settings of the container security context, merged with the pod
+// security context, which are not allowed by the restricted profile
+func getRestrictedViolations(podSC *corev1.PodSecurityContext, sc *corev1.SecurityContext) []string {
+	if podSC == nil {
+		podSC = &corev1.PodSecurityContext{}
+	}
+	if sc == nil {
+		sc = &corev1.SecurityContext{}
+	}
+
+	var violations []string
+	runAsNonRoot := podSC.RunAsNonRoot
+	if sc.RunAsNonRoot != nil {
+		runAsNonRoot = sc.RunAsNonRoot
+	}
+	if runAsNonRoot == nil || !*runAsNonRoot {
+		violations = append(violations, "runAsNonRoot is not true")
+	}
+
+	seccomp := podSC.SeccompProfile
+	if sc.SeccompProfile != nil {
+		seccomp = sc.SeccompProfile
+	}
+	if seccomp == nil || (seccomp.Type != corev1.SeccompProfileTypeRuntimeDefault &&
+		seccomp.Type != corev1.SeccompProfileTypeLocalhost) {
+		violations = append(violations, "seccompProfile is not RuntimeDefault")
+	}
+
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		violations = append(violations, "allowPrivilegeEscalation is not false")
+	}
+
+	if sc.Capabilities != nil {
+		for _, c := range sc.Capabilities.Add {
+			if c != netBindServiceCapability {
+				violations = append(violations, fmt.Sprintf("capability %s is added", c))
+			}
+		}
+	}
+	return violations
+}
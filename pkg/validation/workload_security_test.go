@@ -0,0 +1,271 @@
+// Copyright 2021 The OpenShift OLM Catalog Validator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/manifests"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_WorkloadSecurityValidator(t *testing.T) {
+	const privilegedMsg = "Value : (memcached-operator.v0.0.1) the deployment memcached-operator-controller-manager " +
+		"requires privileged access (hostNetwork, hostPID, hostPath volume host, privileged container manager) " +
+		"which on OpenShift is only allowed by the privileged SCC. Please, avoid it or grant the use of the SCC " +
+		"required to the service account memcached-operator-controller-manager under " +
+		"spec.install.spec.clusterPermissions in the CSV (apiGroups: security.openshift.io, resources: " +
+		"securitycontextconstraints, verbs: use)"
+
+	type args struct {
+		bundleDir string
+		profile   string
+		mutate    func(bundle *manifests.Bundle)
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantError   bool
+		wantWarning bool
+		errStrings  []string
+		warnStrings []string
+	}{
+		{
+			name: "should pass when the deployments comply with the restricted profile",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate:    makeRestricted,
+			},
+		},
+		{
+			name:        "should warn when the deployment requires privileged access",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makeRestricted(bundle)
+					makePrivileged(bundle)
+				},
+			},
+			warnStrings: []string{"Warning: " + privilegedMsg},
+		},
+		{
+			name:      "should fail when the deployment requires privileged access with a strict profile",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   CertifiedProfile,
+				mutate: func(bundle *manifests.Bundle) {
+					makeRestricted(bundle)
+					makePrivileged(bundle)
+				},
+			},
+			errStrings: []string{"Error: " + privilegedMsg},
+		},
+		{
+			name:        "should warn when the containers do not comply with the restricted profile",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makeRestricted(bundle)
+					spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec
+					spec.SecurityContext = nil
+					spec.Containers[1].SecurityContext.Capabilities.Add = []corev1.Capability{"NET_BIND_SERVICE",
+						"SYS_ADMIN"}
+				},
+			},
+			warnStrings: []string{
+				"Warning: Value : (memcached-operator.v0.0.1) the container kube-rbac-proxy of the deployment " +
+					"memcached-operator-controller-manager does not comply with the restricted Pod Security " +
+					"Admission profile enforced from OCP 4.12 (runAsNonRoot is not true, seccompProfile is not " +
+					"RuntimeDefault). Note that non-compliant pods fail to be admitted in the namespaces labeled as " +
+					"restricted. Please, set runAsNonRoot: true, seccompProfile.type: RuntimeDefault, " +
+					"allowPrivilegeEscalation: false and capabilities.drop: [ALL] in its securityContext",
+				"Warning: Value : (memcached-operator.v0.0.1) the container manager of the deployment " +
+					"memcached-operator-controller-manager does not comply with the restricted Pod Security " +
+					"Admission profile enforced from OCP 4.12 (runAsNonRoot is not true, seccompProfile is not " +
+					"RuntimeDefault, capability SYS_ADMIN is added). Note that non-compliant pods fail to be " +
+					"admitted in the namespaces labeled as restricted. Please, set runAsNonRoot: true, " +
+					"seccompProfile.type: RuntimeDefault, allowPrivilegeEscalation: false and capabilities.drop: " +
+					"[ALL] in its securityContext",
+			},
+		},
+		{
+			name:      "should fail when the containers do not comply with the restricted profile with a strict profile",
+			wantError: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				profile:   RedHatProfile,
+			},
+			errStrings: []string{
+				"Error: Value : (memcached-operator.v0.0.1) the container kube-rbac-proxy of the deployment " +
+					"memcached-operator-controller-manager does not comply with the restricted Pod Security " +
+					"Admission profile enforced from OCP 4.12 (seccompProfile is not RuntimeDefault, " +
+					"allowPrivilegeEscalation is not false). Note that non-compliant pods fail to be admitted in " +
+					"the namespaces labeled as restricted. Please, set runAsNonRoot: true, seccompProfile.type: " +
+					"RuntimeDefault, allowPrivilegeEscalation: false and capabilities.drop: [ALL] in its securityContext",
+				"Error: Value : (memcached-operator.v0.0.1) the container manager of the deployment " +
+					"memcached-operator-controller-manager does not comply with the restricted Pod Security " +
+					"Admission profile enforced from OCP 4.12 (seccompProfile is not RuntimeDefault). Note that " +
+					"non-compliant pods fail to be admitted in the namespaces labeled as restricted. Please, set " +
+					"runAsNonRoot: true, seccompProfile.type: RuntimeDefault, allowPrivilegeEscalation: false and " +
+					"capabilities.drop: [ALL] in its securityContext",
+			},
+		},
+		{
+			name: "should only warn about the privileged access when the suggested namespace allows it",
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makeRestricted(bundle)
+					makePrivileged(bundle)
+					setSuggestedNamespaceTemplate(bundle, "privileged")
+				},
+			},
+			wantWarning: true,
+			warnStrings: []string{"Warning: " + privilegedMsg},
+		},
+		{
+			name:        "should warn when the suggested namespace enforces the restricted level",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makeRestricted(bundle)
+					makePrivileged(bundle)
+					setSuggestedNamespaceTemplate(bundle, "restricted")
+				},
+			},
+			warnStrings: []string{"Warning: " + privilegedMsg,
+				"Warning: Value : (memcached-operator.v0.0.1) the deployments require the privileged Pod Security " +
+					"Admission level but the namespace memcached-operator-system suggested via the annotation " +
+					"operatorframework.io/suggested-namespace-template enforces the restricted level. Note that the " +
+					"pods will not be admitted. Please, set the label pod-security.kubernetes.io/enforce: privileged",
+			},
+		},
+		{
+			name:        "should warn when the suggested namespace has no pod security label",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makeRestricted(bundle)
+					makePrivileged(bundle)
+					bundle.CSV.Annotations[suggestedNamespaceAnnotation] = "memcached-operator-system"
+				},
+			},
+			warnStrings: []string{"Warning: " + privilegedMsg,
+				"Warning: Value : (memcached-operator.v0.0.1) the deployments require the privileged Pod Security " +
+					"Admission level but the namespace suggested via the annotation " +
+					"operatorframework.io/suggested-namespace will be created without the label " +
+					"pod-security.kubernetes.io/enforce. Please, use the annotation " +
+					"operatorframework.io/suggested-namespace-template to suggest a namespace with the label " +
+					"pod-security.kubernetes.io/enforce: privileged",
+			},
+		},
+		{
+			name:        "should warn when the operator can be installed in any namespace",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makeRestricted(bundle)
+					makePrivileged(bundle)
+					bundle.CSV.Spec.InstallModes[0].Supported = true
+				},
+			},
+			warnStrings: []string{"Warning: " + privilegedMsg,
+				"Warning: Value : (memcached-operator.v0.0.1) the deployments require the privileged Pod Security " +
+					"Admission level and the install modes supported allow the operator to be installed in any " +
+					"namespace, which might enforce the restricted level. Please, consider suggesting a namespace " +
+					"with the label pod-security.kubernetes.io/enforce: privileged via the annotation " +
+					"operatorframework.io/suggested-namespace-template",
+			},
+		},
+		{
+			name:        "should not check the level of the suggested namespace template when it is invalid",
+			wantWarning: true,
+			args: args{
+				bundleDir: "./testdata/valid_bundle_v1",
+				mutate: func(bundle *manifests.Bundle) {
+					makeRestricted(bundle)
+					makePrivileged(bundle)
+					bundle.CSV.Annotations[suggestedNamespaceTemplateAnnotation] = "{invalid"
+				},
+			},
+			warnStrings: []string{"Warning: " + privilegedMsg},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := manifests.GetBundleFromDir(tt.args.bundleDir)
+			require.NoError(t, err)
+			if tt.args.mutate != nil {
+				tt.args.mutate(bundle)
+			}
+
+			results := WorkloadSecurityValidator.Validate(bundle, map[string]string{ProfileKey: tt.args.profile})
+			require.Equal(t, 1, len(results))
+			requireResult(t, results[0], tt.wantError, tt.wantWarning, tt.errStrings, tt.warnStrings)
+		})
+	}
+}
+
+// makeRestricted configures the deployment of the memcached bundle to comply with the restricted profile
+func makeRestricted(bundle *manifests.Bundle) {
+	spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec
+	runAsNonRoot := true
+	spec.SecurityContext = &corev1.PodSecurityContext{
+		RunAsNonRoot:   &runAsNonRoot,
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	allowPrivilegeEscalation := false
+	for i := range spec.Containers {
+		spec.Containers[i].SecurityContext = &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		}
+	}
+}
+
+// setSuggestedNamespaceTemplate suggests a namespace which enforces the Pod Security Admission level informed
+func setSuggestedNamespaceTemplate(bundle *manifests.Bundle, level string) {
+	bundle.CSV.Annotations[suggestedNamespaceTemplateAnnotation] = `{"apiVersion":"v1","kind":"Namespace",` +
+		`"metadata":{"name":"memcached-operator-system","labels":{"pod-security.kubernetes.io/enforce":"` +
+		level + `"}}}`
+}
+
+// makePrivileged configures the deployment of the memcached bundle to require privileged access
+func makePrivileged(bundle *manifests.Bundle) {
+	spec := &bundle.CSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs[0].Spec.Template.Spec
+	spec.HostNetwork = true
+	spec.HostPID = true
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name:         "host",
+		VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run"}},
+	})
+	privileged := true
+	for i := range spec.Containers {
+		if spec.Containers[i].Name == "manager" {
+			if spec.Containers[i].SecurityContext == nil {
+				spec.Containers[i].SecurityContext = &corev1.SecurityContext{}
+			}
+			spec.Containers[i].SecurityContext.Privileged = &privileged
+		}
+	}
+}